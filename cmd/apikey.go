@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"web-clean/infra"
+	"web-clean/infra/database"
+	"web-clean/internal/application/service"
+	"web-clean/internal/domain/usecase"
+	"web-clean/internal/infrastructure/idgen"
+	"web-clean/internal/infrastructure/repository"
+)
+
+// runAPIKey handles `apikey create <name> [scope...]` and returns the process exit code. It creates
+// the first key holding apikeys:manage, since the management endpoints themselves require that scope
+func runAPIKey(args []string) int {
+	if len(args) < 2 || args[0] != "create" {
+		fmt.Fprintln(os.Stderr, "usage: apikey create <name> [scope...]")
+		return 2
+	}
+
+	context, err := infra.Prepare(infra.PrepareConfig{Loader: configLoader})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		return 1
+	}
+
+	db, err := database.From(context)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to database: %v\n", err)
+		return 1
+	}
+
+	ids, err := idgen.From(context.Conf.IDStrategy)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid id strategy: %v\n", err)
+		return 1
+	}
+
+	apiKeys := service.NewAPIKeyService(repository.NewAPIKeyRepository(db), context.Log, ids)
+	created, err := apiKeys.CreateAPIKey(context.Ctx, usecase.CreateAPIKeyRequest{Name: args[1], Scopes: args[2:]})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create API key: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stdout, "created API key %s (%s) with scopes %v\n", created.Key.ID, created.Key.DisplayPrefix, created.Key.Scopes)
+	fmt.Fprintf(os.Stdout, "%s\n", created.Secret)
+	return 0
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	stdcontext "context"
+	"fmt"
+	"os"
+	"strings"
+
+	"web-clean/infra"
+	"web-clean/infra/database"
+	oldRepository "web-clean/repository"
+)
+
+// checkResult is the outcome of a single startup self-check
+type checkResult struct {
+	name string
+	err  error
+}
+
+// runCheck validates config, database connectivity, migrations and the error fallback
+// directory, prints a report and returns the process exit code
+func runCheck() int {
+	results := make([]checkResult, 0)
+	defer func() {
+		printCheckReport(results)
+	}()
+
+	context, err := infra.Prepare(infra.PrepareConfig{Loader: configLoader})
+	if err == nil {
+		_, err = oldRepository.NewPayloadCodec(context.Conf.Persistence)
+	}
+	results = append(results, checkResult{name: "config", err: err})
+	if err != nil {
+		return 1
+	}
+
+	results = append(results, checkResult{name: "schema registry", err: database.Schemas.Err()})
+
+	db, err := database.From(context)
+	if err == nil {
+		err = db.Ping(stdcontext.Background())
+	}
+	results = append(results, checkResult{name: "database connection", err: err})
+
+	if err == nil {
+		pending, err := database.PendingMigrations(db)
+		if err == nil && len(pending) > 0 {
+			err = fmt.Errorf("%d pending: %s", len(pending), strings.Join(pending, "; "))
+		}
+		results = append(results, checkResult{name: "migrations", err: err})
+	}
+
+	errorsPersister := oldRepository.Errors{FallbackFilePath: errorsFallbackPath}
+	results = append(results, checkResult{
+		name: fmt.Sprintf("error fallback directory %s writable", errorsFallbackPath),
+		err:  errorsPersister.CheckWritable(),
+	})
+
+	for _, result := range results {
+		if result.err != nil {
+			return 1
+		}
+	}
+	return 0
+}
+
+func printCheckReport(results []checkResult) {
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(os.Stdout, "FAIL  %s: %v\n", result.name, result.err)
+		} else {
+			fmt.Fprintf(os.Stdout, "OK    %s\n", result.name)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"web-clean/infra"
+	"web-clean/infra/database"
+)
+
+// runDB handles `db <subcommand>` and returns the process exit code
+func runDB(args []string) int {
+	if len(args) != 1 || args[0] != "diff" {
+		fmt.Fprintln(os.Stderr, "usage: db diff")
+		return 2
+	}
+
+	context, err := infra.Prepare(infra.PrepareConfig{Loader: configLoader})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		return 1
+	}
+
+	db, err := database.From(context)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to database: %v\n", err)
+		return 1
+	}
+
+	drifts, err := database.Diff(db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to inspect schema: %v\n", err)
+		return 1
+	}
+
+	if len(drifts) == 0 {
+		fmt.Fprintf(os.Stdout, "no drift across %d registered models\n", len(database.RegisteredSchemas()))
+		return 0
+	}
+
+	for _, drift := range drifts {
+		fixable := "manual"
+		if drift.Fixable() {
+			fixable = "auto-migrate"
+		}
+		fmt.Fprintf(os.Stdout, "%-14s %-12s %s\n", drift.Kind, fixable, drift)
+	}
+	return 1
+}
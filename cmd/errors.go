@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"web-clean/infra"
+	"web-clean/infra/database"
+	oldRepository "web-clean/repository"
+)
+
+// runErrors handles `errors <subcommand>` and returns the process exit code
+func runErrors(args []string) int {
+	if len(args) != 1 || args[0] != "replay" {
+		fmt.Fprintln(os.Stderr, "usage: errors replay")
+		return 2
+	}
+
+	context, err := infra.Prepare(infra.PrepareConfig{Loader: configLoader})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		return 1
+	}
+
+	db, err := database.From(context)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to database: %v\n", err)
+		return 1
+	}
+
+	errorsPersister := oldRepository.Errors{
+		Context:          context,
+		FallbackFilePath: errorsFallbackPath,
+		Database:         db,
+	}
+
+	result, err := errorsPersister.Replay()
+	fmt.Fprintf(os.Stdout, "replayed %d records from %d files\n", result.Records, result.Files)
+	for _, skipped := range result.Skipped {
+		fmt.Fprintf(os.Stdout, "skipped unreadable file %s\n", skipped)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay stopped: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
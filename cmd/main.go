@@ -1,138 +1,688 @@
 package main
 
 import (
+	stdcontext "context"
+	"errors"
+	"expvar"
+	"fmt"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 
 	"web-clean/domain"
 	"web-clean/infra"
+	"web-clean/infra/cache"
+	"web-clean/infra/captcha"
+	"web-clean/infra/conf"
 	"web-clean/infra/database"
+	"web-clean/infra/experiments"
+	"web-clean/infra/health"
+	"web-clean/infra/httpclient"
+	"web-clean/infra/risk"
+	"web-clean/infra/scheduler"
+	"web-clean/infra/startup"
+	"web-clean/infra/loader"
+	bydotenv "web-clean/infra/loader/dotenv"
+	byenv "web-clean/infra/loader/env"
 	byjson "web-clean/infra/loader/json"
+	byremote "web-clean/infra/loader/remote"
+	bytoml "web-clean/infra/loader/toml"
 	"web-clean/infra/web"
 	oldRepository "web-clean/repository"
+	"web-clean/pkg/app"
 	
 	// Clean Architecture layers
 	"web-clean/internal/application/service"
+	domainRepository "web-clean/internal/domain/repository"
+	"web-clean/internal/domain/usecase"
+	"web-clean/internal/infrastructure/idgen"
+	"web-clean/internal/infrastructure/passwords"
+	"web-clean/internal/infrastructure/usernames"
 	userHttpHandler "web-clean/internal/interface/http"
 	"web-clean/internal/infrastructure/repository"
 )
 
+// remoteConfig is the optional centralized config source set with WEBCLEAN_REMOTE_CONFIG_URL
+var remoteConfig = byremote.FromEnv()
+
+// configLoader reads the config file in the format chosen by WEBCLEAN_CONFIG_FORMAT (json by default),
+// overlays the file of the WEBCLEAN_PROFILE profile (e.g. config.prod.json) and the remote config,
+// then lets WEBCLEAN_* environment variables override it
+var configLoader = loader.Chain(loader.Profiles(loader.ByFormat(map[string]loader.Loader{
+	"json":   byjson.JSONLoader,
+	"toml":   bytoml.TOMLLoader,
+	"dotenv": bydotenv.DotEnvLoader,
+})), remoteConfig, byenv.EnvLoader)
+
+// errorsFallbackPath is where error stacks are written when the database is unavailable
+const errorsFallbackPath = app.DefaultErrorsFallbackPath
+
+// idempotencyTTL is how long a response is replayed for retries carrying the same Idempotency-Key
+const idempotencyTTL = 24 * time.Hour
+
+// idempotencySweepInterval is how often expired idempotency records are dropped
+const idempotencySweepInterval = time.Minute
+
+// recordingsRetention is how long debug recordings are kept before the prune task deletes them
+const recordingsRetention = 7 * 24 * time.Hour
+
+// shutdownDrainTimeout bounds waiting for background work after the server stopped
+const shutdownDrainTimeout = 5 * time.Second
+
+// docsCacheTTL is how long clients may cache the API documentation
+const docsCacheTTL = time.Hour
+
+// docsStaleWhileRevalidate is how long clients may keep using expired documentation while refetching it
+const docsStaleWhileRevalidate = 24 * time.Hour
+
 func main() {
+	// Subcommands; no arguments starts the web server
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "check":
+			os.Exit(runCheck())
+		case "errors":
+			os.Exit(runErrors(os.Args[2:]))
+		case "db":
+			os.Exit(runDB(os.Args[2:]))
+		case "profile":
+			os.Exit(runProfile(os.Args[2:]))
+		case "apikey":
+			os.Exit(runAPIKey(os.Args[2:]))
+		default:
+			fmt.Fprintf(os.Stderr, "unknown command %q, available commands: check, errors, db, profile, apikey\n", os.Args[1])
+			os.Exit(2)
+		}
+	}
+
+	serve()
+}
+
+// serve wires all layers together and runs the web server until shutdown
+func serve() {
+	timer := startup.NewTimer()
+	context, server, drain := boot(timer)
+
+	// Start the server
+	context.Log.Infow("Starting Clean Architecture web server", 
+		"architecture", "Clean Architecture",
+		"layers", []string{"Domain", "Application", "Infrastructure", "Interface"},
+		"patterns", []string{"Dependency Inversion", "Separation of Concerns", "Single Responsibility"},
+		"startup", timer.Total(),
+		"startup_phases", timer.Phases(),
+	)
+	
+	server.Serve()
+
+	// Background work started by requests, such as stale cache reloads, finishes before exiting
+	drainCtx, cancel := stdcontext.WithTimeout(stdcontext.Background(), shutdownDrainTimeout)
+	defer cancel()
+	if err := drain(drainCtx); err != nil {
+		context.Log.Warnw("Background work still running at exit", "error", err)
+	}
+}
+
+// boot wires all layers together and returns the server without listening, and a function waiting
+// for background work to finish after the server stopped; each startup phase is recorded on timer
+func boot(timer *startup.Timer) (*infra.Context, web.Web, func(stdcontext.Context) error) {
 	// Initialize infrastructure context
-	context, err := infra.Prepare(infra.PrepareConfig{Loader: byjson.JSONLoader})
+	context, err := infra.Prepare(infra.PrepareConfig{Loader: configLoader})
 	if err != nil {
 		panic(err)
 	}
+	timer.Done("config")
 
 	// Initialize database
 	db, err := database.From(context)
 	if err != nil {
 		panic(err)
 	}
+	timer.Done("database")
 
-	// Auto-migrate schemas (including new user schema)
-	err = database.AutoMigrateRegisteredSchema(db)
+	// Report drift between the live schema and the registered models before touching anything
+	drifts, err := database.Diff(db)
 	if err != nil {
 		panic(err)
 	}
+	for _, drift := range drifts {
+		context.Log.Warnw("Schema drift detected", "kind", drift.Kind, "drift", drift.String())
+	}
+
+	// A read-only replica cannot be migrated; writes are rejected with 503 instead
+	readOnly := context.Conf.Database.ReadOnly
+	if readOnly {
+		context.Log.Warnw("Database is in read-only mode, migrations are skipped and writes are rejected")
+	}
+
+	// Trimmed deployments switch modules off in config; their routes and jobs are not registered
+	modules := struct{ users, scim, admin, jobs, apiKeys bool }{
+		users:   context.Conf.ModuleEnabled(conf.ModuleUsers),
+		scim:    context.Conf.ModuleEnabled(conf.ModuleSCIM),
+		admin:   context.Conf.ModuleEnabled(conf.ModuleAdmin),
+		jobs:    context.Conf.ModuleEnabled(conf.ModuleJobs),
+		apiKeys: context.Conf.ModuleEnabled(conf.ModuleAPIKeys),
+	}
+	for _, name := range conf.Modules {
+		if !context.Conf.ModuleEnabled(name) {
+			context.Log.Warnw("Module disabled by config", "module", name)
+		}
+	}
+
+	// Auto-migrate schemas (including new user schema); when disabled, missing tables or columns are fatal
+	if context.Conf.Database.AutoMigrateEnabled() && !readOnly {
+		err = database.AutoMigrateRegisteredSchema(db)
+		if err != nil {
+			panic(err)
+		}
+		// Emails and usernames are unique ignoring case; users that collide are reported, not changed
+		report, err := repository.MigrateCaseInsensitiveUniqueness(context.Ctx, db)
+		if err != nil {
+			panic(err)
+		}
+		context.Log.Infow("Case-insensitive user uniqueness migrated", "normalized", report.Normalized, "enforced", report.Enforced)
+		logCaseConflicts(context.Log, report.Conflicts)
+	} else {
+		for _, drift := range drifts {
+			if drift.Fixable() {
+				panic(fmt.Errorf("the schema is not migrated and auto-migration is disabled or read-only: %s", drift))
+			}
+		}
+	}
+
+	timer.Done("migrations")
 
 	// Initialize Clean Architecture layers following dependency inversion principle
 	
 	// Infrastructure Layer - implements domain interfaces
-	userRepo := repository.NewUserRepository(db)
+	// Concurrent identical hot reads (GetByID, Count) share one query
+	// Read-only mode rejects writes with 503, and statements rejected by the per-request
+	// database budget surface as 503; both also apply inside units of work
+	// With a cache configured, reads by ID and email are cached and writes invalidate them;
+	// with cache.stale_seconds, expired users are served while being refreshed in the background
+	userCache, err := cache.From(context.Conf.Cache)
+	if err != nil {
+		panic(err)
+	}
+	decorateUsers := func(users domainRepository.UserRepository) domainRepository.UserRepository {
+		if readOnly {
+			users = repository.NewReadOnlyUserRepository(users)
+		}
+		return repository.NewBudgetUserRepository(users)
+	}
+	userRepo := decorateUsers(repository.NewSingleFlightUserRepository(repository.NewUserRepository(db)))
+	drain := func(stdcontext.Context) error { return nil }
+	var invalidateUser repository.UserWritten
+	if userCache != nil {
+		cachedUsers := repository.NewCachedUserRepository(userRepo, userCache, context.Conf.Cache.TTL(), context.Conf.Cache.Stale())
+		drain = cachedUsers.(repository.Drainer).Drain
+		invalidateUser = cachedUsers.(repository.Invalidator).Invalidate
+		userRepo = cachedUsers
+	}
+
+	// Writes that need an audit record run in one transaction across repositories. Its repositories are not
+	// cached (they would cache uncommitted rows); the users it writes are dropped from the cache after the commit
+	unitOfWork := repository.NewUnitOfWork(db, decorateUsers, invalidateUser)
 	
+	// Time-ordered IDs by default to keep the primary key index compact
+	ids, err := idgen.From(context.Conf.IDStrategy)
+	if err != nil {
+		panic(err)
+	}
+
+	// Reserved and offensive usernames; the rules can be replaced at runtime via /admin/reserved-usernames
+	usernamePolicy, err := usernames.From(context.Conf.ReservedUsernames)
+	if err != nil {
+		panic(err)
+	}
+
+	// Passwords are hashed with bcrypt before they reach the repository
+	passwordHasher := passwords.NewBcrypt(context.Conf.PasswordCost)
+
+	// Remote config changes refresh the runtime-adjustable settings (SQL sampling, reserved usernames);
+	// everything else still needs a restart
+	go remoteConfig.Watch(context.Ctx, context.Log, func() {
+		reloaded, err := infra.Reload(infra.PrepareConfig{Loader: configLoader}, context.Log)
+		if err != nil {
+			context.Log.Errorw("Reloading remote config failed, keeping the current settings", "error", err)
+			return
+		}
+		if sampler, ok := database.SamplerOf(db); ok && reloaded.Database != nil {
+			sampler.SetRate(reloaded.Database.SQLSampleRate)
+		}
+		if err := usernamePolicy.Replace(usernames.RulesFrom(reloaded.ReservedUsernames)); err != nil {
+			context.Log.Errorw("Reloaded reserved usernames are invalid, keeping the current rules", "error", err)
+		}
+		context.Log.Infow("Applied reloaded config")
+	})
+
+	// Default and maximum list page sizes, shared by the handlers and the service
+	defaultLimit, maxLimit := context.Conf.Web.PageLimits()
+	pages := usecase.PageLimits{Default: defaultLimit, Max: maxLimit}
+
 	// Application Layer - contains business logic
-	userService := service.NewUserService(userRepo, context.Log)
+	userService := service.NewUserService(userRepo, unitOfWork, context.Log, ids, usernamePolicy, pages, passwordHasher)
 	
 	// Interface Layer - handles HTTP concerns
-	userHandler := userHttpHandler.NewUserHandler(userService, context.Log)
+	userHandler := userHttpHandler.NewUserHandler(userService, context.Log, pages)
+	userHandlerV2 := userHttpHandler.NewUserHandlerV2(userService, context.Log, pages)
+	scimHandler := userHttpHandler.NewSCIMHandler(userService, context.Log, pages)
+
+	// Machine clients authenticate with "Authorization: ApiKey ..."; keys carry their own scopes.
+	// Read-only mode still authenticates keys but does not record their last use
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	if readOnly {
+		apiKeyRepo = repository.NewReadOnlyAPIKeyRepository(apiKeyRepo)
+	}
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, context.Log, ids)
+	apiKeyHandler := userHttpHandler.NewAPIKeyHandler(apiKeyService, context.Log)
+
+	// Per-client usage of API versions we want to retire
+	apiUsage := web.NewUsageRecorder(context.Log)
+
+	// Deprecated routes get Deprecation/Sunset/Link headers and per-client usage tracking;
+	// mark a route with deprecations.Deprecate("PUT /api/v1/users/:id", web.Deprecation{...})
+	deprecations := web.NewDeprecationRegistry(context.Log)
+
+	// CAPTCHA protection for signup; disabled unless configured for the environment
+	captchaVerifier, err := captcha.From(context.Conf.Captcha, httpclient.New("captcha", context.Log, httpclient.Default()))
+	if err != nil {
+		panic(err)
+	}
+
+	// Signup risk scoring; when enabled, only risky signups are challenged with CAPTCHA
+	signupGuard := web.CaptchaMiddleware(captchaVerifier, context.Log)
+	if assessor := risk.From(context.Conf.SignupRisk, httpclient.New("reputation", context.Log, httpclient.Default()), context.Log); assessor != nil {
+		signupGuard = web.SignupRiskMiddleware(assessor, captchaVerifier, context.Log)
+	}
+
+	// Retried signups carrying an Idempotency-Key get the first response replayed instead of a 409;
+	// it runs after the signup guard so that a rejected CAPTCHA is not replayed to the retry that solves it
+	idempotency := web.NewIdempotency(idempotencyTTL, context.Log)
+	go idempotency.Sweep(context.Ctx, idempotencySweepInterval)
+
+	// Route metadata (rate class, scope, cache TTL, strict JSON) is declared in the route tables below and
+	// interpreted by these middlewares, in this order, ahead of each route's own middleware
+	routeMiddlewares := []web.RouteMiddleware{
+		web.NewRateLimiter(context.Conf.Web.RateLimits).Route,
+		web.RequireScope,
+		web.CacheControl,
+		web.StrictJSON,
+	}
+
+	// Request logs and error stacks go to the database or the configured external sink
+	persistence, err := app.NewPersistence(context, db, errorsFallbackPath)
+	if err != nil {
+		panic(err)
+	}
 
 	// Legacy components (keeping for existing functionality)
-	logsPersister := oldRepository.Logs{
-		Context:  context,
-		Database: db,
+	requests := oldRepository.Requests{Database: db}
+	summaries := oldRepository.Metrics{Database: db, SignupsTable: repository.UserModel{}.TableName()}
+
+	// Passwords, tokens and auth headers are masked before request logs are written or persisted
+	redactor := web.RedactorFrom(context.Conf.Logger)
+
+	// A/B experiments: callers are bucketed by identity and see their variants in X-Experiments
+	experimentRegistry, err := experiments.From(context.Conf.Experiments)
+	if err != nil {
+		panic(err)
 	}
 
-	contextMiddleware := web.ContextMiddleware(func(log domain.Log) *web.Context {
-		return &web.Context{
-			Database: db,
-			Log:      log,
+	// Debug recordings of full request/response pairs, started per user or request ID via /admin/recordings/rules
+	recordings := oldRepository.Recordings{Database: db}
+	recorder := web.NewRecorder(recordings, redactor, context.Log)
+
+	var providers []web.Provider
+	if experimentRegistry != nil {
+		providers = append(providers, web.ExperimentsProvider(experimentRegistry))
+	}
+
+	// Background tasks, listed and triggered via /admin/scheduler/tasks
+	tasks := scheduler.New(context.Log)
+
+	// Re-ingest error stacks that fell back to files while the database was unavailable
+	if !readOnly && modules.jobs {
+		if err := tasks.Register(persistence.ReplayTask()); err != nil {
+			panic(err)
+		}
+		// Debug recordings hold full bodies, so they are only kept for a bounded time
+		if err := tasks.Register(scheduler.Task{
+			Name:     "recordings_prune",
+			Interval: time.Hour,
+			Run: func(ctx stdcontext.Context) error {
+				deleted, err := recordings.DeleteBefore(ctx, time.Now().Add(-recordingsRetention))
+				if deleted > 0 {
+					context.Log.Infow("Pruned debug recordings", "deleted", deleted)
+				}
+				return err
+			},
+		}); err != nil {
+			panic(err)
 		}
-	}, context.Log, &logsPersister)
+	}
+	tasks.Start(context.Ctx)
 
-	errorsPersister := oldRepository.Errors{
-		Context:          context,
-		FallbackFilePath: "./errors",
-		Database:         db,
+	// Dependency checks: readiness fails only when a critical dependency is down
+	healthChecks := health.NewRegistry()
+	healthChecks.Register(health.Check{
+		Name:     "database",
+		Severity: health.Critical,
+		Probe:    db.Ping,
+	})
+	// Reports an unwritable error fallback directory and error stacks going to files instead of the database
+	for _, check := range persistence.Checks() {
+		healthChecks.Register(check)
+	}
+	// Reads fall back to the database while the cache is down
+	if userCache != nil {
+		healthChecks.Register(health.Check{
+			Name:     "cache",
+			Severity: health.Degraded,
+			Probe:    userCache.Ping,
+		})
 	}
 
+	timer.Done("dependencies")
+
 	// Initialize web server with Clean Architecture routes
 	server := web.Gin(context, func(engine *gin.Engine) {
-		// Global middleware
-		engine.Use(web.RequestIDMiddleware(func() string {
-			return uuid.NewString()
-		}))
-
-		engine.Use(web.ErrorPersisterMiddleware(errorsPersister, context.Log, web.RequestIdGetter))
-
-		engine.Use(web.Recover(func(context *gin.Context, err any) {
-			// Handle panics gracefully
-			context.JSON(http.StatusInternalServerError, gin.H{
-				"error": "internal_server_error",
-				"message": "An internal error occurred",
-			})
-		}))
+		// Global middleware, shared with applications embedding web-clean through pkg/app
+		app.Stack{
+			Context:   context,
+			Database:  db,
+			Logs:      persistence.Logs,
+			Errors:    persistence.Errors,
+			Redactor:  redactor,
+			Recorder:  recorder,
+			Providers: providers,
+		}.Apply(engine)
 
-		engine.Use(contextMiddleware)
+		// Callers presenting an API key get its scopes checked by routes declaring a Scope
+		if modules.apiKeys {
+			engine.Use(userHttpHandler.APIKeyAuth(apiKeyService, context.Log))
+		}
 
-		// Health check endpoint
-		engine.GET("/health", func(c *gin.Context) {
-			c.JSON(http.StatusOK, gin.H{
-				"status": "healthy",
-				"service": "web-clean",
-			})
-		})
+		// Health check and readiness endpoints: /ready is 503 only when a critical dependency is down
+		app.Probes(engine, "web-clean", healthChecks)
 
 		// API v1 routes following Clean Architecture
-		apiV1 := engine.Group("/api/v1")
+		apiV1 := engine.Group("/api/v1", apiUsage.Middleware("v1"))
 		{
 			// User management endpoints
-			users := apiV1.Group("/users")
-			{
-				users.POST("", userHandler.CreateUser)           // POST /api/v1/users
-				users.GET("", userHandler.ListUsers)             // GET /api/v1/users?offset=0&limit=10
-				users.GET("/:id", userHandler.GetUserByID)       // GET /api/v1/users/:id
-				users.PUT("/:id", userHandler.UpdateUserProfile) // PUT /api/v1/users/:id
-				users.DELETE("/:id", userHandler.DeleteUser)     // DELETE /api/v1/users/:id
+			if modules.users {
+				web.Register(apiV1.Group("/users"), routeMiddlewares,
+					web.Route{Method: http.MethodPost, Path: "", Handler: userHandler.CreateUser, RateClass: "signup", Middleware: []gin.HandlerFunc{signupGuard, idempotency.Middleware()}},
+					web.Route{Method: http.MethodPost, Path: "/batch", Handler: userHandler.CreateUsers, RateClass: "write", Middleware: []gin.HandlerFunc{signupGuard, idempotency.Middleware()}},
+					web.Route{Method: http.MethodPost, Path: "/import", Handler: userHandler.ImportUsers, RateClass: "write", Scope: userHttpHandler.ScopeUsersAdmin, Middleware: []gin.HandlerFunc{idempotency.Middleware()}}, // ?on_duplicate=skip|overwrite|error&dry_run=true
+					web.Route{Method: http.MethodGet, Path: "", Handler: userHandler.ListUsers, RateClass: "read", Scope: userHttpHandler.ScopeUsersRead},         // ?offset=0&limit=10
+					web.Route{Method: http.MethodGet, Path: "/export", Handler: userHandler.ExportUsers, RateClass: "read", Scope: userHttpHandler.ScopeUsersRead}, // ?format=csv|jsonl
+					web.Route{Method: http.MethodGet, Path: "/:id", Handler: userHandler.GetUserByID, RateClass: "read", Scope: userHttpHandler.ScopeUsersRead},
+					web.Route{Method: http.MethodPut, Path: "/:id", Handler: userHandler.UpdateUserProfile, RateClass: "write", Scope: userHttpHandler.ScopeUsersWrite},
+					web.Route{Method: http.MethodPost, Path: "/:id/password", Handler: userHandler.ChangePassword, RateClass: "password", Scope: userHttpHandler.ScopeUsersAdmin},
+					web.Route{Method: http.MethodDelete, Path: "/:id", Handler: userHandler.DeleteUser, RateClass: "write", Scope: userHttpHandler.ScopeUsersWrite},
+				)
+			}
+		}
+
+		// API key management, for callers holding the apikeys:manage scope; the secret is only returned
+		// when a key is created. The first key is created with `apikey create`
+		if modules.apiKeys {
+			web.Register(apiV1.Group("/apikeys"), routeMiddlewares,
+				web.Route{Method: http.MethodPost, Path: "", Handler: apiKeyHandler.CreateAPIKey, RateClass: "write", Scope: userHttpHandler.ScopeAPIKeysManage, StrictJSON: true},
+				web.Route{Method: http.MethodGet, Path: "", Handler: apiKeyHandler.ListAPIKeys, RateClass: "read", Scope: userHttpHandler.ScopeAPIKeysManage},
+				web.Route{Method: http.MethodPost, Path: "/:id/revoke", Handler: apiKeyHandler.RevokeAPIKey, RateClass: "write", Scope: userHttpHandler.ScopeAPIKeysManage},
+			)
+		}
+
+		// API v2 routes: cursor pagination, enveloped bodies, PATCH semantics, unknown body fields rejected
+		apiV2 := engine.Group("/api/v2")
+		{
+			if modules.users {
+				web.Register(apiV2.Group("/users"), routeMiddlewares,
+					web.Route{Method: http.MethodPost, Path: "", Handler: userHandlerV2.CreateUser, RateClass: "signup", StrictJSON: true, Middleware: []gin.HandlerFunc{signupGuard, idempotency.Middleware()}},
+					web.Route{Method: http.MethodGet, Path: "", Handler: userHandlerV2.ListUsers, RateClass: "read", Scope: userHttpHandler.ScopeUsersRead}, // ?cursor=&limit=10
+					web.Route{Method: http.MethodGet, Path: "/:id", Handler: userHandlerV2.GetUserByID, RateClass: "read", Scope: userHttpHandler.ScopeUsersRead},
+					web.Route{Method: http.MethodPatch, Path: "/:id", Handler: userHandlerV2.PatchUser, RateClass: "write", Scope: userHttpHandler.ScopeUsersWrite, StrictJSON: true},
+					web.Route{Method: http.MethodDelete, Path: "/:id", Handler: userHandlerV2.DeleteUser, RateClass: "write", Scope: userHttpHandler.ScopeUsersWrite},
+				)
 			}
 		}
 
+		// Admin endpoints
+		if modules.admin {
+			// Operator endpoints expose user traffic and change runtime behaviour (username policy, SQL
+			// sampling, jobs), so the whole group requires the admin scope
+			admin := engine.Group("/admin", web.RequireScope(web.Route{Scope: userHttpHandler.ScopeAdmin}))
+			// Per-client API usage, used to decide when v1 can be retired
+			admin.GET("/api-usage", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"usage": apiUsage.Snapshot()})
+			})
+
+			// Deprecated routes with their sunset dates and the clients still calling them
+			admin.GET("/deprecations", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"deprecations": deprecations.Report()})
+			})
+
+			// Process metrics (persister pipelines, runtime memstats) in expvar JSON format
+			admin.GET("/metrics", gin.WrapH(expvar.Handler()))
+
+			// Runtime toggle for sampled SQL statement logging
+			if sampler, ok := database.SamplerOf(db); ok {
+				admin.GET("/sql-sampling", func(c *gin.Context) {
+					c.JSON(http.StatusOK, gin.H{"rate": sampler.Rate()})
+				})
+				admin.PUT("/sql-sampling", func(c *gin.Context) {
+					var req struct {
+						Rate *float64 `json:"rate" binding:"required,min=0,max=1"`
+					}
+					if err := c.ShouldBindJSON(&req); err != nil {
+						c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": err.Error()})
+						return
+					}
+					sampler.SetRate(*req.Rate)
+					context.Log.Infow("SQL sampling rate changed", "rate", *req.Rate)
+					c.JSON(http.StatusOK, gin.H{"rate": sampler.Rate()})
+				})
+			}
+
+			// Background tasks with their last and next run; a manual run waits for the task to finish
+			admin.GET("/scheduler/tasks", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"tasks": tasks.Tasks()})
+			})
+			admin.POST("/scheduler/tasks/:name/run", func(c *gin.Context) {
+				status, err := tasks.Run(c.Request.Context(), c.Param("name"))
+				switch {
+				case errors.Is(err, scheduler.ErrUnknownTask):
+					c.JSON(http.StatusNotFound, gin.H{"error": "task_not_found", "message": err.Error()})
+				case errors.Is(err, scheduler.ErrTaskRunning):
+					c.JSON(http.StatusConflict, gin.H{"error": "task_running", "message": err.Error(), "task": status})
+				default:
+					context.Log.Infow("Scheduler task triggered manually", "task", status.Name, "error", status.LastError)
+					c.JSON(http.StatusOK, gin.H{"task": status})
+				}
+			})
+
+			// Reserved usernames; replaced rules apply to this instance only and reset on restart
+			admin.GET("/reserved-usernames", func(c *gin.Context) {
+				c.JSON(http.StatusOK, usernamePolicy.Rules())
+			})
+			admin.PUT("/reserved-usernames", func(c *gin.Context) {
+				var rules usernames.Rules
+				if err := c.ShouldBindJSON(&rules); err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": err.Error()})
+					return
+				}
+				if err := usernamePolicy.Replace(rules); err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": err.Error()})
+					return
+				}
+				context.Log.Infow("Reserved usernames replaced", "words", len(rules.Words), "patterns", len(rules.Patterns))
+				c.JSON(http.StatusOK, usernamePolicy.Rules())
+			})
+
+			// Users whose email or username collide ignoring case; the unique index on that column is
+			// created at the next startup once none are left
+			admin.GET("/users/case-conflicts", func(c *gin.Context) {
+				conflicts, err := repository.FindCaseConflicts(c.Request.Context(), db)
+				if err != nil {
+					_ = c.Error(err)
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+					return
+				}
+				c.JSON(http.StatusOK, gin.H{"conflicts": conflicts})
+			})
+
+			// Bucketed request, error and signup counts from persisted data, e.g. ?window=7d&bucket=1h
+			admin.GET("/metrics/summary", func(c *gin.Context) {
+				window, err := oldRepository.ParseSpan(c.DefaultQuery("window", "7d"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_window", "message": err.Error()})
+					return
+				}
+				bucket, err := oldRepository.ParseSpan(c.DefaultQuery("bucket", "1h"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_bucket", "message": err.Error()})
+					return
+				}
+
+				summary, err := summaries.Summary(c.Request.Context(), time.Now(), window, bucket)
+				if errors.Is(err, oldRepository.ErrInvalidSpan) {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_window", "message": err.Error()})
+					return
+				}
+				if err != nil {
+					_ = c.Error(err)
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+					return
+				}
+				c.JSON(http.StatusOK, summary)
+			})
+
+			// Debug recording rules: full request/response pairs (redacted) of one user or of request IDs
+			// matching a pattern are stored for a bounded window, e.g. {"user_id": "...", "duration": "30m"}
+			admin.GET("/recordings/rules", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"rules": recorder.Rules()})
+			})
+			admin.POST("/recordings/rules", func(c *gin.Context) {
+				var req struct {
+					UserID           string `json:"user_id"`
+					RequestIDPattern string `json:"request_id_pattern"`
+					Duration         string `json:"duration"`
+				}
+				if err := c.ShouldBindJSON(&req); err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": err.Error()})
+					return
+				}
+				var window time.Duration
+				if req.Duration != "" {
+					parsed, err := time.ParseDuration(req.Duration)
+					if err != nil {
+						c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_recording_rule", "message": err.Error()})
+						return
+					}
+					window = parsed
+				}
+				rule, err := recorder.Start(req.UserID, req.RequestIDPattern, window)
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_recording_rule", "message": err.Error()})
+					return
+				}
+				context.Log.Infow("Debug recording started", "rule_id", rule.ID, "user_id", rule.UserID, "request_id_pattern", rule.RequestIDPattern, "expires_at", rule.ExpiresAt)
+				c.JSON(http.StatusCreated, rule)
+			})
+			admin.DELETE("/recordings/rules/:id", func(c *gin.Context) {
+				if err := recorder.Stop(c.Param("id")); err != nil {
+					c.JSON(http.StatusNotFound, gin.H{"error": "recording_rule_not_found"})
+					return
+				}
+				context.Log.Infow("Debug recording stopped", "rule_id", c.Param("id"))
+				c.Status(http.StatusNoContent)
+			})
+			// Stored recordings, newest first, e.g. ?rule_id=&limit=50
+			admin.GET("/recordings", func(c *gin.Context) {
+				limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_limit", "message": err.Error()})
+					return
+				}
+				list, err := recordings.List(c.Request.Context(), web.RecordingQuery{RuleID: c.Query("rule_id"), Limit: limit})
+				if err != nil {
+					_ = c.Error(err)
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+					return
+				}
+				c.JSON(http.StatusOK, gin.H{"recordings": list})
+			})
+
+			// Logs, errors and access details persisted for one request
+			admin.GET("/requests/:id", func(c *gin.Context) {
+				trace, err := requests.Trace(c.Param("id"))
+				if errors.Is(err, oldRepository.ErrRequestNotFound) {
+					c.JSON(http.StatusNotFound, gin.H{"error": "request_not_found"})
+					return
+				}
+				if err != nil {
+					_ = c.Error(err)
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "internal_server_error"})
+					return
+				}
+				c.JSON(http.StatusOK, trace)
+			})
+		}
+
+		// SCIM 2.0 provisioning endpoints for identity providers, which provision with a users:admin key
+		if modules.scim {
+			web.Register(engine.Group("/scim/v2/Users"), routeMiddlewares,
+				web.Route{Method: http.MethodPost, Path: "", Handler: scimHandler.CreateUser, Scope: userHttpHandler.ScopeUsersAdmin},
+				web.Route{Method: http.MethodGet, Path: "", Handler: scimHandler.ListUsers, Scope: userHttpHandler.ScopeUsersAdmin},
+				web.Route{Method: http.MethodGet, Path: "/:id", Handler: scimHandler.GetUser, Scope: userHttpHandler.ScopeUsersAdmin},
+				web.Route{Method: http.MethodPatch, Path: "/:id", Handler: scimHandler.PatchUser, Scope: userHttpHandler.ScopeUsersAdmin},
+				web.Route{Method: http.MethodDelete, Path: "/:id", Handler: scimHandler.DeleteUser, Scope: userHttpHandler.ScopeUsersAdmin},
+			)
+		}
+
 		// API documentation endpoint
-		apiV1.GET("/", func(c *gin.Context) {
+		web.Register(apiV1, routeMiddlewares, web.Route{Method: http.MethodGet, Path: "/", CacheTTL: docsCacheTTL, StaleWhileRevalidate: docsStaleWhileRevalidate, Handler: func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{
 				"message": "Clean Architecture API v1",
 				"endpoints": gin.H{
 					"users": gin.H{
-						"POST /api/v1/users":        "Create a new user",
-						"GET /api/v1/users":         "List users with pagination",
-						"GET /api/v1/users/:id":     "Get user by ID",
-						"PUT /api/v1/users/:id":     "Update user profile",
-						"DELETE /api/v1/users/:id":  "Delete user",
+						"POST /api/v1/users":              "Create a new user",
+						"POST /api/v1/users/batch":        "Create up to 100 users, reporting each one's status",
+						"POST /api/v1/users/import":       "Import up to 1000 users from CSV or JSON (?on_duplicate=skip|overwrite|error&dry_run=true; requires users:admin)",
+						"GET /api/v1/users":               "List users with pagination (?fields=id,username,...&filter=created_at>=2024-01-01 AND email~\"@corp.com\"&sort=name:asc, or ?cursor= for keyset pages)",
+						"GET /api/v1/users/export":        "Stream all users as CSV or JSON lines (?format=csv|jsonl, same filters as the list)",
+						"GET /api/v1/users/:id":           "Get user by ID (?fields=id,username,...)",
+						"PUT /api/v1/users/:id":           "Update user profile",
+						"POST /api/v1/users/:id/password": "Reset the password without the current one (requires users:admin)",
+						"DELETE /api/v1/users/:id":        "Delete user",
+					},
+					"apikeys": gin.H{
+						"POST /api/v1/apikeys":            "Create an API key for a machine client (the key is only shown in this response; requires apikeys:manage)",
+						"GET /api/v1/apikeys":             "List API keys with their scopes and last use (requires apikeys:manage)",
+						"POST /api/v1/apikeys/:id/revoke": "Revoke an API key (requires apikeys:manage)",
 					},
-					"health": "GET /health - Health check",
+					"v2":         "GET/POST /api/v2/users, GET/PATCH/DELETE /api/v2/users/:id - cursor pagination and enveloped responses",
+					"scim":       "POST/GET /scim/v2/Users, GET/PATCH/DELETE /scim/v2/Users/:id - SCIM 2.0 provisioning (requires users:admin)",
+					"health":     "GET /health - Health check",
+					"timestamps": "?tz=Europe/Berlin renders created_at/updated_at in that zone, ?time_format=epoch_ms as integer milliseconds",
 				},
 			})
-		})
+		}})
 	})
 
-	// Start the server
-	context.Log.Infow("Starting Clean Architecture web server", 
-		"architecture", "Clean Architecture",
-		"layers", []string{"Domain", "Application", "Infrastructure", "Interface"},
-		"patterns", []string{"Dependency Inversion", "Separation of Concerns", "Single Responsibility"},
-	)
-	
-	server.Serve()
+	timer.Done("routes")
+
+	return context, server, drain
+}
+
+// logCaseConflicts warns about every group of users whose email or username differ only by case
+func logCaseConflicts(log domain.Log, conflicts []repository.CaseConflict) {
+	for _, conflict := range conflicts {
+		log.Warnw("Users collide ignoring case, resolve them to enforce case-insensitive uniqueness",
+			"field", conflict.Field, "value", conflict.Value, "user_ids", conflict.UserIDs)
+	}
 }
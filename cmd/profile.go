@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"web-clean/infra/startup"
+)
+
+// runProfile handles `profile startup`: it runs the same startup sequence as the server
+// (including migrations) without listening, then reports the time spent in each phase
+func runProfile(args []string) int {
+	if len(args) != 1 || args[0] != "startup" {
+		fmt.Fprintln(os.Stderr, "usage: profile startup")
+		return 2
+	}
+
+	timer := startup.NewTimer()
+	boot(timer)
+
+	total := timer.Total()
+	for _, phase := range timer.Phases() {
+		share := 0.0
+		if total > 0 {
+			share = float64(phase.Duration) / float64(total) * 100
+		}
+		fmt.Fprintf(os.Stdout, "%-14s %12s %5.1f%%\n", phase.Name, phase.Duration, share)
+	}
+	fmt.Fprintf(os.Stdout, "%-14s %12s\n", "total", total)
+	return 0
+}
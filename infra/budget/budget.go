@@ -0,0 +1,100 @@
+// Package budget 限制单个请求可以消耗的数据库资源（查询次数与返回行数），
+// 防止 limit=100 加上超大字段之类的异常请求拖垮数据库与进程内存
+package budget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"web-clean/infra/conf"
+)
+
+// ErrExceeded 表示请求超出了数据库预算，超出后该请求的后续查询都会直接失败
+var ErrExceeded = errors.New("request database budget exceeded")
+
+// Limits 是单个请求的数据库预算，0 表示不限制
+type Limits struct {
+	MaxQueries int
+	MaxRows    int64
+}
+
+// LimitsFrom 读取 database.max_queries_per_request 与 database.max_rows_per_request
+func LimitsFrom(config *conf.DatabaseConf) Limits {
+	if config == nil {
+		return Limits{}
+	}
+	return Limits{MaxQueries: config.MaxQueriesPerRequest, MaxRows: config.MaxRowsPerRequest}
+}
+
+// Enabled 表示是否设置了任一限制
+func (l Limits) Enabled() bool {
+	return l.MaxQueries > 0 || l.MaxRows > 0
+}
+
+// Usage 记录一个请求已经消耗的预算，可并发使用
+type Usage struct {
+	limits   Limits
+	queries  atomic.Int64
+	rows     atomic.Int64
+	exceeded atomic.Bool
+}
+
+type usageKey struct{}
+
+// WithUsage 为请求的 context 附加一份新的预算
+func WithUsage(ctx context.Context, limits Limits) (context.Context, *Usage) {
+	usage := &Usage{limits: limits}
+	return context.WithValue(ctx, usageKey{}, usage), usage
+}
+
+// UsageFrom 取出请求的预算，没有附加预算时返回 false
+func UsageFrom(ctx context.Context) (*Usage, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	usage, ok := ctx.Value(usageKey{}).(*Usage)
+	return usage, ok
+}
+
+// BeforeQuery 在执行查询前调用，已超出或本次查询将超出次数限制时返回 ErrExceeded
+func (u *Usage) BeforeQuery() error {
+	if u.exceeded.Load() {
+		return ErrExceeded
+	}
+
+	queries := u.queries.Add(1)
+	if u.limits.MaxQueries > 0 && queries > int64(u.limits.MaxQueries) {
+		u.exceeded.Store(true)
+		return fmt.Errorf("%w: 超过 %d 次查询", ErrExceeded, u.limits.MaxQueries)
+	}
+	return nil
+}
+
+// AfterQuery 在查询返回后累计行数，累计行数超出限制时返回 ErrExceeded
+func (u *Usage) AfterQuery(rows int64) error {
+	if rows <= 0 {
+		return nil
+	}
+
+	total := u.rows.Add(rows)
+	if u.limits.MaxRows > 0 && total > u.limits.MaxRows {
+		u.exceeded.Store(true)
+		return fmt.Errorf("%w: 超过 %d 行", ErrExceeded, u.limits.MaxRows)
+	}
+	return nil
+}
+
+func (u *Usage) Queries() int64 {
+	return u.queries.Load()
+}
+
+func (u *Usage) Rows() int64 {
+	return u.rows.Load()
+}
+
+// Exceeded 表示请求是否超出过预算
+func (u *Usage) Exceeded() bool {
+	return u.exceeded.Load()
+}
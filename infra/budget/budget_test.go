@@ -0,0 +1,38 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsage_MaxQueries(t *testing.T) {
+	ctx, usage := WithUsage(context.Background(), Limits{MaxQueries: 2})
+
+	fromCtx, ok := UsageFrom(ctx)
+	assert.True(t, ok)
+	assert.Same(t, usage, fromCtx)
+
+	assert.NoError(t, usage.BeforeQuery())
+	assert.NoError(t, usage.BeforeQuery())
+	assert.True(t, errors.Is(usage.BeforeQuery(), ErrExceeded))
+	assert.True(t, usage.Exceeded())
+	assert.EqualValues(t, 3, usage.Queries())
+}
+
+func TestUsage_MaxRows(t *testing.T) {
+	_, usage := WithUsage(context.Background(), Limits{MaxRows: 100})
+
+	assert.NoError(t, usage.AfterQuery(60))
+	assert.True(t, errors.Is(usage.AfterQuery(60), ErrExceeded))
+
+	// 超出后后续查询直接失败
+	assert.True(t, errors.Is(usage.BeforeQuery(), ErrExceeded))
+}
+
+func TestUsageFrom_Missing(t *testing.T) {
+	_, ok := UsageFrom(context.Background())
+	assert.False(t, ok)
+}
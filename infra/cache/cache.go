@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"web-clean/infra/conf"
+)
+
+const (
+	KindRedis  = "redis"
+	KindMemory = "memory"
+)
+
+// Cache 是按 key 存取字节串的缓存，实现需要并发安全。缓存只用于加速读取，调用方在出错时应回源
+type Cache interface {
+	// Get 返回 key 对应的值，不存在或已过期时 ok 为 false
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set 写入 key，ttl 之后过期
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete 删除 keys，不存在的 key 被忽略
+	Delete(ctx context.Context, keys ...string) error
+	// Ping 检查缓存是否可用，供健康检查使用
+	Ping(ctx context.Context) error
+}
+
+// From 根据配置创建缓存，未配置 kind 时返回 nil，表示不启用缓存；
+// 返回的缓存会为所有 key 加上 cache.prefix
+func From(config *conf.Cache) (Cache, error) {
+	if config == nil || config.Kind == "" {
+		return nil, nil
+	}
+
+	prefix := config.Prefix
+	if prefix == "" {
+		prefix = conf.DefaultCachePrefix
+	}
+
+	switch strings.ToLower(config.Kind) {
+	case KindRedis:
+		if config.Addr == "" {
+			return nil, errors.New("cache.addr 为空")
+		}
+		poolSize := config.PoolSize
+		if poolSize <= 0 {
+			poolSize = conf.DefaultCachePoolSize
+		}
+		return Prefixed(NewRedis(RedisOptions{
+			Addr:     config.Addr,
+			Password: config.Password,
+			DB:       config.DB,
+			PoolSize: poolSize,
+		}), prefix), nil
+	case KindMemory:
+		return Prefixed(NewMemory(), prefix), nil
+	default:
+		return nil, fmt.Errorf("不支持的缓存类型 %q", config.Kind)
+	}
+}
+
+// Prefixed 为所有 key 加上 prefix
+func Prefixed(inner Cache, prefix string) Cache {
+	return prefixed{inner: inner, prefix: prefix}
+}
+
+type prefixed struct {
+	inner  Cache
+	prefix string
+}
+
+func (p prefixed) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	return p.inner.Get(ctx, p.prefix+key)
+}
+
+func (p prefixed) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return p.inner.Set(ctx, p.prefix+key, value, ttl)
+}
+
+func (p prefixed) Delete(ctx context.Context, keys ...string) error {
+	prefixedKeys := make([]string, len(keys))
+	for i, key := range keys {
+		prefixedKeys[i] = p.prefix + key
+	}
+	return p.inner.Delete(ctx, prefixedKeys...)
+}
+
+func (p prefixed) Ping(ctx context.Context) error {
+	return p.inner.Ping(ctx)
+}
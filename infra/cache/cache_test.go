@@ -0,0 +1,201 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"web-clean/infra/conf"
+)
+
+func TestFrom(t *testing.T) {
+	c, err := From(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, c)
+
+	c, err = From(&conf.Cache{Kind: KindMemory})
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+
+	_, err = From(&conf.Cache{Kind: KindRedis})
+	assert.Error(t, err)
+
+	_, err = From(&conf.Cache{Kind: "memcached"})
+	assert.Error(t, err)
+}
+
+func TestMemory_Expiry(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, m.Set(ctx, "a", []byte("1"), time.Minute))
+	value, ok, err := m.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	now = now.Add(time.Minute)
+	_, ok, _ = m.Get(ctx, "a")
+	assert.False(t, ok, "expired entries are misses")
+
+	require.NoError(t, m.Set(ctx, "b", []byte("2"), time.Hour))
+	require.NoError(t, m.Delete(ctx, "b", "missing"))
+	_, ok, _ = m.Get(ctx, "b")
+	assert.False(t, ok)
+}
+
+func TestPrefixed(t *testing.T) {
+	m := NewMemory()
+	c := Prefixed(m, "app:")
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "k", []byte("v"), time.Minute))
+	_, ok, _ := m.Get(ctx, "app:k")
+	assert.True(t, ok)
+
+	require.NoError(t, c.Delete(ctx, "k"))
+	_, ok, _ = m.Get(ctx, "app:k")
+	assert.False(t, ok)
+}
+
+// fakeRedis is a RESP server that understands AUTH, SELECT, GET, SET, DEL and PING
+type fakeRedis struct {
+	mu       sync.Mutex
+	data     map[string]string
+	commands []string
+	password string
+}
+
+func startFakeRedis(t *testing.T, password string) (*fakeRedis, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	server := &fakeRedis{data: make(map[string]string), password: password}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server, listener.Addr().String()
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authed := s.password == ""
+
+	for {
+		reply, err := readReply(reader)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, arg := range reply.([]interface{}) {
+			args = append(args, string(arg.([]byte)))
+		}
+
+		s.mu.Lock()
+		s.commands = append(s.commands, strings.Join(args, " "))
+		var out string
+		switch {
+		case args[0] == "AUTH":
+			authed = args[1] == s.password
+			out = "+OK\r\n"
+			if !authed {
+				out = "-WRONGPASS invalid password\r\n"
+			}
+		case !authed:
+			out = "-NOAUTH Authentication required.\r\n"
+		case args[0] == "PING":
+			out = "+PONG\r\n"
+		case args[0] == "SELECT":
+			out = "+OK\r\n"
+		case args[0] == "SET":
+			s.data[args[1]] = args[2]
+			out = "+OK\r\n"
+		case args[0] == "GET":
+			value, ok := s.data[args[1]]
+			out = "$-1\r\n"
+			if ok {
+				out = "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+			}
+		case args[0] == "DEL":
+			deleted := 0
+			for _, key := range args[1:] {
+				if _, ok := s.data[key]; ok {
+					delete(s.data, key)
+					deleted++
+				}
+			}
+			out = ":" + strconv.Itoa(deleted) + "\r\n"
+		default:
+			out = "-ERR unknown command\r\n"
+		}
+		s.mu.Unlock()
+
+		if _, err := conn.Write([]byte(out)); err != nil {
+			return
+		}
+	}
+}
+
+func TestRedis_Commands(t *testing.T) {
+	server, addr := startFakeRedis(t, "secret")
+	r := NewRedis(RedisOptions{Addr: addr, Password: "secret", DB: 2, PoolSize: 1})
+	defer r.Close()
+	ctx := context.Background()
+
+	assert.NoError(t, r.Ping(ctx))
+
+	_, ok, err := r.Get(ctx, "user")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, r.Set(ctx, "user", []byte("a\r\nb"), 1500*time.Millisecond))
+	value, ok, err := r.Get(ctx, "user")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("a\r\nb"), value, "bulk strings are binary safe")
+
+	require.NoError(t, r.Delete(ctx, "user", "other"))
+	_, ok, _ = r.Get(ctx, "user")
+	assert.False(t, ok)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, []string{"AUTH secret", "SELECT 2", "PING"}, server.commands[:3], "one pooled connection is reused")
+	assert.Contains(t, server.commands, "SET user a\r\nb PX 1500")
+}
+
+func TestRedis_Errors(t *testing.T) {
+	_, addr := startFakeRedis(t, "secret")
+	ctx := context.Background()
+
+	r := NewRedis(RedisOptions{Addr: addr, Password: "wrong"})
+	var redisErr RedisError
+	assert.ErrorAs(t, r.Ping(ctx), &redisErr)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closed := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	r = NewRedis(RedisOptions{Addr: closed, Timeout: 100 * time.Millisecond})
+	_, _, err = r.Get(ctx, "user")
+	assert.Error(t, err)
+}
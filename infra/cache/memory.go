@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory 是进程内缓存，多个实例之间不共享，一个实例上的失效对其他实例不可见，适合单实例部署与测试
+type Memory struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// memorySweepInterval 是清理过期条目的最小间隔
+const memorySweepInterval = time.Minute
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemory 创建进程内缓存
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]memoryEntry), now: time.Now}
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !m.now().Before(entry.expiresAt) {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// 定期顺带清理过期条目，避免只写不读的 key 无限增长
+	now := m.now()
+	if now.Sub(m.lastSweep) >= memorySweepInterval {
+		for k, entry := range m.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(m.entries, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	m.entries[key] = memoryEntry{value: append([]byte(nil), value...), expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+func (m *Memory) Ping(context.Context) error {
+	return nil
+}
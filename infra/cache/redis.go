@@ -0,0 +1,242 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// defaultRedisTimeout 是 ctx 未设置截止时间时单条命令的超时，缓存慢于数据库就失去了意义
+const defaultRedisTimeout = 500 * time.Millisecond
+
+// RedisOptions 配置 Redis 连接
+type RedisOptions struct {
+	Addr     string
+	Password string
+	DB       int
+	// PoolSize 是最多保留的空闲连接数，超出的连接用完即关闭
+	PoolSize int
+	// Timeout 是 ctx 未设置截止时间时单条命令的超时，0 表示 500ms
+	Timeout time.Duration
+}
+
+// RedisError 是 Redis 返回的错误回复，例如 WRONGTYPE 或 NOAUTH
+type RedisError string
+
+func (e RedisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// errNilReply 表示 Redis 返回了空回复（key 不存在）
+var errNilReply = errors.New("redis: nil reply")
+
+// Redis 是基于 RESP 协议的最小 Redis 客户端，只实现缓存需要的 GET、SET PX、DEL 与 PING
+type Redis struct {
+	options RedisOptions
+	dialer  net.Dialer
+	idle    chan *redisConn
+}
+
+// NewRedis 创建 Redis 缓存，连接在第一次使用时建立
+func NewRedis(options RedisOptions) *Redis {
+	if options.Timeout <= 0 {
+		options.Timeout = defaultRedisTimeout
+	}
+	return &Redis{options: options, idle: make(chan *redisConn, max(options.PoolSize, 1))}
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", key)
+	if errors.Is(err, errNilReply) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected GET reply %T", reply)
+	}
+	return value, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := r.do(ctx, "SET", key, string(value), "PX", strconv.FormatInt(max(ttl.Milliseconds(), 1), 10))
+	return err
+}
+
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := r.do(ctx, append([]string{"DEL"}, keys...)...)
+	return err
+}
+
+func (r *Redis) Ping(ctx context.Context) error {
+	_, err := r.do(ctx, "PING")
+	return err
+}
+
+// Close 关闭所有空闲连接
+func (r *Redis) Close() error {
+	for {
+		select {
+		case conn := <-r.idle:
+			_ = conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// redisConn 是一条带读缓冲的 Redis 连接
+type redisConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// do 执行一条命令。网络或协议错误会关闭连接，Redis 的错误回复不影响连接复用
+func (r *Redis) do(ctx context.Context, args ...string) (interface{}, error) {
+	conn, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.roundTrip(ctx, r.options.Timeout, args)
+	var redisErr RedisError
+	if err != nil && !errors.Is(err, errNilReply) && !errors.As(err, &redisErr) {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	r.release(conn)
+	return reply, err
+}
+
+// conn 取出一条空闲连接，没有时新建并完成 AUTH 与 SELECT
+func (r *Redis) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-r.idle:
+		return conn, nil
+	default:
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, r.options.Timeout)
+	defer cancel()
+
+	raw, err := r.dialer.DialContext(dialCtx, "tcp", r.options.Addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{Conn: raw, reader: bufio.NewReader(raw)}
+
+	if r.options.Password != "" {
+		if _, err := conn.roundTrip(ctx, r.options.Timeout, []string{"AUTH", r.options.Password}); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	if r.options.DB != 0 {
+		if _, err := conn.roundTrip(ctx, r.options.Timeout, []string{"SELECT", strconv.Itoa(r.options.DB)}); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// release 将连接放回空闲池，池满时关闭
+func (r *Redis) release(conn *redisConn) {
+	select {
+	case r.idle <- conn:
+	default:
+		_ = conn.Close()
+	}
+}
+
+// roundTrip 以 RESP 数组发送命令并读取一条回复
+func (c *redisConn) roundTrip(ctx context.Context, timeout time.Duration, args []string) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(timeout)
+	}
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := c.Write(buf); err != nil {
+		return nil, err
+	}
+
+	return readReply(c.reader)
+}
+
+// readReply 解析一条 RESP2 回复：简单字符串返回 string，整数返回 int64，批量字符串返回 []byte，数组返回 []interface{}
+func readReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, payload := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, RedisError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", payload)
+		}
+		if size < 0 {
+			return nil, errNilReply
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		return data[:size], nil
+	case '*':
+		count, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed array length %q", payload)
+		}
+		if count < 0 {
+			return nil, errNilReply
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			item, err := readReply(reader)
+			if err != nil && !errors.Is(err, errNilReply) {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+	}
+}
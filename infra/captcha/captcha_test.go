@@ -0,0 +1,39 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"web-clean/infra/conf"
+)
+
+func TestFrom_Disabled(t *testing.T) {
+	verifier, err := From(&conf.Captcha{}, http.DefaultClient)
+
+	assert.NoError(t, err)
+	assert.NoError(t, verifier.Verify(context.Background(), "", ""))
+}
+
+func TestSiteVerify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "secret", r.PostForm.Get("secret"))
+		if r.PostForm.Get("response") == "good" {
+			_, _ = w.Write([]byte(`{"success":true}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":false,"error-codes":["invalid-input-response"]}`))
+	}))
+	defer server.Close()
+
+	verifier := &siteVerify{endpoint: server.URL, secret: "secret", client: server.Client()}
+
+	assert.NoError(t, verifier.Verify(context.Background(), "good", "127.0.0.1"))
+	assert.True(t, errors.Is(verifier.Verify(context.Background(), "bad", ""), ErrVerificationFailed))
+	assert.True(t, errors.Is(verifier.Verify(context.Background(), "", ""), ErrVerificationFailed))
+}
@@ -0,0 +1,90 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"web-clean/infra/conf"
+)
+
+// ErrInjected 是故障注入产生的错误
+var ErrInjected = errors.New("chaos: injected fault")
+
+// Fault 是一次注入：先等待 Latency，再以 ErrorRate 的概率失败
+type Fault struct {
+	Latency   time.Duration
+	ErrorRate float64
+}
+
+// FromConf 将配置转换为 Fault
+func FromConf(fault conf.Fault) Fault {
+	return Fault{Latency: time.Duration(fault.LatencyMs) * time.Millisecond, ErrorRate: fault.ErrorRate}
+}
+
+// IsZero 表示该 Fault 不会产生任何影响
+func (f Fault) IsZero() bool {
+	return f.Latency <= 0 && f.ErrorRate <= 0
+}
+
+// Inject 执行故障注入，等待期间 ctx 结束时返回 ctx.Err()
+func (f Fault) Inject(ctx context.Context) error {
+	if f.Latency > 0 {
+		timer := time.NewTimer(f.Latency)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if f.ErrorRate > 0 && rand.Float64() < f.ErrorRate {
+		return ErrInjected
+	}
+	return nil
+}
+
+// Rules 按键（路由或表名）查找 Fault，"*" 为兜底规则
+type Rules map[string]Fault
+
+// RulesFromConf 将配置转换为 Rules
+func RulesFromConf(faults map[string]conf.Fault) Rules {
+	rules := make(Rules, len(faults))
+	for key, fault := range faults {
+		rules[key] = FromConf(fault)
+	}
+	return rules
+}
+
+// Lookup 返回 key 对应的 Fault，不存在时回退到 "*"
+func (r Rules) Lookup(key string) (Fault, bool) {
+	if fault, ok := r[key]; ok {
+		return fault, true
+	}
+	fault, ok := r["*"]
+	return fault, ok
+}
+
+// Enabled 表示故障注入是否应当开启：需要显式开启，并且永远不会在生产模式下开启
+func Enabled(c *conf.Conf) bool {
+	return c != nil && !c.ProductionMode && c.Chaos != nil && c.Chaos.Enabled
+}
+
+type databaseFaultKey struct{}
+
+// WithDatabaseFault 为 ctx 下执行的数据库语句指定故障，优先于按表配置的规则
+func WithDatabaseFault(ctx context.Context, fault Fault) context.Context {
+	return context.WithValue(ctx, databaseFaultKey{}, fault)
+}
+
+// DatabaseFaultFrom 取出 WithDatabaseFault 设置的故障
+func DatabaseFaultFrom(ctx context.Context) (Fault, bool) {
+	if ctx == nil {
+		return Fault{}, false
+	}
+	fault, ok := ctx.Value(databaseFaultKey{}).(Fault)
+	return fault, ok
+}
@@ -0,0 +1,49 @@
+package chaos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"web-clean/infra/conf"
+)
+
+func TestFault_Inject(t *testing.T) {
+	assert.NoError(t, Fault{}.Inject(context.Background()))
+	assert.ErrorIs(t, Fault{ErrorRate: 1}.Inject(context.Background()), ErrInjected)
+
+	start := time.Now()
+	assert.NoError(t, Fault{Latency: 20 * time.Millisecond}.Inject(context.Background()))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, Fault{Latency: time.Hour}.Inject(ctx), context.Canceled)
+}
+
+func TestRules_Lookup(t *testing.T) {
+	rules := RulesFromConf(map[string]conf.Fault{
+		"users": {LatencyMs: 10},
+		"*":     {ErrorRate: 0.5},
+	})
+
+	fault, ok := rules.Lookup("users")
+	assert.True(t, ok)
+	assert.Equal(t, 10*time.Millisecond, fault.Latency)
+
+	fault, ok = rules.Lookup("logs_models")
+	assert.True(t, ok)
+	assert.Equal(t, 0.5, fault.ErrorRate)
+
+	_, ok = Rules{}.Lookup("users")
+	assert.False(t, ok)
+}
+
+func TestEnabled_NeverInProduction(t *testing.T) {
+	assert.False(t, Enabled(nil))
+	assert.False(t, Enabled(&conf.Conf{Chaos: &conf.Chaos{}}))
+	assert.True(t, Enabled(&conf.Conf{Chaos: &conf.Chaos{Enabled: true}}))
+	assert.False(t, Enabled(&conf.Conf{ProductionMode: true, Chaos: &conf.Chaos{Enabled: true}}))
+}
@@ -1,26 +1,356 @@
 package conf
 
+import "time"
+
 type Conf struct {
 	ProductionMode bool          `json:"production"`
 	Logger         *Logger       `json:"logger"`
 	Web            *Web          `json:"web"`
 	Database       *DatabaseConf `json:"database"`
+	Captcha        *Captcha      `json:"captcha"`
+	SignupRisk     *SignupRisk   `json:"signup_risk"`
+	// ReservedUsernames 追加到内置保留用户名列表的词与正则，可通过 /admin/reserved-usernames 在运行时替换
+	ReservedUsernames *ReservedUsernames `json:"reserved_usernames"`
+	Persistence       *Persistence       `json:"persistence"`
+	Sink              *Sink              `json:"sink"`
+	// Cache 为用户读取等热点查询提供缓存，未配置时直接查询数据库
+	Cache *Cache `json:"cache"`
+
+	Chaos *Chaos `json:"chaos"`
+
+	// Experiments 以实验键为键配置 A/B 实验，调用方按身份确定性地分配到分组
+	Experiments map[string]Experiment `json:"experiments"`
+
+	// IDStrategy 新实体的主键生成方式：uuidv7（默认）、ulid 或 uuidv4
+	IDStrategy string `json:"id_strategy"`
+
+	// PasswordCost 是用户密码的 bcrypt 成本，默认 10；调高后已有的哈希仍按各自的成本校验
+	PasswordCost int `json:"password_cost"`
+
+	// Modules 按模块名开关功能，未列出的模块默认启用，用同一个二进制部署裁剪后的实例
+	Modules map[string]bool `json:"modules"`
+}
+
+const (
+	// MinPasswordCost 与 MaxPasswordCost 是 password_cost 的取值范围，与 bcrypt 一致
+	MinPasswordCost = 4
+	MaxPasswordCost = 31
+)
+
+const (
+	// ModuleUsers 是 /api/v1/users 与 /api/v2/users 用户接口
+	ModuleUsers = "users"
+	// ModuleSCIM 是供身份提供方调用的 /scim/v2/Users 接口
+	ModuleSCIM = "scim"
+	// ModuleAdmin 是 /admin 运维接口
+	ModuleAdmin = "admin"
+	// ModuleJobs 是后台周期任务，例如错误回退文件回放
+	ModuleJobs = "jobs"
+	// ModuleAPIKeys 是 /api/v1/apikeys 管理接口与 Authorization: ApiKey 认证，
+	// users、scim 与 admin 模块依赖它认证，不能单独关闭
+	ModuleAPIKeys = "apikeys"
+)
+
+// Modules 是所有可通过 modules 配置开关的模块
+var Modules = []string{ModuleUsers, ModuleSCIM, ModuleAdmin, ModuleJobs, ModuleAPIKeys}
+
+// ModuleEnabled 判断模块是否启用，未配置的模块默认启用
+func (c *Conf) ModuleEnabled(name string) bool {
+	enabled, ok := c.Modules[name]
+	return !ok || enabled
 }
 
 type Logger struct {
 	Level string `json:"level"`
+
+	// RedactFields 追加到 web.DefaultRedactedFields 的字段名，这些字段的值在日志与持久化前被替换为 [REDACTED]
+	RedactFields []string `json:"redact_fields"`
 }
 
 type Web struct {
 	Port int `json:"port"`
+
+	// Listen 覆盖 Port：可以是 TCP 地址（"127.0.0.1:9000"）、unix socket（"unix:/run/webclean.sock"），
+	// 或者 "systemd" 表示使用 systemd socket activation 传入的套接字
+	Listen string `json:"listen"`
+
+	// Pagination 配置列表接口的默认与最大分页大小，内部的大流量调用方可以按部署放宽上限
+	Pagination *Pagination `json:"pagination"`
+
+	// PropagateHeaders 是从入站请求透传到出站 HTTP 调用的请求头白名单，
+	// 未配置时为 traceparent、tracestate、baggage、X-Request-ID 与 X-Tenant-ID，配置为 [] 表示不透传
+	PropagateHeaders []string `json:"propagate_headers"`
+
+	// RateLimits 按限流等级配置每个客户端的请求速率，等级由路由声明（例如 "read"、"write"、"signup"），
+	// 未配置的等级不限流
+	RateLimits map[string]RateLimit `json:"rate_limits"`
+}
+
+// RateLimit 是一个限流等级的令牌桶参数
+type RateLimit struct {
+	PerMinute int `json:"per_minute"` // 每分钟补充的请求数
+	Burst     int `json:"burst"`      // 允许的突发请求数，默认等于 per_minute
+}
+
+// Pagination 配置列表接口的分页大小
+type Pagination struct {
+	DefaultLimit int `json:"default_limit"` // 未指定 limit 时的分页大小，默认 10
+	MaxLimit     int `json:"max_limit"`     // 允许的最大分页大小，默认 100
+}
+
+const (
+	// DefaultPageLimit 是未配置 web.pagination.default_limit 时的分页大小
+	DefaultPageLimit = 10
+	// DefaultMaxPageLimit 是未配置 web.pagination.max_limit 时的最大分页大小
+	DefaultMaxPageLimit = 100
+)
+
+// PageLimits 返回默认与最大分页大小，未配置的项取默认值，默认值不会超过最大值
+func (w *Web) PageLimits() (defaultLimit, maxLimit int) {
+	defaultLimit, maxLimit = DefaultPageLimit, DefaultMaxPageLimit
+	if w != nil && w.Pagination != nil {
+		if w.Pagination.MaxLimit > 0 {
+			maxLimit = w.Pagination.MaxLimit
+		}
+		if w.Pagination.DefaultLimit > 0 {
+			defaultLimit = w.Pagination.DefaultLimit
+		}
+	}
+	return min(defaultLimit, maxLimit), maxLimit
+}
+
+// Captcha 配置注册等接口的人机校验，Provider 为空时不启用
+type Captcha struct {
+	Provider string `json:"provider"`             // hcaptcha 或 turnstile
+	Secret   string `json:"secret" secret:"true"` // 服务端密钥
+}
+
+// ReservedUsernames 配置不允许注册的用户名
+type ReservedUsernames struct {
+	Words    []string `json:"words"`    // 保留词，忽略大小写与 - _ . 分隔符
+	Patterns []string `json:"patterns"` // 正则表达式，匹配小写后的用户名
+}
+
+// SignupRisk 配置注册风险评估，启用后只有分数达到 ChallengeScore 的注册才需要 CAPTCHA，达到 RejectScore 的直接拒绝
+type SignupRisk struct {
+	Enabled bool `json:"enabled"`
+
+	ChallengeScore int `json:"challenge_score"` // 要求 CAPTCHA 的分数，默认 50
+	RejectScore    int `json:"reject_score"`    // 拒绝注册的分数，默认 100
+
+	DisposableDomains []string `json:"disposable_domains"` // 追加到内置列表的一次性邮箱域名
+
+	VelocityLimit         int `json:"velocity_limit"`          // 同一 IP 在窗口内允许的注册次数，0 表示不限制
+	VelocityWindowSeconds int `json:"velocity_window_seconds"` // 统计窗口，默认 3600 秒
+
+	ReputationURL string `json:"reputation_url"` // 可选的外部信誉服务地址
+}
+
+// VelocityWindow 返回 IP 频率统计窗口，未配置时为一小时
+func (s *SignupRisk) VelocityWindow() time.Duration {
+	if s.VelocityWindowSeconds <= 0 {
+		return time.Hour
+	}
+	return time.Duration(s.VelocityWindowSeconds) * time.Second
+}
+
+// Persistence 控制请求日志与错误堆栈写入数据库前的处理，未配置时原样写入 jsonb
+type Persistence struct {
+	Payload        string `json:"payload"`          // raw（默认）、gzip（压缩后写入 bytea）或 trim（截断过长字符串）
+	MaxStringBytes int    `json:"max_string_bytes"` // trim 模式下单个字符串的最大字节数，默认 2048
+
+	FallbackMaxMB       int `json:"fallback_max_mb"`        // 错误回退目录（含已归档文件）的大小上限，默认 256，-1 表示不限制
+	FallbackMaxAgeHours int `json:"fallback_max_age_hours"` // 回退文件的保留时间，默认 168（7 天），-1 表示不限制
+}
+
+const (
+	// DefaultFallbackMaxMB 是未配置 persistence.fallback_max_mb 时错误回退目录的大小上限
+	DefaultFallbackMaxMB = 256
+	// DefaultFallbackMaxAgeHours 是未配置 persistence.fallback_max_age_hours 时回退文件的保留时间
+	DefaultFallbackMaxAgeHours = 7 * 24
+)
+
+// FallbackQuota 返回错误回退目录的大小上限与保留时间，未配置的项取默认值，返回 0 表示不限制
+func (p *Persistence) FallbackQuota() (maxBytes int64, maxAge time.Duration) {
+	maxMB, maxAgeHours := DefaultFallbackMaxMB, DefaultFallbackMaxAgeHours
+	if p != nil {
+		if p.FallbackMaxMB != 0 {
+			maxMB = p.FallbackMaxMB
+		}
+		if p.FallbackMaxAgeHours != 0 {
+			maxAgeHours = p.FallbackMaxAgeHours
+		}
+	}
+	return int64(max(maxMB, 0)) << 20, time.Duration(max(maxAgeHours, 0)) * time.Hour
+}
+
+// Sink 选择请求日志与错误堆栈的写入目标，未配置或 Kind 为 postgres 时写入主数据库
+type Sink struct {
+	Kind string `json:"kind"` // postgres（默认）、loki、elasticsearch 或 s3
+	URL  string `json:"url"`  // Loki / Elasticsearch 的地址，或 S3 兼容服务的 endpoint
+
+	Username string `json:"username"`               // Loki / Elasticsearch 的 Basic Auth 用户名
+	Password string `json:"password" secret:"true"` // Loki / Elasticsearch 的 Basic Auth 密码
+
+	Labels map[string]string `json:"labels"` // Loki 流标签，会追加 kind=logs|errors
+	Index  string            `json:"index"`  // Elasticsearch 索引前缀，按天追加日期后缀
+
+	Bucket    string `json:"bucket"`                   // S3 存储桶
+	Region    string `json:"region"`                   // S3 区域
+	Prefix    string `json:"prefix"`                   // S3 对象键前缀
+	AccessKey string `json:"access_key" secret:"true"` // S3 访问密钥 ID
+	SecretKey string `json:"secret_key" secret:"true"` // S3 访问密钥
+}
+
+// Cache 配置读缓存，Kind 为空时不启用
+type Cache struct {
+	Kind string `json:"kind"` // redis 或 memory（仅进程内，多实例之间不共享失效）
+
+	Addr     string `json:"addr"`                   // Redis 地址，如 127.0.0.1:6379
+	Password string `json:"password" secret:"true"` // Redis AUTH 密码
+	DB       int    `json:"db"`                     // Redis 库编号
+	PoolSize int    `json:"pool_size"`              // 最多保留的空闲连接数，默认 10
+
+	Prefix     string `json:"prefix"`      // key 前缀，多个服务共用一个 Redis 时用于区分，默认 web-clean:
+	TTLSeconds int    `json:"ttl_seconds"` // 缓存条目的有效期，默认 60 秒
+
+	// StaleSeconds 是条目过期后仍可返回旧值的时长，期间读取立即返回旧值并在后台刷新，
+	// 避免高负载下条目集中过期时的延迟尖刺；默认 0，过期即回源
+	StaleSeconds int `json:"stale_seconds"`
+}
+
+const (
+	// DefaultCachePoolSize 是未配置 cache.pool_size 时保留的空闲连接数
+	DefaultCachePoolSize = 10
+	// DefaultCacheTTLSeconds 是未配置 cache.ttl_seconds 时缓存条目的有效期
+	DefaultCacheTTLSeconds = 60
+	// DefaultCachePrefix 是未配置 cache.prefix 时的 key 前缀
+	DefaultCachePrefix = "web-clean:"
+)
+
+// TTL 返回缓存条目的有效期，未配置时为 DefaultCacheTTLSeconds
+func (c *Cache) TTL() time.Duration {
+	if c == nil || c.TTLSeconds <= 0 {
+		return DefaultCacheTTLSeconds * time.Second
+	}
+	return time.Duration(c.TTLSeconds) * time.Second
+}
+
+// Stale 返回过期条目仍可返回旧值的时长，未配置时为 0
+func (c *Cache) Stale() time.Duration {
+	if c == nil || c.StaleSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(c.StaleSeconds) * time.Second
+}
+
+// Chaos 配置故障注入，仅在非生产模式下生效，用于验证超时、重试与熔断行为
+type Chaos struct {
+	Enabled bool `json:"enabled"`
+	// Headers 允许请求通过 X-Chaos-* 请求头为本次请求指定故障
+	Headers bool `json:"headers"`
+	// Routes 以 "GET /api/v1/users/:id" 形式的路由为键，"*" 匹配所有路由
+	Routes map[string]Fault `json:"routes"`
+	// Tables 以表名为键为数据库语句注入故障，"*" 匹配所有表
+	Tables map[string]Fault `json:"tables"`
+}
+
+// Experiment 描述一个 A/B 实验，未启用的实验不分配任何分组
+type Experiment struct {
+	Enabled bool `json:"enabled"`
+	// Variants 为分组名到权重的映射，例如 {"control": 50, "treatment": 50}
+	Variants map[string]int `json:"variants"`
+}
+
+// Fault 描述一种注入的故障
+type Fault struct {
+	LatencyMs int     `json:"latency_ms"` // 注入的延迟（毫秒）
+	ErrorRate float64 `json:"error_rate"` // 0 到 1 之间的失败概率
 }
 
 type DatabaseConf struct {
-	Driver   string `json:"driver"`   // 数据库驱动类型
-	Host     string `json:"host"`     // 数据库主机地址
-	Port     int    `json:"port"`     // 数据库端口
-	Database string `json:"database"` // 数据库名称
-	Username string `json:"username"` // 用户名
-	Password string `json:"password"` // 密码
-	DSN      string `json:"dsn"`      // 完整的数据源名称，如果提供则优先使用
+	Driver   string `json:"driver"`                 // 数据库驱动类型
+	Host     string `json:"host"`                   // 数据库主机地址
+	Port     int    `json:"port"`                   // 数据库端口
+	Database string `json:"database"`               // 数据库名称
+	Username string `json:"username"`               // 用户名
+	Password string `json:"password" secret:"true"` // 密码，可以是 vault:// 或 awssm:// 引用
+	DSN      string `json:"dsn" secret:"true"`      // 完整的数据源名称，如果提供则优先使用
+
+	// AutoMigrate 为 false 时启动不再自动迁移，表结构缺失会导致启动失败，需先手动迁移；默认为 true
+	AutoMigrate *bool `json:"auto_migrate"`
+
+	// ReadOnly 表示连接的是只读副本：跳过迁移，写接口返回 503，用于主库故障时继续提供读服务
+	ReadOnly bool `json:"read_only"`
+
+	// SQLSampleRate 以 0 到 1 的比例采样记录完整 SQL 与耗时，默认 0 关闭，可通过 /admin/sql-sampling 在运行时调整
+	SQLSampleRate float64 `json:"sql_sample_rate"`
+	// SQLRedactColumns 采样日志中需要脱敏的列，未配置时使用 database.DefaultRedactedColumns
+	SQLRedactColumns []string `json:"sql_redact_columns"`
+
+	// MaxQueriesPerRequest 单个请求允许执行的语句数，超出后请求返回 503，0 表示不限制
+	MaxQueriesPerRequest int `json:"max_queries_per_request"`
+	// MaxRowsPerRequest 单个请求允许读取的总行数，超出后请求返回 503，0 表示不限制
+	MaxRowsPerRequest int64 `json:"max_rows_per_request"`
+
+	MaxOpenConns           int `json:"max_open_conns"`            // 最大连接数，默认 25，应小于 Postgres 的 max_connections 除以实例数
+	MaxIdleConns           int `json:"max_idle_conns"`            // 最大空闲连接数，默认与 MaxOpenConns 相同
+	ConnMaxLifetimeSeconds int `json:"conn_max_lifetime_seconds"` // 连接的最长存活时间，默认 1800 秒，便于故障切换后重新建连
+
+	// 启动时数据库尚未就绪（docker-compose、Kubernetes 的启动顺序）时按指数退避重试连接
+	ConnectRetries        int `json:"connect_retries"`         // 首次失败后的最大重试次数，默认 5，-1 表示不重试
+	ConnectBackoffMs      int `json:"connect_backoff_ms"`      // 首次重试前的等待时间，之后每次翻倍，默认 500 毫秒
+	ConnectTimeoutSeconds int `json:"connect_timeout_seconds"` // 连接（包括所有重试）的总超时，默认 60 秒
+}
+
+// DefaultMaxOpenConns 是未配置 max_open_conns 时的最大连接数
+const DefaultMaxOpenConns = 25
+
+// OpenConns 返回最大连接数，未配置时为 DefaultMaxOpenConns
+func (d *DatabaseConf) OpenConns() int {
+	if d.MaxOpenConns <= 0 {
+		return DefaultMaxOpenConns
+	}
+	return d.MaxOpenConns
+}
+
+// IdleConns 返回最大空闲连接数，未配置时与最大连接数相同，且不会超过最大连接数
+func (d *DatabaseConf) IdleConns() int {
+	if d.MaxIdleConns <= 0 || d.MaxIdleConns > d.OpenConns() {
+		return d.OpenConns()
+	}
+	return d.MaxIdleConns
+}
+
+// DefaultConnectRetries 是未配置 connect_retries 时的重试次数
+const DefaultConnectRetries = 5
+
+// ConnectRetryPolicy 返回启动连接数据库的重试次数、首次退避时间与总超时，未配置的项取默认值
+func (d *DatabaseConf) ConnectRetryPolicy() (retries int, backoff, timeout time.Duration) {
+	retries, backoff, timeout = DefaultConnectRetries, 500*time.Millisecond, time.Minute
+	if d.ConnectRetries < 0 {
+		retries = 0
+	} else if d.ConnectRetries > 0 {
+		retries = d.ConnectRetries
+	}
+	if d.ConnectBackoffMs > 0 {
+		backoff = time.Duration(d.ConnectBackoffMs) * time.Millisecond
+	}
+	if d.ConnectTimeoutSeconds > 0 {
+		timeout = time.Duration(d.ConnectTimeoutSeconds) * time.Second
+	}
+	return retries, backoff, timeout
+}
+
+// ConnMaxLifetime 返回连接的最长存活时间，未配置时为 30 分钟
+func (d *DatabaseConf) ConnMaxLifetime() time.Duration {
+	if d.ConnMaxLifetimeSeconds <= 0 {
+		return 30 * time.Minute
+	}
+	return time.Duration(d.ConnMaxLifetimeSeconds) * time.Second
+}
+
+// AutoMigrateEnabled 返回启动时是否自动迁移，未配置时为 true
+func (d *DatabaseConf) AutoMigrateEnabled() bool {
+	return d.AutoMigrate == nil || *d.AutoMigrate
 }
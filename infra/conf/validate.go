@@ -0,0 +1,216 @@
+package conf
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// FieldError 描述一个无效的配置项，Field 为 JSON 路径，例如 database.port
+type FieldError struct {
+	Field   string
+	Problem string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Problem
+}
+
+// ValidationErrors 汇总所有无效的配置项，使启动时一次列出全部问题
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, fieldError := range e {
+		lines[i] = "  - " + fieldError.Error()
+	}
+	return fmt.Sprintf("配置无效，共 %d 项:\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// LoggerLevels 是 logger.level 允许的取值
+var LoggerLevels = []string{"debug", "info", "warn", "error", "dpanic", "panic", "fatal"}
+
+// Validate 检查启动必需的配置项，返回包含所有问题的 ValidationErrors，没有问题时返回 nil。
+// 这样配置错误会在启动时被清楚地报告，而不是稍后以难以理解的 GORM 或 listen 错误出现
+func (c *Conf) Validate() error {
+	errs := make(ValidationErrors, 0)
+	add := func(field, problem string, args ...any) {
+		errs = append(errs, FieldError{Field: field, Problem: fmt.Sprintf(problem, args...)})
+	}
+
+	if c.Logger != nil && c.Logger.Level != "" && !contains(LoggerLevels, strings.ToLower(c.Logger.Level)) {
+		add("logger.level", "%q 不是有效的日志级别，可选 %s", c.Logger.Level, strings.Join(LoggerLevels, ", "))
+	}
+
+	switch {
+	case c.Web == nil:
+		add("web", "缺少 web 配置")
+	case c.Web.Listen == "" && !validPort(c.Web.Port):
+		add("web.port", "%d 不在 1-65535 范围内（或使用 web.listen）", c.Web.Port)
+	}
+
+	if c.Web != nil {
+		for _, class := range slices.Sorted(maps.Keys(c.Web.RateLimits)) {
+			limit := c.Web.RateLimits[class]
+			if limit.PerMinute <= 0 {
+				add("web.rate_limits."+class+".per_minute", "%d 必须大于 0", limit.PerMinute)
+			}
+			if limit.Burst < 0 {
+				add("web.rate_limits."+class+".burst", "%d 不能为负数", limit.Burst)
+			}
+		}
+	}
+
+	if c.Web != nil && c.Web.Pagination != nil {
+		pagination := c.Web.Pagination
+		if pagination.DefaultLimit < 0 {
+			add("web.pagination.default_limit", "%d 不能为负数", pagination.DefaultLimit)
+		}
+		if pagination.MaxLimit < 0 {
+			add("web.pagination.max_limit", "%d 不能为负数", pagination.MaxLimit)
+		}
+		if pagination.DefaultLimit > 0 && pagination.MaxLimit > 0 && pagination.DefaultLimit > pagination.MaxLimit {
+			add("web.pagination.default_limit", "%d 大于 max_limit %d", pagination.DefaultLimit, pagination.MaxLimit)
+		}
+	}
+
+	if c.Database == nil {
+		add("database", "缺少 database 配置")
+	} else {
+		database := c.Database
+		if database.Driver != "" && database.Driver != "postgres" {
+			add("database.driver", "不支持 %q，目前只支持 postgres", database.Driver)
+		}
+		if database.DSN == "" {
+			if strings.TrimSpace(database.Host) == "" {
+				add("database.host", "不能为空")
+			}
+			if !validPort(database.Port) {
+				add("database.port", "%d 不在 1-65535 范围内", database.Port)
+			}
+			if strings.TrimSpace(database.Database) == "" {
+				add("database.database", "不能为空")
+			}
+			if strings.TrimSpace(database.Username) == "" {
+				add("database.username", "不能为空")
+			}
+		}
+		if database.SQLSampleRate < 0 || database.SQLSampleRate > 1 {
+			add("database.sql_sample_rate", "%v 不在 0-1 范围内", database.SQLSampleRate)
+		}
+		if database.MaxQueriesPerRequest < 0 {
+			add("database.max_queries_per_request", "%d 不能为负数", database.MaxQueriesPerRequest)
+		}
+		if database.MaxRowsPerRequest < 0 {
+			add("database.max_rows_per_request", "%d 不能为负数", database.MaxRowsPerRequest)
+		}
+		if database.MaxOpenConns < 0 {
+			add("database.max_open_conns", "%d 不能为负数", database.MaxOpenConns)
+		}
+		if database.MaxIdleConns < 0 {
+			add("database.max_idle_conns", "%d 不能为负数", database.MaxIdleConns)
+		}
+		if database.ConnMaxLifetimeSeconds < 0 {
+			add("database.conn_max_lifetime_seconds", "%d 不能为负数", database.ConnMaxLifetimeSeconds)
+		}
+		if database.ConnectRetries < -1 {
+			add("database.connect_retries", "%d 无效，-1 表示不重试", database.ConnectRetries)
+		}
+		if database.ConnectBackoffMs < 0 {
+			add("database.connect_backoff_ms", "%d 不能为负数", database.ConnectBackoffMs)
+		}
+		if database.ConnectTimeoutSeconds < 0 {
+			add("database.connect_timeout_seconds", "%d 不能为负数", database.ConnectTimeoutSeconds)
+		}
+	}
+
+	if c.Persistence != nil {
+		if c.Persistence.FallbackMaxMB < -1 {
+			add("persistence.fallback_max_mb", "%d 无效，-1 表示不限制", c.Persistence.FallbackMaxMB)
+		}
+		if c.Persistence.FallbackMaxAgeHours < -1 {
+			add("persistence.fallback_max_age_hours", "%d 无效，-1 表示不限制", c.Persistence.FallbackMaxAgeHours)
+		}
+	}
+
+	if c.Cache != nil && c.Cache.Kind != "" {
+		cache := c.Cache
+		switch strings.ToLower(cache.Kind) {
+		case "redis":
+			if strings.TrimSpace(cache.Addr) == "" {
+				add("cache.addr", "redis 缓存需要配置地址")
+			}
+		case "memory":
+		default:
+			add("cache.kind", "不支持 %q，可选 redis, memory", cache.Kind)
+		}
+		if cache.DB < 0 {
+			add("cache.db", "%d 不能为负数", cache.DB)
+		}
+		if cache.PoolSize < 0 {
+			add("cache.pool_size", "%d 不能为负数", cache.PoolSize)
+		}
+		if cache.TTLSeconds < 0 {
+			add("cache.ttl_seconds", "%d 不能为负数", cache.TTLSeconds)
+		}
+		if cache.StaleSeconds < 0 {
+			add("cache.stale_seconds", "%d 不能为负数", cache.StaleSeconds)
+		}
+	}
+
+	if c.PasswordCost != 0 && (c.PasswordCost < MinPasswordCost || c.PasswordCost > MaxPasswordCost) {
+		add("password_cost", "%d 不在 %d-%d 范围内", c.PasswordCost, MinPasswordCost, MaxPasswordCost)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Modules)) {
+		if !contains(Modules, name) {
+			add("modules."+name, "未知模块，可选 %s", strings.Join(Modules, ", "))
+		}
+	}
+	// API Key 是唯一的认证方式，关闭后声明了 Scope 的接口对所有请求都返回 401
+	if !c.ModuleEnabled(ModuleAPIKeys) {
+		var scoped []string
+		for _, name := range []string{ModuleUsers, ModuleSCIM, ModuleAdmin} {
+			if c.ModuleEnabled(name) {
+				scoped = append(scoped, name)
+			}
+		}
+		if len(scoped) > 0 {
+			add("modules."+ModuleAPIKeys, "关闭后 %s 模块的接口无法认证，需要一并关闭", strings.Join(scoped, ", "))
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(c.Experiments)) {
+		experiment := c.Experiments[key]
+		total := 0
+		for _, name := range slices.Sorted(maps.Keys(experiment.Variants)) {
+			weight := experiment.Variants[name]
+			if weight < 0 {
+				add("experiments."+key+".variants."+name, "%d 不能为负数", weight)
+			}
+			total += weight
+		}
+		if experiment.Enabled && total <= 0 {
+			add("experiments."+key+".variants", "启用的实验至少需要一个权重大于 0 的分组")
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package conf
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validConf() *Conf {
+	return &Conf{
+		Logger: &Logger{Level: "debug"},
+		Web:    &Web{Port: 9000},
+		Database: &DatabaseConf{
+			Driver:   "postgres",
+			Host:     "localhost",
+			Port:     5432,
+			Database: "app",
+			Username: "postgres",
+		},
+	}
+}
+
+func TestValidate_Valid(t *testing.T) {
+	assert.NoError(t, validConf().Validate())
+
+	listen := validConf()
+	listen.Web = &Web{Listen: "unix:/run/app.sock"}
+	assert.NoError(t, listen.Validate())
+
+	dsn := validConf()
+	dsn.Database = &DatabaseConf{DSN: "postgres://localhost/app"}
+	assert.NoError(t, dsn.Validate())
+}
+
+func TestValidate_ListsEveryProblem(t *testing.T) {
+	c := validConf()
+	c.Logger.Level = "verbose"
+	c.Web.Port = 70000
+	c.Database.Host = ""
+	c.Database.Username = ""
+	c.Database.SQLSampleRate = 2
+
+	err := c.Validate()
+
+	var errs ValidationErrors
+	assert.True(t, errors.As(err, &errs))
+	fields := make([]string, len(errs))
+	for i, fieldError := range errs {
+		fields[i] = fieldError.Field
+	}
+	assert.Equal(t, []string{"logger.level", "web.port", "database.host", "database.username", "database.sql_sample_rate"}, fields)
+	assert.Contains(t, err.Error(), "共 5 项")
+}
+
+func TestValidate_MissingSections(t *testing.T) {
+	err := (&Conf{}).Validate()
+
+	var errs ValidationErrors
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, ValidationErrors{
+		{Field: "web", Problem: "缺少 web 配置"},
+		{Field: "database", Problem: "缺少 database 配置"},
+	}, errs)
+}
+
+func TestDatabaseConf_PoolDefaults(t *testing.T) {
+	d := &DatabaseConf{}
+	assert.Equal(t, DefaultMaxOpenConns, d.OpenConns())
+	assert.Equal(t, DefaultMaxOpenConns, d.IdleConns())
+	assert.Equal(t, 30*time.Minute, d.ConnMaxLifetime())
+
+	d = &DatabaseConf{MaxOpenConns: 10, MaxIdleConns: 50, ConnMaxLifetimeSeconds: 60}
+	assert.Equal(t, 10, d.OpenConns())
+	assert.Equal(t, 10, d.IdleConns())
+	assert.Equal(t, time.Minute, d.ConnMaxLifetime())
+}
+
+func TestWeb_PageLimits(t *testing.T) {
+	var w *Web
+	defaultLimit, maxLimit := w.PageLimits()
+	assert.Equal(t, DefaultPageLimit, defaultLimit)
+	assert.Equal(t, DefaultMaxPageLimit, maxLimit)
+
+	w = &Web{Pagination: &Pagination{MaxLimit: 5}}
+	defaultLimit, maxLimit = w.PageLimits()
+	assert.Equal(t, 5, defaultLimit)
+	assert.Equal(t, 5, maxLimit)
+
+	c := validConf()
+	c.Web.Pagination = &Pagination{DefaultLimit: 200, MaxLimit: 100}
+	assert.Equal(t, ValidationErrors{
+		{Field: "web.pagination.default_limit", Problem: "200 大于 max_limit 100"},
+	}, c.Validate())
+}
+
+func TestDatabaseConf_ConnectRetryPolicy(t *testing.T) {
+	retries, backoff, timeout := (&DatabaseConf{}).ConnectRetryPolicy()
+	assert.Equal(t, DefaultConnectRetries, retries)
+	assert.Equal(t, 500*time.Millisecond, backoff)
+	assert.Equal(t, time.Minute, timeout)
+
+	retries, _, _ = (&DatabaseConf{ConnectRetries: -1}).ConnectRetryPolicy()
+	assert.Equal(t, 0, retries)
+}
+
+func TestPersistence_FallbackQuota(t *testing.T) {
+	var unset *Persistence
+	maxBytes, maxAge := unset.FallbackQuota()
+	assert.Equal(t, int64(DefaultFallbackMaxMB)<<20, maxBytes)
+	assert.Equal(t, DefaultFallbackMaxAgeHours*time.Hour, maxAge)
+
+	maxBytes, maxAge = (&Persistence{FallbackMaxMB: 10, FallbackMaxAgeHours: -1}).FallbackQuota()
+	assert.Equal(t, int64(10)<<20, maxBytes)
+	assert.Zero(t, maxAge)
+}
+
+func TestConf_Modules(t *testing.T) {
+	c := validConf()
+	assert.True(t, c.ModuleEnabled(ModuleUsers), "unlisted modules are enabled")
+
+	c.Modules = map[string]bool{ModuleUsers: true, ModuleSCIM: false}
+	assert.True(t, c.ModuleEnabled(ModuleUsers))
+	assert.False(t, c.ModuleEnabled(ModuleSCIM))
+	assert.NoError(t, c.Validate())
+
+	c.Modules["webhooks"] = false
+	var errs ValidationErrors
+	assert.True(t, errors.As(c.Validate(), &errs))
+	assert.Equal(t, "modules.webhooks", errs[0].Field)
+
+	// Without API keys nothing can authenticate against the scoped routes
+	c.Modules = map[string]bool{ModuleAPIKeys: false, ModuleSCIM: false}
+	assert.True(t, errors.As(c.Validate(), &errs))
+	assert.Equal(t, "modules.apikeys", errs[0].Field)
+	assert.Contains(t, errs[0].Error(), "users, admin")
+
+	c.Modules = map[string]bool{ModuleAPIKeys: false, ModuleUsers: false, ModuleSCIM: false, ModuleAdmin: false}
+	assert.NoError(t, c.Validate())
+}
+
+func TestCache_TTLAndValidation(t *testing.T) {
+	var unset *Cache
+	assert.Equal(t, DefaultCacheTTLSeconds*time.Second, unset.TTL())
+	assert.Equal(t, 5*time.Second, (&Cache{TTLSeconds: 5}).TTL())
+
+	c := validConf()
+	c.Cache = &Cache{Kind: "redis"}
+	var errs ValidationErrors
+	assert.True(t, errors.As(c.Validate(), &errs))
+	assert.Equal(t, "cache.addr", errs[0].Field)
+
+	c.Cache = &Cache{Kind: "memcached"}
+	assert.Error(t, c.Validate())
+
+	c.Cache = &Cache{Kind: "redis", Addr: "localhost:6379"}
+	assert.NoError(t, c.Validate())
+
+	assert.Zero(t, unset.Stale())
+	assert.Equal(t, 30*time.Second, (&Cache{StaleSeconds: 30}).Stale())
+	c.Cache = &Cache{Kind: "memory", StaleSeconds: -1}
+	assert.True(t, errors.As(c.Validate(), &errs))
+	assert.Equal(t, "cache.stale_seconds", errs[0].Field)
+}
+
+func TestValidate_RateLimits(t *testing.T) {
+	c := validConf()
+	c.Web.RateLimits = map[string]RateLimit{
+		"write":  {PerMinute: 0},
+		"read":   {PerMinute: 600, Burst: -1},
+		"signup": {PerMinute: 5},
+	}
+
+	var errs ValidationErrors
+	assert.True(t, errors.As(c.Validate(), &errs))
+	fields := make([]string, len(errs))
+	for i, fieldError := range errs {
+		fields[i] = fieldError.Field
+	}
+	assert.Equal(t, []string{"web.rate_limits.read.burst", "web.rate_limits.write.per_minute"}, fields)
+}
+
+func TestValidate_PasswordCost(t *testing.T) {
+	c := validConf()
+	c.PasswordCost = 12
+	assert.NoError(t, c.Validate())
+
+	c.PasswordCost = 3
+	var errs ValidationErrors
+	assert.True(t, errors.As(c.Validate(), &errs))
+	assert.Equal(t, "password_cost", errs[0].Field)
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"gorm.io/gorm"
+
+	"web-clean/infra/budget"
+)
+
+// RegisterBudget 为数据库语句注册预算回调：请求 context 中附加了 budget.Usage 时统计查询次数与返回行数，
+// 超出预算后语句以 budget.ErrExceeded 失败；没有附加预算的语句（后台任务、迁移等）不受影响
+func RegisterBudget(db *gorm.DB) error {
+	before := func(db *gorm.DB) {
+		if usage, ok := budget.UsageFrom(db.Statement.Context); ok {
+			if err := usage.BeforeQuery(); err != nil {
+				_ = db.AddError(err)
+			}
+		}
+	}
+	after := func(db *gorm.DB) {
+		if usage, ok := budget.UsageFrom(db.Statement.Context); ok {
+			if err := usage.AfterQuery(db.Statement.RowsAffected); err != nil {
+				_ = db.AddError(err)
+			}
+		}
+	}
+
+	callback := db.Callback()
+	registrations := []func() error{
+		func() error { return callback.Create().Before("gorm:create").Register("budget:before_create", before) },
+		func() error { return callback.Query().Before("gorm:query").Register("budget:before_query", before) },
+		func() error { return callback.Query().After("gorm:query").Register("budget:after_query", after) },
+		func() error { return callback.Update().Before("gorm:update").Register("budget:before_update", before) },
+		func() error { return callback.Delete().Before("gorm:delete").Register("budget:before_delete", before) },
+		func() error { return callback.Row().Before("gorm:row").Register("budget:before_row", before) },
+		func() error { return callback.Raw().Before("gorm:raw").Register("budget:before_raw", before) },
+	}
+
+	for _, register := range registrations {
+		if err := register(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"gorm.io/gorm"
+
+	"web-clean/infra/chaos"
+)
+
+// RegisterChaos 为数据库语句注册故障注入回调：请求 context 中的故障优先，其次按表名匹配 tables
+func RegisterChaos(db *gorm.DB, tables chaos.Rules) error {
+	inject := func(db *gorm.DB) {
+		ctx := db.Statement.Context
+
+		fault, ok := chaos.DatabaseFaultFrom(ctx)
+		if !ok {
+			fault, ok = tables.Lookup(db.Statement.Table)
+		}
+		if !ok || fault.IsZero() {
+			return
+		}
+
+		if err := fault.Inject(ctx); err != nil {
+			_ = db.AddError(err)
+		}
+	}
+
+	callback := db.Callback()
+	registrations := []func() error{
+		func() error { return callback.Create().Before("gorm:create").Register("chaos:create", inject) },
+		func() error { return callback.Query().Before("gorm:query").Register("chaos:query", inject) },
+		func() error { return callback.Update().Before("gorm:update").Register("chaos:update", inject) },
+		func() error { return callback.Delete().Before("gorm:delete").Register("chaos:delete", inject) },
+		func() error { return callback.Row().Before("gorm:row").Register("chaos:row", inject) },
+		func() error { return callback.Raw().Before("gorm:raw").Register("chaos:raw", inject) },
+	}
+
+	for _, register := range registrations {
+		if err := register(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
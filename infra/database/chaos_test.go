@@ -0,0 +1,28 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"web-clean/infra/chaos"
+)
+
+func TestRegisterChaos(t *testing.T) {
+	db, err := gorm.Open(postgres.Open("host=127.0.0.1"), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	assert.NoError(t, err)
+	assert.NoError(t, RegisterChaos(db, chaos.Rules{"sampled_models": {ErrorRate: 1}}))
+
+	err = db.Find(&[]sampledModel{}).Error
+	assert.ErrorIs(t, err, chaos.ErrInjected)
+
+	err = db.Table("other").Find(&[]map[string]interface{}{}).Error
+	assert.NoError(t, err)
+
+	ctx := chaos.WithDatabaseFault(context.Background(), chaos.Fault{ErrorRate: 1})
+	err = db.WithContext(ctx).Table("other").Find(&[]map[string]interface{}{}).Error
+	assert.ErrorIs(t, err, chaos.ErrInjected)
+}
@@ -1,26 +1,76 @@
 package database
 
 import (
+	"context"
 	"fmt"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
 	"web-clean/infra"
+	"web-clean/infra/chaos"
 )
 
 type Database interface {
+	// Transaction 在事务中执行多条语句，用于需要原子性的写操作
 	Transaction(func(tx *gorm.DB) error) error
+	// WithContext 返回绑定 ctx 的查询会话，单条读语句无需开启事务；ctx 经 WithTx 携带事务时返回该事务
+	WithContext(ctx context.Context) *gorm.DB
+	// Ping 检查数据库是否可达，供健康检查使用，不占用事务
+	Ping(ctx context.Context) error
 }
 
 type _database struct {
-	raw *gorm.DB
+	raw     *gorm.DB
+	sampler *StatementSampler
 }
 
 func (d *_database) Transaction(f func(tx *gorm.DB) error) error {
 	return d.raw.Transaction(f)
 }
 
+func (d *_database) WithContext(ctx context.Context) *gorm.DB {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx.WithContext(ctx)
+	}
+	return d.raw.WithContext(ctx)
+}
+
+func (d *_database) Ping(ctx context.Context) error {
+	sqlDB, err := d.raw.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// _session 是绑定到一个已开启事务的 Database，由 Session 创建
+type _session struct {
+	tx *gorm.DB
+}
+
+// Session 将已开启的事务包装为 Database，使基于 Database 的仓储可以参与调用方的事务；
+// 在其上调用 Transaction 直接复用该事务，不会开启新事务
+func Session(tx *gorm.DB) Database {
+	return _session{tx: tx}
+}
+
+func (s _session) Transaction(f func(tx *gorm.DB) error) error {
+	return f(s.tx)
+}
+
+func (s _session) WithContext(ctx context.Context) *gorm.DB {
+	return s.tx.WithContext(ctx)
+}
+
+func (s _session) Ping(ctx context.Context) error {
+	sqlDB, err := s.tx.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 func From(ctx *infra.Context) (Database, error) {
 
 	config := ctx.Conf.Database
@@ -28,12 +78,59 @@ func From(ctx *infra.Context) (Database, error) {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable TimeZone=Asia/Shanghai",
 		config.Host, config.Username, config.Password, config.Database, config.Port)
 
-	ctx.Log.Infow("连接PostgresSQL数据库", "dsn", dsn)
+	ctx.Log.Infow("连接PostgresSQL数据库", "host", config.Host, "port", config.Port, "database", config.Database, "user", config.Username)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// 数据库可能晚于应用就绪（docker-compose、Kubernetes），按指数退避重试
+	retries, backoff, timeout := config.ConnectRetryPolicy()
+	var db *gorm.DB
+	err := connectWithRetry(ctx.Ctx, ctx.Log, RetryPolicy{Retries: retries, Backoff: backoff, Timeout: timeout}, func(attemptCtx context.Context) error {
+		// gorm.Open 不接受 ctx，单次尝试只能靠 DSN 的 connect_timeout 限制在总超时的剩余时间内
+		attemptDSN := dsn
+		if seconds := connectTimeoutSeconds(attemptCtx); seconds > 0 {
+			attemptDSN = fmt.Sprintf("%s connect_timeout=%d", dsn, seconds)
+		}
+		var err error
+		db, err = gorm.Open(postgres.Open(attemptDSN), &gorm.Config{})
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &_database{raw: db}, nil
+	// 连接池上限避免多实例在高负载下耗尽 Postgres 的 max_connections
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(config.OpenConns())
+	sqlDB.SetMaxIdleConns(config.IdleConns())
+	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime())
+
+	sampler := NewStatementSampler(ctx.Log, config.SQLSampleRate, config.SQLRedactColumns)
+	if err := sampler.Register(db); err != nil {
+		return nil, err
+	}
+
+	if err := RegisterBudget(db); err != nil {
+		return nil, err
+	}
+
+	// 故障注入只在非生产模式下显式开启时注册
+	if chaos.Enabled(ctx.Conf) {
+		ctx.Log.Warnw("数据库故障注入已开启", "tables", ctx.Conf.Chaos.Tables)
+		if err := RegisterChaos(db, chaos.RulesFromConf(ctx.Conf.Chaos.Tables)); err != nil {
+			return nil, err
+		}
+	}
+
+	return &_database{raw: db, sampler: sampler}, nil
+}
+
+// SamplerOf 返回由 From 创建的数据库所使用的 SQL 采样器
+func SamplerOf(database Database) (*StatementSampler, bool) {
+	d, ok := database.(*_database)
+	if !ok || d.sampler == nil {
+		return nil, false
+	}
+	return d.sampler, true
 }
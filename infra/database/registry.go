@@ -0,0 +1,119 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+// ErrSchemaRegistration 表示模型注册存在重复或冲突，迁移与结构对比会在访问数据库前拒绝执行
+var ErrSchemaRegistration = errors.New("invalid schema registration")
+
+// SchemaRegistry 以表名为键保存需要迁移的模型。
+//
+// 注册发生在各包的 init 中，无法直接返回错误，因此重复与冲突的注册会被记录下来，
+// 由 Err 统一报告，而不是在迁移时以难以理解的 SQL 错误出现
+type SchemaRegistry struct {
+	mu       sync.RWMutex
+	tables   []string
+	models   map[string]any
+	problems []error
+	cache    sync.Map
+}
+
+// NewSchemaRegistry 创建一个空的注册表，主要用于测试
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{models: make(map[string]any)}
+}
+
+// Schemas 是进程级别的默认注册表，RegisterSchema 向其注册
+var Schemas = NewSchemaRegistry()
+
+// Register 注册一个模型。同一张表只保留第一次注册：
+// 同一类型重复注册视为重复，不同类型映射到同一张表视为冲突，二者都会返回错误并被记录
+func (r *SchemaRegistry) Register(model any) error {
+	table, err := r.tableOf(model)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err != nil {
+		err = fmt.Errorf("%w: %T: %v", ErrSchemaRegistration, model, err)
+		r.problems = append(r.problems, err)
+		return err
+	}
+
+	if existing, ok := r.models[table]; ok {
+		if modelType(existing) == modelType(model) {
+			err = fmt.Errorf("%w: %T registered twice for table %s", ErrSchemaRegistration, model, table)
+		} else {
+			err = fmt.Errorf("%w: table %s is claimed by both %T and %T", ErrSchemaRegistration, table, existing, model)
+		}
+		r.problems = append(r.problems, err)
+		return err
+	}
+
+	r.models[table] = model
+	r.tables = append(r.tables, table)
+	return nil
+}
+
+// modelType 去掉指针，使 Model{} 与 &Model{} 被视为同一模型
+func modelType(model any) reflect.Type {
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// tableOf 使用 GORM 默认命名策略解析模型对应的表名，与迁移时的解析结果一致
+func (r *SchemaRegistry) tableOf(model any) (string, error) {
+	s, err := schema.Parse(model, &r.cache, schema.NamingStrategy{})
+	if err != nil {
+		return "", err
+	}
+	return s.Table, nil
+}
+
+// Models 返回已注册模型的副本，顺序与注册顺序一致
+func (r *SchemaRegistry) Models() []any {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	models := make([]any, len(r.tables))
+	for i, table := range r.tables {
+		models[i] = r.models[table]
+	}
+	return models
+}
+
+// Tables 返回已注册的表名，顺序与注册顺序一致
+func (r *SchemaRegistry) Tables() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tables := make([]string, len(r.tables))
+	copy(tables, r.tables)
+	return tables
+}
+
+// Lookup 按表名查找已注册的模型
+func (r *SchemaRegistry) Lookup(table string) (any, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	model, ok := r.models[table]
+	return model, ok
+}
+
+// Err 返回注册过程中记录的全部问题，没有问题时返回 nil
+func (r *SchemaRegistry) Err() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return errors.Join(r.problems...)
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"web-clean/domain"
+)
+
+// maxConnectBackoff 是两次连接尝试之间的最长等待时间
+const maxConnectBackoff = 10 * time.Second
+
+// RetryPolicy 描述启动时连接数据库的重试策略
+type RetryPolicy struct {
+	// Retries 是首次失败后的最大重试次数，0 表示不重试
+	Retries int
+	// Backoff 是首次重试前的等待时间，之后每次翻倍，最长 maxConnectBackoff
+	Backoff time.Duration
+	// Timeout 是包括所有重试在内的总超时，0 表示不限制；单次连接尝试同样受其剩余时间约束
+	Timeout time.Duration
+}
+
+// connectWithRetry 调用 connect 直到成功、重试次数用尽、总超时或 ctx 结束，每次失败都会记录日志。
+// connect 收到的 ctx 带有总超时的截止时间，单次尝试应以 connectTimeoutSeconds(ctx) 限制自身耗时
+func connectWithRetry(ctx context.Context, log domain.Log, policy RetryPolicy, connect func(ctx context.Context) error) error {
+	if policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.Timeout)
+		defer cancel()
+	}
+
+	backoff := policy.Backoff
+	for attempt := 1; ; attempt++ {
+		err := connect(ctx)
+		if err == nil {
+			if attempt > 1 {
+				log.Infow("数据库连接成功", "attempt", attempt)
+			}
+			return nil
+		}
+		if attempt > policy.Retries {
+			return fmt.Errorf("连接数据库失败，已尝试 %d 次: %w", attempt, err)
+		}
+
+		log.Warnw("连接数据库失败，准备重试", "attempt", attempt, "retries", policy.Retries, "backoff", backoff, "error", err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("连接数据库超时，已尝试 %d 次: %w", attempt, err)
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+}
+
+// connectTimeoutSeconds 返回单次连接尝试的 connect_timeout：ctx 剩余时间向上取整到秒，至少 1 秒；
+// ctx 没有截止时间时返回 0，即不限制
+func connectTimeoutSeconds(ctx context.Context) int {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+	remaining := time.Until(deadline)
+	if remaining <= time.Second {
+		return 1
+	}
+	return int((remaining + time.Second - 1) / time.Second)
+}
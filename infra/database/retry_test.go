@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var errRefused = errors.New("connection refused")
+
+func TestConnectWithRetry_SucceedsAfterFailures(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	attempts := 0
+
+	err := connectWithRetry(context.Background(), zap.New(core).Sugar(), RetryPolicy{Retries: 5, Backoff: time.Millisecond}, func(context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errRefused
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 2, logs.Len())
+}
+
+func TestConnectWithRetry_GivesUpAfterRetries(t *testing.T) {
+	attempts := 0
+	err := connectWithRetry(context.Background(), zap.NewNop().Sugar(), RetryPolicy{Retries: 2, Backoff: time.Millisecond}, func(context.Context) error {
+		attempts++
+		return errRefused
+	})
+
+	assert.ErrorIs(t, err, errRefused)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestConnectWithRetry_Timeout(t *testing.T) {
+	attempts := 0
+	start := time.Now()
+	err := connectWithRetry(context.Background(), zap.NewNop().Sugar(), RetryPolicy{Retries: 100, Backoff: 20 * time.Millisecond, Timeout: 50 * time.Millisecond}, func(context.Context) error {
+		attempts++
+		return errRefused
+	})
+
+	assert.ErrorIs(t, err, errRefused)
+	assert.Less(t, attempts, 5)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestConnectWithRetry_AttemptsSeeTheRemainingTimeout(t *testing.T) {
+	var timeouts []int
+	err := connectWithRetry(context.Background(), zap.NewNop().Sugar(), RetryPolicy{Retries: 1, Backoff: time.Millisecond, Timeout: 5 * time.Second}, func(ctx context.Context) error {
+		timeouts = append(timeouts, connectTimeoutSeconds(ctx))
+		return errRefused
+	})
+
+	assert.ErrorIs(t, err, errRefused)
+	assert.Equal(t, []int{5, 5}, timeouts, "每次尝试的 connect_timeout 不超过总超时的剩余时间")
+}
+
+func TestConnectTimeoutSeconds(t *testing.T) {
+	assert.Equal(t, 0, connectTimeoutSeconds(context.Background()), "没有截止时间时不限制")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2500*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, 3, connectTimeoutSeconds(ctx))
+
+	expired, cancelExpired := context.WithTimeout(context.Background(), -time.Second)
+	defer cancelExpired()
+	assert.Equal(t, 1, connectTimeoutSeconds(expired), "至少 1 秒，0 在 DSN 中表示不限制")
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"math"
+	"math/rand/v2"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+
+	"web-clean/domain"
+)
+
+// DefaultRedactedColumns 是未配置时需要脱敏的列
+var DefaultRedactedColumns = []string{"email", "phone", "password", "password_hash", "token", "secret"}
+
+// redactedValue 替换被脱敏的参数
+const redactedValue = "[REDACTED]"
+
+const sampleStartKey = "sample:start"
+
+// StatementSampler 按比例记录完整 SQL（绑定参数后的语句）与耗时，用于在生产环境排查查询计划，
+// 而不必打开全量 SQL 日志。敏感列对应的参数会被替换为 [REDACTED]
+type StatementSampler struct {
+	log      domain.Log
+	rate     atomic.Uint64 // float64 的位表示
+	redacted map[string]bool
+}
+
+// NewStatementSampler 创建采样器，rate 为 0 到 1 之间的采样比例，0 表示关闭
+func NewStatementSampler(log domain.Log, rate float64, redactedColumns []string) *StatementSampler {
+	if len(redactedColumns) == 0 {
+		redactedColumns = DefaultRedactedColumns
+	}
+
+	s := &StatementSampler{log: log, redacted: make(map[string]bool, len(redactedColumns))}
+	for _, column := range redactedColumns {
+		s.redacted[strings.ToLower(column)] = true
+	}
+	s.SetRate(rate)
+	return s
+}
+
+// SetRate 修改采样比例，超出 [0, 1] 的值会被截断，可在运行时调用
+func (s *StatementSampler) SetRate(rate float64) {
+	rate = math.Max(0, math.Min(1, rate))
+	s.rate.Store(math.Float64bits(rate))
+}
+
+func (s *StatementSampler) Rate() float64 {
+	return math.Float64frombits(s.rate.Load())
+}
+
+// Register 将采样回调注册到 db 的所有语句类型上
+func (s *StatementSampler) Register(db *gorm.DB) error {
+	callback := db.Callback()
+	registrations := []func() error{
+		func() error {
+			return callback.Create().Before("gorm:create").Register("sample:before_create", s.before)
+		},
+		func() error { return callback.Create().After("gorm:create").Register("sample:after_create", s.after) },
+		func() error { return callback.Query().Before("gorm:query").Register("sample:before_query", s.before) },
+		func() error { return callback.Query().After("gorm:query").Register("sample:after_query", s.after) },
+		func() error {
+			return callback.Update().Before("gorm:update").Register("sample:before_update", s.before)
+		},
+		func() error { return callback.Update().After("gorm:update").Register("sample:after_update", s.after) },
+		func() error {
+			return callback.Delete().Before("gorm:delete").Register("sample:before_delete", s.before)
+		},
+		func() error { return callback.Delete().After("gorm:delete").Register("sample:after_delete", s.after) },
+		func() error { return callback.Row().Before("gorm:row").Register("sample:before_row", s.before) },
+		func() error { return callback.Row().After("gorm:row").Register("sample:after_row", s.after) },
+		func() error { return callback.Raw().Before("gorm:raw").Register("sample:before_raw", s.before) },
+		func() error { return callback.Raw().After("gorm:raw").Register("sample:after_raw", s.after) },
+	}
+
+	for _, register := range registrations {
+		if err := register(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *StatementSampler) before(db *gorm.DB) {
+	if s.Rate() == 0 {
+		return
+	}
+	db.InstanceSet(sampleStartKey, time.Now())
+}
+
+func (s *StatementSampler) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(sampleStartKey)
+	if !ok {
+		return
+	}
+	start, ok := value.(time.Time)
+	if !ok || rand.Float64() >= s.Rate() {
+		return
+	}
+
+	sql := db.Statement.SQL.String()
+	if sql == "" {
+		return
+	}
+	vars := s.redact(sql, db.Statement.Vars)
+
+	s.log.Infow("SQL 采样",
+		"sql", db.Dialector.Explain(sql, vars...),
+		"duration", time.Since(start),
+		"rows", db.Statement.RowsAffected,
+		"error", db.Error,
+	)
+}
+
+var (
+	// comparisonPattern 匹配 `"email" = $1`、`email ILIKE $2`、`email IN ($3` 等形式，
+	// 列外可以包一层函数调用，如忽略大小写比较的 `lower(email) = $1`
+	comparisonPattern = regexp.MustCompile(`(?i)(?:\w+\()?"?(\w+)"?\)?\s*(?:=|<>|!=|<=|>=|<|>|\bI?LIKE\b|\bIN\b)\s*\(?\s*\$(\d+)`)
+	// insertPattern 匹配 INSERT 的列清单与 VALUES 部分
+	insertPattern = regexp.MustCompile(`(?is)^\s*INSERT\s+INTO\s+\S+\s*\(([^)]*)\)\s*VALUES\s*(.*)$`)
+	// placeholderPattern 匹配 $n 占位符
+	placeholderPattern = regexp.MustCompile(`\$(\d+)`)
+)
+
+// redact 返回 vars 的副本，其中属于敏感列的参数被替换。列与参数的对应关系从 SQL 文本中推断，
+// 覆盖 GORM 生成的 INSERT 列清单、SET 与 WHERE 比较；无法推断的参数保持原样
+func (s *StatementSampler) redact(sql string, vars []interface{}) []interface{} {
+	redacted := make([]interface{}, len(vars))
+	copy(redacted, vars)
+
+	mark := func(column string, placeholder string) {
+		index, err := strconv.Atoi(placeholder)
+		if err != nil || index < 1 || index > len(redacted) {
+			return
+		}
+		if s.redacted[strings.ToLower(column)] {
+			redacted[index-1] = redactedValue
+		}
+	}
+
+	if match := insertPattern.FindStringSubmatch(sql); match != nil {
+		columns := strings.Split(match[1], ",")
+		for i, placeholder := range placeholderPattern.FindAllStringSubmatch(match[2], -1) {
+			column := strings.Trim(strings.TrimSpace(columns[i%len(columns)]), `"`)
+			mark(column, placeholder[1])
+		}
+	}
+
+	for _, match := range comparisonPattern.FindAllStringSubmatch(sql, -1) {
+		mark(match[1], match[2])
+	}
+
+	return redacted
+}
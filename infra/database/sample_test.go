@@ -0,0 +1,69 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type sampledModel struct {
+	ID    uint
+	Email string
+	Name  string
+}
+
+func TestStatementSampler_Redact(t *testing.T) {
+	sampler := NewStatementSampler(zap.NewNop().Sugar(), 1, nil)
+
+	vars := sampler.redact(
+		`INSERT INTO "users" ("email","name") VALUES ($1,$2),($3,$4)`,
+		[]interface{}{"a@corp.com", "A", "b@corp.com", "B"},
+	)
+	assert.Equal(t, []interface{}{redactedValue, "A", redactedValue, "B"}, vars)
+
+	vars = sampler.redact(
+		`SELECT * FROM "users" WHERE email ILIKE $1 AND name = $2 AND "phone" IN ($3,$4)`,
+		[]interface{}{"%@corp.com%", "A", "1", "2"},
+	)
+	assert.Equal(t, []interface{}{redactedValue, "A", redactedValue, "2"}, vars)
+
+	// Case-insensitive lookups compare a function of the column
+	vars = sampler.redact(
+		`SELECT * FROM "users" WHERE lower(email) = $1 AND lower("users"."email") <> $2 AND lower(name) = $3 LIMIT $4`,
+		[]interface{}{"a@corp.com", "b@corp.com", "alice", 1},
+	)
+	assert.Equal(t, []interface{}{redactedValue, redactedValue, "alice", 1}, vars)
+}
+
+func TestStatementSampler_LogsSampledStatements(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	sampler := NewStatementSampler(zap.New(core).Sugar(), 0, nil)
+
+	db, err := gorm.Open(postgres.Open("host=127.0.0.1"), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	assert.NoError(t, err)
+	assert.NoError(t, sampler.Register(db))
+
+	db.Where("email = ?", "a@corp.com").Find(&[]sampledModel{})
+	assert.Equal(t, 0, logs.Len())
+
+	sampler.SetRate(1)
+	db.Where("email = ? AND name = ?", "a@corp.com", "A").Find(&[]sampledModel{})
+
+	assert.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, `SELECT * FROM "sampled_models" WHERE email = '[REDACTED]' AND name = 'A'`, fields["sql"])
+	assert.IsType(t, time.Duration(0), fields["duration"])
+}
+
+func TestStatementSampler_SetRateClamps(t *testing.T) {
+	sampler := NewStatementSampler(zap.NewNop().Sugar(), 5, nil)
+	assert.Equal(t, 1.0, sampler.Rate())
+
+	sampler.SetRate(-1)
+	assert.Equal(t, 0.0, sampler.Rate())
+}
@@ -1,17 +1,207 @@
 package database
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
-var models = make([]any, 0)
-
+// RegisterSchema 向默认注册表注册模型，重复或冲突的注册由 Schemas.Err 报告
 func RegisterSchema(model any) {
-	models = append(models, model)
+	_ = Schemas.Register(model)
+}
+
+// RegisteredSchemas 返回已注册模型的副本，顺序与注册顺序一致
+func RegisteredSchemas() []any {
+	return Schemas.Models()
 }
 
 func AutoMigrateRegisteredSchema(database Database) error {
+	if err := Schemas.Err(); err != nil {
+		return err
+	}
+	models := Schemas.Models()
 	return database.Transaction(func(tx *gorm.DB) error {
 		return tx.AutoMigrate(models...)
 	})
 }
+
+// DriftKind 描述数据库结构与模型之间的一种差异
+type DriftKind string
+
+const (
+	// DriftMissingTable 模型对应的表不存在，AutoMigrate 可以修复
+	DriftMissingTable DriftKind = "missing_table"
+	// DriftMissingColumn 模型字段对应的列不存在，AutoMigrate 可以修复
+	DriftMissingColumn DriftKind = "missing_column"
+	// DriftExtraColumn 表中存在模型没有声明的列，AutoMigrate 不会删除
+	DriftExtraColumn DriftKind = "extra_column"
+	// DriftTypeMismatch 列类型与模型声明不一致，AutoMigrate 不一定能安全修改
+	DriftTypeMismatch DriftKind = "type_mismatch"
+)
+
+// Drift 是一项结构差异
+type Drift struct {
+	Kind   DriftKind
+	Table  string
+	Column string
+	// Detail 在 DriftTypeMismatch 时为 "模型类型 != 数据库类型"
+	Detail string
+}
+
+// Fixable 表示该差异能否由 AutoMigrate 自动修复
+func (d Drift) Fixable() bool {
+	return d.Kind == DriftMissingTable || d.Kind == DriftMissingColumn
+}
+
+func (d Drift) String() string {
+	switch d.Kind {
+	case DriftMissingTable:
+		return fmt.Sprintf("缺少表 %s", d.Table)
+	case DriftMissingColumn:
+		return fmt.Sprintf("表 %s 缺少列 %s", d.Table, d.Column)
+	case DriftExtraColumn:
+		return fmt.Sprintf("表 %s 存在模型未声明的列 %s", d.Table, d.Column)
+	default:
+		return fmt.Sprintf("表 %s 的列 %s 类型不一致: %s", d.Table, d.Column, d.Detail)
+	}
+}
+
+// Diff 对比已注册模型与数据库中的实际表结构，返回全部差异。该方法只读，不会修改数据库结构
+func Diff(database Database) ([]Drift, error) {
+	return Schemas.Diff(database)
+}
+
+// Diff 对比该注册表中的模型与数据库中的实际表结构，注册存在问题时不会访问数据库
+func (r *SchemaRegistry) Diff(database Database) ([]Drift, error) {
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	models := r.Models()
+	drifts := make([]Drift, 0)
+
+	err := database.Transaction(func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+
+		for _, model := range models {
+			stmt := &gorm.Statement{DB: tx}
+			if err := stmt.Parse(model); err != nil {
+				return err
+			}
+
+			table := stmt.Schema.Table
+			if !migrator.HasTable(model) {
+				drifts = append(drifts, Drift{Kind: DriftMissingTable, Table: table})
+				continue
+			}
+
+			columnTypes, err := migrator.ColumnTypes(model)
+			if err != nil {
+				return err
+			}
+			live := make(map[string]gorm.ColumnType, len(columnTypes))
+			for _, columnType := range columnTypes {
+				live[columnType.Name()] = columnType
+			}
+
+			drifts = append(drifts, diffTable(tx, stmt.Schema, live)...)
+		}
+
+		return nil
+	})
+
+	return drifts, err
+}
+
+// diffTable 对比单个模型的字段与数据库中的列
+func diffTable(tx *gorm.DB, s *schema.Schema, live map[string]gorm.ColumnType) []Drift {
+	drifts := make([]Drift, 0)
+	declared := make(map[string]bool, len(s.Fields))
+
+	for _, field := range s.Fields {
+		if field.DBName == "" {
+			continue
+		}
+		declared[field.DBName] = true
+
+		columnType, ok := live[field.DBName]
+		if !ok {
+			drifts = append(drifts, Drift{Kind: DriftMissingColumn, Table: s.Table, Column: field.DBName})
+			continue
+		}
+
+		expected := normalizeType(tx.Dialector.DataTypeOf(field))
+		actual := normalizeType(columnType.DatabaseTypeName())
+		if expected != "" && actual != "" && expected != actual {
+			drifts = append(drifts, Drift{
+				Kind:   DriftTypeMismatch,
+				Table:  s.Table,
+				Column: field.DBName,
+				Detail: fmt.Sprintf("%s != %s", expected, actual),
+			})
+		}
+	}
+
+	extra := make([]string, 0)
+	for name := range live {
+		if !declared[name] {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		drifts = append(drifts, Drift{Kind: DriftExtraColumn, Table: s.Table, Column: name})
+	}
+
+	return drifts
+}
+
+var typeModifier = regexp.MustCompile(`\(.*\)`)
+
+// typeAliases 将 Postgres 内部类型名与 GORM 生成的 DDL 类型名统一
+var typeAliases = map[string]string{
+	"int8":                        "bigint",
+	"bigserial":                   "bigint",
+	"int4":                        "integer",
+	"int":                         "integer",
+	"serial":                      "integer",
+	"int2":                        "smallint",
+	"smallserial":                 "smallint",
+	"bool":                        "boolean",
+	"float8":                      "double precision",
+	"float4":                      "real",
+	"character varying":           "varchar",
+	"timestamp with time zone":    "timestamptz",
+	"timestamp without time zone": "timestamp",
+}
+
+// normalizeType 去掉长度等修饰并统一别名，使 "varchar(255)" 与 "VARCHAR" 被视为同一类型
+func normalizeType(name string) string {
+	name = strings.ToLower(strings.TrimSpace(typeModifier.ReplaceAllString(name, "")))
+	if alias, ok := typeAliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// PendingMigrations 检查已注册的模型在数据库中是否缺少表或列，返回尚未迁移的项目描述。
+// 该方法只读，不会修改数据库结构
+func PendingMigrations(database Database) ([]string, error) {
+	drifts, err := Diff(database)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]string, 0)
+	for _, drift := range drifts {
+		if drift.Fixable() {
+			pending = append(pending, drift.String())
+		}
+	}
+	return pending, nil
+}
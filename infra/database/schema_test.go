@@ -0,0 +1,131 @@
+package database
+
+import (
+	"database/sql"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type driftModel struct {
+	gorm.Model
+	ExternalID uuid.UUID `gorm:"type:uuid"`
+	Name       string    `gorm:"size:100"`
+}
+
+type columnType = gorm.ColumnType
+
+// fakeColumn 只实现 diffTable 用到的方法
+type fakeColumn struct {
+	columnType
+	name, typeName string
+}
+
+func (c fakeColumn) Name() string             { return c.name }
+func (c fakeColumn) DatabaseTypeName() string { return c.typeName }
+
+func TestNormalizeType(t *testing.T) {
+	assert.Equal(t, "varchar", normalizeType("varchar(255)"))
+	assert.Equal(t, "varchar", normalizeType("VARCHAR"))
+	assert.Equal(t, "bigint", normalizeType("bigserial"))
+	assert.Equal(t, "bigint", normalizeType("INT8"))
+	assert.Equal(t, "timestamptz", normalizeType("TIMESTAMPTZ"))
+}
+
+func TestDiffTable(t *testing.T) {
+	// 不会建立连接：pgx 的 sql.Open 是惰性的，且关闭了自动 ping
+	db, err := gorm.Open(postgres.Open("host=127.0.0.1"), &gorm.Config{DisableAutomaticPing: true})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	defer func(sqlDB *sql.DB) { _ = sqlDB.Close() }(sqlDB)
+
+	s, err := schema.Parse(&driftModel{}, &sync.Map{}, db.NamingStrategy)
+	assert.NoError(t, err)
+
+	live := map[string]gorm.ColumnType{
+		"id":          fakeColumn{name: "id", typeName: "INT8"},
+		"created_at":  fakeColumn{name: "created_at", typeName: "TIMESTAMPTZ"},
+		"updated_at":  fakeColumn{name: "updated_at", typeName: "TIMESTAMPTZ"},
+		"deleted_at":  fakeColumn{name: "deleted_at", typeName: "TIMESTAMPTZ"},
+		"external_id": fakeColumn{name: "external_id", typeName: "TEXT"},
+		"legacy_flag": fakeColumn{name: "legacy_flag", typeName: "BOOL"},
+	}
+
+	drifts := diffTable(db, s, live)
+
+	assert.Equal(t, []Drift{
+		{Kind: DriftTypeMismatch, Table: "drift_models", Column: "external_id", Detail: "uuid != text"},
+		{Kind: DriftMissingColumn, Table: "drift_models", Column: "name"},
+		{Kind: DriftExtraColumn, Table: "drift_models", Column: "legacy_flag"},
+	}, drifts)
+	assert.False(t, drifts[0].Fixable())
+	assert.True(t, drifts[1].Fixable())
+}
+
+func TestRegisteredSchemas_IsCopy(t *testing.T) {
+	registered := RegisteredSchemas()
+	if len(registered) == 0 {
+		return
+	}
+	registered[0] = nil
+	assert.False(t, reflect.DeepEqual(registered, RegisteredSchemas()))
+}
+
+type conflictingModel struct {
+	ID uint
+}
+
+func (conflictingModel) TableName() string { return "drift_models" }
+
+func TestSchemaRegistry_KeyedByTable(t *testing.T) {
+	registry := NewSchemaRegistry()
+
+	assert.NoError(t, registry.Register(driftModel{}))
+	assert.NoError(t, registry.Err())
+	assert.Equal(t, []string{"drift_models"}, registry.Tables())
+
+	model, ok := registry.Lookup("drift_models")
+	assert.True(t, ok)
+	assert.Equal(t, driftModel{}, model)
+}
+
+func TestSchemaRegistry_Duplicate(t *testing.T) {
+	registry := NewSchemaRegistry()
+	assert.NoError(t, registry.Register(driftModel{}))
+
+	err := registry.Register(&driftModel{})
+
+	assert.ErrorIs(t, err, ErrSchemaRegistration)
+	assert.Contains(t, err.Error(), "registered twice")
+	assert.Len(t, registry.Models(), 1)
+	assert.ErrorIs(t, registry.Err(), ErrSchemaRegistration)
+}
+
+func TestSchemaRegistry_Conflict(t *testing.T) {
+	registry := NewSchemaRegistry()
+	assert.NoError(t, registry.Register(driftModel{}))
+
+	err := registry.Register(conflictingModel{})
+
+	assert.ErrorIs(t, err, ErrSchemaRegistration)
+	assert.Contains(t, err.Error(), "claimed by both")
+	model, _ := registry.Lookup("drift_models")
+	assert.Equal(t, driftModel{}, model)
+}
+
+func TestSchemaRegistry_DiffRefusesInvalidRegistry(t *testing.T) {
+	registry := NewSchemaRegistry()
+	assert.Error(t, registry.Register(42))
+
+	// 注册存在问题时不会访问数据库，因此传入 nil 也不会触发调用
+	drifts, err := registry.Diff(nil)
+
+	assert.ErrorIs(t, err, ErrSchemaRegistration)
+	assert.Nil(t, drifts)
+}
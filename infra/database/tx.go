@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+type commitHooksKey struct{}
+
+// commitHooks 是最外层事务提交后要执行的回调
+type commitHooks struct {
+	mu    sync.Mutex
+	hooks []func()
+}
+
+// WithTx 返回携带事务 tx 的 ctx，之后以该 ctx 调用 Database.WithContext 得到的会话都会加入这个事务，
+// 调用方因此无需把 *gorm.DB 逐层传给仓储
+func WithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext 取出 WithTx 放入 ctx 的事务，不存在时返回 false
+func TxFromContext(ctx context.Context) (*gorm.DB, bool) {
+	tx, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return tx, ok && tx != nil
+}
+
+// WithCommitHooks 为即将开启的事务准备提交后回调。ctx 已属于某个事务时返回原 ctx 与空操作，
+// 回调仍随最外层事务执行；否则返回的 committed 应在事务提交成功后调用，回滚时不调用
+func WithCommitHooks(ctx context.Context) (context.Context, func()) {
+	if _, ok := ctx.Value(commitHooksKey{}).(*commitHooks); ok {
+		return ctx, func() {}
+	}
+
+	hooks := &commitHooks{}
+	return context.WithValue(ctx, commitHooksKey{}, hooks), func() {
+		hooks.mu.Lock()
+		pending := hooks.hooks
+		hooks.hooks = nil
+		hooks.mu.Unlock()
+
+		for _, hook := range pending {
+			hook()
+		}
+	}
+}
+
+// AfterCommit 在 ctx 所属的最外层事务提交后调用 fn，事务回滚时不调用。
+// ctx 不属于由 WithCommitHooks 准备的事务时立即调用 fn
+func AfterCommit(ctx context.Context, fn func()) {
+	hooks, ok := ctx.Value(commitHooksKey{}).(*commitHooks)
+	if !ok {
+		fn()
+		return
+	}
+
+	hooks.mu.Lock()
+	hooks.hooks = append(hooks.hooks, fn)
+	hooks.mu.Unlock()
+}
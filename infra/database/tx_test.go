@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func dryRun(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(postgres.Open("host=127.0.0.1"), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	assert.NoError(t, err)
+	return db
+}
+
+func TestTxFromContext(t *testing.T) {
+	tx := dryRun(t)
+
+	_, ok := TxFromContext(context.Background())
+	assert.False(t, ok)
+
+	got, ok := TxFromContext(WithTx(context.Background(), tx))
+	assert.True(t, ok)
+	assert.Same(t, tx, got)
+
+	_, ok = TxFromContext(WithTx(context.Background(), nil))
+	assert.False(t, ok)
+}
+
+func TestDatabase_WithContext_JoinsContextTransaction(t *testing.T) {
+	raw, tx := dryRun(t), dryRun(t)
+	db := &_database{raw: raw}
+
+	assert.Same(t, raw.Statement.ConnPool, db.WithContext(context.Background()).Statement.ConnPool)
+	assert.NotSame(t, raw.Statement.ConnPool, tx.Statement.ConnPool)
+
+	ctx := WithTx(context.Background(), tx)
+	session := db.WithContext(ctx)
+	assert.Same(t, tx.Statement.ConnPool, session.Statement.ConnPool)
+	assert.Equal(t, ctx, session.Statement.Context)
+}
+
+func TestAfterCommit(t *testing.T) {
+	var calls []string
+	AfterCommit(context.Background(), func() { calls = append(calls, "immediate") })
+	assert.Equal(t, []string{"immediate"}, calls)
+
+	ctx, committed := WithCommitHooks(context.Background())
+	AfterCommit(ctx, func() { calls = append(calls, "outer") })
+	// A nested transaction runs its callbacks with the outermost one
+	nested, nestedCommitted := WithCommitHooks(WithTx(ctx, dryRun(t)))
+	AfterCommit(nested, func() { calls = append(calls, "nested") })
+	nestedCommitted()
+	assert.Equal(t, []string{"immediate"}, calls)
+
+	committed()
+	assert.Equal(t, []string{"immediate", "outer", "nested"}, calls)
+	committed()
+	assert.Len(t, calls, 3, "callbacks run once")
+}
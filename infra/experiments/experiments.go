@@ -0,0 +1,151 @@
+// Package experiments 按调用方身份确定性地将请求分配到 A/B 实验的各个分组。
+//
+// 分组由 sha256(实验键 + 调用方标识) 决定，同一调用方在所有实例、所有请求中始终落在同一分组，
+// 无需保存分配结果；调整权重只会移动边界附近的调用方
+package experiments
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"expvar"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"web-clean/infra/conf"
+)
+
+// exposures 按 "实验键/分组" 统计曝光次数
+var exposures = expvar.NewMap("experiment_exposures_total")
+
+// Variant 是实验的一个分组
+type Variant struct {
+	Name   string
+	Weight int
+}
+
+// Experiment 是一个已启用的实验，Variants 按名称排序以保证分组边界稳定
+type Experiment struct {
+	Key      string
+	Variants []Variant
+	total    int
+}
+
+// Bucket 返回 unit 在该实验中所属的分组
+func (e Experiment) Bucket(unit string) string {
+	sum := sha256.Sum256([]byte(e.Key + "\x00" + unit))
+	point := int(binary.BigEndian.Uint64(sum[:8]) % uint64(e.total))
+
+	for _, variant := range e.Variants {
+		if point < variant.Weight {
+			return variant.Name
+		}
+		point -= variant.Weight
+	}
+	return e.Variants[len(e.Variants)-1].Name
+}
+
+// Registry 保存所有已启用的实验
+type Registry struct {
+	experiments []Experiment
+}
+
+// From 根据配置创建 Registry，没有启用任何实验时返回 nil
+func From(config map[string]conf.Experiment) (*Registry, error) {
+	registry := &Registry{}
+	for _, key := range slices.Sorted(maps.Keys(config)) {
+		experiment := config[key]
+		if !experiment.Enabled {
+			continue
+		}
+
+		e := Experiment{Key: key}
+		for _, name := range slices.Sorted(maps.Keys(experiment.Variants)) {
+			weight := experiment.Variants[name]
+			if weight < 0 {
+				return nil, fmt.Errorf("实验 %s 的分组 %s 权重不能为负数", key, name)
+			}
+			if weight == 0 {
+				continue
+			}
+			e.Variants = append(e.Variants, Variant{Name: name, Weight: weight})
+			e.total += weight
+		}
+		if e.total == 0 {
+			return nil, fmt.Errorf("实验 %s 没有权重大于 0 的分组", key)
+		}
+		registry.experiments = append(registry.experiments, e)
+	}
+
+	if len(registry.experiments) == 0 {
+		return nil, nil
+	}
+	return registry, nil
+}
+
+// Experiments 返回所有已启用的实验
+func (r *Registry) Experiments() []Experiment {
+	return r.experiments
+}
+
+// Assign 计算 unit 在所有实验中的分组，onExpose 在某个实验的分组首次被读取时调用，可以为 nil
+func (r *Registry) Assign(unit string, onExpose func(key, variant string)) *Assignments {
+	variants := make(map[string]string, len(r.experiments))
+	for _, experiment := range r.experiments {
+		variants[experiment.Key] = experiment.Bucket(unit)
+	}
+	return &Assignments{variants: variants, onExpose: onExpose, exposed: make(map[string]bool)}
+}
+
+// Assignments 是一个请求的实验分组。读取分组即视为曝光：每个实验在一个请求内只记录一次，
+// 只分配而未被读取的实验不计入曝光，避免稀释实验数据
+type Assignments struct {
+	variants map[string]string
+	onExpose func(key, variant string)
+
+	mu      sync.Mutex
+	exposed map[string]bool
+}
+
+// Variant 返回实验 key 的分组并记录曝光；实验不存在、未启用或请求没有可用的调用方标识时返回 false，
+// 调用方应按对照组处理
+func (a *Assignments) Variant(key string) (string, bool) {
+	if a == nil {
+		return "", false
+	}
+
+	variant, ok := a.variants[key]
+	if !ok {
+		return "", false
+	}
+
+	a.mu.Lock()
+	first := !a.exposed[key]
+	a.exposed[key] = true
+	a.mu.Unlock()
+
+	if first {
+		exposures.Add(key+"/"+variant, 1)
+		if a.onExpose != nil {
+			a.onExpose(key, variant)
+		}
+	}
+	return variant, true
+}
+
+// String 以 "key=variant" 逗号分隔的形式列出所有分组，按实验键排序，用于响应头
+func (a *Assignments) String() string {
+	if a == nil {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(a.variants))
+	for key, variant := range a.variants {
+		pairs = append(pairs, key+"="+variant)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
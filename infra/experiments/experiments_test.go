@@ -0,0 +1,69 @@
+package experiments
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"web-clean/infra/conf"
+)
+
+func TestFrom_SkipsDisabledExperiments(t *testing.T) {
+	registry, err := From(map[string]conf.Experiment{
+		"checkout": {Enabled: false, Variants: map[string]int{"a": 1}},
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, registry)
+
+	_, err = From(map[string]conf.Experiment{
+		"checkout": {Enabled: true, Variants: map[string]int{"a": 0}},
+	})
+	assert.Error(t, err)
+}
+
+func TestExperiment_BucketIsDeterministicAndWeighted(t *testing.T) {
+	registry, err := From(map[string]conf.Experiment{
+		"checkout": {Enabled: true, Variants: map[string]int{"control": 90, "treatment": 10}},
+	})
+	require.NoError(t, err)
+	experiment := registry.Experiments()[0]
+
+	assert.Equal(t, experiment.Bucket("user-1"), experiment.Bucket("user-1"))
+
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		counts[experiment.Bucket(fmt.Sprintf("user-%d", i))]++
+	}
+	assert.InDelta(t, 9000, counts["control"], 300)
+	assert.InDelta(t, 1000, counts["treatment"], 300)
+}
+
+func TestAssignments_ExposeOncePerRequest(t *testing.T) {
+	registry, err := From(map[string]conf.Experiment{
+		"checkout": {Enabled: true, Variants: map[string]int{"only": 1}},
+		"signup":   {Enabled: true, Variants: map[string]int{"only": 1}},
+	})
+	require.NoError(t, err)
+
+	var exposed []string
+	assignments := registry.Assign("user-1", func(key, variant string) {
+		exposed = append(exposed, key+"="+variant)
+	})
+
+	assert.Equal(t, "checkout=only,signup=only", assignments.String())
+
+	variant, ok := assignments.Variant("checkout")
+	assert.True(t, ok)
+	assert.Equal(t, "only", variant)
+	assignments.Variant("checkout")
+	_, ok = assignments.Variant("unknown")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"checkout=only"}, exposed)
+
+	var none *Assignments
+	_, ok = none.Variant("checkout")
+	assert.False(t, ok)
+}
@@ -0,0 +1,143 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Severity 决定检查失败对整体状态的影响
+type Severity string
+
+const (
+	// Critical 依赖失败时服务不可用，就绪检查失败，实例会被移出负载均衡
+	Critical Severity = "critical"
+	// Degraded 依赖失败时服务仍可提供核心功能，只在报告中体现
+	Degraded Severity = "degraded"
+)
+
+// Status 是单项检查或整体的状态
+type Status string
+
+const (
+	StatusUp       Status = "up"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// defaultTimeout 是单项检查未指定超时时使用的值
+const defaultTimeout = 2 * time.Second
+
+// Check 是一项依赖检查
+type Check struct {
+	Name     string
+	Severity Severity
+	// Timeout 单项检查的超时，0 表示使用默认值
+	Timeout time.Duration
+	Probe   func(ctx context.Context) error
+}
+
+// Result 是单项检查的结果
+type Result struct {
+	Status   Status        `json:"status"`
+	Severity Severity      `json:"severity"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Report 是一次完整检查的结果
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Ready 表示实例是否应继续接收流量，只有 Critical 检查失败时为 false
+func (r Report) Ready() bool {
+	return r.Status != StatusDown
+}
+
+// Registry 保存所有依赖检查并计算整体状态
+type Registry struct {
+	mu     sync.RWMutex
+	checks []Check
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register 注册一项检查，同名检查会被替换
+func (r *Registry) Register(check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.checks {
+		if existing.Name == check.Name {
+			r.checks[i] = check
+			return
+		}
+	}
+	r.checks = append(r.checks, check)
+}
+
+// Run 并发执行所有检查。任一 Critical 检查失败时整体为 down；
+// 只有 Degraded 检查失败时整体为 degraded；否则为 up
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make([]Check, len(r.checks))
+	copy(checks, r.checks)
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(checks))}
+	for i, check := range checks {
+		result := results[i]
+		report.Checks[check.Name] = result
+		if result.Status == StatusUp {
+			continue
+		}
+		if check.Severity == Critical {
+			report.Status = StatusDown
+		} else if report.Status == StatusUp {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func run(ctx context.Context, check Check) Result {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	severity := check.Severity
+	if severity == "" {
+		severity = Critical
+	}
+
+	start := time.Now()
+	err := check.Probe(ctx)
+	result := Result{Status: StatusUp, Severity: severity, Duration: time.Since(start)}
+	if err != nil {
+		result.Error = err.Error()
+		if severity == Critical {
+			result.Status = StatusDown
+		} else {
+			result.Status = StatusDegraded
+		}
+	}
+	return result
+}
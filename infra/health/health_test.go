@@ -0,0 +1,61 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ok(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("unreachable") }
+
+func TestRegistry_OverallStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []Check
+		status Status
+		ready  bool
+	}{
+		{"all up", []Check{{Name: "db", Severity: Critical, Probe: ok}, {Name: "cache", Severity: Degraded, Probe: ok}}, StatusUp, true},
+		{"degraded dependency", []Check{{Name: "db", Severity: Critical, Probe: ok}, {Name: "cache", Severity: Degraded, Probe: failing}}, StatusDegraded, true},
+		{"critical dependency", []Check{{Name: "db", Severity: Critical, Probe: failing}, {Name: "cache", Severity: Degraded, Probe: failing}}, StatusDown, false},
+		{"no checks", nil, StatusUp, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			for _, check := range tt.checks {
+				registry.Register(check)
+			}
+
+			report := registry.Run(context.Background())
+
+			assert.Equal(t, tt.status, report.Status)
+			assert.Equal(t, tt.ready, report.Ready())
+			assert.Len(t, report.Checks, len(tt.checks))
+		})
+	}
+}
+
+func TestRegistry_TimeoutAndReplace(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(Check{Name: "slow", Severity: Degraded, Timeout: 10 * time.Millisecond, Probe: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	registry.Register(Check{Name: "db", Probe: failing})
+	registry.Register(Check{Name: "db", Probe: ok})
+
+	report := registry.Run(context.Background())
+
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.Equal(t, StatusDegraded, report.Checks["slow"].Status)
+	assert.Contains(t, report.Checks["slow"].Error, "deadline exceeded")
+	assert.Equal(t, Critical, report.Checks["db"].Severity)
+	assert.Equal(t, StatusUp, report.Checks["db"].Status)
+}
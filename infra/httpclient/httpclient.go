@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"time"
+
+	"web-clean/domain"
+)
+
+// Config 描述出站 HTTP 客户端的行为，零值字段会回退到 Default 中的取值
+type Config struct {
+	// Timeout 单次调用（包括所有重试）的总超时
+	Timeout time.Duration
+
+	// MaxRetries 幂等请求在网络错误或 429/502/503/504 时的最大重试次数
+	MaxRetries int
+	// RetryBackoff 首次重试前的等待时间，之后每次翻倍
+	RetryBackoff time.Duration
+
+	// BreakerThreshold 连续失败多少次后熔断，0 表示使用默认值，负数表示关闭熔断
+	BreakerThreshold int
+	// BreakerCooldown 熔断后多久允许一次试探请求
+	BreakerCooldown time.Duration
+
+	// TLS 覆盖默认的 TLS 设置，例如自定义 CA 或客户端证书，nil 时使用系统默认值
+	TLS *tls.Config
+
+	// RequestIDHeader 透传 RequestID 使用的请求头
+	RequestIDHeader string
+	// RequestIDFromContext 从请求的 context 中取出 RequestID，为空时不透传
+	RequestIDFromContext func(ctx context.Context) string
+}
+
+func Default() Config {
+	return Config{
+		Timeout:          10 * time.Second,
+		MaxRetries:       2,
+		RetryBackoff:     100 * time.Millisecond,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+		RequestIDHeader:  "X-Request-ID",
+	}
+}
+
+func (c Config) withDefaults() Config {
+	d := Default()
+	if c.Timeout <= 0 {
+		c.Timeout = d.Timeout
+	}
+	if c.MaxRetries < 0 {
+		c.MaxRetries = 0
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = d.RetryBackoff
+	}
+	if c.BreakerThreshold == 0 {
+		c.BreakerThreshold = d.BreakerThreshold
+	}
+	if c.BreakerCooldown <= 0 {
+		c.BreakerCooldown = d.BreakerCooldown
+	}
+	if c.RequestIDHeader == "" {
+		c.RequestIDHeader = d.RequestIDHeader
+	}
+	return c
+}
+
+// New 创建一个预置超时、重试、熔断与请求头透传的 http.Client。
+//
+// name 用于日志中区分不同的下游（例如 "webhook"、"oidc"），每个下游应当使用独立的 Client，
+// 这样熔断状态不会相互影响。
+func New(name string, log domain.Log, config Config) *http.Client {
+	config = config.withDefaults()
+
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	if config.TLS != nil {
+		base.TLSClientConfig = config.TLS
+	}
+
+	var transport http.RoundTripper = base
+
+	transport = &logTransport{next: transport, name: name, log: log}
+	transport = &retryTransport{next: transport, name: name, log: log, maxRetries: config.MaxRetries, backoff: config.RetryBackoff}
+	if config.BreakerThreshold > 0 {
+		transport = &breakerTransport{next: transport, name: name, log: log, threshold: config.BreakerThreshold, cooldown: config.BreakerCooldown}
+	}
+	if config.RequestIDFromContext != nil {
+		transport = &requestIDTransport{next: transport, header: config.RequestIDHeader, getter: config.RequestIDFromContext}
+	}
+	// 入站请求中白名单内的请求头（trace context、RequestID、租户 ID 等）自动透传给下游
+	transport = &propagationTransport{next: transport}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   config.Timeout,
+	}
+}
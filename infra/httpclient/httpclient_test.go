@@ -0,0 +1,115 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"web-clean/infra/propagation"
+)
+
+type nopLog struct {
+	*zap.SugaredLogger
+}
+
+var testLog = nopLog{zap.NewNop().Sugar()}
+
+type requestIDKey struct{}
+
+func TestClient_RetriesIdempotentRequests(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := New("test", testLog, Config{MaxRetries: 2, RetryBackoff: time.Millisecond})
+
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// POST 不是幂等请求，不会重试
+	calls.Store(0)
+	resp, err = client.Post(server.URL, "text/plain", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := New("test", testLog, Config{MaxRetries: -1, BreakerThreshold: 2, BreakerCooldown: time.Hour})
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Get(server.URL)
+		assert.NoError(t, err)
+	}
+
+	_, err := client.Get(server.URL)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestClient_PropagatesRequestID(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("X-Request-ID")
+	}))
+	defer server.Close()
+
+	client := New("test", testLog, Config{
+		RequestIDFromContext: func(ctx context.Context) string {
+			id, _ := ctx.Value(requestIDKey{}).(string)
+			return id
+		},
+	})
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "request-1")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	_, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "request-1", received)
+}
+
+func TestClient_PropagatesAllowlistedHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	inbound := http.Header{}
+	inbound.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	inbound.Set(propagation.TenantHeader, "acme")
+	ctx := propagation.Start(context.Background(), propagation.NewAllowlist(nil), inbound)
+	ctx = propagation.Set(ctx, propagation.RequestIDHeader, "request-1")
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	_, err := New("test", testLog, Config{}).Do(req)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", received.Get("traceparent"))
+	assert.Equal(t, "acme", received.Get(propagation.TenantHeader))
+	assert.Equal(t, "request-1", received.Get("X-Request-ID"))
+	assert.Empty(t, req.Header.Get("traceparent"))
+}
@@ -0,0 +1,199 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"web-clean/domain"
+	"web-clean/infra/propagation"
+)
+
+// ErrCircuitOpen 熔断打开期间的请求会直接返回该错误，不会访问下游
+var ErrCircuitOpen = errors.New("httpclient: circuit breaker is open")
+
+// requestIDTransport 将 context 中的 RequestID 写入请求头，已显式设置的请求头不会被覆盖
+type requestIDTransport struct {
+	next   http.RoundTripper
+	header string
+	getter func(ctx context.Context) string
+}
+
+func (t *requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get(t.header) == "" {
+		if id := t.getter(req.Context()); id != "" {
+			// RoundTripper 不允许修改原始请求
+			req = req.Clone(req.Context())
+			req.Header.Set(t.header, id)
+		}
+	}
+	return t.next.RoundTrip(req)
+}
+
+// propagationTransport 将 context 中需要透传的请求头（trace context、baggage、租户 ID 等）写入请求
+type propagationTransport struct {
+	next http.RoundTripper
+}
+
+func (t *propagationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(propagation.From(req.Context())) > 0 {
+		// RoundTripper 不允许修改原始请求
+		clone := req.Clone(req.Context())
+		if propagation.Inject(req.Context(), clone.Header) {
+			req = clone
+		}
+	}
+	return t.next.RoundTrip(req)
+}
+
+// retryTransport 对幂等请求在可重试的失败上进行指数退避重试
+type retryTransport struct {
+	next       http.RoundTripper
+	name       string
+	log        domain.Log
+	maxRetries int
+	backoff    time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.maxRetries == 0 || !replayable(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	backoff := t.backoff
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && req.Body != nil && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := t.next.RoundTrip(attemptReq)
+		if attempt >= t.maxRetries || !retryable(resp, err) {
+			return resp, err
+		}
+
+		t.log.Warnw("出站请求失败，准备重试", "client", t.name, "url", req.URL.Redacted(), "attempt", attempt+1, "error", err)
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// replayable 只有幂等方法且请求体可以重放时才允许重试
+func replayable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// breakerTransport 是一个简单的连续失败计数熔断器。
+//
+// 连续失败达到 threshold 次后进入打开状态，cooldown 之后放行一次试探请求：
+// 试探成功则关闭熔断，失败则重新计时。
+type breakerTransport struct {
+	next      http.RoundTripper
+	name      string
+	log       domain.Log
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	t.record(err == nil && resp.StatusCode < http.StatusInternalServerError)
+	return resp, err
+}
+
+func (t *breakerTransport) allow() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.failures < t.threshold {
+		return true
+	}
+	if t.probing || time.Since(t.openedAt) < t.cooldown {
+		return false
+	}
+	t.probing = true
+	return true
+}
+
+func (t *breakerTransport) record(success bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	wasProbing := t.probing
+	t.probing = false
+
+	if success {
+		if t.failures >= t.threshold {
+			t.log.Infow("出站熔断恢复", "client", t.name)
+		}
+		t.failures = 0
+		return
+	}
+
+	t.failures++
+	if t.failures == t.threshold || wasProbing {
+		t.openedAt = time.Now()
+		t.log.Warnw("出站熔断打开", "client", t.name, "failures", t.failures, "cooldown", t.cooldown)
+	}
+}
+
+// logTransport 记录每一次实际发出的请求
+type logTransport struct {
+	next http.RoundTripper
+	name string
+	log  domain.Log
+}
+
+func (t *logTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	if err != nil {
+		t.log.Warnw("出站请求错误", "client", t.name, "method", req.Method, "url", req.URL.Redacted(), "duration", time.Since(start), "error", err)
+		return resp, err
+	}
+
+	t.log.Debugw("出站请求", "client", t.name, "method", req.Method, "url", req.URL.Redacted(), "status", resp.StatusCode, "duration", time.Since(start))
+	return resp, err
+}
@@ -5,8 +5,10 @@ import (
 
 	"web-clean/domain"
 	"web-clean/infra/conf"
+	"web-clean/infra/httpclient"
 	"web-clean/infra/loader"
 	"web-clean/infra/log"
+	"web-clean/infra/secrets"
 )
 
 // Context 为应用程序提供核心功能组件，支持直接嵌入业务结构体。
@@ -33,6 +35,22 @@ func Prepare(prepare PrepareConfig) (*Context, error) {
 
 	logger := log.Zap()
 
+	config, err := Reload(prepare, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Context{
+		Log:  logger,
+		Ctx:  context.Background(),
+		Conf: config,
+	}
+
+	return c, nil
+}
+
+// Reload 重新执行完整的配置加载：读取、解析密钥引用并校验，用于远程配置变化后刷新可在运行时调整的设置
+func Reload(prepare PrepareConfig, logger domain.Log) (*conf.Conf, error) {
 	if prepare.config == nil {
 		prepare.config = loader.Default()
 	}
@@ -45,11 +63,17 @@ func Prepare(prepare PrepareConfig) (*Context, error) {
 		return nil, err
 	}
 
-	c := &Context{
-		Log:  logger,
-		Ctx:  context.Background(),
-		Conf: config,
+	// vault:// 与 awssm:// 引用需要在校验之前替换为真实值
+	resolvers := secrets.FromEnv(httpclient.New("secrets", logger, httpclient.Default()))
+	if err := secrets.Resolve(context.Background(), config, resolvers); err != nil {
+		logger.Errorw("解析配置中的密钥引用失败", "error", err)
+		return nil, err
 	}
 
-	return c, nil
+	if err := config.Validate(); err != nil {
+		logger.Errorw("配置校验失败", "error", err)
+		return nil, err
+	}
+
+	return config, nil
 }
@@ -0,0 +1,101 @@
+package loader
+
+import (
+	"errors"
+	"reflect"
+
+	"web-clean/infra/conf"
+)
+
+// ErrNotFound 表示某个 Loader 没有找到任何配置来源（例如配置文件不存在、没有设置任何环境变量），
+// Chain 会跳过这样的 Loader 而不是直接失败
+var ErrNotFound = errors.New("config source not found")
+
+// Overlay 是能够在已有配置之上只覆盖部分字段的 Loader。
+// Chain 优先使用 Overlay，使零值（例如 production=false）也能覆盖前一个 Loader 的结果
+type Overlay interface {
+	Loader
+	Overlay(ctx *Context, c *conf.Conf) error
+}
+
+type chain struct {
+	loaders []Loader
+}
+
+// Chain 按顺序组合多个 Loader，后者覆盖前者，例如 Chain(byjson.JSONLoader, byenv.EnvLoader) 让环境变量覆盖配置文件。
+//
+// 返回 ErrNotFound 的 Loader 会被跳过，其余错误立即返回；所有 Loader 都未找到配置时返回 ErrNotFound。
+// 不支持 Overlay 的 Loader 只会覆盖非零值字段
+func Chain(loaders ...Loader) Loader {
+	return &chain{loaders: loaders}
+}
+
+func (c *chain) Load(ctx *Context) (*conf.Conf, error) {
+	var result *conf.Conf
+	notFound := ErrNotFound
+
+	for _, l := range c.loaders {
+		if overlay, ok := l.(Overlay); ok && result != nil {
+			if err := overlay.Overlay(ctx, result); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		loaded, err := l.Load(ctx)
+		if errors.Is(err, ErrNotFound) {
+			notFound = err
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if result == nil {
+			result = loaded
+		} else {
+			merge(reflect.ValueOf(result).Elem(), reflect.ValueOf(loaded).Elem())
+		}
+	}
+
+	if result == nil {
+		return nil, notFound
+	}
+	return result, nil
+}
+
+// merge 将 src 中的非零值覆盖到 dst，结构体与 map 逐项合并
+func merge(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				merge(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Pointer:
+		if src.IsNil() {
+			return
+		}
+		if dst.IsNil() || src.Elem().Kind() != reflect.Struct {
+			dst.Set(src)
+			return
+		}
+		merge(dst.Elem(), src.Elem())
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(src.Type()))
+		}
+		iter := src.MapRange()
+		for iter.Next() {
+			dst.SetMapIndex(iter.Key(), iter.Value())
+		}
+	default:
+		if !src.IsZero() {
+			dst.Set(src)
+		}
+	}
+}
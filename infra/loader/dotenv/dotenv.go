@@ -0,0 +1,109 @@
+package bydotenv
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"web-clean/infra/conf"
+	"web-clean/infra/loader"
+	byenv "web-clean/infra/loader/env"
+)
+
+var DotEnvLoader loader.Loader = &_dotenv{}
+
+type _dotenv struct {
+}
+
+// Load 读取 .env 文件，其中的 WEBCLEAN_* 变量按与 byenv 相同的规则映射到 conf.Conf。
+// 文件中的变量不会写入进程环境，真实的环境变量仍可通过 loader.Chain 覆盖它们
+func (_ _dotenv) Load(ctx *loader.Context) (*conf.Conf, error) {
+	vars, err := read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return vars.Load(ctx)
+}
+
+// Overlay 用 .env 文件中设置的变量覆盖 c 中对应的字段
+func (_ _dotenv) Overlay(ctx *loader.Context, c *conf.Conf) error {
+	vars, err := read(ctx)
+	if err != nil {
+		return err
+	}
+	return vars.Overlay(ctx, c)
+}
+
+// read 读取并解析 .env 文件，返回以其中变量为来源的 byenv Loader
+func read(ctx *loader.Context) (loader.Overlay, error) {
+	data, path, err := loader.ReadFile(ctx)
+	if err != nil {
+		return nil, &Error{Msg: err.Error(), Err: err}
+	}
+
+	vars, err := Parse(data)
+	if err != nil {
+		ctx.Log.Errorw("无法解析 .env 文件", "path", path, "error", err)
+		return nil, &Error{Msg: "无法解析 .env 文件 " + path + ": " + err.Error(), Err: err}
+	}
+
+	return byenv.New(byenv.Prefix, func(key string) (string, bool) {
+		value, ok := vars[key]
+		return value, ok
+	}), nil
+}
+
+// Parse 解析 .env 内容：每行一个 KEY=VALUE，支持 # 注释、export 前缀、
+// 单引号（原样）与双引号（支持 \n、\" 与 \\ 转义）包裹的值
+func Parse(data []byte) (map[string]string, error) {
+	vars := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+
+		key, value, ok := strings.Cut(text, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("第 %d 行不是 KEY=VALUE 形式", line)
+		}
+
+		value, err := unquote(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行: %w", line, err)
+		}
+		vars[key] = value
+	}
+
+	return vars, scanner.Err()
+}
+
+func unquote(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	switch quote := value[0]; quote {
+	case '\'', '"':
+		end := strings.LastIndexByte(value, quote)
+		if end == 0 {
+			return "", fmt.Errorf("引号未闭合")
+		}
+		inner := value[1:end]
+		if quote == '\'' {
+			return inner, nil
+		}
+		return strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(inner), nil
+	default:
+		// 未加引号的值中，空格后的 # 开始行尾注释
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		return value, nil
+	}
+}
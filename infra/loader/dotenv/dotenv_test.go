@@ -0,0 +1,54 @@
+package bydotenv
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"web-clean/infra/loader"
+)
+
+func TestParse(t *testing.T) {
+	vars, err := Parse([]byte(`
+# comment
+export WEBCLEAN_WEB_PORT=8080
+WEBCLEAN_DATABASE_HOST = db # trailing comment
+WEBCLEAN_DATABASE_PASSWORD='p#ss "word"'
+WEBCLEAN_CAPTCHA_SECRET="line\nbreak"
+EMPTY=
+`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"WEBCLEAN_WEB_PORT":          "8080",
+		"WEBCLEAN_DATABASE_HOST":     "db",
+		"WEBCLEAN_DATABASE_PASSWORD": `p#ss "word"`,
+		"WEBCLEAN_CAPTCHA_SECRET":    "line\nbreak",
+		"EMPTY":                      "",
+	}, vars)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := Parse([]byte("NOT A PAIR"))
+	assert.Error(t, err)
+
+	_, err = Parse([]byte(`KEY="unterminated`))
+	assert.Error(t, err)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("WEBCLEAN_WEB_PORT=8080\nWEBCLEAN_DATABASE_HOST=db\nOTHER=ignored\n"), 0o644))
+
+	c, err := DotEnvLoader.Load(&loader.Context{
+		Config: &loader.LoadConfig{Paths: []string{dir}, Files: []string{".env"}},
+		Log:    zap.NewNop().Sugar(),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, c.Web.Port)
+	assert.Equal(t, "db", c.Database.Host)
+}
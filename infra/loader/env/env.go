@@ -0,0 +1,171 @@
+package byenv
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"web-clean/infra/conf"
+	"web-clean/infra/loader"
+)
+
+// Prefix 是默认的环境变量前缀
+const Prefix = "WEBCLEAN_"
+
+// EnvLoader 从 WEBCLEAN_ 前缀的环境变量读取配置
+var EnvLoader loader.Loader = New(Prefix, os.LookupEnv)
+
+// New 创建一个环境变量 Loader，lookup 通常为 os.LookupEnv，测试中可以替换。
+//
+// 变量名由前缀与字段 json 名称路径的大写形式以 _ 连接而成，例如 web.port 对应 WEBCLEAN_WEB_PORT，
+// database.sql_sample_rate 对应 WEBCLEAN_DATABASE_SQL_SAMPLE_RATE。
+// 字符串切片以逗号分隔，map[string]string 以 "k1=v1,k2=v2" 表示；值为结构体的 map（如 chaos.routes）无法通过环境变量配置
+func New(prefix string, lookup func(string) (string, bool)) loader.Overlay {
+	return &_env{prefix: prefix, lookup: lookup}
+}
+
+type _env struct {
+	prefix string
+	lookup func(string) (string, bool)
+}
+
+// Load 只用环境变量构造配置，没有设置任何相关变量时返回 loader.ErrNotFound
+func (e *_env) Load(ctx *loader.Context) (*conf.Conf, error) {
+	var config conf.Conf
+	applied, err := e.apply(ctx, &config)
+	if err != nil {
+		return nil, err
+	}
+	if len(applied) == 0 {
+		return nil, &Error{Msg: fmt.Sprintf("未设置任何 %s 前缀的环境变量", e.prefix), Err: loader.ErrNotFound}
+	}
+	return &config, nil
+}
+
+// Overlay 用已设置的环境变量覆盖 c 中对应的字段
+func (e *_env) Overlay(ctx *loader.Context, c *conf.Conf) error {
+	_, err := e.apply(ctx, c)
+	return err
+}
+
+func (e *_env) apply(ctx *loader.Context, c *conf.Conf) ([]string, error) {
+	applied := make([]string, 0)
+	if err := e.walk(reflect.ValueOf(c).Elem(), strings.TrimSuffix(e.prefix, "_"), &applied); err != nil {
+		return nil, err
+	}
+
+	// 只记录变量名，值中可能含有密码等敏感信息
+	if len(applied) > 0 {
+		ctx.Log.Infow("使用环境变量覆盖配置", "variables", applied)
+	}
+	return applied, nil
+}
+
+// walk 遍历结构体字段，为每个已设置的环境变量写入对应字段
+func (e *_env) walk(value reflect.Value, name string, applied *[]string) error {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if !field.IsExported() || tag == "" || tag == "-" {
+			continue
+		}
+		key := name + "_" + strings.ToUpper(tag)
+		target := value.Field(i)
+
+		switch {
+		case target.Kind() == reflect.Struct:
+			if err := e.walk(target, key, applied); err != nil {
+				return err
+			}
+		case target.Kind() == reflect.Pointer && target.Type().Elem().Kind() == reflect.Struct:
+			// 只有当该结构体下至少设置了一个变量时才分配，避免把未配置的段落变成零值结构体
+			nested := reflect.New(target.Type().Elem())
+			if !target.IsNil() {
+				nested = target
+			}
+			before := len(*applied)
+			if err := e.walk(nested.Elem(), key, applied); err != nil {
+				return err
+			}
+			if len(*applied) > before {
+				target.Set(nested)
+			}
+		default:
+			raw, ok := e.lookup(key)
+			if !ok {
+				continue
+			}
+			if err := set(target, raw); err != nil {
+				return &Error{Msg: fmt.Sprintf("环境变量 %s 的值无效: %v", key, err), Err: err}
+			}
+			*applied = append(*applied, key)
+		}
+	}
+	return nil
+}
+
+// set 将字符串解析为字段类型并写入
+func set(target reflect.Value, raw string) error {
+	switch target.Kind() {
+	case reflect.Pointer:
+		value := reflect.New(target.Type().Elem())
+		if err := set(value.Elem(), raw); err != nil {
+			return err
+		}
+		target.Set(value)
+	case reflect.String:
+		target.SetString(raw)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		target.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(raw, 10, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+		target.SetInt(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(raw, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+		target.SetFloat(v)
+	case reflect.Slice:
+		if target.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("不支持的类型 %s", target.Type())
+		}
+		target.Set(reflect.ValueOf(splitList(raw)))
+	case reflect.Map:
+		if target.Type().Key().Kind() != reflect.String || target.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("不支持的类型 %s", target.Type())
+		}
+		pairs := make(map[string]string)
+		for _, item := range splitList(raw) {
+			k, v, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("%q 不是 key=value 形式", item)
+			}
+			pairs[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		target.Set(reflect.ValueOf(pairs))
+	default:
+		return fmt.Errorf("不支持的类型 %s", target.Type())
+	}
+	return nil
+}
+
+func splitList(raw string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,125 @@
+package byenv
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"web-clean/infra/conf"
+	"web-clean/infra/loader"
+)
+
+func lookupFrom(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := vars[key]
+		return value, ok
+	}
+}
+
+func testContext() *loader.Context {
+	return &loader.Context{Config: loader.Default(), Log: zap.NewNop().Sugar()}
+}
+
+func TestLoad(t *testing.T) {
+	l := New(Prefix, lookupFrom(map[string]string{
+		"WEBCLEAN_PRODUCTION":                  "true",
+		"WEBCLEAN_WEB_PORT":                    "8080",
+		"WEBCLEAN_DATABASE_HOST":               "db",
+		"WEBCLEAN_DATABASE_AUTO_MIGRATE":       "false",
+		"WEBCLEAN_DATABASE_SQL_SAMPLE_RATE":    "0.5",
+		"WEBCLEAN_DATABASE_SQL_REDACT_COLUMNS": "email, password",
+		"WEBCLEAN_SINK_LABELS":                 "app=web, env=prod",
+	}))
+
+	c, err := l.Load(testContext())
+
+	assert.NoError(t, err)
+	assert.True(t, c.ProductionMode)
+	assert.Equal(t, 8080, c.Web.Port)
+	assert.Equal(t, "db", c.Database.Host)
+	assert.False(t, c.Database.AutoMigrateEnabled())
+	assert.Equal(t, 0.5, c.Database.SQLSampleRate)
+	assert.Equal(t, []string{"email", "password"}, c.Database.SQLRedactColumns)
+	assert.Equal(t, map[string]string{"app": "web", "env": "prod"}, c.Sink.Labels)
+	// 未设置任何变量的段落保持为 nil
+	assert.Nil(t, c.Captcha)
+	assert.Nil(t, c.Logger)
+}
+
+func TestLoad_NothingSet(t *testing.T) {
+	_, err := New(Prefix, lookupFrom(nil)).Load(testContext())
+
+	assert.True(t, errors.Is(err, loader.ErrNotFound))
+}
+
+func TestLoad_InvalidValue(t *testing.T) {
+	_, err := New(Prefix, lookupFrom(map[string]string{"WEBCLEAN_WEB_PORT": "http"})).Load(testContext())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "WEBCLEAN_WEB_PORT")
+	assert.False(t, errors.Is(err, loader.ErrNotFound))
+}
+
+type staticLoader struct {
+	conf *conf.Conf
+	err  error
+}
+
+func (s staticLoader) Load(*loader.Context) (*conf.Conf, error) {
+	return s.conf, s.err
+}
+
+func TestChain_EnvOverridesFile(t *testing.T) {
+	file := staticLoader{conf: &conf.Conf{
+		ProductionMode: true,
+		Web:            &conf.Web{Port: 9000},
+		Database:       &conf.DatabaseConf{Host: "localhost", Port: 5432},
+	}}
+	env := New(Prefix, lookupFrom(map[string]string{
+		"WEBCLEAN_PRODUCTION":    "false",
+		"WEBCLEAN_DATABASE_HOST": "db",
+	}))
+
+	c, err := loader.Chain(file, env).Load(testContext())
+
+	assert.NoError(t, err)
+	assert.False(t, c.ProductionMode)
+	assert.Equal(t, 9000, c.Web.Port)
+	assert.Equal(t, "db", c.Database.Host)
+	assert.Equal(t, 5432, c.Database.Port)
+}
+
+func TestChain_SkipsMissingSources(t *testing.T) {
+	file := staticLoader{err: &Error{Msg: "未找到可用的配置文件", Err: loader.ErrNotFound}}
+	env := New(Prefix, lookupFrom(map[string]string{"WEBCLEAN_WEB_PORT": "8080"}))
+
+	c, err := loader.Chain(file, env).Load(testContext())
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, c.Web.Port)
+
+	_, err = loader.Chain(file, New(Prefix, lookupFrom(nil))).Load(testContext())
+	assert.True(t, errors.Is(err, loader.ErrNotFound))
+}
+
+func TestChain_StopsOnError(t *testing.T) {
+	broken := staticLoader{err: errors.New("invalid json")}
+	env := New(Prefix, lookupFrom(map[string]string{"WEBCLEAN_WEB_PORT": "8080"}))
+
+	_, err := loader.Chain(broken, env).Load(testContext())
+
+	assert.EqualError(t, err, "invalid json")
+}
+
+func TestChain_MergesNonOverlayLoaders(t *testing.T) {
+	first := staticLoader{conf: &conf.Conf{Web: &conf.Web{Port: 9000}, Database: &conf.DatabaseConf{Host: "a", Port: 5432}}}
+	second := staticLoader{conf: &conf.Conf{Database: &conf.DatabaseConf{Host: "b"}}}
+
+	c, err := loader.Chain(first, second).Load(testContext())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 9000, c.Web.Port)
+	assert.Equal(t, "b", c.Database.Host)
+	assert.Equal(t, 5432, c.Database.Port)
+}
@@ -0,0 +1,51 @@
+package loader
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MaxFileSize 配置文件的大小上限
+const MaxFileSize = 100 * 1024 * 1024
+
+// ReadFile 按 Paths × Files 的顺序查找第一个存在的配置文件并读取其内容，
+// 没有找到任何文件时返回包装了 ErrNotFound 的错误
+func ReadFile(ctx *Context) ([]byte, string, error) {
+	for _, dir := range ctx.Config.Paths {
+		for _, name := range ctx.Config.Files {
+			if strings.TrimSpace(dir) == "" || strings.TrimSpace(name) == "" {
+				continue
+			}
+
+			path := filepath.Join(dir, name)
+			info, err := os.Stat(path)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, path, err
+			}
+			if info.Size() > MaxFileSize {
+				return nil, path, fmt.Errorf("配置文件 %s 大小超过 %d MB", path, MaxFileSize/(1024*1024))
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				return nil, path, err
+			}
+			data, err := io.ReadAll(io.LimitReader(file, MaxFileSize))
+			_ = file.Close()
+			if err != nil {
+				return nil, path, err
+			}
+
+			ctx.Log.Debugw("发现配置文件", "path", path)
+			return data, path, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("%w: %v 中没有 %v", ErrNotFound, ctx.Config.Paths, ctx.Config.Files)
+}
@@ -8,3 +8,7 @@ type Error struct {
 func (e *Error) Error() string {
 	return e.Msg
 }
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -32,6 +32,20 @@ func (_ _json) Load(ctx *loader.Context) (*conf.Conf, error) {
 	return load(ctx)
 }
 
+// Overlay 将找到的配置文件反序列化到 c 之上，文件中出现的键（包括零值）覆盖 c，未出现的键保持不变
+func (_ _json) Overlay(ctx *loader.Context, c *conf.Conf) error {
+	data, path, err := loader.ReadFile(ctx)
+	if err != nil {
+		return &Error{Msg: err.Error(), Err: err}
+	}
+
+	if err := json.Unmarshal(data, c); err != nil {
+		ctx.Log.Errorw("无法反序列化配置文件到 Conf", "path", path, "error", err)
+		return &Error{Msg: "无法反序列化配置文件到 Conf: " + err.Error(), Err: err}
+	}
+	return nil
+}
+
 func load(ctx *loader.Context) (*conf.Conf, error) {
 
 	loadConfig := ctx.Config
@@ -93,7 +107,7 @@ func load(ctx *loader.Context) (*conf.Conf, error) {
 	// 否则返回一个未找到
 	return nil, &Error{
 		Msg: "未找到可用的配置文件",
-		Err: nil,
+		Err: loader.ErrNotFound,
 	}
 }
 
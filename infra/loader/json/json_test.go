@@ -0,0 +1,89 @@
+package byjson
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"web-clean/infra/loader"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestProfiles_OverridesBase(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.json":      `{"production": true, "web": {"port": 8080}, "database": {"host": "localhost", "port": 5432, "username": "app"}}`,
+		"config.prod.json": `{"production": false, "database": {"host": "db.prod"}}`,
+	})
+
+	c, err := loader.Profiles(JSONLoader).Load(&loader.Context{
+		Config: &loader.LoadConfig{Paths: []string{dir}, Files: []string{"config.json"}, ActiveProfile: "prod"},
+		Log:    zap.NewNop().Sugar(),
+	})
+
+	assert.NoError(t, err)
+	assert.False(t, c.ProductionMode)
+	assert.Equal(t, 8080, c.Web.Port)
+	assert.Equal(t, "db.prod", c.Database.Host)
+	assert.Equal(t, 5432, c.Database.Port)
+	assert.Equal(t, "app", c.Database.Username)
+}
+
+func TestProfiles_NoProfile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.json":      `{"web": {"port": 8080}}`,
+		"config.prod.json": `{"web": {"port": 9000}}`,
+	})
+
+	c, err := loader.Profiles(JSONLoader).Load(&loader.Context{
+		Config: &loader.LoadConfig{Paths: []string{dir}, Files: []string{"config.json"}},
+		Log:    zap.NewNop().Sugar(),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, c.Web.Port)
+}
+
+func TestProfiles_OnlyProfileFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.staging.json": `{"web": {"port": 9000}}`,
+	})
+
+	c, err := loader.Profiles(JSONLoader).Load(&loader.Context{
+		Config: &loader.LoadConfig{Paths: []string{dir}, Files: []string{"config.json"}, ActiveProfile: "staging"},
+		Log:    zap.NewNop().Sugar(),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 9000, c.Web.Port)
+}
+
+func TestProfiles_MissingProfileFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.json": `{"web": {"port": 8080}}`,
+	})
+
+	_, err := loader.Profiles(JSONLoader).Load(&loader.Context{
+		Config: &loader.LoadConfig{Paths: []string{dir}, Files: []string{"config.json"}, ActiveProfile: "prdo"},
+		Log:    zap.NewNop().Sugar(),
+	})
+
+	assert.True(t, errors.Is(err, loader.ErrProfileNotFound))
+	assert.False(t, errors.Is(err, loader.ErrNotFound))
+}
+
+func TestProfileFile(t *testing.T) {
+	assert.Equal(t, "config.prod.json", loader.ProfileFile("config.json", "prod"))
+	assert.Equal(t, "app.dev.toml", loader.ProfileFile("app.toml", "dev"))
+	assert.Equal(t, ".env.prod", loader.ProfileFile(".env", "prod"))
+}
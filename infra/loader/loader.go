@@ -1,6 +1,11 @@
 package loader
 
 import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
 	"web-clean/domain"
 	"web-clean/infra/conf"
 )
@@ -13,15 +18,81 @@ type Context struct {
 type LoadConfig struct {
 	Paths []string
 	Files []string
+	// Format 选择配置文件格式：json（默认）、toml 或 dotenv，由 ByFormat 分派到对应的 Loader
+	Format string
+	// ActiveProfile 非空时由 Profiles 在基础配置之上叠加 config.<profile>.json 等文件，默认读取 WEBCLEAN_PROFILE
+	ActiveProfile string
 }
 
 type Loader interface {
 	Load(ctx *Context) (*conf.Conf, error)
 }
 
+// FormatEnv 用于在不同环境中选择配置文件格式，例如 WEBCLEAN_CONFIG_FORMAT=toml
+const FormatEnv = "WEBCLEAN_CONFIG_FORMAT"
+
+// DefaultFiles 是各格式默认查找的文件名
+var DefaultFiles = map[string][]string{
+	"json":   {"config.json", "app.json"},
+	"toml":   {"config.toml", "app.toml"},
+	"dotenv": {".env"},
+}
+
 func Default() *LoadConfig {
+	format := strings.ToLower(strings.TrimSpace(os.Getenv(FormatEnv)))
+	if format == "" {
+		format = "json"
+	}
+
 	return &LoadConfig{
-		Paths: []string{".", "./config"},
-		Files: []string{"config.json", "app.json"},
+		Paths:         []string{".", "./config"},
+		Files:         DefaultFiles[format],
+		Format:        format,
+		ActiveProfile: strings.TrimSpace(os.Getenv(ProfileEnv)),
+	}
+}
+
+type byFormat struct {
+	loaders map[string]Loader
+}
+
+// ByFormat 根据 LoadConfig.Format 选择 Loader，Format 为空时使用 json
+func ByFormat(loaders map[string]Loader) Loader {
+	return &byFormat{loaders: loaders}
+}
+
+func (b *byFormat) Load(ctx *Context) (*conf.Conf, error) {
+	format := ctx.Config.Format
+	if format == "" {
+		format = "json"
+	}
+
+	l, ok := b.loaders[format]
+	if !ok {
+		return nil, fmt.Errorf("不支持的配置文件格式 %q", format)
+	}
+	return l.Load(ctx)
+}
+
+// Overlay 将 Overlay 分派给所选格式的 Loader，该 Loader 不支持 Overlay 时只覆盖非零值字段
+func (b *byFormat) Overlay(ctx *Context, c *conf.Conf) error {
+	format := ctx.Config.Format
+	if format == "" {
+		format = "json"
+	}
+
+	l, ok := b.loaders[format]
+	if !ok {
+		return fmt.Errorf("不支持的配置文件格式 %q", format)
+	}
+	if overlay, ok := l.(Overlay); ok {
+		return overlay.Overlay(ctx, c)
+	}
+
+	loaded, err := l.Load(ctx)
+	if err != nil {
+		return err
 	}
+	merge(reflect.ValueOf(c).Elem(), reflect.ValueOf(loaded).Elem())
+	return nil
 }
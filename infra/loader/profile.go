@@ -0,0 +1,83 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"web-clean/infra/conf"
+)
+
+// ProfileEnv 用于选择当前环境的配置 profile，例如 WEBCLEAN_PROFILE=prod
+const ProfileEnv = "WEBCLEAN_PROFILE"
+
+// ErrProfileNotFound 表示设置了 ActiveProfile 但没有找到对应的配置文件。
+// 它不包装 ErrNotFound，避免 Chain 将拼写错误的 profile 当作缺失的可选来源静默跳过
+var ErrProfileNotFound = errors.New("config profile not found")
+
+type profiles struct {
+	loader Loader
+}
+
+// Profiles 在 l 加载的基础配置之上叠加 LoadConfig.ActiveProfile 对应的配置文件，
+// 例如 config.json 之上叠加 config.prod.json，.env 之上叠加 .env.prod。
+//
+// profile 文件中出现的键覆盖基础配置，未出现的键沿用基础配置；l 实现 Overlay 时零值也能覆盖，
+// 否则只覆盖非零值字段。基础配置不存在时只使用 profile 文件
+func Profiles(l Loader) Loader {
+	return &profiles{loader: l}
+}
+
+func (p *profiles) Load(ctx *Context) (*conf.Conf, error) {
+	profile := strings.TrimSpace(ctx.Config.ActiveProfile)
+	base, err := p.loader.Load(ctx)
+	if profile == "" {
+		return base, err
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	ctx.Log.Infow("使用配置 profile", "profile", profile)
+	profileCtx := &Context{Config: ctx.Config.ForProfile(profile), Log: ctx.Log}
+
+	if base == nil {
+		return p.loader.Load(profileCtx)
+	}
+
+	if overlay, ok := p.loader.(Overlay); ok {
+		err = overlay.Overlay(profileCtx, base)
+	} else {
+		var loaded *conf.Conf
+		if loaded, err = p.loader.Load(profileCtx); err == nil {
+			merge(reflect.ValueOf(base).Elem(), reflect.ValueOf(loaded).Elem())
+		}
+	}
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s 中没有 %v", ErrProfileNotFound, profile, profileCtx.Config.Files)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return base, nil
+}
+
+// ForProfile 返回查找 profile 配置文件的 LoadConfig，文件名在扩展名之前插入 profile
+func (c *LoadConfig) ForProfile(profile string) *LoadConfig {
+	files := make([]string, 0, len(c.Files))
+	for _, name := range c.Files {
+		files = append(files, ProfileFile(name, profile))
+	}
+	return &LoadConfig{Paths: c.Paths, Files: files, Format: c.Format}
+}
+
+// ProfileFile 返回 profile 对应的文件名：config.json -> config.prod.json，.env -> .env.prod
+func ProfileFile(name, profile string) string {
+	ext := filepath.Ext(name)
+	if ext == "" || ext == name {
+		return name + "." + profile
+	}
+	return strings.TrimSuffix(name, ext) + "." + profile + ext
+}
@@ -0,0 +1,295 @@
+package byremote
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"web-clean/domain"
+	"web-clean/infra/conf"
+	"web-clean/infra/httpclient"
+	"web-clean/infra/loader"
+)
+
+const (
+	KindHTTP   = "http"
+	KindEtcd   = "etcd"
+	KindConsul = "consul"
+)
+
+// Config 描述远程配置来源。远程配置无法从配置文件本身读取，因此由 WEBCLEAN_REMOTE_CONFIG_* 环境变量提供
+type Config struct {
+	Kind string // http（默认）、etcd 或 consul
+	URL  string // http 为配置文档地址；etcd 与 consul 为服务地址，例如 https://etcd:2379
+	Key  string // etcd 与 consul 中保存配置的键
+
+	Token string // http 使用 Bearer Token，etcd 使用 Authorization，consul 使用 X-Consul-Token
+
+	CAFile   string // 校验服务端证书的 CA
+	CertFile string // mTLS 客户端证书
+	KeyFile  string // mTLS 客户端私钥
+
+	// Interval 大于 0 时 Watch 按该间隔轮询，配置变化时通知调用方
+	Interval time.Duration
+}
+
+// FromEnv 读取 WEBCLEAN_REMOTE_CONFIG_URL、_KIND、_KEY、_TOKEN、_CA_FILE、_CERT_FILE、_KEY_FILE 与 _INTERVAL（如 30s），
+// 未设置 URL 时返回的 Loader 总是返回 loader.ErrNotFound
+func FromEnv() *Remote {
+	interval, _ := time.ParseDuration(os.Getenv("WEBCLEAN_REMOTE_CONFIG_INTERVAL"))
+	return New(Config{
+		Kind:     os.Getenv("WEBCLEAN_REMOTE_CONFIG_KIND"),
+		URL:      os.Getenv("WEBCLEAN_REMOTE_CONFIG_URL"),
+		Key:      os.Getenv("WEBCLEAN_REMOTE_CONFIG_KEY"),
+		Token:    os.Getenv("WEBCLEAN_REMOTE_CONFIG_TOKEN"),
+		CAFile:   os.Getenv("WEBCLEAN_REMOTE_CONFIG_CA_FILE"),
+		CertFile: os.Getenv("WEBCLEAN_REMOTE_CONFIG_CERT_FILE"),
+		KeyFile:  os.Getenv("WEBCLEAN_REMOTE_CONFIG_KEY_FILE"),
+		Interval: interval,
+	})
+}
+
+// Remote 从 HTTP 地址、etcd（v3 JSON 网关）或 Consul KV 读取 JSON 格式的 conf.Conf，键名与 config.json 相同。
+//
+// 它实现了 loader.Overlay，放在 loader.Chain 中时远程配置里出现的键覆盖本地配置文件
+type Remote struct {
+	config Config
+
+	mu     sync.Mutex
+	client *http.Client
+	last   []byte
+}
+
+func New(config Config) *Remote {
+	if config.Kind == "" {
+		config.Kind = KindHTTP
+	}
+	config.URL = strings.TrimRight(config.URL, "/")
+	return &Remote{config: config}
+}
+
+// Enabled 表示是否配置了远程来源
+func (r *Remote) Enabled() bool {
+	return r.config.URL != ""
+}
+
+func (r *Remote) Load(ctx *loader.Context) (*conf.Conf, error) {
+	var config conf.Conf
+	if err := r.Overlay(ctx, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Overlay 将远程配置反序列化到 c 之上，远程配置中出现的键覆盖 c
+func (r *Remote) Overlay(ctx *loader.Context, c *conf.Conf) error {
+	if !r.Enabled() {
+		return &Error{Msg: "未配置远程配置地址", Err: loader.ErrNotFound}
+	}
+
+	data, err := r.fetch(context.Background(), ctx.Log)
+	if err != nil {
+		ctx.Log.Errorw("无法读取远程配置", "kind", r.config.Kind, "url", r.config.URL, "key", r.config.Key, "error", err)
+		return &Error{Msg: "无法读取远程配置: " + err.Error(), Err: err}
+	}
+
+	if err := json.Unmarshal(data, c); err != nil {
+		return &Error{Msg: "无法反序列化远程配置到 Conf: " + err.Error(), Err: err}
+	}
+
+	ctx.Log.Infow("使用远程配置", "kind", r.config.Kind, "url", r.config.URL, "key", r.config.Key)
+	return nil
+}
+
+// Watch 按 Interval 轮询远程配置，内容变化时调用 onChange，直到 ctx 结束。
+// Interval 未配置或未启用远程配置时立即返回。onChange 通常重新执行完整的配置加载（本地文件、远程与环境变量）
+func (r *Remote) Watch(ctx context.Context, log domain.Log, onChange func()) {
+	if !r.Enabled() || r.config.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		r.mu.Lock()
+		previous := r.last
+		r.mu.Unlock()
+
+		data, err := r.fetch(ctx, log)
+		if err != nil {
+			log.Warnw("轮询远程配置失败", "kind", r.config.Kind, "url", r.config.URL, "error", err)
+			continue
+		}
+		if !bytes.Equal(data, previous) {
+			log.Infow("远程配置已变化", "kind", r.config.Kind, "url", r.config.URL, "key", r.config.Key)
+			onChange()
+		}
+	}
+}
+
+// fetch 读取远程配置的原始内容并记录下来，供 Watch 比较
+func (r *Remote) fetch(ctx context.Context, log domain.Log) ([]byte, error) {
+	client, err := r.httpClient(log)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	switch r.config.Kind {
+	case KindHTTP:
+		data, err = r.fetchHTTP(ctx, client)
+	case KindEtcd:
+		data, err = r.fetchEtcd(ctx, client)
+	case KindConsul:
+		data, err = r.fetchConsul(ctx, client)
+	default:
+		return nil, fmt.Errorf("不支持的远程配置类型 %q", r.config.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.last = data
+	r.mu.Unlock()
+	return data, nil
+}
+
+func (r *Remote) fetchHTTP(ctx context.Context, client *http.Client) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.config.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if r.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.config.Token)
+	}
+	return do(client, req)
+}
+
+// fetchEtcd 通过 etcd v3 的 gRPC-gateway 读取键，键与值在 JSON 中均为 base64 编码
+func (r *Remote) fetchEtcd(ctx context.Context, client *http.Client) ([]byte, error) {
+	payload, err := json.Marshal(map[string]string{"key": base64.StdEncoding.EncodeToString([]byte(r.config.Key))})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.config.URL+"/v3/kv/range", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.config.Token != "" {
+		req.Header.Set("Authorization", r.config.Token)
+	}
+
+	body, err := do(client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Kvs []struct {
+			Value string `json:"value"`
+		} `json:"kvs"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, fmt.Errorf("etcd 中不存在键 %q", r.config.Key)
+	}
+	return base64.StdEncoding.DecodeString(resp.Kvs[0].Value)
+}
+
+func (r *Remote) fetchConsul(ctx context.Context, client *http.Client) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.config.URL+"/v1/kv/"+strings.TrimLeft(r.config.Key, "/")+"?raw", nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.config.Token != "" {
+		req.Header.Set("X-Consul-Token", r.config.Token)
+	}
+	return do(client, req)
+}
+
+func do(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, loader.MaxFileSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("远程配置服务返回 %d", resp.StatusCode)
+	}
+	return body, nil
+}
+
+// httpClient 在首次使用时按 TLS 配置创建客户端
+func (r *Remote) httpClient(log domain.Log) (*http.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.client != nil {
+		return r.client, nil
+	}
+
+	tlsConfig, err := r.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	config := httpclient.Default()
+	config.TLS = tlsConfig
+	r.client = httpclient.New("remote-config", log, config)
+	return r.client, nil
+}
+
+func (r *Remote) tlsConfig() (*tls.Config, error) {
+	if r.config.CAFile == "" && r.config.CertFile == "" {
+		return nil, nil
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if r.config.CAFile != "" {
+		pem, err := os.ReadFile(r.config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s 中没有有效的 CA 证书", r.config.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if r.config.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
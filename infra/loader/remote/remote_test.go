@@ -0,0 +1,130 @@
+package byremote
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"web-clean/infra/conf"
+	"web-clean/infra/loader"
+)
+
+func newContext() *loader.Context {
+	return &loader.Context{Config: loader.Default(), Log: zap.NewNop().Sugar()}
+}
+
+func TestLoad_HTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"web": {"port": 9000}}`))
+	}))
+	defer server.Close()
+
+	c, err := New(Config{URL: server.URL, Token: "secret"}).Load(newContext())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 9000, c.Web.Port)
+}
+
+func TestLoad_Etcd(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/kv/range", r.URL.Path)
+		assert.Equal(t, "etcd-token", r.Header.Get("Authorization"))
+
+		var req map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("/webclean/config")), req["key"])
+
+		value := base64.StdEncoding.EncodeToString([]byte(`{"id_strategy": "ulid"}`))
+		_, _ = w.Write([]byte(`{"kvs": [{"value": "` + value + `"}]}`))
+	}))
+	defer server.Close()
+
+	c, err := New(Config{Kind: KindEtcd, URL: server.URL, Key: "/webclean/config", Token: "etcd-token"}).Load(newContext())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ulid", c.IDStrategy)
+}
+
+func TestOverlay_Consul(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/kv/webclean/config", r.URL.Path)
+		assert.Equal(t, "consul-token", r.Header.Get("X-Consul-Token"))
+		_, _ = w.Write([]byte(`{"database": {"host": "db.prod"}}`))
+	}))
+	defer server.Close()
+
+	base := &conf.Conf{Database: &conf.DatabaseConf{Host: "localhost", Port: 5432}}
+	err := New(Config{Kind: KindConsul, URL: server.URL, Key: "webclean/config", Token: "consul-token"}).Overlay(newContext(), base)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "db.prod", base.Database.Host)
+	assert.Equal(t, 5432, base.Database.Port)
+}
+
+func TestLoad_NotConfigured(t *testing.T) {
+	_, err := New(Config{}).Load(newContext())
+
+	assert.True(t, errors.Is(err, loader.ErrNotFound))
+}
+
+func TestLoad_ServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, err := New(Config{URL: server.URL}).Load(newContext())
+
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, loader.ErrNotFound))
+}
+
+func TestWatch_NotifiesOnChange(t *testing.T) {
+	var version atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if version.Load() == 0 {
+			_, _ = w.Write([]byte(`{"web": {"port": 8080}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"web": {"port": 9000}}`))
+	}))
+	defer server.Close()
+
+	remote := New(Config{URL: server.URL, Interval: 10 * time.Millisecond})
+	_, err := remote.Load(newContext())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changed := make(chan struct{}, 1)
+	go remote.Watch(ctx, zap.NewNop().Sugar(), func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+
+	select {
+	case <-changed:
+		t.Fatal("unchanged config must not notify")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	version.Store(1)
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("expected a change notification")
+	}
+}
@@ -0,0 +1,14 @@
+package bytoml
+
+type Error struct {
+	Msg string
+	Err error
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
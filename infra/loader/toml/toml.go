@@ -0,0 +1,51 @@
+package bytoml
+
+import (
+	"encoding/json"
+
+	"github.com/pelletier/go-toml/v2"
+
+	"web-clean/infra/conf"
+	"web-clean/infra/loader"
+)
+
+var TOMLLoader loader.Loader = &_toml{}
+
+type _toml struct {
+}
+
+// Load 读取 TOML 配置文件。键名与 JSON 配置相同（例如 [database] 下的 sql_sample_rate），
+// 先解析为通用结构再按 json 标签映射到 conf.Conf，因此 conf 中的字段不需要额外的 toml 标签
+func (t _toml) Load(ctx *loader.Context) (*conf.Conf, error) {
+	var config conf.Conf
+	if err := t.Overlay(ctx, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Overlay 将找到的 TOML 配置文件映射到 c 之上，文件中出现的键覆盖 c，未出现的键保持不变
+func (_ _toml) Overlay(ctx *loader.Context, c *conf.Conf) error {
+	data, path, err := loader.ReadFile(ctx)
+	if err != nil {
+		return &Error{Msg: err.Error(), Err: err}
+	}
+
+	var document map[string]any
+	if err := toml.Unmarshal(data, &document); err != nil {
+		ctx.Log.Errorw("无法解析 TOML 配置文件", "path", path, "error", err)
+		return &Error{Msg: "无法解析 TOML 配置文件 " + path + ": " + err.Error(), Err: err}
+	}
+
+	intermediate, err := json.Marshal(document)
+	if err != nil {
+		return &Error{Msg: "无法转换 TOML 配置文件 " + path, Err: err}
+	}
+
+	if err := json.Unmarshal(intermediate, c); err != nil {
+		ctx.Log.Errorw("无法反序列化配置文件到 Conf", "path", path, "error", err)
+		return &Error{Msg: "无法反序列化配置文件到 Conf: " + err.Error(), Err: err}
+	}
+
+	return nil
+}
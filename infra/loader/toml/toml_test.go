@@ -0,0 +1,54 @@
+package bytoml
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"web-clean/infra/loader"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), []byte(`
+production = true
+
+[web]
+port = 9000
+
+[database]
+host = "db"
+port = 5432
+sql_sample_rate = 0.25
+sql_redact_columns = ["email"]
+
+[chaos.routes."GET /api/v1/users"]
+latency_ms = 200
+`), 0o644))
+
+	c, err := TOMLLoader.Load(&loader.Context{
+		Config: &loader.LoadConfig{Paths: []string{dir}, Files: []string{"config.toml"}},
+		Log:    zap.NewNop().Sugar(),
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, c.ProductionMode)
+	assert.Equal(t, 9000, c.Web.Port)
+	assert.Equal(t, "db", c.Database.Host)
+	assert.Equal(t, 0.25, c.Database.SQLSampleRate)
+	assert.Equal(t, []string{"email"}, c.Database.SQLRedactColumns)
+	assert.Equal(t, 200, c.Chaos.Routes["GET /api/v1/users"].LatencyMs)
+}
+
+func TestLoad_NotFound(t *testing.T) {
+	_, err := TOMLLoader.Load(&loader.Context{
+		Config: &loader.LoadConfig{Paths: []string{t.TempDir()}, Files: []string{"config.toml"}},
+		Log:    zap.NewNop().Sugar(),
+	})
+
+	assert.True(t, errors.Is(err, loader.ErrNotFound))
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter 是只增不减的计数器
+type Counter struct {
+	value atomic.Int64
+}
+
+// NewCounter 创建计数器并以 name 发布到 expvar，name 重复时 panic
+func NewCounter(name string) *Counter {
+	c := &Counter{}
+	expvar.Publish(name, c)
+	return c
+}
+
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+func (c *Counter) Add(delta int64) {
+	c.value.Add(delta)
+}
+
+func (c *Counter) Value() int64 {
+	return c.value.Load()
+}
+
+func (c *Counter) String() string {
+	return strconv.FormatInt(c.Value(), 10)
+}
+
+// Gauge 是可增可减的瞬时值
+type Gauge struct {
+	value atomic.Int64
+}
+
+// NewGauge 创建瞬时值并以 name 发布到 expvar，name 重复时 panic
+func NewGauge(name string) *Gauge {
+	g := &Gauge{}
+	expvar.Publish(name, g)
+	return g
+}
+
+func (g *Gauge) Add(delta int64) {
+	g.value.Add(delta)
+}
+
+func (g *Gauge) Set(value int64) {
+	g.value.Store(value)
+}
+
+func (g *Gauge) Value() int64 {
+	return g.value.Load()
+}
+
+func (g *Gauge) String() string {
+	return strconv.FormatInt(g.Value(), 10)
+}
+
+// Histogram 统计观测值落在各个上界内的次数，最后一个桶为 +Inf
+type Histogram struct {
+	bounds []float64
+
+	mu     sync.Mutex
+	counts []int64
+	count  int64
+	sum    float64
+}
+
+// NewHistogram 创建直方图并以 name 发布到 expvar，bounds 需按升序排列
+func NewHistogram(name string, bounds []float64) *Histogram {
+	h := &Histogram{
+		bounds: bounds,
+		counts: make([]int64, len(bounds)+1),
+	}
+	expvar.Publish(name, h)
+	return h
+}
+
+func (h *Histogram) Observe(value float64) {
+	i := 0
+	for i < len(h.bounds) && value > h.bounds[i] {
+		i++
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[i]++
+	h.count++
+	h.sum += value
+}
+
+// HistogramSnapshot 是直方图某一时刻的副本，Buckets 为累计计数，与 Prometheus 的 le 语义一致
+type HistogramSnapshot struct {
+	Buckets map[string]int64 `json:"buckets"`
+	Count   int64            `json:"count"`
+	Sum     float64          `json:"sum"`
+}
+
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	snapshot := HistogramSnapshot{
+		Buckets: make(map[string]int64, len(h.counts)),
+		Count:   h.count,
+		Sum:     h.sum,
+	}
+
+	var cumulative int64
+	for i, count := range h.counts {
+		cumulative += count
+		bound := math.Inf(1)
+		if i < len(h.bounds) {
+			bound = h.bounds[i]
+		}
+		snapshot.Buckets[strconv.FormatFloat(bound, 'g', -1, 64)] = cumulative
+	}
+	return snapshot
+}
+
+func (h *Histogram) String() string {
+	data, _ := json.Marshal(h.Snapshot())
+	return string(data)
+}
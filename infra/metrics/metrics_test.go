@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"errors"
+	"expvar"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogram_Snapshot(t *testing.T) {
+	h := NewHistogram("test_histogram", []float64{1, 10})
+
+	h.Observe(0.5)
+	h.Observe(1)
+	h.Observe(5)
+	h.Observe(50)
+
+	snapshot := h.Snapshot()
+	assert.Equal(t, map[string]int64{"1": 2, "10": 3, "+Inf": 4}, snapshot.Buckets)
+	assert.Equal(t, int64(4), snapshot.Count)
+	assert.Equal(t, 56.5, snapshot.Sum)
+}
+
+func TestPersister_Track(t *testing.T) {
+	p := NewPersister("test")
+
+	done := p.Track(3)
+	assert.Equal(t, int64(1), p.InFlight.Value())
+	done(errors.New("db down"))
+
+	assert.Equal(t, int64(0), p.InFlight.Value())
+	assert.Equal(t, int64(1), p.Batches.Value())
+	assert.Equal(t, int64(1), p.Failures.Value())
+	assert.Equal(t, int64(1), p.BatchSize.Snapshot().Buckets["5"])
+	assert.Equal(t, "1", expvar.Get("persister_test_failures_total").String())
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"time"
+
+	"web-clean/infra/web"
+)
+
+var (
+	// sizeBuckets 是批次大小（条数）的桶上界
+	sizeBuckets = []float64{1, 5, 10, 25, 50, 100, 250, 500}
+	// latencyBuckets 是写入耗时（秒）的桶上界
+	latencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+)
+
+// Persister 是一条持久化管道（请求日志或错误堆栈）的指标
+type Persister struct {
+	// InFlight 正在写入的批次数，持久化在请求结束时同步执行，持续升高说明写入变慢并开始拖住请求
+	InFlight *Gauge
+	// Batches 写入的批次总数
+	Batches *Counter
+	// BatchSize 每个批次包含的条数
+	BatchSize *Histogram
+	// FlushLatency 每个批次的写入耗时（秒）
+	FlushLatency *Histogram
+	// Failures 写入主数据库或外部 sink 失败的次数
+	Failures *Counter
+	// Fallbacks 写入回退文件的次数，非零说明数据库不可用且回退目录在增长
+	Fallbacks *Counter
+	// Evictions 回退文件因超出大小或保留时间配额被删除的次数
+	Evictions *Counter
+}
+
+// NewPersister 创建并发布以 persister_<kind>_ 为前缀的一组指标
+func NewPersister(kind string) *Persister {
+	prefix := "persister_" + kind + "_"
+	return &Persister{
+		InFlight:     NewGauge(prefix + "in_flight"),
+		Batches:      NewCounter(prefix + "batches_total"),
+		BatchSize:    NewHistogram(prefix+"batch_size", sizeBuckets),
+		FlushLatency: NewHistogram(prefix+"flush_latency_seconds", latencyBuckets),
+		Failures:     NewCounter(prefix + "failures_total"),
+		Fallbacks:    NewCounter(prefix + "fallbacks_total"),
+		Evictions:    NewCounter(prefix + "evictions_total"),
+	}
+}
+
+var (
+	Logs   = NewPersister("logs")
+	Errors = NewPersister("errors")
+)
+
+// Track 记录一个大小为 size 的批次开始写入，返回的函数需在写入结束时调用
+func (p *Persister) Track(size int) func(err error) {
+	start := time.Now()
+	p.InFlight.Add(1)
+
+	return func(err error) {
+		p.InFlight.Add(-1)
+		p.Batches.Inc()
+		p.BatchSize.Observe(float64(size))
+		p.FlushLatency.Observe(time.Since(start).Seconds())
+		if err != nil {
+			p.Failures.Inc()
+		}
+	}
+}
+
+// LogPersister 为日志持久化添加指标统计
+func LogPersister(inner web.LogPersister) web.LogPersister {
+	return logPersister{inner: inner}
+}
+
+type logPersister struct {
+	inner web.LogPersister
+}
+
+func (p logPersister) Persist(requestID string, logs []web.Log) error {
+	done := Logs.Track(len(logs))
+	err := p.inner.Persist(requestID, logs)
+	done(err)
+	return err
+}
+
+// ErrorPersister 为错误堆栈持久化添加指标统计。ErrorStackPersister 不返回错误，
+// 失败与回退次数由具体实现通过 Errors.Failures 与 Errors.Fallbacks 记录
+func ErrorPersister(inner web.ErrorStackPersister) web.ErrorStackPersister {
+	return errorPersister{inner: inner}
+}
+
+type errorPersister struct {
+	inner web.ErrorStackPersister
+}
+
+func (p errorPersister) Persist(errors web.Errors) {
+	size := 1
+	if entries, ok := errors.Stack.([]web.ErrorEntry); ok {
+		size = len(entries)
+	}
+
+	done := Errors.Track(size)
+	p.inner.Persist(errors)
+	done(nil)
+}
@@ -0,0 +1,104 @@
+// Package propagation 在请求的 context 中携带需要透传给下游的请求头，
+// 例如 W3C trace context（traceparent、tracestate）、OpenTelemetry baggage、RequestID 与租户 ID。
+//
+// 入站中间件按白名单从请求中提取这些请求头，出站 HTTP 客户端自动把它们写入每一个请求，
+// 业务代码无需在每次调用时手动传递
+package propagation
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	// TenantHeader 是透传租户 ID 使用的请求头
+	TenantHeader = "X-Tenant-ID"
+	// RequestIDHeader 是透传 RequestID 使用的请求头
+	RequestIDHeader = "X-Request-ID"
+)
+
+// DefaultKeys 是未配置白名单时透传的请求头
+var DefaultKeys = []string{"traceparent", "tracestate", "baggage", RequestIDHeader, TenantHeader}
+
+// Allowlist 是允许透传的请求头集合，名称不区分大小写
+type Allowlist map[string]bool
+
+// NewAllowlist 创建白名单，keys 为 nil 时使用 DefaultKeys，空切片表示不透传任何请求头
+func NewAllowlist(keys []string) Allowlist {
+	if keys == nil {
+		keys = DefaultKeys
+	}
+	allow := make(Allowlist, len(keys))
+	for _, key := range keys {
+		allow[http.CanonicalHeaderKey(key)] = true
+	}
+	return allow
+}
+
+// Allows 判断请求头是否允许透传
+func (a Allowlist) Allows(key string) bool {
+	return a[http.CanonicalHeaderKey(key)]
+}
+
+// Extract 从入站请求头中复制白名单内的请求头
+func (a Allowlist) Extract(header http.Header) http.Header {
+	fields := make(http.Header)
+	for key := range a {
+		if values := header.Values(key); len(values) > 0 {
+			fields[key] = append([]string(nil), values...)
+		}
+	}
+	return fields
+}
+
+type stateKey struct{}
+
+// state 是 context 中的透传状态，allow 随状态一起保存，使后续的 Set 同样受白名单约束
+type state struct {
+	allow  Allowlist
+	fields http.Header
+}
+
+// Start 按白名单从入站请求头中提取需要透传的字段，返回携带这些字段的 context
+func Start(ctx context.Context, allow Allowlist, header http.Header) context.Context {
+	return context.WithValue(ctx, stateKey{}, state{allow: allow, fields: allow.Extract(header)})
+}
+
+// Set 返回设置了单个字段的 context，value 为空时移除该字段。
+// ctx 未经过 Start 或 key 不在白名单内时原样返回
+func Set(ctx context.Context, key, value string) context.Context {
+	current, ok := ctx.Value(stateKey{}).(state)
+	if !ok || !current.allow.Allows(key) {
+		return ctx
+	}
+
+	fields := current.fields.Clone()
+	if fields == nil {
+		fields = make(http.Header)
+	}
+	if value == "" {
+		fields.Del(key)
+	} else {
+		fields.Set(key, value)
+	}
+	return context.WithValue(ctx, stateKey{}, state{allow: current.allow, fields: fields})
+}
+
+// From 取出 context 中需要透传的请求头，调用方不应修改返回值
+func From(ctx context.Context) http.Header {
+	current, _ := ctx.Value(stateKey{}).(state)
+	return current.fields
+}
+
+// Inject 将 context 中的字段写入出站请求头，已显式设置的请求头不会被覆盖，返回是否写入了任何字段
+func Inject(ctx context.Context, header http.Header) bool {
+	injected := false
+	for key, values := range From(ctx) {
+		if len(header.Values(key)) > 0 {
+			continue
+		}
+		header[key] = append([]string(nil), values...)
+		injected = true
+	}
+	return injected
+}
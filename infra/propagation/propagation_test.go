@@ -0,0 +1,56 @@
+package propagation
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStart_ExtractsAllowlistedHeaders(t *testing.T) {
+	inbound := http.Header{}
+	inbound.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	inbound.Set("baggage", "userId=alice")
+	inbound.Set("Authorization", "Bearer secret")
+
+	ctx := Start(context.Background(), NewAllowlist(nil), inbound)
+
+	fields := From(ctx)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", fields.Get("traceparent"))
+	assert.Equal(t, "userId=alice", fields.Get("baggage"))
+	assert.Empty(t, fields.Get("Authorization"))
+}
+
+func TestSet_RespectsAllowlist(t *testing.T) {
+	inbound := http.Header{}
+	inbound.Set(TenantHeader, "spoofed")
+	ctx := Start(context.Background(), NewAllowlist([]string{TenantHeader}), inbound)
+
+	ctx = Set(ctx, RequestIDHeader, "request-1")
+	assert.Empty(t, From(ctx).Get(RequestIDHeader))
+
+	ctx = Set(ctx, TenantHeader, "")
+	assert.Empty(t, From(ctx).Get(TenantHeader))
+
+	ctx = Set(ctx, TenantHeader, "acme")
+	assert.Equal(t, "acme", From(ctx).Get(TenantHeader))
+
+	// Without Start nothing is propagated
+	assert.Nil(t, From(Set(context.Background(), TenantHeader, "acme")))
+}
+
+func TestInject_KeepsExplicitHeaders(t *testing.T) {
+	inbound := http.Header{}
+	inbound.Set(TenantHeader, "acme")
+	inbound.Set("tracestate", "vendor=1")
+	ctx := Start(context.Background(), NewAllowlist(nil), inbound)
+
+	outbound := http.Header{}
+	outbound.Set(TenantHeader, "explicit")
+	assert.True(t, Inject(ctx, outbound))
+	assert.Equal(t, "explicit", outbound.Get(TenantHeader))
+	assert.Equal(t, "vendor=1", outbound.Get("tracestate"))
+
+	assert.False(t, Inject(context.Background(), http.Header{}))
+}
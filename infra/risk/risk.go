@@ -0,0 +1,130 @@
+// Package risk 为注册请求打分，根据分数决定直接放行、要求 CAPTCHA 或拒绝
+package risk
+
+import (
+	"context"
+	"strings"
+
+	"web-clean/domain"
+	"web-clean/infra/conf"
+)
+
+// Signup 是参与打分的注册请求信息
+type Signup struct {
+	Email string
+	IP    string
+}
+
+// Domain 返回邮箱的域名部分（小写），用于打分与日志，避免记录完整邮箱
+func (s Signup) Domain() string {
+	_, domain, _ := strings.Cut(strings.ToLower(strings.TrimSpace(s.Email)), "@")
+	return domain
+}
+
+// Decision 是打分后的处理方式
+type Decision string
+
+const (
+	Allow     Decision = "allow"
+	Challenge Decision = "challenge" // 需要通过 CAPTCHA
+	Reject    Decision = "reject"
+)
+
+// Scorer 是一条打分规则，返回增加的分数与原因，分数为 0 时原因可以为空
+type Scorer interface {
+	Score(ctx context.Context, signup Signup) (int, string, error)
+}
+
+// Assessment 是一次打分的结果
+type Assessment struct {
+	Score    int      `json:"score"`
+	Reasons  []string `json:"reasons"`
+	Decision Decision `json:"decision"`
+}
+
+// Assessor 汇总所有规则的分数并给出决定
+type Assessor struct {
+	scorers     []Scorer
+	challengeAt int
+	rejectAt    int
+	log         domain.Log
+}
+
+// NewAssessor 创建 Assessor，分数达到 challengeAt 时要求 CAPTCHA，达到 rejectAt 时拒绝，阈值为 0 表示不启用该档位
+func NewAssessor(log domain.Log, challengeAt, rejectAt int, scorers ...Scorer) *Assessor {
+	return &Assessor{scorers: scorers, challengeAt: challengeAt, rejectAt: rejectAt, log: log}
+}
+
+// Assess 依次执行所有规则。单条规则出错（例如外部信誉服务不可用）只记录日志并跳过，不阻塞注册
+func (a *Assessor) Assess(ctx context.Context, signup Signup) Assessment {
+	assessment := Assessment{Reasons: make([]string, 0), Decision: Allow}
+
+	for _, scorer := range a.scorers {
+		score, reason, err := scorer.Score(ctx, signup)
+		if err != nil {
+			a.log.Warnw("注册风险规则执行失败，已跳过", "scorer", scorerName(scorer), "error", err)
+			continue
+		}
+		if score != 0 {
+			assessment.Score += score
+			assessment.Reasons = append(assessment.Reasons, reason)
+		}
+	}
+
+	switch {
+	case a.rejectAt > 0 && assessment.Score >= a.rejectAt:
+		assessment.Decision = Reject
+	case a.challengeAt > 0 && assessment.Score >= a.challengeAt:
+		assessment.Decision = Challenge
+	}
+
+	// 所有决定都记录下来，用于调整阈值与规则
+	a.log.Infow("注册风险评估",
+		"decision", assessment.Decision,
+		"score", assessment.Score,
+		"reasons", assessment.Reasons,
+		"email_domain", signup.Domain(),
+		"ip", signup.IP,
+	)
+	return assessment
+}
+
+func scorerName(scorer Scorer) string {
+	if named, ok := scorer.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return "unknown"
+}
+
+const (
+	defaultChallengeScore = 50
+	defaultRejectScore    = 100
+	defaultRulePoints     = 50
+)
+
+// From 根据配置创建 Assessor，未配置时返回 nil 表示不启用风险评估
+func From(config *conf.SignupRisk, reputation Doer, log domain.Log) *Assessor {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+
+	challengeAt, rejectAt := config.ChallengeScore, config.RejectScore
+	if challengeAt == 0 {
+		challengeAt = defaultChallengeScore
+	}
+	if rejectAt == 0 {
+		rejectAt = defaultRejectScore
+	}
+
+	scorers := []Scorer{
+		NewDisposableDomains(append(DefaultDisposableDomains, config.DisposableDomains...), defaultRulePoints),
+	}
+	if config.VelocityLimit > 0 {
+		scorers = append(scorers, NewVelocity(config.VelocityLimit, config.VelocityWindow(), defaultRulePoints))
+	}
+	if config.ReputationURL != "" {
+		scorers = append(scorers, NewReputation(reputation, config.ReputationURL))
+	}
+
+	return NewAssessor(log, challengeAt, rejectAt, scorers...)
+}
@@ -0,0 +1,84 @@
+package risk
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestDisposableDomains(t *testing.T) {
+	rule := NewDisposableDomains([]string{"mailinator.com"}, 50)
+
+	score, reason, err := rule.Score(context.Background(), Signup{Email: "a@Mailinator.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, 50, score)
+	assert.Equal(t, "disposable_domain", reason)
+
+	score, _, _ = rule.Score(context.Background(), Signup{Email: "a@eu.mailinator.com"})
+	assert.Equal(t, 50, score)
+
+	score, _, _ = rule.Score(context.Background(), Signup{Email: "a@example.com"})
+	assert.Equal(t, 0, score)
+}
+
+func TestVelocity(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rule := NewVelocity(2, time.Minute, 50)
+	rule.now = func() time.Time { return now }
+
+	scores := make([]int, 0)
+	for i := 0; i < 3; i++ {
+		score, _, _ := rule.Score(context.Background(), Signup{IP: "1.2.3.4"})
+		scores = append(scores, score)
+	}
+	assert.Equal(t, []int{0, 0, 50}, scores)
+
+	// 其他 IP 不受影响，窗口过去之后重新计数
+	score, _, _ := rule.Score(context.Background(), Signup{IP: "5.6.7.8"})
+	assert.Equal(t, 0, score)
+	now = now.Add(2 * time.Minute)
+	score, _, _ = rule.Score(context.Background(), Signup{IP: "1.2.3.4"})
+	assert.Equal(t, 0, score)
+	assert.Len(t, rule.attempts, 1)
+}
+
+func TestReputation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"score":30}`))
+	}))
+	defer server.Close()
+
+	score, reason, err := NewReputation(server.Client(), server.URL).Score(context.Background(), Signup{Email: "a@example.com"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 30, score)
+	assert.Equal(t, "reputation", reason)
+}
+
+type failingScorer struct{}
+
+func (failingScorer) Score(context.Context, Signup) (int, string, error) {
+	return 0, "", assert.AnError
+}
+
+func TestAssessor(t *testing.T) {
+	assessor := NewAssessor(zap.NewNop().Sugar(), 50, 100,
+		NewDisposableDomains([]string{"mailinator.com"}, 50),
+		NewVelocity(1, time.Minute, 50),
+		failingScorer{},
+	)
+	ctx := context.Background()
+
+	assert.Equal(t, Allow, assessor.Assess(ctx, Signup{Email: "a@example.com", IP: "1.1.1.1"}).Decision)
+	assert.Equal(t, Challenge, assessor.Assess(ctx, Signup{Email: "a@mailinator.com", IP: "2.2.2.2"}).Decision)
+
+	rejected := assessor.Assess(ctx, Signup{Email: "b@mailinator.com", IP: "2.2.2.2"})
+	assert.Equal(t, Reject, rejected.Decision)
+	assert.Equal(t, 100, rejected.Score)
+	assert.Equal(t, []string{"disposable_domain", "ip_velocity"}, rejected.Reasons)
+}
@@ -0,0 +1,168 @@
+package risk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDisposableDomains 是内置的常见一次性邮箱域名，可通过配置追加
+var DefaultDisposableDomains = []string{
+	"10minutemail.com",
+	"dispostable.com",
+	"getnada.com",
+	"guerrillamail.com",
+	"mailinator.com",
+	"maildrop.cc",
+	"sharklasers.com",
+	"temp-mail.org",
+	"trashmail.com",
+	"yopmail.com",
+}
+
+// DisposableDomains 对一次性邮箱域名（包括其子域名）加分
+type DisposableDomains struct {
+	domains map[string]bool
+	points  int
+}
+
+func NewDisposableDomains(domains []string, points int) *DisposableDomains {
+	set := make(map[string]bool, len(domains))
+	for _, domain := range domains {
+		set[strings.ToLower(strings.TrimSpace(domain))] = true
+	}
+	return &DisposableDomains{domains: set, points: points}
+}
+
+func (d *DisposableDomains) Name() string { return "disposable_domain" }
+
+func (d *DisposableDomains) Score(_ context.Context, signup Signup) (int, string, error) {
+	domain := signup.Domain()
+	for domain != "" {
+		if d.domains[domain] {
+			return d.points, "disposable_domain", nil
+		}
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok {
+			break
+		}
+		domain = parent
+	}
+	return 0, "", nil
+}
+
+// Velocity 统计同一 IP 在时间窗口内的注册次数，超过 limit 后加分。
+// 计数只在当前进程内有效，多实例部署时每个实例独立计数
+type Velocity struct {
+	limit  int
+	window time.Duration
+	points int
+	now    func() time.Time
+
+	mu        sync.Mutex
+	attempts  map[string][]time.Time
+	lastSweep time.Time
+}
+
+func NewVelocity(limit int, window time.Duration, points int) *Velocity {
+	return &Velocity{
+		limit:    limit,
+		window:   window,
+		points:   points,
+		now:      time.Now,
+		attempts: make(map[string][]time.Time),
+	}
+}
+
+func (v *Velocity) Name() string { return "ip_velocity" }
+
+// Score 记录本次注册尝试，并在窗口内的次数（包括本次）超过 limit 时加分
+func (v *Velocity) Score(_ context.Context, signup Signup) (int, string, error) {
+	if signup.IP == "" {
+		return 0, "", nil
+	}
+
+	now := v.now()
+	cutoff := now.Add(-v.window)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// 定期清理不再活跃的 IP，避免内存无限增长
+	if now.Sub(v.lastSweep) > v.window {
+		for ip, attempts := range v.attempts {
+			if attempts[len(attempts)-1].Before(cutoff) {
+				delete(v.attempts, ip)
+			}
+		}
+		v.lastSweep = now
+	}
+
+	attempts := v.attempts[signup.IP]
+	kept := attempts[:0]
+	for _, at := range attempts {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	kept = append(kept, now)
+	v.attempts[signup.IP] = kept
+
+	if len(kept) > v.limit {
+		return v.points, "ip_velocity", nil
+	}
+	return 0, "", nil
+}
+
+// Doer 是发送 HTTP 请求的客户端，通常为 httpclient.New 创建的 *http.Client
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Reputation 调用外部信誉服务：POST {"email", "ip"}，响应 {"score": n}，n 直接计入总分
+type Reputation struct {
+	client   Doer
+	endpoint string
+}
+
+func NewReputation(client Doer, endpoint string) *Reputation {
+	return &Reputation{client: client, endpoint: endpoint}
+}
+
+func (r *Reputation) Name() string { return "reputation" }
+
+func (r *Reputation) Score(ctx context.Context, signup Signup) (int, string, error) {
+	payload, err := json.Marshal(map[string]string{"email": signup.Email, "ip": signup.IP})
+	if err != nil {
+		return 0, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, "", fmt.Errorf("信誉服务返回 %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Score int `json:"score"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, "", err
+	}
+	return body.Score, "reputation", nil
+}
@@ -0,0 +1,206 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"web-clean/domain"
+)
+
+var (
+	// ErrUnknownTask 表示没有以该名称注册的任务
+	ErrUnknownTask = errors.New("unknown task")
+	// ErrTaskRunning 表示任务正在执行，同一任务不会并发执行
+	ErrTaskRunning = errors.New("task is already running")
+)
+
+// Task 是一个按固定间隔执行的后台任务
+type Task struct {
+	// Name 任务的唯一名称，用于管理接口
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Status 是任务的运行状态，供 /admin/scheduler/tasks 输出
+type Status struct {
+	Name     string        `json:"name"`
+	Interval time.Duration `json:"interval"`
+	Running  bool          `json:"running"`
+	// Runs 与 Failures 统计定时触发与手动触发的执行次数
+	Runs     int `json:"runs"`
+	Failures int `json:"failures"`
+
+	LastRun      *time.Time    `json:"last_run,omitempty"`
+	LastDuration time.Duration `json:"last_duration,omitempty"`
+	LastError    string        `json:"last_error,omitempty"`
+	// NextRun 在调度器启动前为空
+	NextRun *time.Time `json:"next_run,omitempty"`
+}
+
+// task 是已注册任务及其状态，running 同时作为执行锁
+type task struct {
+	Task
+
+	mu     sync.Mutex
+	status Status
+}
+
+// Scheduler 管理进程内的周期任务，每个任务在独立的 goroutine 中按间隔执行
+type Scheduler struct {
+	log domain.Log
+
+	mu      sync.RWMutex
+	tasks   []*task
+	started bool
+}
+
+// New 创建调度器，任务需在 Start 之前注册
+func New(log domain.Log) *Scheduler {
+	return &Scheduler{log: log}
+}
+
+// Register 注册任务，名称重复、间隔不为正或调度器已启动时返回错误
+func (s *Scheduler) Register(t Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return fmt.Errorf("调度器已启动，无法注册任务 %s", t.Name)
+	}
+	if t.Interval <= 0 {
+		return fmt.Errorf("任务 %s 的执行间隔必须大于 0", t.Name)
+	}
+	for _, existing := range s.tasks {
+		if existing.Name == t.Name {
+			return fmt.Errorf("任务 %s 已注册", t.Name)
+		}
+	}
+
+	s.tasks = append(s.tasks, &task{Task: t, status: Status{Name: t.Name, Interval: t.Interval}})
+	return nil
+}
+
+// Start 启动所有任务，直到 ctx 结束
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+	s.started = true
+
+	for _, t := range s.tasks {
+		go s.loop(ctx, t)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, t *task) {
+	ticker := time.NewTicker(t.Interval)
+	defer ticker.Stop()
+
+	t.setNextRun(time.Now().Add(t.Interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			t.setNextRun(now.Add(t.Interval))
+			// 手动触发的执行尚未结束时跳过本次，不排队
+			if _, err := s.run(ctx, t); errors.Is(err, ErrTaskRunning) {
+				s.log.Warnw("定时任务仍在执行，跳过本次调度", "task", t.Name)
+			}
+		}
+	}
+}
+
+// Tasks 返回所有任务的状态，顺序与注册顺序一致
+func (s *Scheduler) Tasks() []Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		statuses = append(statuses, t.snapshot())
+	}
+	return statuses
+}
+
+// Run 立即执行名为 name 的任务并等待其结束，返回执行后的状态；任务执行失败不视为 Run 的错误，
+// 失败原因记录在 Status.LastError 中
+func (s *Scheduler) Run(ctx context.Context, name string) (Status, error) {
+	s.mu.RLock()
+	var target *task
+	for _, t := range s.tasks {
+		if t.Name == name {
+			target = t
+			break
+		}
+	}
+	s.mu.RUnlock()
+
+	if target == nil {
+		return Status{}, fmt.Errorf("%w: %s", ErrUnknownTask, name)
+	}
+	return s.run(ctx, target)
+}
+
+func (s *Scheduler) run(ctx context.Context, t *task) (Status, error) {
+	t.mu.Lock()
+	if t.status.Running {
+		status := t.status
+		t.mu.Unlock()
+		return status, ErrTaskRunning
+	}
+	t.status.Running = true
+	t.mu.Unlock()
+
+	start := time.Now()
+	err := s.safeRun(ctx, t)
+	duration := time.Since(start)
+
+	if err != nil {
+		s.log.Warnw("定时任务执行失败", "task", t.Name, "duration", duration, "err", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.Running = false
+	t.status.Runs++
+	t.status.LastRun = &start
+	t.status.LastDuration = duration
+	t.status.LastError = ""
+	if err != nil {
+		t.status.Failures++
+		t.status.LastError = err.Error()
+	}
+	return t.status, nil
+}
+
+// safeRun 将任务中的 panic 转为错误，避免一个任务拖垮整个进程
+func (s *Scheduler) safeRun(ctx context.Context, t *task) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return t.Run(ctx)
+}
+
+func (t *task) setNextRun(next time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.NextRun = &next
+}
+
+func (t *task) snapshot() Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status
+}
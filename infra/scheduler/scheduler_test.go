@@ -0,0 +1,114 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestScheduler_Register(t *testing.T) {
+	s := New(zap.NewNop().Sugar())
+	noop := func(context.Context) error { return nil }
+
+	assert.NoError(t, s.Register(Task{Name: "a", Interval: time.Minute, Run: noop}))
+	assert.Error(t, s.Register(Task{Name: "a", Interval: time.Minute, Run: noop}), "duplicate name")
+	assert.Error(t, s.Register(Task{Name: "b", Run: noop}), "zero interval")
+
+	s.Start(context.Background())
+	assert.Error(t, s.Register(Task{Name: "c", Interval: time.Minute, Run: noop}), "after start")
+}
+
+func TestScheduler_Run(t *testing.T) {
+	s := New(zap.NewNop().Sugar())
+	fail := errors.New("database unavailable")
+	calls := 0
+	assert.NoError(t, s.Register(Task{Name: "replay", Interval: time.Hour, Run: func(context.Context) error {
+		calls++
+		if calls == 1 {
+			return fail
+		}
+		return nil
+	}}))
+
+	status, err := s.Run(context.Background(), "replay")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, status.Runs)
+	assert.Equal(t, 1, status.Failures)
+	assert.Equal(t, fail.Error(), status.LastError)
+	assert.NotNil(t, status.LastRun)
+	assert.Nil(t, status.NextRun, "not started")
+
+	status, err = s.Run(context.Background(), "replay")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, status.Runs)
+	assert.Empty(t, status.LastError)
+
+	_, err = s.Run(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrUnknownTask)
+}
+
+func TestScheduler_Run_RecoversPanic(t *testing.T) {
+	s := New(zap.NewNop().Sugar())
+	assert.NoError(t, s.Register(Task{Name: "boom", Interval: time.Hour, Run: func(context.Context) error {
+		panic("boom")
+	}}))
+
+	status, err := s.Run(context.Background(), "boom")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "panic: boom", status.LastError)
+	assert.False(t, status.Running)
+}
+
+func TestScheduler_Run_RejectsConcurrentRun(t *testing.T) {
+	s := New(zap.NewNop().Sugar())
+	started, release := make(chan struct{}), make(chan struct{})
+	assert.NoError(t, s.Register(Task{Name: "slow", Interval: time.Hour, Run: func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = s.Run(context.Background(), "slow")
+	}()
+	<-started
+
+	status, err := s.Run(context.Background(), "slow")
+	assert.ErrorIs(t, err, ErrTaskRunning)
+	assert.True(t, status.Running)
+	assert.True(t, s.Tasks()[0].Running)
+
+	close(release)
+	<-done
+	assert.False(t, s.Tasks()[0].Running)
+}
+
+func TestScheduler_Start_RunsOnInterval(t *testing.T) {
+	s := New(zap.NewNop().Sugar())
+	ran := make(chan struct{}, 1)
+	assert.NoError(t, s.Register(Task{Name: "tick", Interval: 10 * time.Millisecond, Run: func(context.Context) error {
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+		return nil
+	}}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Start(ctx)
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("task did not run")
+	}
+	assert.NotNil(t, s.Tasks()[0].NextRun)
+}
@@ -0,0 +1,101 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"web-clean/infra/sigv4"
+)
+
+// AWSSM 从 AWS Secrets Manager 读取密钥。
+//
+// 引用格式为 awssm://<SecretId>#<键>，SecretId 可以是名称或 ARN；
+// 带 #<键> 时将 SecretString 作为 JSON 对象取出该字段，否则使用整个 SecretString
+type AWSSM struct {
+	client      Doer
+	endpoint    string
+	region      string
+	credentials sigv4.Credentials
+	now         func() time.Time
+}
+
+// NewAWSSM 创建解析器，endpoint 为空时使用 https://secretsmanager.<region>.amazonaws.com
+func NewAWSSM(client Doer, region, endpoint string, credentials sigv4.Credentials) *AWSSM {
+	if endpoint == "" && region != "" {
+		endpoint = "https://secretsmanager." + region + ".amazonaws.com"
+	}
+	return &AWSSM{
+		client:      client,
+		endpoint:    strings.TrimRight(endpoint, "/"),
+		region:      region,
+		credentials: credentials,
+		now:         time.Now,
+	}
+}
+
+// AWSSMFromEnv 使用 AWS_REGION（或 AWS_DEFAULT_REGION）、AWS_ACCESS_KEY_ID、AWS_SECRET_ACCESS_KEY、
+// 可选的 AWS_SESSION_TOKEN 与 AWS_ENDPOINT_URL_SECRETS_MANAGER 创建解析器
+func AWSSMFromEnv(client Doer) *AWSSM {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	return NewAWSSM(client, region, os.Getenv("AWS_ENDPOINT_URL_SECRETS_MANAGER"), sigv4.Credentials{
+		AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	})
+}
+
+func (a *AWSSM) Resolve(ctx context.Context, ref string) (string, error) {
+	if a.region == "" || a.credentials.AccessKey == "" || a.credentials.SecretKey == "" {
+		return "", fmt.Errorf("%w: 需要设置 AWS_REGION、AWS_ACCESS_KEY_ID 与 AWS_SECRET_ACCESS_KEY", ErrNotConfigured)
+	}
+
+	secretID, key := splitKey(ref)
+	payload, err := json.Marshal(map[string]string{"SecretId": secretID})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	sigv4.Sign(req, payload, a.now(), a.region, "secretsmanager", a.credentials)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("secrets manager 返回 %d", resp.StatusCode)
+	}
+
+	var body struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+
+	if key == "" {
+		return body.SecretString, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("SecretString 不是 JSON 对象，无法读取字段 %q", key)
+	}
+	return pick(fields, key)
+}
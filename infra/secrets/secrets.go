@@ -0,0 +1,130 @@
+// Package secrets 在启动时把配置中 vault:// 与 awssm:// 形式的引用替换为真实的密钥值，
+// 这样 config.json 中不必再保存明文密码。
+//
+// 只有带 `secret:"true"` 标签的字符串字段会被解析，其余字段以及 postgres:// 等其他 scheme 的值保持不变。
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"web-clean/infra/conf"
+)
+
+const (
+	SchemeVault = "vault"
+	SchemeAWSSM = "awssm"
+)
+
+// ErrNotConfigured 表示配置中引用了某个后端，但运行环境没有提供访问该后端所需的地址或凭证
+var ErrNotConfigured = errors.New("secret backend is not configured")
+
+// Doer 是发送 HTTP 请求的客户端，通常为 httpclient.New 创建的 *http.Client
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Resolver 解析某一种 scheme 的引用，ref 为去掉 "scheme://" 之后的部分
+type Resolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// Resolvers 以 scheme 为键
+type Resolvers map[string]Resolver
+
+// FromEnv 按环境变量创建 Vault 与 AWS Secrets Manager 的解析器，
+// 缺少地址或凭证时仍会创建，只有真正引用到该后端时才返回 ErrNotConfigured
+func FromEnv(client Doer) Resolvers {
+	return Resolvers{
+		SchemeVault: VaultFromEnv(client),
+		SchemeAWSSM: AWSSMFromEnv(client),
+	}
+}
+
+// Error 描述某个配置字段解析失败，不包含任何密钥内容
+type Error struct {
+	Field string
+	Ref   string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("解析配置 %s 的密钥引用 %s 失败: %v", e.Field, e.Ref, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Resolve 遍历配置，将带 `secret:"true"` 标签且值为 vault:// 或 awssm:// 引用的字段替换为解析结果
+func Resolve(ctx context.Context, config *conf.Conf, resolvers Resolvers) error {
+	if config == nil {
+		return nil
+	}
+	return resolve(ctx, reflect.ValueOf(config).Elem(), "", resolvers)
+}
+
+func resolve(ctx context.Context, v reflect.Value, prefix string, resolvers Resolvers) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		value := v.Field(i)
+		switch {
+		case value.Kind() == reflect.Ptr && value.Type().Elem().Kind() == reflect.Struct:
+			if value.IsNil() {
+				continue
+			}
+			if err := resolve(ctx, value.Elem(), path, resolvers); err != nil {
+				return err
+			}
+		case value.Kind() == reflect.Struct:
+			if err := resolve(ctx, value, path, resolvers); err != nil {
+				return err
+			}
+		case value.Kind() == reflect.String && field.Tag.Get("secret") == "true":
+			resolved, err := resolveValue(ctx, value.String(), resolvers)
+			if err != nil {
+				return &Error{Field: path, Ref: value.String(), Err: err}
+			}
+			value.SetString(resolved)
+		}
+	}
+	return nil
+}
+
+// resolveValue 解析单个值，不是 vault:// 或 awssm:// 引用时原样返回
+func resolveValue(ctx context.Context, value string, resolvers Resolvers) (string, error) {
+	scheme, ref, ok := strings.Cut(value, "://")
+	if !ok || (scheme != SchemeVault && scheme != SchemeAWSSM) {
+		return value, nil
+	}
+
+	resolver, ok := resolvers[scheme]
+	if !ok || resolver == nil {
+		return "", ErrNotConfigured
+	}
+	return resolver.Resolve(ctx, ref)
+}
+
+// splitKey 将 "path#key" 拆分为路径与键，没有 # 时键为空
+func splitKey(ref string) (string, string) {
+	path, key, _ := strings.Cut(ref, "#")
+	return path, key
+}
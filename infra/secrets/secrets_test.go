@@ -0,0 +1,97 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"web-clean/infra/conf"
+	"web-clean/infra/sigv4"
+)
+
+func TestResolve_Vault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/secret/data/webclean/db", r.URL.Path)
+		assert.Equal(t, "s.token", r.Header.Get("X-Vault-Token"))
+		_, _ = w.Write([]byte(`{"data":{"data":{"password":"hunter2","username":"app"},"metadata":{"version":3}}}`))
+	}))
+	defer server.Close()
+
+	config := &conf.Conf{Database: &conf.DatabaseConf{
+		Username: "app",
+		Password: "vault://secret/data/webclean/db#password",
+	}}
+
+	err := Resolve(context.Background(), config, Resolvers{SchemeVault: NewVault(server.Client(), server.URL, "s.token", "")})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", config.Database.Password)
+	assert.Equal(t, "app", config.Database.Username)
+}
+
+func TestResolve_VaultKVv1SingleField(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"secret":"captcha-secret"}}`))
+	}))
+	defer server.Close()
+
+	config := &conf.Conf{Captcha: &conf.Captcha{Provider: "hcaptcha", Secret: "vault://kv/captcha"}}
+
+	err := Resolve(context.Background(), config, Resolvers{SchemeVault: NewVault(server.Client(), server.URL, "s.token", "")})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "captcha-secret", config.Captcha.Secret)
+}
+
+func TestResolve_AWSSecretsManager(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/secretsmanager/aws4_request")
+
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "arn:aws:secretsmanager:eu-west-1:123456789012:secret:prod/db", body["SecretId"])
+
+		_, _ = w.Write([]byte(`{"SecretString":"{\"password\":\"s3cr3t\"}"}`))
+	}))
+	defer server.Close()
+
+	config := &conf.Conf{Sink: &conf.Sink{Kind: "s3", SecretKey: "awssm://arn:aws:secretsmanager:eu-west-1:123456789012:secret:prod/db#password"}}
+	resolver := NewAWSSM(server.Client(), "eu-west-1", server.URL, sigv4.Credentials{AccessKey: "AKID", SecretKey: "secret"})
+
+	err := Resolve(context.Background(), config, Resolvers{SchemeAWSSM: resolver})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", config.Sink.SecretKey)
+}
+
+func TestResolve_LeavesPlainValues(t *testing.T) {
+	config := &conf.Conf{Database: &conf.DatabaseConf{
+		Password: "plaintext",
+		DSN:      "postgres://app:pw@localhost/app",
+	}}
+
+	err := Resolve(context.Background(), config, Resolvers{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "plaintext", config.Database.Password)
+	assert.Equal(t, "postgres://app:pw@localhost/app", config.Database.DSN)
+}
+
+func TestResolve_NotConfigured(t *testing.T) {
+	config := &conf.Conf{Database: &conf.DatabaseConf{Password: "vault://secret/data/db#password"}}
+
+	err := Resolve(context.Background(), config, Resolvers{SchemeVault: NewVault(http.DefaultClient, "", "", "")})
+
+	var secretErr *Error
+	assert.True(t, errors.As(err, &secretErr))
+	assert.Equal(t, "database.password", secretErr.Field)
+	assert.ErrorIs(t, err, ErrNotConfigured)
+}
@@ -0,0 +1,99 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Vault 从 HashiCorp Vault 的 KV 引擎读取密钥。
+//
+// 引用格式为 vault://<API 路径>#<键>，API 路径即 /v1/ 之后的部分：
+// KV v2 写作 vault://secret/data/webclean/db#password，KV v1 写作 vault://kv/webclean/db#password。
+// 密钥只有一个字段时可以省略 #<键>
+type Vault struct {
+	client    Doer
+	addr      string
+	token     string
+	namespace string
+}
+
+func NewVault(client Doer, addr, token, namespace string) *Vault {
+	return &Vault{client: client, addr: strings.TrimRight(addr, "/"), token: token, namespace: namespace}
+}
+
+// VaultFromEnv 使用 VAULT_ADDR、VAULT_TOKEN 与可选的 VAULT_NAMESPACE 创建解析器
+func VaultFromEnv(client Doer) *Vault {
+	return NewVault(client, os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN"), os.Getenv("VAULT_NAMESPACE"))
+}
+
+func (v *Vault) Resolve(ctx context.Context, ref string) (string, error) {
+	if v.addr == "" || v.token == "" {
+		return "", fmt.Errorf("%w: 需要设置 VAULT_ADDR 与 VAULT_TOKEN", ErrNotConfigured)
+	}
+
+	path, key := splitKey(ref)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.addr+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault 返回 %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+
+	// KV v2 的字段位于 data.data，KV v1 直接位于 data
+	fields := body.Data
+	if nested, ok := body.Data["data"]; ok {
+		var inner map[string]json.RawMessage
+		if err := json.Unmarshal(nested, &inner); err == nil {
+			fields = inner
+		}
+	}
+
+	return pick(fields, key)
+}
+
+// pick 取出指定键的字符串值，key 为空且只有一个字段时返回该字段
+func pick(fields map[string]json.RawMessage, key string) (string, error) {
+	if key == "" {
+		if len(fields) != 1 {
+			return "", errors.New("密钥包含多个字段，需要使用 #<键> 指定")
+		}
+		for k := range fields {
+			key = k
+		}
+	}
+
+	raw, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("密钥中不存在字段 %q", key)
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", fmt.Errorf("字段 %q 不是字符串", key)
+	}
+	return value, nil
+}
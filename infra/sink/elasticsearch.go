@@ -0,0 +1,88 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"web-clean/infra/conf"
+	"web-clean/infra/web"
+)
+
+// elasticsearch 通过 _bulk API 写入按天滚动的索引：<index>-logs-2006.01.02 与 <index>-errors-2006.01.02
+type elasticsearch struct {
+	config *conf.Sink
+	client *http.Client
+}
+
+type esDocument struct {
+	Timestamp time.Time `json:"@timestamp"`
+	Payload   any       `json:"payload"`
+}
+
+type esBulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  any `json:"error"`
+	} `json:"items"`
+}
+
+func (e *elasticsearch) WriteLogs(ctx context.Context, requestID string, logs []web.Log) error {
+	return e.bulk(ctx, "logs", requestLogs(requestID, logs))
+}
+
+func (e *elasticsearch) WriteErrors(ctx context.Context, errors web.Errors) error {
+	return e.bulk(ctx, "errors", []any{errors})
+}
+
+func (e *elasticsearch) bulk(ctx context.Context, kind string, payloads []any) error {
+	now := time.Now().UTC()
+	index := fmt.Sprintf("%s-%s-%s", e.config.Index, kind, now.Format("2006.01.02"))
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, payload := range payloads {
+		if err := encoder.Encode(map[string]any{"index": map[string]string{"_index": index}}); err != nil {
+			return err
+		}
+		if err := encoder.Encode(esDocument{Timestamp: now, Payload: payload}); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(e.config.URL, "/")+"/_bulk", &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if e.config.Username != "" {
+		req.SetBasicAuth(e.config.Username, e.config.Password)
+	}
+
+	body, err := send(e.client, req)
+	if err != nil {
+		return err
+	}
+
+	// _bulk 在部分文档失败时仍返回 200，需要检查响应体中的 errors 标记
+	var resp esBulkResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return err
+	}
+	if resp.Errors {
+		for _, item := range resp.Items {
+			for _, result := range item {
+				if result.Error != nil {
+					return fmt.Errorf("elasticsearch bulk 写入失败: %d %v", result.Status, result.Error)
+				}
+			}
+		}
+		return fmt.Errorf("elasticsearch bulk 写入失败")
+	}
+	return nil
+}
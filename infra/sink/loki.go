@@ -0,0 +1,79 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"web-clean/infra/conf"
+	"web-clean/infra/web"
+)
+
+// loki 通过 push API 写入 Grafana Loki，日志与错误分别使用 kind=logs 与 kind=errors 两个流
+type loki struct {
+	config *conf.Sink
+	client *http.Client
+}
+
+type lokiPush struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (l *loki) WriteLogs(ctx context.Context, requestID string, logs []web.Log) error {
+	now := time.Now().UnixNano()
+
+	values := make([][2]string, 0, len(logs))
+	for i, log := range requestLogs(requestID, logs) {
+		line, err := json.Marshal(log)
+		if err != nil {
+			return err
+		}
+		// 同一批次内保持顺序，Loki 要求同一流内时间戳不能倒退
+		values = append(values, [2]string{strconv.FormatInt(now+int64(i), 10), string(line)})
+	}
+
+	return l.push(ctx, "logs", values)
+}
+
+func (l *loki) WriteErrors(ctx context.Context, errors web.Errors) error {
+	line, err := json.Marshal(errors)
+	if err != nil {
+		return err
+	}
+
+	return l.push(ctx, "errors", [][2]string{{strconv.FormatInt(time.Now().UnixNano(), 10), string(line)}})
+}
+
+func (l *loki) push(ctx context.Context, kind string, values [][2]string) error {
+	labels := make(map[string]string, len(l.config.Labels)+1)
+	for key, value := range l.config.Labels {
+		labels[key] = value
+	}
+	labels["kind"] = kind
+
+	body, err := json.Marshal(lokiPush{Streams: []lokiStream{{Stream: labels, Values: values}}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(l.config.URL, "/")+"/loki/api/v1/push", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if l.config.Username != "" {
+		req.SetBasicAuth(l.config.Username, l.config.Password)
+	}
+
+	_, err = send(l.client, req)
+	return err
+}
@@ -0,0 +1,78 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"web-clean/infra/conf"
+	"web-clean/infra/sigv4"
+	"web-clean/infra/web"
+)
+
+// s3 将每个批次写为一个 NDJSON 对象：<prefix>/<kind>/2006/01/02/<时间戳>-<随机串>.ndjson。
+// 使用 path-style 地址与 SigV4 签名，兼容 AWS S3 以及 MinIO 等 S3 兼容服务
+type s3 struct {
+	config *conf.Sink
+	client *http.Client
+	now    func() time.Time
+}
+
+func (s *s3) WriteLogs(ctx context.Context, requestID string, logs []web.Log) error {
+	return s.put(ctx, "logs", requestLogs(requestID, logs))
+}
+
+func (s *s3) WriteErrors(ctx context.Context, errors web.Errors) error {
+	return s.put(ctx, "errors", []any{errors})
+}
+
+func (s *s3) put(ctx context.Context, kind string, payloads []any) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, payload := range payloads {
+		if err := encoder.Encode(payload); err != nil {
+			return err
+		}
+	}
+
+	now := s.now().UTC()
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	key := path.Join(s.config.Prefix, kind, now.Format("2006/01/02"), fmt.Sprintf("%d-%s.ndjson", now.UnixNano(), hex.EncodeToString(suffix)))
+
+	endpoint, err := url.Parse(strings.TrimRight(s.config.URL, "/"))
+	if err != nil {
+		return err
+	}
+	endpoint.Path = "/" + s.config.Bucket + "/" + key
+
+	body := buf.Bytes()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	s.sign(req, body, now)
+
+	_, err = send(s.client, req)
+	return err
+}
+
+// sign 按 AWS Signature Version 4 为请求签名，S3 额外要求签名 X-Amz-Content-Sha256
+func (s *s3) sign(req *http.Request, body []byte, now time.Time) {
+	req.Header.Set("X-Amz-Content-Sha256", sigv4.SHA256Hex(body))
+	sigv4.Sign(req, body, now, s.config.Region, "s3", sigv4.Credentials{
+		AccessKey: s.config.AccessKey,
+		SecretKey: s.config.SecretKey,
+	})
+}
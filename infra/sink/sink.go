@@ -0,0 +1,138 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"web-clean/domain"
+	"web-clean/infra/conf"
+	"web-clean/infra/metrics"
+	"web-clean/infra/web"
+)
+
+const (
+	KindPostgres      = "postgres"
+	KindLoki          = "loki"
+	KindElasticsearch = "elasticsearch"
+	KindS3            = "s3"
+)
+
+// writeTimeout 限制单次写入外部 sink 的耗时，持久化在请求结束时同步执行，不能无限等待
+const writeTimeout = 5 * time.Second
+
+// Sink 是请求日志与错误堆栈的外部写入目标
+type Sink interface {
+	WriteLogs(ctx context.Context, requestID string, logs []web.Log) error
+	WriteErrors(ctx context.Context, errors web.Errors) error
+}
+
+// From 根据配置创建 Sink，未配置或配置为 postgres 时返回 nil，表示继续写入主数据库
+func From(config *conf.Sink, client *http.Client) (Sink, error) {
+	if config == nil || config.Kind == "" || strings.EqualFold(config.Kind, KindPostgres) {
+		return nil, nil
+	}
+
+	switch strings.ToLower(config.Kind) {
+	case KindLoki:
+		if config.URL == "" {
+			return nil, errors.New("sink.url 为空")
+		}
+		return &loki{config: config, client: client}, nil
+	case KindElasticsearch:
+		if config.URL == "" {
+			return nil, errors.New("sink.url 为空")
+		}
+		if config.Index == "" {
+			return nil, errors.New("sink.index 为空")
+		}
+		return &elasticsearch{config: config, client: client}, nil
+	case KindS3:
+		if config.URL == "" || config.Bucket == "" || config.Region == "" {
+			return nil, errors.New("sink.url、sink.bucket 与 sink.region 不能为空")
+		}
+		if config.AccessKey == "" || config.SecretKey == "" {
+			return nil, errors.New("sink.access_key 与 sink.secret_key 不能为空")
+		}
+		return &s3{config: config, client: client, now: time.Now}, nil
+	default:
+		return nil, fmt.Errorf("不支持的 sink: %s", config.Kind)
+	}
+}
+
+// LogPersister 将 Sink 适配为 web.LogPersister
+func LogPersister(sink Sink) web.LogPersister {
+	return logPersister{sink: sink}
+}
+
+type logPersister struct {
+	sink Sink
+}
+
+func (p logPersister) Persist(requestID string, logs []web.Log) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	return p.sink.WriteLogs(ctx, requestID, logs)
+}
+
+// requestLog 是写入外部 sink 的单条日志，携带 RequestID 以便与错误堆栈关联
+type requestLog struct {
+	RequestID string
+	web.Log
+}
+
+func requestLogs(requestID string, logs []web.Log) []any {
+	payloads := make([]any, len(logs))
+	for i, log := range logs {
+		payloads[i] = requestLog{RequestID: requestID, Log: log}
+	}
+	return payloads
+}
+
+// ErrorPersister 将 Sink 适配为 web.ErrorStackPersister，写入失败时交给 fallback 处理，避免错误堆栈丢失
+func ErrorPersister(sink Sink, fallback web.ErrorStackPersister, log domain.Log) web.ErrorStackPersister {
+	return errorPersister{sink: sink, fallback: fallback, log: log}
+}
+
+type errorPersister struct {
+	sink     Sink
+	fallback web.ErrorStackPersister
+	log      domain.Log
+}
+
+func (p errorPersister) Persist(errors web.Errors) {
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+
+	if err := p.sink.WriteErrors(ctx, errors); err != nil {
+		metrics.Errors.Failures.Inc()
+		p.log.Warnw("错误堆栈写入外部 sink 失败，改用回退持久化", "err", err, "requestId", errors.RequestID)
+		p.fallback.Persist(errors)
+	}
+}
+
+// send 发送请求并把非 2xx 响应转换为错误
+func send(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s: %d %s", req.Method, req.URL.Redacted(), resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
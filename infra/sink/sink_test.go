@@ -0,0 +1,127 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"web-clean/infra/conf"
+	"web-clean/infra/web"
+)
+
+func TestFrom(t *testing.T) {
+	s, err := From(nil, http.DefaultClient)
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+
+	s, err = From(&conf.Sink{Kind: "postgres"}, http.DefaultClient)
+	assert.NoError(t, err)
+	assert.Nil(t, s)
+
+	_, err = From(&conf.Sink{Kind: "kafka"}, http.DefaultClient)
+	assert.Error(t, err)
+
+	_, err = From(&conf.Sink{Kind: "elasticsearch", URL: "http://es"}, http.DefaultClient)
+	assert.Error(t, err)
+}
+
+func TestLoki_WriteLogs(t *testing.T) {
+	var push lokiPush
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/loki/api/v1/push", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&push))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	s, err := From(&conf.Sink{Kind: "loki", URL: server.URL, Labels: map[string]string{"app": "web-clean"}}, server.Client())
+	assert.NoError(t, err)
+
+	err = s.WriteLogs(context.Background(), "req-1", []web.Log{{Level: "INFO", Msg: "a"}, {Level: "WARN", Msg: "b"}})
+
+	assert.NoError(t, err)
+	assert.Len(t, push.Streams, 1)
+	assert.Equal(t, map[string]string{"app": "web-clean", "kind": "logs"}, push.Streams[0].Stream)
+	assert.Len(t, push.Streams[0].Values, 2)
+	assert.JSONEq(t, `{"RequestID":"req-1","Level":"WARN","Msg":"b"}`, push.Streams[0].Values[1][1])
+}
+
+func TestElasticsearch_BulkErrors(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		_, _ = w.Write([]byte(`{"errors":true,"items":[{"index":{"status":400,"error":{"type":"mapper_parsing_exception"}}}]}`))
+	}))
+	defer server.Close()
+
+	s, err := From(&conf.Sink{Kind: "elasticsearch", URL: server.URL, Index: "web-clean"}, server.Client())
+	assert.NoError(t, err)
+
+	err = s.WriteErrors(context.Background(), web.Errors{RequestID: "req-1"})
+
+	assert.ErrorContains(t, err, "mapper_parsing_exception")
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"_index":"web-clean-errors-`)
+	assert.Contains(t, lines[1], `"RequestID":"req-1"`)
+}
+
+func TestS3_PutSignedObject(t *testing.T) {
+	var path, auth, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		path, auth, body = r.URL.Path, r.Header.Get("Authorization"), string(data)
+	}))
+	defer server.Close()
+
+	s := &s3{
+		config: &conf.Sink{URL: server.URL, Bucket: "logs", Region: "eu-west-1", Prefix: "web-clean", AccessKey: "AKID", SecretKey: "secret"},
+		client: server.Client(),
+		now:    func() time.Time { return time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC) },
+	}
+
+	err := s.WriteLogs(context.Background(), "req-1", []web.Log{{Level: "INFO", Msg: "a"}, {Level: "INFO", Msg: "b"}})
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(path, "/logs/web-clean/logs/2024/05/06/"), path)
+	assert.True(t, strings.HasSuffix(path, ".ndjson"), path)
+	assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20240506/eu-west-1/s3/aws4_request, SignedHeaders="), auth)
+	assert.Equal(t, 2, strings.Count(body, "\n"))
+}
+
+type fakeSink struct {
+	err error
+}
+
+func (f fakeSink) WriteLogs(context.Context, string, []web.Log) error { return f.err }
+func (f fakeSink) WriteErrors(context.Context, web.Errors) error      { return f.err }
+
+type recordingPersister struct {
+	persisted []web.Errors
+}
+
+func (r *recordingPersister) Persist(errors web.Errors) {
+	r.persisted = append(r.persisted, errors)
+}
+
+func TestErrorPersister_FallsBack(t *testing.T) {
+	fallback := &recordingPersister{}
+	log := zap.NewNop().Sugar()
+
+	ErrorPersister(fakeSink{}, fallback, log).Persist(web.Errors{RequestID: "ok"})
+	ErrorPersister(fakeSink{err: errors.New("down")}, fallback, log).Persist(web.Errors{RequestID: "failed"})
+
+	assert.Len(t, fallback.persisted, 1)
+	assert.Equal(t, "failed", fallback.persisted[0].RequestID)
+}
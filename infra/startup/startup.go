@@ -0,0 +1,55 @@
+// Package startup 记录进程启动各阶段的耗时，用于跟踪和优化冷启动时间
+package startup
+
+import (
+	"sync"
+	"time"
+)
+
+// Phase 是一个启动阶段及其耗时
+type Phase struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Timer 以秒表方式记录启动阶段：每次调用 Done 记录自上一个阶段结束（或 Timer 创建）以来的耗时
+type Timer struct {
+	mu     sync.Mutex
+	start  time.Time
+	last   time.Time
+	phases []Phase
+}
+
+func NewTimer() *Timer {
+	now := time.Now()
+	return &Timer{start: now, last: now}
+}
+
+// Done 结束名为 name 的阶段
+func (t *Timer) Done(name string) {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.phases = append(t.phases, Phase{Name: name, Duration: now.Sub(t.last)})
+	t.last = now
+}
+
+// Phases 返回已记录阶段的副本，顺序与记录顺序一致
+func (t *Timer) Phases() []Phase {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	phases := make([]Phase, len(t.phases))
+	copy(phases, t.phases)
+	return phases
+}
+
+// Total 返回从 Timer 创建到最后一个阶段结束的总耗时
+func (t *Timer) Total() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.last.Sub(t.start)
+}
@@ -0,0 +1,23 @@
+package startup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimer(t *testing.T) {
+	timer := NewTimer()
+
+	time.Sleep(5 * time.Millisecond)
+	timer.Done("config")
+	timer.Done("database")
+
+	phases := timer.Phases()
+	assert.Len(t, phases, 2)
+	assert.Equal(t, "config", phases[0].Name)
+	assert.GreaterOrEqual(t, phases[0].Duration, 5*time.Millisecond)
+	assert.Less(t, phases[1].Duration, phases[0].Duration)
+	assert.Equal(t, phases[0].Duration+phases[1].Duration, timer.Total())
+}
@@ -0,0 +1,51 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"web-clean/domain"
+)
+
+// StatusClientClosedRequest 是客户端在响应完成前断开连接时记录的状态码，沿用 nginx 的 499
+const StatusClientClosedRequest = 499
+
+// AccessLogMiddleware 在请求结束时记录一行访问日志。
+//
+// 客户端断开后 c.Request.Context() 会被取消，service 与 repository 中的查询随之中止，
+// 处理函数写出的状态码已没有接收方，因此这类请求统一记录为 499 并标记 cancelled，
+// 与真正的 5xx 区分开；请求超时（DeadlineExceeded）保留处理函数写出的状态码
+func AccessLogMiddleware(log domain.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		cancelled := errors.Is(c.Request.Context().Err(), context.Canceled)
+		if cancelled {
+			status = StatusClientClosedRequest
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		fields := []interface{}{
+			"method", c.Request.Method,
+			"route", route,
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"request_id", RequestIdGetter(c),
+		}
+		if cancelled {
+			log.Infow("请求已被客户端取消", append(fields, "cancelled", true)...)
+			return
+		}
+		log.Infow("请求完成", fields...)
+	}
+}
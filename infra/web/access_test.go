@@ -0,0 +1,45 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAccessLogMiddleware(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(AccessLogMiddleware(zap.New(core).Sugar()))
+	engine.GET("/users/:id", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	// 模拟客户端在处理过程中断开：处理函数等到 context 被取消后仍写出 500
+	ctx, cancel := context.WithCancel(context.Background())
+	engine.GET("/slow", func(c *gin.Context) {
+		cancel()
+		<-c.Request.Context().Done()
+		c.Status(http.StatusInternalServerError)
+	})
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil).WithContext(ctx))
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 2)
+
+	done := entries[0].ContextMap()
+	assert.Equal(t, "/users/:id", done["route"])
+	assert.Equal(t, int64(http.StatusNoContent), done["status"])
+	assert.NotContains(t, done, "cancelled")
+
+	cancelled := entries[1].ContextMap()
+	assert.Equal(t, int64(StatusClientClosedRequest), cancelled["status"])
+	assert.Equal(t, true, cancelled["cancelled"])
+}
@@ -0,0 +1,31 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"web-clean/domain"
+	"web-clean/infra/budget"
+)
+
+// BudgetMiddleware 为每个请求附加一份数据库预算，数据库回调据此统计查询次数与返回行数。
+// 超出预算的语句直接失败，业务层将其映射为 503；这里在请求结束后记录超出预算的请求以便排查
+func BudgetMiddleware(limits budget.Limits, log domain.Log) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		ctx, usage := budget.WithUsage(context.Request.Context(), limits)
+		context.Request = context.Request.WithContext(ctx)
+
+		context.Next()
+
+		if usage.Exceeded() {
+			log.Warnw("请求超出数据库预算",
+				"method", context.Request.Method,
+				"path", context.FullPath(),
+				"request_id", RequestIdGetter(context),
+				"queries", usage.Queries(),
+				"rows", usage.Rows(),
+				"max_queries", limits.MaxQueries,
+				"max_rows", limits.MaxRows,
+			)
+		}
+	}
+}
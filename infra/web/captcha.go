@@ -0,0 +1,47 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"web-clean/domain"
+	"web-clean/infra/captcha"
+)
+
+// CaptchaTokenHeader 客户端通过该请求头提交 CAPTCHA 令牌
+const CaptchaTokenHeader = "X-Captcha-Token"
+
+// CaptchaMiddleware 要求请求携带有效的 CAPTCHA 令牌，应只挂在需要保护的路由上（例如注册）
+func CaptchaMiddleware(verifier captcha.Verifier, log domain.Log) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if verifyCaptcha(context, verifier, log) {
+			context.Next()
+		}
+	}
+}
+
+// verifyCaptcha 校验请求中的 CAPTCHA 令牌，未通过时写入错误响应并终止请求
+func verifyCaptcha(context *gin.Context, verifier captcha.Verifier, log domain.Log) bool {
+	err := verifier.Verify(context.Request.Context(), context.GetHeader(CaptchaTokenHeader), context.ClientIP())
+	if err == nil {
+		return true
+	}
+
+	if errors.Is(err, captcha.ErrVerificationFailed) {
+		log.Infow("CAPTCHA 校验未通过", "path", context.Request.URL.Path, "ip", context.ClientIP(), "error", err)
+		context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error":   "captcha_failed",
+			"message": "A valid CAPTCHA token is required in the " + CaptchaTokenHeader + " header",
+		})
+		return false
+	}
+
+	log.Errorw("CAPTCHA 服务不可用", "error", err)
+	context.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+		"error":   "captcha_unavailable",
+		"message": "CAPTCHA verification is temporarily unavailable",
+	})
+	return false
+}
@@ -0,0 +1,59 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"web-clean/infra/chaos"
+	"web-clean/infra/conf"
+)
+
+// 开启 Chaos.Headers 后可用的请求头
+const (
+	ChaosLatencyHeader         = "X-Chaos-Latency"       // 如 "250ms"
+	ChaosErrorRateHeader       = "X-Chaos-Error-Rate"    // 如 "0.5"
+	ChaosDatabaseLatencyHeader = "X-Chaos-DB-Latency"    // 本次请求中每条 SQL 的延迟
+	ChaosDatabaseErrorHeader   = "X-Chaos-DB-Error-Rate" // 本次请求中每条 SQL 的失败概率
+)
+
+// ChaosMiddleware 按路由或请求头注入延迟与错误，注入的错误以 503 返回。
+// 请求头中的数据库故障会写入请求的 context，由数据库的故障注入回调读取
+func ChaosMiddleware(config *conf.Chaos) gin.HandlerFunc {
+	routes := chaos.RulesFromConf(config.Routes)
+
+	return func(context *gin.Context) {
+		fault, _ := routes.Lookup(context.Request.Method + " " + context.FullPath())
+
+		if config.Headers {
+			fault = faultFromHeaders(context, ChaosLatencyHeader, ChaosErrorRateHeader, fault)
+
+			if dbFault := faultFromHeaders(context, ChaosDatabaseLatencyHeader, ChaosDatabaseErrorHeader, chaos.Fault{}); !dbFault.IsZero() {
+				context.Request = context.Request.WithContext(chaos.WithDatabaseFault(context.Request.Context(), dbFault))
+			}
+		}
+
+		if err := fault.Inject(context.Request.Context()); err != nil {
+			context.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "chaos_injected",
+				"message": err.Error(),
+			})
+			return
+		}
+
+		context.Next()
+	}
+}
+
+// faultFromHeaders 用请求头覆盖 fallback 中对应的字段，无法解析的请求头会被忽略
+func faultFromHeaders(context *gin.Context, latencyHeader, errorRateHeader string, fallback chaos.Fault) chaos.Fault {
+	if latency, err := time.ParseDuration(context.GetHeader(latencyHeader)); err == nil {
+		fallback.Latency = latency
+	}
+	if rate, err := strconv.ParseFloat(context.GetHeader(errorRateHeader), 64); err == nil {
+		fallback.ErrorRate = rate
+	}
+	return fallback
+}
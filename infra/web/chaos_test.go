@@ -0,0 +1,47 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"web-clean/infra/chaos"
+	"web-clean/infra/conf"
+)
+
+func TestChaosMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(ChaosMiddleware(&conf.Chaos{
+		Headers: true,
+		Routes:  map[string]conf.Fault{"GET /broken": {ErrorRate: 1}},
+	}))
+
+	var dbFault chaos.Fault
+	handler := func(c *gin.Context) {
+		dbFault, _ = chaos.DatabaseFaultFrom(c.Request.Context())
+		c.Status(http.StatusOK)
+	}
+	engine.GET("/broken", handler)
+	engine.GET("/ok", handler)
+
+	broken := httptest.NewRecorder()
+	engine.ServeHTTP(broken, httptest.NewRequest(http.MethodGet, "/broken", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, broken.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/ok", nil)
+	req.Header.Set(ChaosDatabaseErrorHeader, "0.25")
+	ok := httptest.NewRecorder()
+	engine.ServeHTTP(ok, req)
+	assert.Equal(t, http.StatusOK, ok.Code)
+	assert.Equal(t, 0.25, dbFault.ErrorRate)
+
+	req = httptest.NewRequest(http.MethodGet, "/ok", nil)
+	req.Header.Set(ChaosErrorRateHeader, "1")
+	failed := httptest.NewRecorder()
+	engine.ServeHTTP(failed, req)
+	assert.Equal(t, http.StatusServiceUnavailable, failed.Code)
+}
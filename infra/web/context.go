@@ -1,41 +1,125 @@
 package web
 
 import (
+	"sync"
+
 	"github.com/gin-gonic/gin"
 
 	"web-clean/domain"
 	"web-clean/infra/database"
 )
 
+// Context 是请求级别的依赖容器。
+//
+// Database 与 Log 是每个请求都具备的基础依赖，直接以字段形式提供；
+// 其余请求级依赖（调用方身份、租户、事务、特性开关等）通过类型化的 Key 注册与获取，
+// 不再各自向 gin.Context 写入零散的字符串 key。
 type Context struct {
 	database.Database
 	Log domain.Log
+
+	mu     sync.RWMutex
+	values map[any]any
+}
+
+// Key 是请求级依赖的类型化键，以指针身份区分，不同 Key 即使名称相同也不会冲突
+type Key[T any] struct {
+	name string
+}
+
+// NewKey 创建一个新的类型化键，name 仅用于调试输出
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+func (k *Key[T]) String() string {
+	return k.name
+}
+
+// Set 向容器中注册依赖，同一个 Key 重复注册时后者覆盖前者
+func Set[T any](ctx *Context, key *Key[T], value T) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	if ctx.values == nil {
+		ctx.values = make(map[any]any)
+	}
+	ctx.values[key] = value
+}
+
+// Get 从容器中取出依赖
+func Get[T any](ctx *Context, key *Key[T]) (T, bool) {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+
+	value, ok := ctx.values[key]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return value.(T), true
+}
+
+// From 从 gin.Context 所绑定的 Context 中取出依赖
+func From[T any](c *gin.Context, key *Key[T]) (T, bool) {
+	webCtx, ok := ContextMiddlewareGetter(c)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return Get(webCtx, key)
 }
 
+// Provider 在每个请求创建 Context 后被调用，用于注册请求级依赖
+type Provider func(c *gin.Context, ctx *Context)
+
+// Provide 将一个依赖构造函数包装为 Provider
+func Provide[T any](key *Key[T], constructor func(c *gin.Context, ctx *Context) T) Provider {
+	return func(c *gin.Context, ctx *Context) {
+		Set(ctx, key, constructor(c, ctx))
+	}
+}
+
+// RequestIDKey 保存当前请求的 RequestID，需要配合 RequestIDProvider 使用
+var RequestIDKey = NewKey[string]("request_id")
+
+// RequestIDProvider 将 RequestIDMiddleware 生成的 RequestID 注册到容器中
+var RequestIDProvider = Provide(RequestIDKey, func(c *gin.Context, _ *Context) string {
+	return RequestIdGetter(c)
+})
+
 var (
 	webContextKey = "__webCtxKey__"
 )
 
+// ContextMiddleware 为每个请求创建 Context，请求日志经 redactor 脱敏后输出并在请求结束时持久化，redactor 为 nil 时不脱敏
 func ContextMiddleware(
 	constructor func(log domain.Log) *Context,
 	innerLogger domain.Log,
 	webLogPersister LogPersister,
+	redactor *Redactor,
+	providers ...Provider,
 ) gin.HandlerFunc {
 
 	return func(context *gin.Context) {
 
 		webLogger := webLog{
-			inner:   innerLogger,
-			context: context,
-			logs:    make([]Log, 0),
+			inner:    innerLogger,
+			context:  context,
+			logs:     make([]Log, 0),
+			redactor: redactor,
 		}
 
 		defer func() {
-			webLogPersister.Persist(webLogger.logs)
+			webLogPersister.Persist(RequestIdGetter(context), webLogger.logs)
 		}()
 
 		webCtx := constructor(&webLogger)
 
+		for _, provide := range providers {
+			provide(context, webCtx)
+		}
+
 		context.Set(webContextKey, webCtx)
 		defer context.Set(webContextKey, nil)
 
@@ -50,7 +134,7 @@ func ContextMiddlewareGetter(context *gin.Context) (*Context, bool) {
 	}
 
 	c, ok := value.(*Context)
-	if !ok {
+	if !ok || c == nil {
 		return nil, false
 	}
 
@@ -0,0 +1,41 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext_SetGet(t *testing.T) {
+	ctx := &Context{}
+	number := NewKey[int]("number")
+	other := NewKey[int]("number")
+
+	_, ok := Get(ctx, number)
+	assert.False(t, ok)
+
+	Set(ctx, number, 42)
+
+	value, ok := Get(ctx, number)
+	assert.True(t, ok)
+	assert.Equal(t, 42, value)
+
+	// 同名但不同的 Key 互不影响
+	_, ok = Get(ctx, other)
+	assert.False(t, ok)
+}
+
+func TestContext_ProviderAndFrom(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set(idKey, "request-1")
+
+	webCtx := &Context{}
+	RequestIDProvider(c, webCtx)
+	c.Set(webContextKey, webCtx)
+
+	requestID, ok := From(c, RequestIDKey)
+	assert.True(t, ok)
+	assert.Equal(t, "request-1", requestID)
+}
@@ -0,0 +1,168 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"web-clean/domain"
+)
+
+// Deprecation 描述一个路由（或路由返回的部分字段）的弃用计划
+type Deprecation struct {
+	// Since 为弃用生效时间，写入 Deprecation 响应头 (RFC 9745)
+	Since time.Time
+	// Sunset 为计划下线时间，零值表示尚未确定，非零时写入 Sunset 响应头 (RFC 8594)
+	Sunset time.Time
+	// Successor 为替代接口的地址，写入 rel="successor-version" 的 Link 响应头
+	Successor string
+	// Fields 非空时表示只弃用这些响应字段，路由本身仍然有效，此时只写入 X-Deprecated-Fields 响应头
+	Fields []string
+}
+
+// DeprecatedFieldsHeader 列出当前响应中已弃用的字段
+const DeprecatedFieldsHeader = "X-Deprecated-Fields"
+
+// DeprecationClient 是某个客户端对已弃用路由的调用统计
+type DeprecationClient struct {
+	Client    string    `json:"client"`
+	Count     int64     `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// DeprecationReport 是一个已弃用路由及其仍在调用的客户端
+type DeprecationReport struct {
+	Route     string              `json:"route"`
+	Since     time.Time           `json:"since"`
+	Sunset    *time.Time          `json:"sunset,omitempty"`
+	Successor string              `json:"successor,omitempty"`
+	Fields    []string            `json:"fields,omitempty"`
+	Clients   []DeprecationClient `json:"clients"`
+}
+
+type deprecatedRoute struct {
+	deprecation Deprecation
+	clients     map[string]*DeprecationClient
+}
+
+// DeprecationRegistry 登记已弃用的路由，为其响应加上弃用相关的响应头，并按客户端统计调用，
+// 用于判断何时可以真正下线。与 UsageRecorder 一样，统计只在当前进程内有效，
+// 每个客户端第一次调用已弃用路由时会打印一条日志，便于在日志系统中跨实例汇总。
+// 每个路由单独统计的客户端数量有上限，之后新出现的客户端计入 OtherClient
+type DeprecationRegistry struct {
+	log        domain.Log
+	maxClients int
+
+	mu     sync.Mutex
+	routes map[string]*deprecatedRoute
+}
+
+func NewDeprecationRegistry(log domain.Log) *DeprecationRegistry {
+	return &DeprecationRegistry{
+		log:        log,
+		maxClients: maxUsageClients,
+		routes:     make(map[string]*deprecatedRoute),
+	}
+}
+
+// Deprecate 登记 route（如 "PUT /api/v1/users/:id" 或 "/api/v1"）的弃用计划，并返回应挂在该路由或路由组上的中间件。
+// 弃用只影响响应头与统计，过了 Sunset 时间的路由仍会正常处理请求，下线需要显式删除路由
+func (r *DeprecationRegistry) Deprecate(route string, deprecation Deprecation) gin.HandlerFunc {
+	r.mu.Lock()
+	r.routes[route] = &deprecatedRoute{deprecation: deprecation, clients: make(map[string]*DeprecationClient)}
+	r.mu.Unlock()
+
+	headers := deprecationHeaders(deprecation)
+
+	return func(context *gin.Context) {
+		for name, value := range headers {
+			context.Header(name, value)
+		}
+
+		r.record(route, requestClient(context))
+
+		context.Next()
+	}
+}
+
+// deprecationHeaders 预先计算中间件需要写入的响应头
+func deprecationHeaders(deprecation Deprecation) map[string]string {
+	headers := make(map[string]string)
+
+	if len(deprecation.Fields) > 0 {
+		headers[DeprecatedFieldsHeader] = strings.Join(deprecation.Fields, ",")
+		return headers
+	}
+
+	headers["Deprecation"] = "true"
+	if !deprecation.Since.IsZero() {
+		headers["Deprecation"] = fmt.Sprintf("@%d", deprecation.Since.Unix())
+	}
+	if !deprecation.Sunset.IsZero() {
+		headers["Sunset"] = deprecation.Sunset.UTC().Format(http.TimeFormat)
+	}
+	if deprecation.Successor != "" {
+		headers["Link"] = fmt.Sprintf(`<%s>; rel="successor-version"`, deprecation.Successor)
+	}
+	return headers
+}
+
+func (r *DeprecationRegistry) record(route, client string) {
+	now := time.Now()
+
+	r.mu.Lock()
+	deprecated := r.routes[route]
+	stat, ok := deprecated.clients[client]
+	if !ok && len(deprecated.clients) >= r.maxClients {
+		client = OtherClient
+		stat, ok = deprecated.clients[client]
+	}
+	if !ok {
+		stat = &DeprecationClient{Client: client, FirstSeen: now}
+		deprecated.clients[client] = stat
+	}
+	stat.Count++
+	stat.LastSeen = now
+	r.mu.Unlock()
+
+	if !ok {
+		r.log.Warnw("客户端调用了已弃用的接口", "route", route, "client", client)
+	}
+}
+
+// Report 返回全部已弃用路由及其调用方，按路由与客户端排序
+func (r *DeprecationRegistry) Report() []DeprecationReport {
+	r.mu.Lock()
+	reports := make([]DeprecationReport, 0, len(r.routes))
+	for route, deprecated := range r.routes {
+		report := DeprecationReport{
+			Route:     route,
+			Since:     deprecated.deprecation.Since,
+			Successor: deprecated.deprecation.Successor,
+			Fields:    deprecated.deprecation.Fields,
+			Clients:   make([]DeprecationClient, 0, len(deprecated.clients)),
+		}
+		if sunset := deprecated.deprecation.Sunset; !sunset.IsZero() {
+			report.Sunset = &sunset
+		}
+		for _, stat := range deprecated.clients {
+			report.Clients = append(report.Clients, *stat)
+		}
+		sort.Slice(report.Clients, func(i, j int) bool {
+			return report.Clients[i].Client < report.Clients[j].Client
+		})
+		reports = append(reports, report)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Route < reports[j].Route
+	})
+	return reports
+}
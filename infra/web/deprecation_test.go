@@ -0,0 +1,79 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestDeprecationRegistry(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	since := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	registry := NewDeprecationRegistry(zap.NewNop().Sugar())
+	engine := gin.New()
+	engine.PUT("/users/:id", registry.Deprecate("PUT /users/:id", Deprecation{
+		Since:     since,
+		Sunset:    sunset,
+		Successor: "/v2/users/:id",
+	}), func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.GET("/users/:id", registry.Deprecate("GET /users/:id", Deprecation{
+		Fields: []string{"name"},
+	}), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, client := range []string{"billing", "billing", "crm"} {
+		req := httptest.NewRequest(http.MethodPut, "/users/1", nil)
+		req.Header.Set(ClientIDHeader, client)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "@1717200000", w.Header().Get("Deprecation"))
+		assert.Equal(t, "Wed, 01 Jan 2025 00:00:00 GMT", w.Header().Get("Sunset"))
+		assert.Equal(t, `</v2/users/:id>; rel="successor-version"`, w.Header().Get("Link"))
+	}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	assert.Empty(t, w.Header().Get("Deprecation"))
+	assert.Equal(t, "name", w.Header().Get(DeprecatedFieldsHeader))
+
+	report := registry.Report()
+	assert.Len(t, report, 2)
+	assert.Equal(t, "GET /users/:id", report[0].Route)
+	assert.Nil(t, report[0].Sunset)
+	assert.Equal(t, "PUT /users/:id", report[1].Route)
+	assert.Equal(t, sunset, *report[1].Sunset)
+	assert.Len(t, report[1].Clients, 2)
+	assert.Equal(t, "billing", report[1].Clients[0].Client)
+	assert.Equal(t, int64(2), report[1].Clients[0].Count)
+	assert.Equal(t, int64(1), report[1].Clients[1].Count)
+}
+
+func TestDeprecationRegistry_CapsClients(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry := NewDeprecationRegistry(zap.NewNop().Sugar())
+	registry.maxClients = 1
+
+	engine := gin.New()
+	engine.GET("/users", registry.Deprecate("GET /users", Deprecation{}), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, client := range []string{"billing", "scraper-1", "scraper-2", "billing"} {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set(ClientIDHeader, client)
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	clients := registry.Report()[0].Clients
+	assert.Len(t, clients, 2)
+	assert.Equal(t, "billing", clients[0].Client)
+	assert.Equal(t, int64(2), clients[0].Count)
+	assert.Equal(t, OtherClient, clients[1].Client)
+	assert.Equal(t, int64(2), clients[1].Count)
+}
@@ -1,6 +1,8 @@
 package web
 
 import (
+	"errors"
+
 	"github.com/gin-gonic/gin"
 
 	"web-clean/domain"
@@ -15,23 +17,51 @@ type Errors struct {
 	RequestID string
 }
 
+// ErrorEntry 是持久化时单个错误的结构，Panic 仅在错误来自 RecoverWithError 时存在
+type ErrorEntry struct {
+	Error string      `json:"error"`
+	Panic *PanicEntry `json:"panic,omitempty"`
+}
+
+type PanicEntry struct {
+	Kind  PanicKind `json:"kind"`
+	Stack string    `json:"stack"`
+}
+
+func errorEntries(errs []*gin.Error) []ErrorEntry {
+	entries := make([]ErrorEntry, 0, len(errs))
+	for _, err := range errs {
+		entry := ErrorEntry{Error: err.Error()}
+
+		var panicErr *PanicError
+		if errors.As(err.Err, &panicErr) {
+			entry.Panic = &PanicEntry{Kind: panicErr.Kind, Stack: panicErr.Stack}
+		}
+
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
 type ErrorStackPersister interface {
 
 	// Persist 该方法将错误堆栈持久化，请注意由于该插件注册于 Recover 外围，该插件不能返回错误，不能 panic
 	Persist(errors Errors)
 }
 
+// ErrorPersisterMiddleware 在请求产生错误时持久化错误堆栈与访问信息，URL 中的敏感查询参数经 redactor 脱敏
 func ErrorPersisterMiddleware(
 	persistent ErrorStackPersister,
 	log domain.Log,
 	requestIdGetter func(ctx *gin.Context) string,
+	redactor *Redactor,
 ) gin.HandlerFunc {
 	return func(context *gin.Context) {
 
-		requestMethod := context.Request.Method    // 请求方法
-		requestURL := context.Request.URL.String() // 完整 URL
-		requestPath := context.Request.URL.Path    // 请求路径
-		requestIP := context.ClientIP()            // 客户端 IP
+		requestMethod := context.Request.Method                  // 请求方法
+		requestURL := redactor.URL(context.Request.URL.String()) // 完整 URL，敏感查询参数已脱敏
+		requestPath := context.Request.URL.Path                  // 请求路径
+		requestIP := context.ClientIP()                          // 客户端 IP
 		requestID := requestIdGetter(context)
 
 		defer func() {
@@ -49,12 +79,8 @@ func ErrorPersisterMiddleware(
 			return
 		}
 
-		var errBody any
-
-		errBody = context.Errors.String()
-
 		persistent.Persist(Errors{
-			Stack:     errBody,
+			Stack:     errorEntries(context.Errors),
 			Method:    requestMethod,
 			URL:       requestURL,
 			Path:      requestPath,
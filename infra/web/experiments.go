@@ -0,0 +1,40 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"web-clean/infra/experiments"
+)
+
+// ExperimentsHeader 响应头列出本次请求的实验分组，例如 "checkout=treatment,signup=control"
+const ExperimentsHeader = "X-Experiments"
+
+// ExperimentsKey 保存当前请求的实验分组，需要配合 ExperimentsProvider 使用
+var ExperimentsKey = NewKey[*experiments.Assignments]("experiments")
+
+// ExperimentsProvider 按调用方身份计算实验分组并写入响应头。
+// 调用方标识优先使用已认证的 Principal.Subject，其次是 X-Client-ID；两者都没有时不分配任何分组。
+// 分组被读取时通过请求日志记录曝光，曝光随请求日志一起持久化
+func ExperimentsProvider(registry *experiments.Registry) Provider {
+	return Provide(ExperimentsKey, func(c *gin.Context, ctx *Context) *experiments.Assignments {
+		unit := c.GetHeader(ClientIDHeader)
+		if principal, ok := PrincipalFrom(c.Request.Context()); ok && principal.Subject != "" {
+			unit = principal.Subject
+		}
+		if unit == "" {
+			return nil
+		}
+
+		assignments := registry.Assign(unit, func(key, variant string) {
+			ctx.Log.Infow("实验曝光", "experiment", key, "variant", variant)
+		})
+		c.Header(ExperimentsHeader, assignments.String())
+		return assignments
+	})
+}
+
+// ExperimentVariant 返回当前请求在实验 key 中的分组并记录曝光，未分配时返回 false，调用方应按对照组处理
+func ExperimentVariant(c *gin.Context, key string) (string, bool) {
+	assignments, _ := From(c, ExperimentsKey)
+	return assignments.Variant(key)
+}
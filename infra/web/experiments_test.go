@@ -0,0 +1,52 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"web-clean/domain"
+	"web-clean/infra/conf"
+	"web-clean/infra/experiments"
+)
+
+func TestExperimentsProvider(t *testing.T) {
+	registry, err := experiments.From(map[string]conf.Experiment{
+		"checkout": {Enabled: true, Variants: map[string]int{"treatment": 1}},
+	})
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(ContextMiddleware(func(log domain.Log) *Context {
+		return &Context{Log: log}
+	}, zap.NewNop().Sugar(), nopLogPersister{}, nil, ExperimentsProvider(registry)))
+	engine.GET("/", func(c *gin.Context) {
+		variant, ok := ExperimentVariant(c, "checkout")
+		if !ok {
+			variant = "control"
+		}
+		c.String(http.StatusOK, variant)
+	})
+
+	identified := httptest.NewRequest(http.MethodGet, "/", nil)
+	identified.Header.Set(ClientIDHeader, "client-1")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, identified)
+	assert.Equal(t, "treatment", w.Body.String())
+	assert.Equal(t, "checkout=treatment", w.Header().Get(ExperimentsHeader))
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "control", w.Body.String())
+	assert.Empty(t, w.Header().Get(ExperimentsHeader))
+}
+
+type nopLogPersister struct{}
+
+func (nopLogPersister) Persist(string, []Log) error { return nil }
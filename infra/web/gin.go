@@ -3,8 +3,9 @@ package web
 import (
 	"context"
 	"errors"
-	"fmt"
+	"net"
 	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
@@ -21,21 +22,32 @@ type _gin struct {
 
 func (g *_gin) Serve() {
 	srv := &http.Server{
-		Addr:    fmt.Sprintf(":%d", g.Conf.Web.Port),
 		Handler: g.engine,
 	}
 
-	ctx, stop := signal.NotifyContext(g.Ctx, syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
+	listener, err := listen(g.Conf.Web)
+	if err != nil {
+		g.Log.Fatal("💥 listen: ", err)
+	}
+
+	g.Log.Infow("🚀 Server listening", "network", listener.Addr().Network(), "address", listener.Addr().String())
 
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			g.Log.Fatal("💥 listen: ", err)
 		}
 	}()
 
-	// Listen for the interrupt signal.
-	<-ctx.Done()
+	ctx, stop := signal.NotifyContext(g.Ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	restart := make(chan os.Signal, 1)
+	if len(restartSignals) > 0 {
+		signal.Notify(restart, restartSignals...)
+		defer signal.Stop(restart)
+	}
+
+	g.waitForShutdown(ctx, restart, listener)
 
 	stop()
 	g.Log.Info("🛑 shutting down gracefully, press Ctrl+C again to force")
@@ -49,6 +61,26 @@ func (g *_gin) Serve() {
 	g.Log.Infow("👋 Server exiting")
 }
 
+// waitForShutdown 阻塞直到收到退出信号，或者热重启成功把监听套接字交给了新进程
+func (g *_gin) waitForShutdown(ctx context.Context, restart <-chan os.Signal, listener net.Listener) {
+	for {
+		select {
+		// Listen for the interrupt signal.
+		case <-ctx.Done():
+			return
+		case <-restart:
+			pid, err := handoff(listener)
+			if err != nil {
+				// 新进程没能启动，继续由当前进程提供服务
+				g.Log.Errorw("🔁 热重启失败，继续使用当前进程", "error", err)
+				continue
+			}
+			g.Log.Infow("🔁 监听套接字已交给新进程，当前进程开始排空", "pid", pid)
+			return
+		}
+	}
+}
+
 func Gin(
 	ctx *infra.Context,
 	opt func(*gin.Engine),
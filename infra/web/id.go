@@ -1,11 +1,19 @@
 package web
 
-import "github.com/gin-gonic/gin"
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
 
 var (
 	idKey = "__idKey__"
 )
 
+// requestIDContextKey 用于在 http.Request 的 context 中保存 RequestID，
+// 使不接触 gin.Context 的下游（service、出站 HTTP 客户端）也能取到
+type requestIDContextKey struct{}
+
 func RequestIDMiddleware(idGen func() string) gin.HandlerFunc {
 	return func(context *gin.Context) {
 		requestID := context.GetHeader("X-Request-ID")
@@ -14,6 +22,7 @@ func RequestIDMiddleware(idGen func() string) gin.HandlerFunc {
 			context.Set("X-Request-ID", requestID)
 		}
 		context.Set(idKey, requestID)
+		context.Request = context.Request.WithContext(WithRequestID(context.Request.Context(), requestID))
 		context.Next()
 	}
 }
@@ -25,3 +34,14 @@ func RequestIdGetter(c *gin.Context) string {
 	}
 	return s.(string)
 }
+
+// WithRequestID 返回携带 RequestID 的 context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext 从 context 中取出 RequestID，不存在时返回空字符串
+func RequestIDFromContext(ctx context.Context) string {
+	s, _ := ctx.Value(requestIDContextKey{}).(string)
+	return s
+}
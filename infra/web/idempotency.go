@@ -0,0 +1,259 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"web-clean/domain"
+)
+
+const (
+	// IdempotencyKeyHeader 是客户端为写请求附带的幂等键
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader 标记响应是对先前请求结果的重放
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// maxIdempotentEntries 是同时保存的记录上限，达到上限后新的键不再记录，请求照常执行
+	maxIdempotentEntries = 10000
+	// maxIdempotentRequestBytes 是携带幂等键的请求体上限，与导入文件的上限一致
+	maxIdempotentRequestBytes = 10 << 20
+	// maxIdempotentResponseBytes 是可以记录的响应体上限，更大的响应不记录
+	maxIdempotentResponseBytes = 64 << 10
+)
+
+// Idempotency 按幂等键记录写请求的响应：同一个键的重试直接重放首次结果，
+// 并发到达的相同请求等待首个请求完成后重放，不会重复执行业务逻辑。
+// 键按调用方区分（认证身份，未认证时为客户端 IP），不同调用方使用相同的键互不影响。
+// 记录只保存在当前进程内，ttl 过后由 Sweep 清理；5xx 与认证、权限、限流的拒绝不记录，客户端可以用同一个键重试
+type Idempotency struct {
+	ttl        time.Duration
+	log        domain.Log
+	now        func() time.Time
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*idempotentEntry
+}
+
+type idempotentEntry struct {
+	fingerprint string
+	// done 在首个请求完成后关闭，此后 recorded 为 false 表示该结果未被记录
+	done     chan struct{}
+	recorded bool
+	status   int
+	header   http.Header
+	body     []byte
+	expires  time.Time
+}
+
+// NewIdempotency 创建幂等记录，ttl 为单条记录的保留时长
+func NewIdempotency(ttl time.Duration, log domain.Log) *Idempotency {
+	return &Idempotency{
+		ttl:        ttl,
+		log:        log,
+		now:        time.Now,
+		maxEntries: maxIdempotentEntries,
+		entries:    make(map[string]*idempotentEntry),
+	}
+}
+
+// Sweep 每隔 interval 清理过期的记录，直到 ctx 结束，应在单独的 goroutine 中运行
+func (i *Idempotency) Sweep(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			i.sweep()
+		}
+	}
+}
+
+func (i *Idempotency) sweep() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := i.now()
+	for scope, entry := range i.entries {
+		if entry.recorded && now.After(entry.expires) {
+			delete(i.entries, scope)
+		}
+	}
+}
+
+// Middleware 对携带 Idempotency-Key 的请求生效，未携带的请求直接放行。
+// 同一个键配合不同的请求体复用时返回 422
+func (i *Idempotency) Middleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		key := context.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			context.Next()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(context.Writer, context.Request.Body, maxIdempotentRequestBytes))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			context.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error":   "request_too_large",
+				"message": fmt.Sprintf("A request with an Idempotency-Key may be at most %d bytes", maxIdempotentRequestBytes),
+			})
+			return
+		}
+		if err != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_request",
+				"message": "Failed to read request body",
+			})
+			return
+		}
+		context.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(body)
+		fingerprint := hex.EncodeToString(sum[:])
+		scope := context.Request.Method + " " + context.FullPath() + " " + idempotencyCaller(context) + " " + key
+
+		for {
+			entry, owner := i.claim(scope, fingerprint)
+			if entry == nil {
+				i.log.Warnw("幂等记录已满，请求不做幂等处理", "entries", i.maxEntries, "request_id", RequestIdGetter(context))
+				context.Next()
+				return
+			}
+			if entry.fingerprint != fingerprint {
+				context.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+					"error":   "idempotency_key_reused",
+					"message": "The Idempotency-Key was already used with a different request body",
+				})
+				return
+			}
+
+			if owner {
+				i.execute(context, scope, entry)
+				return
+			}
+
+			select {
+			case <-entry.done:
+			case <-context.Request.Context().Done():
+				context.Abort()
+				return
+			}
+
+			if entry.recorded {
+				i.replay(context, entry)
+				return
+			}
+			// 首个请求的结果未被记录（例如 5xx），重新竞争执行权
+		}
+	}
+}
+
+// idempotencyCaller 区分使用幂等键的调用方：认证身份，未认证时为客户端 IP
+func idempotencyCaller(context *gin.Context) string {
+	if principal, ok := PrincipalFrom(context.Request.Context()); ok {
+		return "principal:" + principal.Subject
+	}
+	return "ip:" + context.ClientIP()
+}
+
+// claim 返回键对应的记录，owner 为 true 表示调用方负责执行请求；记录已满时返回 nil
+func (i *Idempotency) claim(scope, fingerprint string) (*idempotentEntry, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if entry, ok := i.entries[scope]; ok {
+		if !entry.recorded || !i.now().After(entry.expires) {
+			return entry, false
+		}
+		delete(i.entries, scope)
+	}
+	if len(i.entries) >= i.maxEntries {
+		return nil, false
+	}
+
+	entry := &idempotentEntry{fingerprint: fingerprint, done: make(chan struct{})}
+	i.entries[scope] = entry
+	return entry, true
+}
+
+func (i *Idempotency) execute(context *gin.Context, scope string, entry *idempotentEntry) {
+	recorder := &responseRecorder{ResponseWriter: context.Writer}
+	context.Writer = recorder
+
+	// completed 为 false 说明处理过程中发生了 panic，此时的状态码并不可信
+	completed := false
+	defer func() {
+		i.mu.Lock()
+		status := recorder.Status()
+		if completed && recordable(status) && recorder.body.Len() <= maxIdempotentResponseBytes {
+			entry.recorded = true
+			entry.status = status
+			entry.header = recorder.Header().Clone()
+			entry.body = recorder.body.Bytes()
+			entry.expires = i.now().Add(i.ttl)
+		} else {
+			delete(i.entries, scope)
+		}
+		i.mu.Unlock()
+		close(entry.done)
+	}()
+
+	context.Next()
+	completed = true
+}
+
+// recordable 判断响应是否可以重放：5xx 与认证、权限、限流的拒绝在条件变化后重试可能成功，不记录
+func recordable(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return false
+	}
+	return status < http.StatusInternalServerError
+}
+
+func (i *Idempotency) replay(context *gin.Context, entry *idempotentEntry) {
+	i.log.Infow("重放幂等请求的响应",
+		"method", context.Request.Method,
+		"path", context.FullPath(),
+		"request_id", RequestIdGetter(context),
+		"status", entry.status,
+	)
+
+	for name, values := range entry.header {
+		context.Writer.Header()[name] = append([]string(nil), values...)
+	}
+	context.Header(IdempotentReplayedHeader, "true")
+	context.Status(entry.status)
+	_, _ = context.Writer.Write(entry.body)
+	context.Abort()
+}
+
+// responseRecorder 在写出响应的同时保留一份响应体
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,170 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newIdempotencyEngine(calls *atomic.Int32, status int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	idempotency := NewIdempotency(time.Hour, zap.NewNop().Sugar())
+	engine.POST("/users", idempotency.Middleware(), func(c *gin.Context) {
+		n := calls.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		c.JSON(status, gin.H{"call": n})
+	})
+	return engine
+}
+
+func postWithKey(engine *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ConcurrentRequestsExecuteOnce(t *testing.T) {
+	var calls atomic.Int32
+	engine := newIdempotencyEngine(&calls, http.StatusCreated)
+
+	responses := make([]*httptest.ResponseRecorder, 5)
+	var wg sync.WaitGroup
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = postWithKey(engine, "k1", `{"email":"a@example.com"}`)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	replayed := 0
+	for _, w := range responses {
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.JSONEq(t, `{"call":1}`, w.Body.String())
+		if w.Header().Get(IdempotentReplayedHeader) == "true" {
+			replayed++
+		}
+	}
+	assert.Equal(t, len(responses)-1, replayed)
+}
+
+func TestIdempotency_KeyReusedWithDifferentBody(t *testing.T) {
+	var calls atomic.Int32
+	engine := newIdempotencyEngine(&calls, http.StatusCreated)
+
+	assert.Equal(t, http.StatusCreated, postWithKey(engine, "k1", `{"email":"a@example.com"}`).Code)
+	w := postWithKey(engine, "k1", `{"email":"b@example.com"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "idempotency_key_reused")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestIdempotency_ServerErrorsAreNotRecorded(t *testing.T) {
+	var calls atomic.Int32
+	engine := newIdempotencyEngine(&calls, http.StatusServiceUnavailable)
+
+	postWithKey(engine, "k1", `{}`)
+	postWithKey(engine, "k1", `{}`)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotency_WithoutKey(t *testing.T) {
+	var calls atomic.Int32
+	engine := newIdempotencyEngine(&calls, http.StatusCreated)
+
+	postWithKey(engine, "", `{}`)
+	postWithKey(engine, "", `{}`)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotency_AuthRejectionsAreNotRecorded(t *testing.T) {
+	var calls atomic.Int32
+	engine := newIdempotencyEngine(&calls, http.StatusForbidden)
+
+	postWithKey(engine, "k1", `{}`)
+	postWithKey(engine, "k1", `{}`)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotency_KeysAreScopedByCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var calls atomic.Int32
+	idempotency := NewIdempotency(time.Hour, zap.NewNop().Sugar())
+	engine := gin.New()
+	engine.POST("/users", func(c *gin.Context) {
+		if subject := c.GetHeader("X-Test-Subject"); subject != "" {
+			SetPrincipal(c, Principal{Subject: subject})
+		}
+	}, idempotency.Middleware(), func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"call": calls.Add(1)})
+	})
+
+	post := func(remoteAddr, subject string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{}`))
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(IdempotencyKeyHeader, "k1")
+		if subject != "" {
+			req.Header.Set("X-Test-Subject", subject)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.JSONEq(t, `{"call":1}`, post("10.0.0.1:1000", "").Body.String())
+	assert.JSONEq(t, `{"call":2}`, post("10.0.0.2:1000", "").Body.String(), "another client reusing the key is not replayed")
+	assert.JSONEq(t, `{"call":3}`, post("10.0.0.1:1000", "apikey:a").Body.String())
+	assert.JSONEq(t, `{"call":4}`, post("10.0.0.1:1000", "apikey:b").Body.String())
+
+	replayed := post("10.0.0.3:1000", "apikey:a")
+	assert.JSONEq(t, `{"call":3}`, replayed.Body.String(), "the same principal is replayed from any address")
+	assert.Equal(t, "true", replayed.Header().Get(IdempotentReplayedHeader))
+}
+
+func TestIdempotency_CapsEntriesAndSweeps(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var calls atomic.Int32
+	now := time.Now()
+	idempotency := NewIdempotency(time.Hour, zap.NewNop().Sugar())
+	idempotency.maxEntries = 1
+	idempotency.now = func() time.Time { return now }
+	engine := gin.New()
+	engine.POST("/users", idempotency.Middleware(), func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"call": calls.Add(1)})
+	})
+
+	postWithKey(engine, "k1", `{}`)
+	postWithKey(engine, "k2", `{}`)
+	postWithKey(engine, "k2", `{}`)
+	assert.Equal(t, int32(3), calls.Load(), "keys beyond the cap are executed without being recorded")
+	assert.Equal(t, "true", postWithKey(engine, "k1", `{}`).Header().Get(IdempotentReplayedHeader))
+
+	now = now.Add(2 * time.Hour)
+	idempotency.sweep()
+	assert.Empty(t, idempotency.entries)
+	assert.Empty(t, postWithKey(engine, "k1", `{}`).Header().Get(IdempotentReplayedHeader))
+}
+
+func TestIdempotency_RejectsLargeBodies(t *testing.T) {
+	var calls atomic.Int32
+	engine := newIdempotencyEngine(&calls, http.StatusCreated)
+
+	w := postWithKey(engine, "k1", strings.Repeat("a", maxIdempotentRequestBytes+1))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Zero(t, calls.Load())
+}
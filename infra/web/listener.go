@@ -0,0 +1,99 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"web-clean/infra/conf"
+)
+
+const (
+	// inheritedListenerEnv 由旧进程在热重启时设置，表示监听套接字以 fd 3 传入
+	inheritedListenerEnv = "WEBCLEAN_INHERITED_LISTENER"
+
+	// inheritedListenerFd 是 exec.Cmd.ExtraFiles 中第一个文件对应的描述符
+	inheritedListenerFd = 3
+
+	// systemdListenFdsStart 是 systemd socket activation 传入的第一个描述符（SD_LISTEN_FDS_START）
+	systemdListenFdsStart = 3
+
+	unixListenPrefix = "unix:"
+	systemdListen    = "systemd"
+)
+
+// listen 按以下优先级创建监听套接字：
+//   - 热重启时旧进程传入的套接字
+//   - web.listen 为 "systemd" 时使用 systemd socket activation 传入的套接字
+//   - web.listen 为 "unix:/path/to.sock" 时监听 unix domain socket
+//   - web.listen 为其他非空值时作为 TCP 地址（例如 "127.0.0.1:9000"）
+//   - 否则监听 web.port
+func listen(web *conf.Web) (net.Listener, error) {
+	if os.Getenv(inheritedListenerEnv) != "" {
+		// 避免继续传递给本进程之后再启动的子进程
+		_ = os.Unsetenv(inheritedListenerEnv)
+		return fileListener(inheritedListenerFd, "inherited-listener")
+	}
+
+	switch {
+	case web.Listen == systemdListen:
+		return systemdListener()
+	case strings.HasPrefix(web.Listen, unixListenPrefix):
+		return unixListener(strings.TrimPrefix(web.Listen, unixListenPrefix))
+	case web.Listen != "":
+		return net.Listen("tcp", web.Listen)
+	default:
+		return net.Listen("tcp", fmt.Sprintf(":%d", web.Port))
+	}
+}
+
+func fileListener(fd uintptr, name string) (net.Listener, error) {
+	f := os.NewFile(fd, name)
+	if f == nil {
+		return nil, fmt.Errorf("无效的文件描述符 %d", fd)
+	}
+	defer f.Close()
+
+	return net.FileListener(f)
+}
+
+// systemdListener 读取 LISTEN_PID/LISTEN_FDS，只使用第一个传入的套接字
+func systemdListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, errors.New("web.listen 为 systemd，但当前进程不是由 systemd socket activation 启动的")
+	}
+
+	fds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || fds < 1 {
+		return nil, errors.New("systemd 没有传入任何监听套接字")
+	}
+
+	_ = os.Unsetenv("LISTEN_PID")
+	_ = os.Unsetenv("LISTEN_FDS")
+	_ = os.Unsetenv("LISTEN_FDNAMES")
+
+	return fileListener(systemdListenFdsStart, "systemd-listener")
+}
+
+// unixListener 监听 unix domain socket，上次异常退出遗留的套接字文件会被清理
+func unixListener(path string) (net.Listener, error) {
+	if path == "" {
+		return nil, errors.New("web.listen 的 unix socket 路径为空")
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("%s 已存在且不是 socket 文件", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return net.Listen("unix", path)
+}
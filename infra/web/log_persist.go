@@ -10,7 +10,8 @@ import (
 )
 
 type LogPersister interface {
-	Persist(logs []Log) error
+	// Persist 在请求结束时写入该请求产生的全部日志，requestID 用于与错误堆栈关联
+	Persist(requestID string, logs []Log) error
 }
 
 type Log struct {
@@ -19,9 +20,10 @@ type Log struct {
 }
 
 type webLog struct {
-	inner   domain.Log
-	context *gin.Context
-	logs    []Log
+	inner    domain.Log
+	context  *gin.Context
+	logs     []Log
+	redactor *Redactor
 }
 
 func (w *webLog) appendToLogs(level string, args ...interface{}) {
@@ -42,106 +44,127 @@ func (w *webLog) appendToLogs(level string, args ...interface{}) {
 }
 
 func (w *webLog) Debug(args ...interface{}) {
+	args = w.redactor.Values(args)
 	w.inner.Debug(args...)
 	w.appendToLogs("DEBUG", args...)
 }
 
 func (w *webLog) Info(args ...interface{}) {
+	args = w.redactor.Values(args)
 	w.inner.Info(args...)
 	w.appendToLogs("INFO", args...)
 }
 
 func (w *webLog) Warn(args ...interface{}) {
+	args = w.redactor.Values(args)
 	w.inner.Warn(args...)
 	w.appendToLogs("WARN", args...)
 }
 
 func (w *webLog) Error(args ...interface{}) {
+	args = w.redactor.Values(args)
 	w.inner.Error(args...)
 	w.appendToLogs("ERROR", args...)
 }
 
 func (w *webLog) DPanic(args ...interface{}) {
+	args = w.redactor.Values(args)
 	w.inner.DPanic(args...)
 	w.appendToLogs("DPANIC", args...)
 }
 
 func (w *webLog) Panic(args ...interface{}) {
+	args = w.redactor.Values(args)
 	w.inner.Panic(args...)
 	w.appendToLogs("PANIC", args...)
 }
 
 func (w *webLog) Fatal(args ...interface{}) {
+	args = w.redactor.Values(args)
 	w.inner.Fatal(args...)
 	w.appendToLogs("FATAL", args...)
 }
 
 func (w *webLog) Debugf(template string, args ...interface{}) {
+	args = w.redactor.Values(args)
 	w.inner.Debugf(template, args...)
 	w.appendToLogs("DEBUG", template, args)
 }
 
 func (w *webLog) Infof(template string, args ...interface{}) {
+	args = w.redactor.Values(args)
 	w.inner.Infof(template, args...)
 	w.appendToLogs("INFO", template, args)
 }
 
 func (w *webLog) Warnf(template string, args ...interface{}) {
+	args = w.redactor.Values(args)
 	w.inner.Warnf(template, args...)
 	w.appendToLogs("WARN", template, args)
 }
 
 func (w *webLog) Errorf(template string, args ...interface{}) {
+	args = w.redactor.Values(args)
 	w.inner.Errorf(template, args...)
 	w.appendToLogs("ERROR", template, args)
 }
 
 func (w *webLog) DPanicf(template string, args ...interface{}) {
+	args = w.redactor.Values(args)
 	w.inner.DPanicf(template, args...)
 	w.appendToLogs("DPANIC", template, args)
 }
 
 func (w *webLog) Panicf(template string, args ...interface{}) {
+	args = w.redactor.Values(args)
 	w.inner.Panicf(template, args...)
 	w.appendToLogs("PANIC", template, args)
 }
 
 func (w *webLog) Fatalf(template string, args ...interface{}) {
+	args = w.redactor.Values(args)
 	w.inner.Fatalf(template, args...)
 	w.appendToLogs("FATAL", template, args)
 }
 
 func (w *webLog) Debugw(msg string, keysAndValues ...interface{}) {
+	keysAndValues = w.redactor.KeysAndValues(keysAndValues)
 	w.inner.Debugw(msg, keysAndValues...)
 	w.appendToLogs("DEBUG", msg, keysAndValues)
 }
 
 func (w *webLog) Infow(msg string, keysAndValues ...interface{}) {
+	keysAndValues = w.redactor.KeysAndValues(keysAndValues)
 	w.inner.Infow(msg, keysAndValues...)
 	w.appendToLogs("INFO", msg, keysAndValues)
 }
 
 func (w *webLog) Warnw(msg string, keysAndValues ...interface{}) {
+	keysAndValues = w.redactor.KeysAndValues(keysAndValues)
 	w.inner.Warnw(msg, keysAndValues...)
 	w.appendToLogs("WARN", msg, keysAndValues)
 }
 
 func (w *webLog) Errorw(msg string, keysAndValues ...interface{}) {
+	keysAndValues = w.redactor.KeysAndValues(keysAndValues)
 	w.inner.Errorw(msg, keysAndValues...)
 	w.appendToLogs("ERROR", msg, keysAndValues)
 }
 
 func (w *webLog) DPanicw(msg string, keysAndValues ...interface{}) {
+	keysAndValues = w.redactor.KeysAndValues(keysAndValues)
 	w.inner.DPanicw(msg, keysAndValues...)
 	w.appendToLogs("DPANIC", msg, keysAndValues)
 }
 
 func (w *webLog) Panicw(msg string, keysAndValues ...interface{}) {
+	keysAndValues = w.redactor.KeysAndValues(keysAndValues)
 	w.inner.Panicw(msg, keysAndValues...)
 	w.appendToLogs("PANIC", msg, keysAndValues)
 }
 
 func (w *webLog) Fatalw(msg string, keysAndValues ...interface{}) {
+	keysAndValues = w.redactor.KeysAndValues(keysAndValues)
 	w.inner.Fatalw(msg, keysAndValues...)
 	w.appendToLogs("FATAL", msg, keysAndValues)
 }
@@ -0,0 +1,83 @@
+package web
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PanicKind 是 panic 的分类
+type PanicKind string
+
+const (
+	// PanicAssertion 类型断言失败
+	PanicAssertion PanicKind = "assertion"
+	// PanicNilDereference 空指针解引用
+	PanicNilDereference PanicKind = "nil_dereference"
+	// PanicRuntime 其他运行时错误，例如越界、除零
+	PanicRuntime PanicKind = "runtime"
+	// PanicCustom 业务代码主动 panic 的值
+	PanicCustom PanicKind = "custom"
+)
+
+// PanicError 是 recover 到的 panic 的结构化表示，会通过 c.Error 挂到请求上，
+// 使 ErrorPersister 与错误响应拿到一致的数据
+type PanicError struct {
+	Kind  PanicKind
+	Value any
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic (%s): %v", e.Kind, e.Value)
+}
+
+// Unwrap 当 panic 的值本身是 error 时允许 errors.Is/As 继续匹配
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// NewPanicError 对 recover 到的值进行分类
+func NewPanicError(recovered any, stack []byte) *PanicError {
+	return &PanicError{
+		Kind:  classifyPanic(recovered),
+		Value: recovered,
+		Stack: string(stack),
+	}
+}
+
+func classifyPanic(recovered any) PanicKind {
+	if _, ok := recovered.(*runtime.TypeAssertionError); ok {
+		return PanicAssertion
+	}
+
+	runtimeErr, ok := recovered.(runtime.Error)
+	if !ok {
+		return PanicCustom
+	}
+	if strings.Contains(runtimeErr.Error(), "nil pointer dereference") {
+		return PanicNilDereference
+	}
+	return PanicRuntime
+}
+
+// RecoverWithError 捕获 panic，转换为 *PanicError 并通过 c.Error 挂到请求上，再交给 panicHandler 生成响应。
+// 没有发生 panic 时 panicHandler 不会被调用
+func RecoverWithError(panicHandler func(context *gin.Context, err *PanicError)) gin.HandlerFunc {
+	return Recover(func(context *gin.Context, recovered any) {
+		if recovered == nil {
+			return
+		}
+
+		err := NewPanicError(recovered, debug.Stack())
+
+		// SAFETY: err 永远不为 nil
+		_ = context.Error(err).SetType(gin.ErrorTypePrivate)
+
+		panicHandler(context, err)
+	})
+}
@@ -0,0 +1,65 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func recoverValue(f func()) (recovered any) {
+	defer func() {
+		recovered = recover()
+	}()
+	f()
+	return nil
+}
+
+func TestClassifyPanic(t *testing.T) {
+	var nilMap *map[string]int
+	var value any = "text"
+	index := 3
+
+	assert.Equal(t, PanicNilDereference, classifyPanic(recoverValue(func() { _ = (*nilMap)["a"] })))
+	assert.Equal(t, PanicAssertion, classifyPanic(recoverValue(func() { _ = value.(int) })))
+	assert.Equal(t, PanicRuntime, classifyPanic(recoverValue(func() { _ = []int{}[index] })))
+	assert.Equal(t, PanicCustom, classifyPanic(recoverValue(func() { panic(errors.New("boom")) })))
+}
+
+func TestRecoverWithError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+
+	var attached []*gin.Error
+	handled := 0
+
+	engine.Use(func(c *gin.Context) {
+		c.Next()
+		attached = c.Errors
+	})
+	engine.Use(RecoverWithError(func(c *gin.Context, err *PanicError) {
+		handled++
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	engine.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	// 没有 panic 时不会调用 panicHandler
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, 0, handled)
+
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, 1, handled)
+
+	entries := errorEntries(attached)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, PanicCustom, entries[0].Panic.Kind)
+	assert.NotEmpty(t, entries[0].Panic.Stack)
+}
@@ -0,0 +1,68 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"web-clean/infra/propagation"
+)
+
+// Principal 是当前请求已认证的调用方。
+//
+// 认证中间件在验证凭据后通过 SetPrincipal 写入；处理器与服务只通过 PrincipalFrom 读取，
+// 不再各自约定 context key
+type Principal struct {
+	// Subject 是调用方的唯一标识，对用户而言为用户 ID
+	Subject string
+	Roles   []string
+	Scopes  []string
+	// Tenant 为空表示调用方不属于任何租户
+	Tenant string
+}
+
+// HasRole 判断调用方是否具备指定角色
+func (p Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// HasScope 判断调用方是否被授予指定 scope
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// principalKey 为未导出类型，避免与其他包写入 context 的值冲突
+type principalKey struct{}
+
+// WithPrincipal 返回携带调用方身份的 context
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom 从 context 中取出调用方身份，处理器可传入 c.Request.Context()，服务直接传入收到的 ctx
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// SetPrincipal 将调用方身份写入请求的 context，使其随 c.Request.Context() 传递到服务层；
+// 透传给下游的租户 ID 以已认证的租户为准，覆盖（或移除）入站请求头中的值
+func SetPrincipal(c *gin.Context, principal Principal) {
+	ctx := WithPrincipal(c.Request.Context(), principal)
+	ctx = propagation.Set(ctx, propagation.TenantHeader, principal.Tenant)
+	c.Request = c.Request.WithContext(ctx)
+}
+
+// RequirePrincipal 取出调用方身份；请求未认证时以 401 终止请求并返回 false，调用方应直接 return
+func RequirePrincipal(c *gin.Context) (Principal, bool) {
+	principal, ok := PrincipalFrom(c.Request.Context())
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error":   "unauthenticated",
+			"message": "Authentication is required",
+		})
+	}
+	return principal, ok
+}
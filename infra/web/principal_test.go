@@ -0,0 +1,74 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"web-clean/infra/propagation"
+)
+
+func TestPrincipalFrom_Empty(t *testing.T) {
+	_, ok := PrincipalFrom(context.Background())
+	assert.False(t, ok)
+}
+
+func TestPrincipal_RolesAndScopes(t *testing.T) {
+	principal := Principal{Subject: "u1", Roles: []string{"admin"}, Scopes: []string{"users:read"}}
+
+	assert.True(t, principal.HasRole("admin"))
+	assert.False(t, principal.HasRole("owner"))
+	assert.True(t, principal.HasScope("users:read"))
+	assert.False(t, principal.HasScope("users:write"))
+}
+
+func TestRequirePrincipal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		if c.GetHeader("X-Test-User") != "" {
+			SetPrincipal(c, Principal{Subject: c.GetHeader("X-Test-User"), Tenant: "acme"})
+		}
+	})
+	engine.GET("/me", func(c *gin.Context) {
+		principal, ok := RequirePrincipal(c)
+		if !ok {
+			return
+		}
+		// 服务层只拿到 context.Context，同样能读到调用方
+		fromService, _ := PrincipalFrom(c.Request.Context())
+		c.String(http.StatusOK, principal.Subject+"@"+fromService.Tenant)
+	})
+
+	anonymous := httptest.NewRecorder()
+	engine.ServeHTTP(anonymous, httptest.NewRequest(http.MethodGet, "/me", nil))
+	assert.Equal(t, http.StatusUnauthorized, anonymous.Code)
+	assert.Contains(t, anonymous.Body.String(), "unauthenticated")
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("X-Test-User", "u1")
+	authenticated := httptest.NewRecorder()
+	engine.ServeHTTP(authenticated, req)
+	assert.Equal(t, http.StatusOK, authenticated.Code)
+	assert.Equal(t, "u1@acme", authenticated.Body.String())
+}
+
+func TestSetPrincipal_OverridesPropagatedTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(PropagationMiddleware(propagation.NewAllowlist(nil)))
+	engine.GET("/", func(c *gin.Context) {
+		SetPrincipal(c, Principal{Subject: "u1", Tenant: "acme"})
+		c.String(http.StatusOK, propagation.From(c.Request.Context()).Get(propagation.TenantHeader))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(propagation.TenantHeader, "spoofed")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, "acme", w.Body.String())
+}
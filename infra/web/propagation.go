@@ -0,0 +1,19 @@
+package web
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"web-clean/infra/propagation"
+)
+
+// PropagationMiddleware 按白名单提取入站请求中需要透传的请求头（trace context、baggage、租户 ID 等），
+// 并附加本次请求的 RequestID，出站 HTTP 客户端会自动把它们写入发往下游的请求。
+// 需要注册在 RequestIDMiddleware 之后
+func PropagationMiddleware(allow propagation.Allowlist) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		ctx := propagation.Start(context.Request.Context(), allow, context.Request.Header)
+		ctx = propagation.Set(ctx, propagation.RequestIDHeader, RequestIdGetter(context))
+		context.Request = context.Request.WithContext(ctx)
+		context.Next()
+	}
+}
@@ -0,0 +1,108 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"web-clean/infra/conf"
+)
+
+// rateLimitIdle 之后未再访问的客户端令牌桶会被清理
+const rateLimitIdle = 10 * time.Minute
+
+// RateLimiter 按限流等级与客户端 IP 维护令牌桶，状态只在当前进程内有效
+type RateLimiter struct {
+	classes map[string]conf.RateLimit
+	now     func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[rateBucketKey]*rateBucket
+	lastSweep time.Time
+}
+
+type rateBucketKey struct {
+	class  string
+	client string
+}
+
+type rateBucket struct {
+	tokens float64
+	seen   time.Time
+}
+
+// NewRateLimiter 根据 web.rate_limits 创建限流器，classes 为空时 Route 不会为任何路由生成中间件
+func NewRateLimiter(classes map[string]conf.RateLimit) *RateLimiter {
+	return &RateLimiter{
+		classes: classes,
+		now:     time.Now,
+		buckets: make(map[rateBucketKey]*rateBucket),
+	}
+}
+
+// Route 是 RouteMiddleware：为声明了已配置 RateClass 的路由生成限流中间件，超出速率时返回 429
+func (l *RateLimiter) Route(route Route) gin.HandlerFunc {
+	limit, ok := l.classes[route.RateClass]
+	if route.RateClass == "" || !ok {
+		return nil
+	}
+	if limit.Burst <= 0 {
+		limit.Burst = limit.PerMinute
+	}
+
+	return func(c *gin.Context) {
+		allowed, retryAfter := l.take(route.RateClass, c.ClientIP(), limit)
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds()+0.999)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":   "rate_limited",
+				"message": "Too many requests, retry later",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// take 从客户端的令牌桶中取出一个令牌，不足时返回需要等待的时间
+func (l *RateLimiter) take(class, client string, limit conf.RateLimit) (bool, time.Duration) {
+	now := l.now()
+	perSecond := float64(limit.PerMinute) / 60
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	key := rateBucketKey{class: class, client: client}
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &rateBucket{tokens: float64(limit.Burst), seen: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = min(float64(limit.Burst), bucket.tokens+now.Sub(bucket.seen).Seconds()*perSecond)
+	bucket.seen = now
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / perSecond * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// sweep 定期清理长时间未访问的令牌桶，调用方需持有锁
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitIdle {
+		return
+	}
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.seen) >= rateLimitIdle {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,88 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"web-clean/infra/conf"
+)
+
+func newRateLimitedEngine(limiter *RateLimiter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	Register(engine, []RouteMiddleware{limiter.Route},
+		Route{Method: http.MethodPost, Path: "/users", RateClass: "signup", Handler: func(c *gin.Context) {
+			c.Status(http.StatusCreated)
+		}},
+		Route{Method: http.MethodGet, Path: "/users", RateClass: "read", Handler: func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		}},
+	)
+	return engine
+}
+
+func requestFrom(engine *gin.Engine, method, addr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/users", nil)
+	req.RemoteAddr = addr
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimiter_LimitsPerClassAndClient(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(map[string]conf.RateLimit{"signup": {PerMinute: 2}})
+	limiter.now = func() time.Time { return now }
+	engine := newRateLimitedEngine(limiter)
+
+	assert.Equal(t, http.StatusCreated, requestFrom(engine, http.MethodPost, "10.0.0.1:1000").Code)
+	assert.Equal(t, http.StatusCreated, requestFrom(engine, http.MethodPost, "10.0.0.1:1000").Code)
+
+	limited := requestFrom(engine, http.MethodPost, "10.0.0.1:1000")
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "30", limited.Header().Get("Retry-After"))
+	assert.Contains(t, limited.Body.String(), "rate_limited")
+
+	// Other clients and unconfigured classes are unaffected
+	assert.Equal(t, http.StatusCreated, requestFrom(engine, http.MethodPost, "10.0.0.2:1000").Code)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, requestFrom(engine, http.MethodGet, "10.0.0.1:1000").Code)
+	}
+
+	// Tokens refill over time
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, http.StatusCreated, requestFrom(engine, http.MethodPost, "10.0.0.1:1000").Code)
+	assert.Equal(t, http.StatusTooManyRequests, requestFrom(engine, http.MethodPost, "10.0.0.1:1000").Code)
+}
+
+func TestRateLimiter_Burst(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(map[string]conf.RateLimit{"read": {PerMinute: 60, Burst: 3}})
+	limiter.now = func() time.Time { return now }
+	engine := newRateLimitedEngine(limiter)
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, requestFrom(engine, http.MethodGet, "10.0.0.1:1000").Code)
+	}
+	limited := requestFrom(engine, http.MethodGet, "10.0.0.1:1000")
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "1", limited.Header().Get("Retry-After"))
+}
+
+func TestRateLimiter_SweepsIdleBuckets(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(map[string]conf.RateLimit{"read": {PerMinute: 60}})
+	limiter.now = func() time.Time { return now }
+	engine := newRateLimitedEngine(limiter)
+
+	requestFrom(engine, http.MethodGet, "10.0.0.1:1000")
+	now = now.Add(rateLimitIdle)
+	requestFrom(engine, http.MethodGet, "10.0.0.2:1000")
+
+	assert.Len(t, limiter.buckets, 1)
+}
@@ -0,0 +1,22 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadOnlyMiddleware 在只读模式（数据库指向只读副本）下拒绝所有写请求，只放行 GET、HEAD 与 OPTIONS
+func ReadOnlyMiddleware() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		switch context.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			context.Next()
+		default:
+			context.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "read_only_mode",
+				"message": "The service is in read-only mode, writes are temporarily unavailable",
+			})
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOnlyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(ReadOnlyMiddleware())
+	engine.GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.POST("/users", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	get := httptest.NewRecorder()
+	engine.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Equal(t, http.StatusOK, get.Code)
+
+	post := httptest.NewRecorder()
+	engine.ServeHTTP(post, httptest.NewRequest(http.MethodPost, "/users", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, post.Code)
+	assert.Contains(t, post.Body.String(), "read_only_mode")
+}
@@ -0,0 +1,346 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"web-clean/domain"
+)
+
+const (
+	// DefaultRecordingWindow 是未指定时长时录制规则的有效期
+	DefaultRecordingWindow = 15 * time.Minute
+	// MaxRecordingWindow 是录制规则有效期的上限，避免调试模式被遗忘后长期录制
+	MaxRecordingWindow = 24 * time.Hour
+	// MaxRecordedBody 是每个请求体或响应体最多保存的字节数，超出部分被截断
+	MaxRecordedBody = 64 << 10
+)
+
+var (
+	ErrInvalidRecordingRule = errors.New("invalid recording rule")
+	ErrRecordingRuleUnknown = errors.New("recording rule not found")
+)
+
+// RecordingRule 指定在一段时间内录制哪些请求：调用方或目标用户为 UserID 的请求，
+// 或 RequestID 匹配 RequestIDPattern 的请求，两者都设置时满足其一即可
+type RecordingRule struct {
+	ID               string    `json:"id"`
+	UserID           string    `json:"user_id,omitempty"`
+	RequestIDPattern string    `json:"request_id_pattern,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	ExpiresAt        time.Time `json:"expires_at"`
+
+	pattern *regexp.Regexp
+}
+
+// Recording 是一次被录制的请求与响应，头、URL 与 JSON 体均已脱敏
+type Recording struct {
+	RuleID          string              `json:"rule_id"`
+	RequestID       string              `json:"request_id"`
+	UserID          string              `json:"user_id,omitempty"`
+	Method          string              `json:"method"`
+	URL             string              `json:"url"`
+	Status          int                 `json:"status"`
+	Duration        time.Duration       `json:"duration"`
+	RequestHeaders  map[string][]string `json:"request_headers"`
+	RequestBody     string              `json:"request_body,omitempty"`
+	ResponseHeaders map[string][]string `json:"response_headers"`
+	ResponseBody    string              `json:"response_body,omitempty"`
+	// Truncated 表示请求体或响应体超过 MaxRecordedBody 被截断
+	Truncated  bool      `json:"truncated,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// RecordingQuery 筛选已保存的录制，RuleID 为空表示所有规则
+type RecordingQuery struct {
+	RuleID string
+	Limit  int
+}
+
+// RecordingStore 是录制的存储端口，由 repository 包实现
+type RecordingStore interface {
+	Save(ctx context.Context, recording Recording) error
+	List(ctx context.Context, query RecordingQuery) ([]Recording, error)
+	// DeleteBefore 删除 before 之前录制的记录，返回删除的条数
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Recorder 按管理员创建的规则录制完整的请求与响应，用于排查难以复现的客户端问题。
+//
+// 没有生效的规则时中间件直接放行，不读取请求体也不包装响应；规则只保存在当前实例的内存中，重启后失效
+type Recorder struct {
+	store    RecordingStore
+	redactor *Redactor
+	log      domain.Log
+	now      func() time.Time
+
+	mu    sync.RWMutex
+	rules map[string]*RecordingRule
+}
+
+// NewRecorder 创建录制器，redactor 为 nil 时不脱敏
+func NewRecorder(store RecordingStore, redactor *Redactor, log domain.Log) *Recorder {
+	return &Recorder{
+		store:    store,
+		redactor: redactor,
+		log:      log,
+		now:      time.Now,
+		rules:    make(map[string]*RecordingRule),
+	}
+}
+
+// Start 创建一条录制规则，window 为 0 时使用 DefaultRecordingWindow
+func (r *Recorder) Start(userID, requestIDPattern string, window time.Duration) (RecordingRule, error) {
+	if userID == "" && requestIDPattern == "" {
+		return RecordingRule{}, fmt.Errorf("%w: user_id or request_id_pattern is required", ErrInvalidRecordingRule)
+	}
+	if window == 0 {
+		window = DefaultRecordingWindow
+	}
+	if window < 0 || window > MaxRecordingWindow {
+		return RecordingRule{}, fmt.Errorf("%w: duration must be between 0 and %s", ErrInvalidRecordingRule, MaxRecordingWindow)
+	}
+
+	var pattern *regexp.Regexp
+	if requestIDPattern != "" {
+		compiled, err := regexp.Compile(requestIDPattern)
+		if err != nil {
+			return RecordingRule{}, fmt.Errorf("%w: request_id_pattern: %v", ErrInvalidRecordingRule, err)
+		}
+		pattern = compiled
+	}
+
+	now := r.now()
+	rule := &RecordingRule{
+		ID:               uuid.NewString(),
+		UserID:           userID,
+		RequestIDPattern: requestIDPattern,
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(window),
+		pattern:          pattern,
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune(now)
+	r.rules[rule.ID] = rule
+	return *rule, nil
+}
+
+// Stop 提前结束一条录制规则，已保存的录制不受影响
+func (r *Recorder) Stop(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.rules[id]; !ok {
+		return ErrRecordingRuleUnknown
+	}
+	delete(r.rules, id)
+	return nil
+}
+
+// Rules 返回仍在有效期内的规则，按创建时间排序
+func (r *Recorder) Rules() []RecordingRule {
+	now := r.now()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rules := make([]RecordingRule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		if now.Before(rule.ExpiresAt) {
+			rules = append(rules, *rule)
+		}
+	}
+	slices.SortFunc(rules, func(a, b RecordingRule) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return rules
+}
+
+// prune 删除已过期的规则，调用方需持有写锁
+func (r *Recorder) prune(now time.Time) {
+	for id, rule := range r.rules {
+		if !now.Before(rule.ExpiresAt) {
+			delete(r.rules, id)
+		}
+	}
+}
+
+// active 返回当前仍有效的规则
+func (r *Recorder) active() []*RecordingRule {
+	now := r.now()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var rules []*RecordingRule
+	for _, rule := range r.rules {
+		if now.Before(rule.ExpiresAt) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// matchRecordingRule 返回第一条命中请求的规则与录制中记下的用户 ID。调用方身份只能在处理链结束后读取，
+// 因此在请求结束时判断；目标用户取自路由参数 :id，使未认证的用户接口也能按用户录制
+func matchRecordingRule(rules []*RecordingRule, c *gin.Context) (*RecordingRule, string) {
+	var caller string
+	if principal, ok := PrincipalFrom(c.Request.Context()); ok {
+		caller = principal.Subject
+	}
+	target := c.Param("id")
+	requestID := RequestIdGetter(c)
+
+	for _, rule := range rules {
+		if rule.UserID != "" && (rule.UserID == caller || rule.UserID == target) {
+			return rule, rule.UserID
+		}
+		if rule.pattern != nil && rule.pattern.MatchString(requestID) {
+			return rule, caller
+		}
+	}
+	return nil, ""
+}
+
+// Middleware 在有生效规则时缓存请求体并复制响应，请求结束后把命中规则的请求与响应写入存储
+func (r *Recorder) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rules := r.active()
+		if len(rules) == 0 {
+			c.Next()
+			return
+		}
+
+		start := r.now()
+		requestBody, requestTruncated := r.captureRequestBody(c)
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		rule, userID := matchRecordingRule(rules, c)
+		if rule == nil {
+			return
+		}
+
+		responseBody := writer.body.Bytes()
+		recording := Recording{
+			RuleID:          rule.ID,
+			RequestID:       RequestIdGetter(c),
+			UserID:          userID,
+			Method:          c.Request.Method,
+			URL:             r.redactor.URL(c.Request.URL.String()),
+			Status:          writer.Status(),
+			Duration:        r.now().Sub(start),
+			RequestHeaders:  r.headers(c.Request.Header),
+			RequestBody:     r.body(requestBody),
+			ResponseHeaders: r.headers(writer.Header()),
+			ResponseBody:    r.body(responseBody),
+			Truncated:       requestTruncated || writer.truncated,
+			RecordedAt:      start,
+		}
+
+		// 响应已经写出，客户端断开不应使录制丢失
+		if err := r.store.Save(context.WithoutCancel(c.Request.Context()), recording); err != nil {
+			r.log.Errorw("保存请求录制失败", "rule_id", rule.ID, "request_id", recording.RequestID, "error", err)
+		}
+	}
+}
+
+// captureRequestBody 读取至多 MaxRecordedBody 字节的请求体，并把完整的请求体还给后续处理函数
+func (r *Recorder) captureRequestBody(c *gin.Context) ([]byte, bool) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil, false
+	}
+
+	head, err := io.ReadAll(io.LimitReader(c.Request.Body, MaxRecordedBody+1))
+	// 已读取的部分与尚未读取的剩余部分拼接，处理函数读到的请求体与原来一致
+	c.Request.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(head), c.Request.Body), Closer: c.Request.Body}
+	if err != nil {
+		return nil, false
+	}
+
+	if len(head) > MaxRecordedBody {
+		return head[:MaxRecordedBody], true
+	}
+	return head, false
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// headers 复制头并脱敏 Authorization、Cookie 等敏感头
+func (r *Recorder) headers(header http.Header) map[string][]string {
+	copied := make(map[string][]string, len(header))
+	for name, values := range header {
+		if r.redactor.Sensitive(name) {
+			copied[name] = []string{Redacted}
+			continue
+		}
+		copied[name] = slices.Clone(values)
+	}
+	return copied
+}
+
+// body 脱敏 JSON 体中的敏感字段；无法解析的 JSON 体（通常是被截断的）整体替换为 Redacted，
+// 其余非 JSON 体原样保存
+func (r *Recorder) body(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			return Redacted
+		}
+		return string(data)
+	}
+	redacted, err := json.Marshal(r.redactor.Value(generic))
+	if err != nil {
+		return Redacted
+	}
+	return string(redacted)
+}
+
+// recordingWriter 在写出响应的同时保留至多 MaxRecordedBody 字节的响应体
+type recordingWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *recordingWriter) capture(data []byte) {
+	remaining := MaxRecordedBody - w.body.Len()
+	if len(data) > remaining {
+		data = data[:remaining]
+		w.truncated = true
+	}
+	w.body.Write(data)
+}
@@ -0,0 +1,154 @@
+package web
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type memoryRecordings struct {
+	mu         sync.Mutex
+	recordings []Recording
+}
+
+func (m *memoryRecordings) Save(_ context.Context, recording Recording) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.recordings = append(m.recordings, recording)
+	return nil
+}
+
+func (m *memoryRecordings) List(context.Context, RecordingQuery) ([]Recording, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Recording(nil), m.recordings...), nil
+}
+
+func (m *memoryRecordings) DeleteBefore(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func newRecordingEngine(recorder *Recorder) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(RequestIDMiddleware(func() string { return "req-1" }))
+	engine.Use(recorder.Middleware())
+	engine.PUT("/users/:id", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.JSON(http.StatusOK, gin.H{"received": string(body), "token": "abc"})
+	})
+	return engine
+}
+
+func TestRecorder_Start(t *testing.T) {
+	recorder := NewRecorder(&memoryRecordings{}, nil, zap.NewNop().Sugar())
+
+	_, err := recorder.Start("", "", 0)
+	assert.ErrorIs(t, err, ErrInvalidRecordingRule)
+
+	_, err = recorder.Start("user-1", "", MaxRecordingWindow+time.Minute)
+	assert.ErrorIs(t, err, ErrInvalidRecordingRule)
+
+	_, err = recorder.Start("", "([", 0)
+	assert.ErrorIs(t, err, ErrInvalidRecordingRule)
+
+	rule, err := recorder.Start("user-1", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRecordingWindow, rule.ExpiresAt.Sub(rule.CreatedAt))
+	assert.Equal(t, []RecordingRule{rule}, recorder.Rules())
+
+	require.NoError(t, recorder.Stop(rule.ID))
+	assert.Empty(t, recorder.Rules())
+	assert.ErrorIs(t, recorder.Stop(rule.ID), ErrRecordingRuleUnknown)
+}
+
+func TestRecorder_Middleware_RecordsMatchingUser(t *testing.T) {
+	store := &memoryRecordings{}
+	recorder := NewRecorder(store, NewRedactor(DefaultRedactedFields...), zap.NewNop().Sugar())
+	engine := newRecordingEngine(recorder)
+
+	rule, err := recorder.Start("user-1", "", time.Minute)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPut, "/users/user-1?token=secret", strings.NewReader(`{"name":"Ada","password":"hunter22"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	// The handler still sees the full, unredacted body
+	assert.Contains(t, w.Body.String(), `hunter22`)
+
+	other := httptest.NewRecorder()
+	engine.ServeHTTP(other, httptest.NewRequest(http.MethodPut, "/users/user-2", strings.NewReader(`{}`)))
+
+	require.Len(t, store.recordings, 1)
+	recording := store.recordings[0]
+	assert.Equal(t, rule.ID, recording.RuleID)
+	assert.Equal(t, "req-1", recording.RequestID)
+	assert.Equal(t, "user-1", recording.UserID)
+	assert.Equal(t, http.StatusOK, recording.Status)
+	assert.Equal(t, "/users/user-1?token=%5BREDACTED%5D", recording.URL)
+	assert.Equal(t, []string{Redacted}, recording.RequestHeaders["Authorization"])
+	assert.JSONEq(t, `{"name":"Ada","password":"[REDACTED]"}`, recording.RequestBody)
+	assert.Contains(t, recording.ResponseBody, `"token":"[REDACTED]"`)
+	assert.False(t, recording.Truncated)
+}
+
+func TestRecorder_Middleware_RecordsRequestIDPattern(t *testing.T) {
+	store := &memoryRecordings{}
+	recorder := NewRecorder(store, nil, zap.NewNop().Sugar())
+	engine := newRecordingEngine(recorder)
+
+	_, err := recorder.Start("", "^req-", time.Minute)
+	require.NoError(t, err)
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/users/user-2", strings.NewReader(`{}`)))
+
+	require.Len(t, store.recordings, 1)
+	assert.Empty(t, store.recordings[0].UserID)
+}
+
+func TestRecorder_Middleware_SkipsExpiredRules(t *testing.T) {
+	store := &memoryRecordings{}
+	recorder := NewRecorder(store, nil, zap.NewNop().Sugar())
+	engine := newRecordingEngine(recorder)
+
+	_, err := recorder.Start("user-1", "", time.Minute)
+	require.NoError(t, err)
+	recorder.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/users/user-1", strings.NewReader(`{}`)))
+
+	assert.Empty(t, store.recordings)
+	assert.Empty(t, recorder.Rules())
+}
+
+func TestRecorder_Middleware_TruncatesLargeBodies(t *testing.T) {
+	store := &memoryRecordings{}
+	recorder := NewRecorder(store, nil, zap.NewNop().Sugar())
+	engine := newRecordingEngine(recorder)
+
+	_, err := recorder.Start("user-1", "", time.Minute)
+	require.NoError(t, err)
+
+	body := `{"name":"` + strings.Repeat("a", MaxRecordedBody) + `"}`
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/users/user-1", strings.NewReader(body)))
+
+	// The handler reads the whole body even though only part of it is recorded
+	assert.Contains(t, w.Body.String(), strings.Repeat("a", MaxRecordedBody))
+	require.Len(t, store.recordings, 1)
+	assert.True(t, store.recordings[0].Truncated)
+	// A truncated JSON body cannot be redacted field by field
+	assert.Equal(t, Redacted, store.recordings[0].RequestBody)
+}
@@ -0,0 +1,159 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"web-clean/infra/conf"
+)
+
+// Redacted 替换敏感字段的值
+const Redacted = "[REDACTED]"
+
+// DefaultRedactedFields 是默认脱敏的字段名，匹配时忽略大小写以及 - 与 _，因此 Authorization、api-key 与 API_KEY 都会命中
+var DefaultRedactedFields = []string{
+	"password", "passwd", "current_password", "new_password", "password_hash",
+	"secret", "token", "access_token", "refresh_token", "id_token",
+	"api_key", "authorization", "cookie", "set_cookie", "captcha_token",
+}
+
+// Redactor 在日志输出与持久化之前，将敏感字段的值替换为 Redacted。
+//
+// 它作用于 Infow 等方法的键值对、map 与结构体（按 json 标签）以及 URL 查询参数。
+// nil 的 Redactor 不做任何处理
+type Redactor struct {
+	fields map[string]bool
+}
+
+// NewRedactor 创建一个按字段名脱敏的 Redactor
+func NewRedactor(fields ...string) *Redactor {
+	r := &Redactor{fields: make(map[string]bool, len(fields))}
+	for _, field := range fields {
+		r.fields[normalizeField(field)] = true
+	}
+	return r
+}
+
+// RedactorFrom 使用默认字段加上 logger.redact_fields 中配置的字段
+func RedactorFrom(config *conf.Logger) *Redactor {
+	fields := append([]string{}, DefaultRedactedFields...)
+	if config != nil {
+		fields = append(fields, config.RedactFields...)
+	}
+	return NewRedactor(fields...)
+}
+
+func normalizeField(field string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(strings.TrimSpace(field)))
+}
+
+// Sensitive 判断字段名是否需要脱敏
+func (r *Redactor) Sensitive(field string) bool {
+	return r != nil && r.fields[normalizeField(field)]
+}
+
+// KeysAndValues 返回脱敏后的键值对副本，键命中时替换值，其余值按 Value 递归处理
+func (r *Redactor) KeysAndValues(keysAndValues []interface{}) []interface{} {
+	if r == nil || len(keysAndValues) == 0 {
+		return keysAndValues
+	}
+
+	redacted := make([]interface{}, len(keysAndValues))
+	for i := 0; i < len(keysAndValues); i++ {
+		if i%2 == 0 && i+1 < len(keysAndValues) {
+			if key, ok := keysAndValues[i].(string); ok && r.Sensitive(key) {
+				redacted[i] = key
+				redacted[i+1] = Redacted
+				i++
+				continue
+			}
+		}
+		redacted[i] = r.Value(keysAndValues[i])
+	}
+	return redacted
+}
+
+// Values 返回逐个脱敏后的参数副本
+func (r *Redactor) Values(args []interface{}) []interface{} {
+	if r == nil || len(args) == 0 {
+		return args
+	}
+
+	redacted := make([]interface{}, len(args))
+	for i, arg := range args {
+		redacted[i] = r.Value(arg)
+	}
+	return redacted
+}
+
+// Value 递归处理 map、切片与结构体。结构体先按 json 标签转换为 map，因此脱敏后的值类型可能改变；
+// error、fmt.Stringer 与基础类型原样返回
+func (r *Redactor) Value(value interface{}) interface{} {
+	if r == nil || value == nil {
+		return value
+	}
+
+	switch v := value.(type) {
+	case string, bool, int, int64, float64, error, fmt.Stringer, []byte:
+		return value
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if r.Sensitive(key) {
+				redacted[key] = Redacted
+			} else {
+				redacted[key] = r.Value(item)
+			}
+		}
+		return redacted
+	case []interface{}:
+		return r.Values(v)
+	}
+
+	kind := reflect.Indirect(reflect.ValueOf(value)).Kind()
+	if kind != reflect.Map && kind != reflect.Struct && kind != reflect.Slice && kind != reflect.Array {
+		return value
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return value
+	}
+	return r.Value(generic)
+}
+
+// URL 将敏感查询参数的值替换为 Redacted，无法解析的 URL 原样返回
+func (r *Redactor) URL(raw string) string {
+	if r == nil {
+		return raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.RawQuery == "" {
+		return raw
+	}
+
+	query := u.Query()
+	changed := false
+	for key, values := range query {
+		if r.Sensitive(key) {
+			for i := range values {
+				values[i] = Redacted
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return raw
+	}
+
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,67 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"web-clean/infra/conf"
+)
+
+func TestRedactor_KeysAndValues(t *testing.T) {
+	r := RedactorFrom(&conf.Logger{RedactFields: []string{"ssn"}})
+
+	redacted := r.KeysAndValues([]interface{}{
+		"email", "ann@example.com",
+		"Password", "hunter2",
+		"SSN", "123-45-6789",
+		"headers", http.Header{"Authorization": {"Bearer abc"}, "Accept": {"application/json"}},
+	})
+
+	assert.Equal(t, []interface{}{
+		"email", "ann@example.com",
+		"Password", Redacted,
+		"SSN", Redacted,
+		"headers", map[string]interface{}{"Authorization": Redacted, "Accept": []interface{}{"application/json"}},
+	}, redacted)
+}
+
+func TestRedactor_Struct(t *testing.T) {
+	type signup struct {
+		Email        string `json:"email"`
+		Password     string `json:"password"`
+		CaptchaToken string `json:"captcha-token"`
+	}
+
+	redacted := RedactorFrom(nil).Value(signup{Email: "ann@example.com", Password: "hunter2", CaptchaToken: "t"})
+
+	assert.Equal(t, map[string]interface{}{"email": "ann@example.com", "password": Redacted, "captcha-token": Redacted}, redacted)
+}
+
+func TestRedactor_URL(t *testing.T) {
+	r := RedactorFrom(nil)
+
+	assert.Equal(t, "/api/v1/users?access_token=%5BREDACTED%5D&limit=10", r.URL("/api/v1/users?limit=10&access_token=abc"))
+	assert.Equal(t, "/api/v1/users?limit=10", r.URL("/api/v1/users?limit=10"))
+}
+
+func TestRedactor_Nil(t *testing.T) {
+	var r *Redactor
+	kv := []interface{}{"password", "hunter2"}
+
+	assert.Equal(t, kv, r.KeysAndValues(kv))
+	assert.Equal(t, "/a?token=x", r.URL("/a?token=x"))
+}
+
+func TestWebLog_RedactsPersistedLogs(t *testing.T) {
+	w := &webLog{inner: zap.NewNop().Sugar(), redactor: RedactorFrom(nil)}
+
+	w.Infow("signup", "password", "hunter2", "username", "ann")
+
+	var persisted []interface{}
+	assert.NoError(t, json.Unmarshal([]byte(w.logs[0].Msg), &persisted))
+	assert.Equal(t, []interface{}{"signup", []interface{}{"password", Redacted, "username", "ann"}}, persisted)
+}
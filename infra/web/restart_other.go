@@ -0,0 +1,16 @@
+//go:build !unix
+
+package web
+
+import (
+	"errors"
+	"net"
+	"os"
+)
+
+// restartSignals 非 unix 平台不支持热重启
+var restartSignals []os.Signal
+
+func handoff(net.Listener) (int, error) {
+	return 0, errors.New("当前平台不支持热重启")
+}
@@ -0,0 +1,54 @@
+//go:build unix
+
+package web
+
+import (
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// restartSignals 触发热重启的信号
+var restartSignals = []os.Signal{syscall.SIGUSR2}
+
+// handoff 启动当前二进制的新进程并把监听套接字交给它。
+//
+// 新进程通过 fd 3 继承同一个套接字，内核中的 accept 队列是共享的，
+// 因此在新进程开始 Serve 之前到达的连接只会排队而不会被拒绝。
+func handoff(listener net.Listener) (int, error) {
+	fileListener, ok := listener.(interface{ File() (*os.File, error) })
+	if !ok {
+		return 0, errors.New("监听套接字不支持导出文件描述符")
+	}
+
+	f, err := fileListener.File()
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), inheritedListenerEnv+"=1")
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{f}
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+
+	// 新进程仍在使用该 socket 文件，当前进程关闭监听时不能删除它
+	if unixListener, ok := listener.(*net.UnixListener); ok {
+		unixListener.SetUnlinkOnClose(false)
+	}
+
+	return cmd.Process.Pid, nil
+}
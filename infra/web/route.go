@@ -0,0 +1,130 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Route 是一个声明式的路由：处理函数与它的缓存、权限与限流要求写在一起，
+// 由 Register 交给各个 RouteMiddleware 解释，而不是在注册处按位置拼接中间件
+type Route struct {
+	Method  string
+	Path    string
+	Handler gin.HandlerFunc
+
+	// CacheTTL 大于 0 时，成功的 GET 响应带上 Cache-Control: private, max-age=CacheTTL
+	CacheTTL time.Duration
+	// StaleWhileRevalidate 大于 0 时，缓存过期后的这段时间内客户端与代理可以先返回旧响应、在后台重新验证，
+	// 即 Cache-Control 的 stale-while-revalidate 扩展，仅在 CacheTTL 生效时有意义
+	StaleWhileRevalidate time.Duration
+	// Scope 非空时要求调用方已认证并被授予该 scope，否则返回 401 或 403
+	Scope string
+	// RateClass 是限流等级，对应 web.rate_limits 中的配置，为空或未配置时不限流
+	RateClass string
+	// StrictJSON 为 true 时，BindJSON 拒绝请求体中请求类型未声明的字段并列出它们，
+	// 让 user_name 这类拼写错误返回 400 而不是被静默忽略
+	StrictJSON bool
+
+	// Middleware 是只作用于该路由的其他中间件，例如幂等与验证码，在元数据中间件之后、处理函数之前执行
+	Middleware []gin.HandlerFunc
+}
+
+func (r Route) String() string {
+	return r.Method + " " + r.Path
+}
+
+// RouteMiddleware 根据路由元数据构造中间件，路由不需要时返回 nil
+type RouteMiddleware func(route Route) gin.HandlerFunc
+
+// Register 按声明注册路由。每个路由的处理链为：middlewares 按顺序生成的中间件、Route.Middleware、Handler
+func Register(router gin.IRoutes, middlewares []RouteMiddleware, routes ...Route) {
+	for _, route := range routes {
+		if route.Handler == nil {
+			panic(fmt.Sprintf("route %s has no handler", route))
+		}
+
+		handlers := make([]gin.HandlerFunc, 0, len(middlewares)+len(route.Middleware)+1)
+		for _, middleware := range middlewares {
+			if handler := middleware(route); handler != nil {
+				handlers = append(handlers, handler)
+			}
+		}
+		handlers = append(handlers, route.Middleware...)
+		handlers = append(handlers, route.Handler)
+
+		router.Handle(route.Method, route.Path, handlers...)
+	}
+}
+
+// CacheControl 为声明了 CacheTTL 的 GET 路由设置 Cache-Control，只有 2xx 响应可以缓存，其余为 no-store
+func CacheControl(route Route) gin.HandlerFunc {
+	if route.CacheTTL <= 0 || route.Method != http.MethodGet {
+		return nil
+	}
+
+	value := fmt.Sprintf("private, max-age=%d", int(route.CacheTTL.Seconds()))
+	if route.StaleWhileRevalidate > 0 {
+		value += fmt.Sprintf(", stale-while-revalidate=%d", int(route.StaleWhileRevalidate.Seconds()))
+	}
+	return func(c *gin.Context) {
+		c.Writer = &cacheControlWriter{ResponseWriter: c.Writer, value: value}
+		c.Next()
+	}
+}
+
+// cacheControlWriter 在状态码确定时写入 Cache-Control，此时响应头尚未发出
+type cacheControlWriter struct {
+	gin.ResponseWriter
+	value string
+}
+
+func (w *cacheControlWriter) WriteHeader(code int) {
+	w.setCacheControl(code)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *cacheControlWriter) Write(data []byte) (int, error) {
+	w.setCacheControl(w.Status())
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *cacheControlWriter) WriteString(s string) (int, error) {
+	w.setCacheControl(w.Status())
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *cacheControlWriter) setCacheControl(code int) {
+	if w.Written() {
+		return
+	}
+	if code >= 200 && code <= 299 {
+		w.Header().Set("Cache-Control", w.value)
+	} else {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+}
+
+// RequireScope 为声明了 Scope 的路由检查调用方权限，调用方身份由认证中间件通过 SetPrincipal 写入
+func RequireScope(route Route) gin.HandlerFunc {
+	if route.Scope == "" {
+		return nil
+	}
+
+	return func(c *gin.Context) {
+		principal, ok := RequirePrincipal(c)
+		if !ok {
+			return
+		}
+		if !principal.HasScope(route.Scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   "forbidden",
+				"message": fmt.Sprintf("The %q scope is required", route.Scope),
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,120 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister_AppliesMiddlewaresInOrder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+
+	var order []string
+	tag := func(name string) RouteMiddleware {
+		return func(route Route) gin.HandlerFunc {
+			if route.RateClass == "" {
+				return nil
+			}
+			return func(c *gin.Context) {
+				order = append(order, name+":"+route.String())
+				c.Next()
+			}
+		}
+	}
+
+	Register(engine, []RouteMiddleware{tag("first"), tag("second")},
+		Route{Method: http.MethodGet, Path: "/limited", RateClass: "read", Middleware: []gin.HandlerFunc{func(c *gin.Context) {
+			order = append(order, "route")
+		}}, Handler: func(c *gin.Context) {
+			order = append(order, "handler")
+		}},
+		Route{Method: http.MethodGet, Path: "/plain", Handler: func(c *gin.Context) {
+			order = append(order, "plain")
+		}},
+	)
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/limited", nil))
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/plain", nil))
+
+	assert.Equal(t, []string{"first:GET /limited", "second:GET /limited", "route", "handler", "plain"}, order)
+}
+
+func TestRegister_PanicsWithoutHandler(t *testing.T) {
+	assert.PanicsWithValue(t, "route GET /users has no handler", func() {
+		Register(gin.New(), nil, Route{Method: http.MethodGet, Path: "/users"})
+	})
+}
+
+func TestCacheControl(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	Register(engine, []RouteMiddleware{CacheControl},
+		Route{Method: http.MethodGet, Path: "/docs", CacheTTL: time.Hour, Handler: func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"ok": true})
+		}},
+		Route{Method: http.MethodGet, Path: "/missing", CacheTTL: time.Hour, Handler: func(c *gin.Context) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not_found"})
+		}},
+		Route{Method: http.MethodGet, Path: "/empty", CacheTTL: time.Minute, Handler: func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		}},
+		Route{Method: http.MethodPost, Path: "/docs", CacheTTL: time.Hour, Handler: func(c *gin.Context) {
+			c.Status(http.StatusCreated)
+		}},
+		Route{Method: http.MethodGet, Path: "/stale", CacheTTL: time.Minute, StaleWhileRevalidate: 10 * time.Minute, Handler: func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"ok": true})
+		}},
+	)
+
+	serve := func(method, path string) string {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w.Header().Get("Cache-Control")
+	}
+
+	assert.Equal(t, "private, max-age=3600", serve(http.MethodGet, "/docs"))
+	assert.Equal(t, "no-store", serve(http.MethodGet, "/missing"))
+	assert.Equal(t, "private, max-age=60", serve(http.MethodGet, "/empty"))
+	assert.Empty(t, serve(http.MethodPost, "/docs"), "only GET routes are cacheable")
+	assert.Equal(t, "private, max-age=60, stale-while-revalidate=600", serve(http.MethodGet, "/stale"))
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authenticate := func(c *gin.Context) {
+		if subject := c.GetHeader("X-Subject"); subject != "" {
+			SetPrincipal(c, Principal{Subject: subject, Scopes: []string{c.GetHeader("X-Scope")}})
+		}
+		c.Next()
+	}
+
+	engine := gin.New()
+	engine.Use(authenticate)
+	Register(engine, []RouteMiddleware{RequireScope},
+		Route{Method: http.MethodGet, Path: "/export", Scope: "users:export", Handler: func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		}},
+		Route{Method: http.MethodGet, Path: "/public", Handler: func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		}},
+	)
+
+	serve := func(path, subject, scope string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Subject", subject)
+		req.Header.Set("X-Scope", scope)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve("/export", "", ""))
+	assert.Equal(t, http.StatusForbidden, serve("/export", "alice", "users:read"))
+	assert.Equal(t, http.StatusOK, serve("/export", "alice", "users:export"))
+	assert.Equal(t, http.StatusOK, serve("/public", "", ""))
+}
@@ -0,0 +1,77 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"web-clean/domain"
+	"web-clean/infra/captcha"
+	"web-clean/infra/risk"
+)
+
+// maxSignupBodyBytes 限制为读取邮箱而缓冲的请求体大小
+const maxSignupBodyBytes = 64 * 1024
+
+// SignupRiskMiddleware 对注册请求打分：低风险直接放行，中风险要求 CAPTCHA，高风险返回 403。
+// 请求体会被读取以获得邮箱，之后原样交还给后续处理器；批量注册（{"users": [...]}）按其中每个不同的邮箱打分，
+// 取最严格的结果，避免一次请求绕过打分创建大量账号
+func SignupRiskMiddleware(assessor *risk.Assessor, verifier captcha.Verifier, log domain.Log) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		body, err := io.ReadAll(io.LimitReader(context.Request.Body, maxSignupBodyBytes))
+		if err != nil {
+			context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "message": err.Error()})
+			return
+		}
+		context.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), context.Request.Body))
+
+		// 请求体格式错误时邮箱为空，交由处理器返回校验错误
+		var signup struct {
+			Email string `json:"email"`
+			Users []struct {
+				Email string `json:"email"`
+			} `json:"users"`
+		}
+		_ = json.Unmarshal(body, &signup)
+
+		emails := []string{signup.Email}
+		if len(signup.Users) > 0 {
+			emails = emails[:0]
+			for _, user := range signup.Users {
+				if !slices.Contains(emails, user.Email) {
+					emails = append(emails, user.Email)
+				}
+			}
+		}
+
+		decision := risk.Allow
+		for _, email := range emails {
+			assessment := assessor.Assess(context.Request.Context(), risk.Signup{Email: email, IP: context.ClientIP()})
+			if assessment.Decision == risk.Reject {
+				decision = risk.Reject
+				break
+			}
+			if assessment.Decision == risk.Challenge {
+				decision = risk.Challenge
+			}
+		}
+
+		switch decision {
+		case risk.Reject:
+			context.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   "signup_rejected",
+				"message": "This signup cannot be completed",
+			})
+		case risk.Challenge:
+			if verifyCaptcha(context, verifier, log) {
+				context.Next()
+			}
+		default:
+			context.Next()
+		}
+	}
+}
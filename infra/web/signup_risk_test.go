@@ -0,0 +1,93 @@
+package web
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"web-clean/infra/captcha"
+	"web-clean/infra/risk"
+)
+
+type tokenVerifier string
+
+func (v tokenVerifier) Verify(_ context.Context, token, _ string) error {
+	if token != string(v) {
+		return captcha.ErrVerificationFailed
+	}
+	return nil
+}
+
+func TestSignupRiskMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := zap.NewNop().Sugar()
+	// spam.test 同时命中两条规则，用于模拟高风险
+	assessor := risk.NewAssessor(log, 50, 100,
+		risk.NewDisposableDomains([]string{"mailinator.com", "spam.test"}, 50),
+		risk.NewDisposableDomains([]string{"spam.test"}, 50),
+	)
+
+	engine := gin.New()
+	engine.POST("/users", SignupRiskMiddleware(assessor, tokenVerifier("ok"), log), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusCreated, string(body))
+	})
+
+	send := func(email, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"email":"`+email+`"}`))
+		if token != "" {
+			req.Header.Set(CaptchaTokenHeader, token)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	allowed := send("a@example.com", "")
+	assert.Equal(t, http.StatusCreated, allowed.Code)
+	assert.Equal(t, `{"email":"a@example.com"}`, allowed.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, send("a@mailinator.com", "").Code)
+	assert.Equal(t, http.StatusCreated, send("a@mailinator.com", "ok").Code)
+
+	rejected := send("a@spam.test", "ok")
+	assert.Equal(t, http.StatusForbidden, rejected.Code)
+	assert.Contains(t, rejected.Body.String(), "signup_rejected")
+}
+
+func TestSignupRiskMiddleware_Batch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := zap.NewNop().Sugar()
+	assessor := risk.NewAssessor(log, 50, 100,
+		risk.NewDisposableDomains([]string{"mailinator.com", "spam.test"}, 50),
+		risk.NewDisposableDomains([]string{"spam.test"}, 50),
+	)
+
+	engine := gin.New()
+	engine.POST("/users/batch", SignupRiskMiddleware(assessor, tokenVerifier("ok"), log), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	send := func(emails ...string) int {
+		users := make([]string, len(emails))
+		for i, email := range emails {
+			users[i] = `{"email":"` + email + `"}`
+		}
+		req := httptest.NewRequest(http.MethodPost, "/users/batch", strings.NewReader(`{"users":[`+strings.Join(users, ",")+`]}`))
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// The strictest item decides for the whole batch
+	assert.Equal(t, http.StatusOK, send("a@example.com", "b@example.com"))
+	assert.Equal(t, http.StatusBadRequest, send("a@example.com", "b@mailinator.com"), "a risky item requires a CAPTCHA")
+	assert.Equal(t, http.StatusForbidden, send("a@mailinator.com", "b@spam.test"))
+}
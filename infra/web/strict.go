@@ -0,0 +1,185 @@
+package web
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// strictJSONKey 是 StrictJSON 在 gin.Context 中的标记
+const strictJSONKey = "web.strict_json"
+
+// UnknownFieldsError 表示严格模式下请求体包含请求类型未声明的字段，
+// Fields 是这些字段的路径，例如 user_name 或 users[0].user_name，按字典序排列
+type UnknownFieldsError struct {
+	Fields []string
+}
+
+func (e *UnknownFieldsError) Error() string {
+	return "unknown JSON fields: " + strings.Join(e.Fields, ", ")
+}
+
+// StrictJSON 为声明了 StrictJSON 的路由开启严格绑定，之后 BindJSON 会拒绝未知字段
+func StrictJSON(route Route) gin.HandlerFunc {
+	if !route.StrictJSON {
+		return nil
+	}
+
+	return func(c *gin.Context) {
+		c.Set(strictJSONKey, true)
+		c.Next()
+	}
+}
+
+// IsStrictJSON 判断当前路由是否开启了严格绑定
+func IsStrictJSON(c *gin.Context) bool {
+	return c.GetBool(strictJSONKey)
+}
+
+// BindJSON 与 c.ShouldBindJSON 相同，但在开启了严格绑定的路由上，请求体中 obj 未声明的字段
+// 会一次性全部列出并返回 *UnknownFieldsError，而不是被静默忽略。
+// 与 gin 全局的 EnableDecoderDisallowUnknownFields 不同，它按路由生效，且不会只报告第一个未知字段
+func BindJSON(c *gin.Context, obj interface{}) error {
+	if !IsStrictJSON(c) || c.Request.Body == nil {
+		return c.ShouldBindJSON(obj)
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		return err
+	}
+	if fields := UnknownJSONFields(body, obj); len(fields) > 0 {
+		return &UnknownFieldsError{Fields: fields}
+	}
+	return binding.JSON.BindBody(body, obj)
+}
+
+// UnknownJSONFields 返回 data 中 v 的类型未声明的字段路径。字段名按 encoding/json 的规则匹配
+// （json tag、嵌入结构体、大小写不敏感）；data 不是合法 JSON 或类型不匹配的部分不在这里报告，由解码时报错
+func UnknownJSONFields(data []byte, v interface{}) []string {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil
+	}
+
+	var fields []string
+	collectUnknownFields("", value, reflect.TypeOf(v), &fields)
+	sort.Strings(fields)
+	return fields
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func collectUnknownFields(path string, value interface{}, t reflect.Type, fields *[]string) {
+	if t == nil {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// 自定义解码的类型（如 json.RawMessage）自行决定接受哪些内容
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		known := jsonFields(t)
+		for _, name := range sortedKeys(object) {
+			field, ok := known[name]
+			if !ok {
+				field, ok = known[strings.ToLower(name)]
+			}
+			if !ok {
+				*fields = append(*fields, joinPath(path, name))
+				continue
+			}
+			collectUnknownFields(joinPath(path, name), object[name], field, fields)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range items {
+			collectUnknownFields(path+"["+strconv.Itoa(i)+"]", item, t.Elem(), fields)
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for _, name := range sortedKeys(object) {
+			collectUnknownFields(joinPath(path, name), object[name], t.Elem(), fields)
+		}
+	}
+}
+
+// jsonFields 返回结构体可解码的字段，key 为 JSON 名称及其小写形式，嵌入结构体的字段被提升
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for embeddedName, embeddedType := range jsonFields(embedded) {
+					if _, ok := fields[embeddedName]; !ok {
+						fields[embeddedName] = embeddedType
+					}
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+		if _, ok := fields[strings.ToLower(name)]; !ok {
+			fields[strings.ToLower(name)] = field.Type
+		}
+	}
+	return fields
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
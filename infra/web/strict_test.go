@@ -0,0 +1,90 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type strictBase struct {
+	Tenant string `json:"tenant"`
+}
+
+type strictItem struct {
+	Username string `json:"username"`
+}
+
+type strictRequest struct {
+	strictBase
+	Name     string            `json:"name" binding:"required"`
+	Users    []strictItem      `json:"users"`
+	Labels   map[string]string `json:"labels"`
+	Extra    json.RawMessage   `json:"extra"`
+	Ignored  string            `json:"-"`
+	Untagged string
+}
+
+func TestUnknownJSONFields(t *testing.T) {
+	body := `{
+		"name": "a", "Tenant": "t", "untagged": "u", "extra": {"anything": 1},
+		"labels": {"free": "form"},
+		"users": [{"username": "alice"}, {"user_name": "bob"}],
+		"user_name": "typo", "Ignored": "x"
+	}`
+
+	fields := UnknownJSONFields([]byte(body), &strictRequest{})
+
+	assert.Equal(t, []string{"Ignored", "user_name", "users[1].user_name"}, fields)
+	assert.Empty(t, UnknownJSONFields([]byte(`{"name": "a"}`), &strictRequest{}))
+	assert.Empty(t, UnknownJSONFields([]byte(`not json`), &strictRequest{}), "syntax errors are left to the decoder")
+}
+
+func TestBindJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	bind := func(c *gin.Context) {
+		var req strictRequest
+		if err := BindJSON(c, &req); err != nil {
+			var unknown *UnknownFieldsError
+			if errors.As(err, &unknown) {
+				c.JSON(http.StatusBadRequest, gin.H{"fields": unknown.Fields})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"name": req.Name})
+	}
+	Register(engine, []RouteMiddleware{StrictJSON},
+		Route{Method: http.MethodPost, Path: "/strict", StrictJSON: true, Handler: bind},
+		Route{Method: http.MethodPost, Path: "/lenient", Handler: bind},
+	)
+
+	serve := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return w
+	}
+
+	w := serve("/strict", `{"name": "a", "user_name": "typo", "emial": "x"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"fields": ["emial", "user_name"]}`, w.Body.String())
+
+	w = serve("/lenient", `{"name": "a", "user_name": "typo"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve("/strict", `{"name": "a"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"name": "a"}`, w.Body.String())
+
+	// Validation and syntax errors are reported as without strict binding
+	assert.Contains(t, serve("/strict", `{}`).Body.String(), "required")
+	assert.Equal(t, http.StatusBadRequest, serve("/strict", `{"name":`).Code)
+}
@@ -0,0 +1,134 @@
+package web
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"web-clean/domain"
+)
+
+// ClientIDHeader 客户端可以通过该请求头声明自己的身份，未提供时使用 User-Agent
+const ClientIDHeader = "X-Client-ID"
+
+const (
+	// OtherClient 汇总超出 maxUsageClients 之后新出现的客户端
+	OtherClient = "other"
+	// maxUsageClients 是单独统计的客户端数量上限。客户端标识由调用方提供，不设上限时
+	// 任意变换请求头即可让统计无限增长
+	maxUsageClients = 1000
+	// maxClientLength 是客户端标识保留的最大长度
+	maxClientLength = 128
+)
+
+// UsageStat 是某个客户端对某个路由的调用统计
+type UsageStat struct {
+	API       string    `json:"api"`
+	Client    string    `json:"client"`
+	Route     string    `json:"route"`
+	Count     int64     `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+type usageKey struct {
+	api    string
+	client string
+	route  string
+}
+
+// UsageRecorder 在内存中按客户端与路由统计接口调用，用于评估旧版本 API 何时可以下线。
+// 统计只在当前进程内有效，每个客户端第一次出现时会额外打印一条日志，便于在日志系统中跨实例汇总。
+// 单独统计的客户端数量有上限，之后新出现的客户端计入 OtherClient
+type UsageRecorder struct {
+	log        domain.Log
+	maxClients int
+
+	mu      sync.Mutex
+	stats   map[usageKey]*UsageStat
+	clients map[string]struct{}
+}
+
+func NewUsageRecorder(log domain.Log) *UsageRecorder {
+	return &UsageRecorder{
+		log:        log,
+		maxClients: maxUsageClients,
+		stats:      make(map[usageKey]*UsageStat),
+		clients:    make(map[string]struct{}),
+	}
+}
+
+// Middleware 返回统计 api 调用情况的中间件，应挂在对应版本的路由组上
+func (r *UsageRecorder) Middleware(api string) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		context.Next()
+
+		// 未匹配的请求不带方法，方法同样由调用方任意指定
+		route := "unmatched"
+		if path := context.FullPath(); path != "" {
+			route = context.Request.Method + " " + path
+		}
+
+		r.record(usageKey{api: api, client: requestClient(context), route: route})
+	}
+}
+
+// requestClient 返回调用方的标识：ClientIDHeader，未提供时使用 User-Agent，超长部分被截断
+func requestClient(context *gin.Context) string {
+	client := context.GetHeader(ClientIDHeader)
+	if client == "" {
+		client = context.Request.UserAgent()
+	}
+	if len(client) > maxClientLength {
+		client = client[:maxClientLength]
+	}
+	return client
+}
+
+func (r *UsageRecorder) record(key usageKey) {
+	now := time.Now()
+
+	r.mu.Lock()
+	if _, known := r.clients[key.client]; !known {
+		if len(r.clients) >= r.maxClients {
+			key.client = OtherClient
+		} else {
+			r.clients[key.client] = struct{}{}
+		}
+	}
+	stat, ok := r.stats[key]
+	if !ok {
+		stat = &UsageStat{API: key.api, Client: key.client, Route: key.route, FirstSeen: now}
+		r.stats[key] = stat
+	}
+	stat.Count++
+	stat.LastSeen = now
+	r.mu.Unlock()
+
+	if !ok {
+		r.log.Infow("API 调用方首次出现", "api", key.api, "client", key.client, "route", key.route)
+	}
+}
+
+// Snapshot 返回当前统计的副本，按 API、客户端、路由排序
+func (r *UsageRecorder) Snapshot() []UsageStat {
+	r.mu.Lock()
+	stats := make([]UsageStat, 0, len(r.stats))
+	for _, stat := range r.stats {
+		stats = append(stats, *stat)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].API != stats[j].API {
+			return stats[i].API < stats[j].API
+		}
+		if stats[i].Client != stats[j].Client {
+			return stats[i].Client < stats[j].Client
+		}
+		return stats[i].Route < stats[j].Route
+	})
+	return stats
+}
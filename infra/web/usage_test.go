@@ -0,0 +1,56 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestUsageRecorder_CapsClients(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := NewUsageRecorder(zap.NewNop().Sugar())
+	recorder.maxClients = 2
+
+	engine := gin.New()
+	engine.Use(recorder.Middleware("v1"))
+	engine.GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, client := range []string{"billing", "crm", "billing", "scraper-1", "scraper-2", "crm"} {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set(ClientIDHeader, client)
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	counts := make(map[string]int64)
+	for _, stat := range recorder.Snapshot() {
+		assert.Equal(t, "GET /users", stat.Route)
+		counts[stat.Client] = stat.Count
+	}
+	assert.Equal(t, map[string]int64{"billing": 2, "crm": 2, OtherClient: 2}, counts)
+}
+
+func TestUsageRecorder_BoundsKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := NewUsageRecorder(zap.NewNop().Sugar())
+
+	engine := gin.New()
+	engine.Use(recorder.Middleware("v1"))
+
+	for _, method := range []string{"FOO", "BAR"} {
+		req := httptest.NewRequest(method, "/missing", nil)
+		req.Header.Set("User-Agent", strings.Repeat("a", 4096))
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	stats := recorder.Snapshot()
+	if assert.Len(t, stats, 1) {
+		assert.Equal(t, "unmatched", stats[0].Route)
+		assert.Len(t, stats[0].Client, maxClientLength)
+		assert.EqualValues(t, 2, stats[0].Count)
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"web-clean/domain"
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/repository"
+	"web-clean/internal/domain/usecase"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrAPIKeyRejected = errors.New("api key is invalid or revoked")
+)
+
+// apiKeyTouchInterval limits last-used writes to one per key and interval, so a busy client
+// does not turn every request into an UPDATE
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyService implements the APIKeyUseCase interface
+type APIKeyService struct {
+	keys   repository.APIKeyRepository
+	logger domain.Log
+	ids    entity.IDGenerator
+	now    func() time.Time
+}
+
+// NewAPIKeyService creates a new APIKeyService instance
+func NewAPIKeyService(keys repository.APIKeyRepository, logger domain.Log, ids entity.IDGenerator) usecase.APIKeyUseCase {
+	return &APIKeyService{
+		keys:   keys,
+		logger: logger,
+		ids:    ids,
+		now:    time.Now,
+	}
+}
+
+// CreateAPIKey generates and stores a key, returning its secret once
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, req usecase.CreateAPIKeyRequest) (*usecase.CreateAPIKeyResponse, error) {
+	s.logger.Infow("CreateAPIKey", "name", req.Name, "scopes", req.Scopes)
+
+	id, err := s.ids.NewID()
+	if err != nil {
+		s.logger.Errorw("Failed to generate API key ID", "error", err)
+		return nil, fmt.Errorf("failed to generate api key id: %w", err)
+	}
+
+	key, secret, err := entity.NewAPIKey(id, req.Name, req.Scopes)
+	if err != nil {
+		s.logger.Warnw("API key creation failed - invalid data", "error", err)
+		return nil, err
+	}
+
+	if err := s.keys.Create(ctx, key); err != nil {
+		s.logger.Errorw("Failed to create API key", "error", err)
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	s.logger.Infow("API key created successfully", "apiKeyID", key.ID, "prefix", key.DisplayPrefix)
+	return &usecase.CreateAPIKeyResponse{Key: key, Secret: secret}, nil
+}
+
+// ListAPIKeys retrieves all keys
+func (s *APIKeyService) ListAPIKeys(ctx context.Context) ([]*entity.APIKey, error) {
+	keys, err := s.keys.List(ctx)
+	if err != nil {
+		s.logger.Errorw("Failed to list API keys", "error", err)
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes a key; revoking an already revoked key returns it unchanged
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*entity.APIKey, error) {
+	s.logger.Infow("RevokeAPIKey", "apiKeyID", id)
+
+	key, err := s.keys.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Errorw("Failed to get API key for revocation", "error", err, "apiKeyID", id)
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	if key == nil {
+		return nil, ErrAPIKeyNotFound
+	}
+	if !key.Active() {
+		return key, nil
+	}
+
+	key.Revoke(s.now())
+	if err := s.keys.Revoke(ctx, key.ID, *key.RevokedAt); err != nil {
+		s.logger.Errorw("Failed to revoke API key", "error", err, "apiKeyID", id)
+		return nil, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	s.logger.Infow("API key revoked successfully", "apiKeyID", id)
+	return key, nil
+}
+
+// AuthenticateAPIKey looks the key up by the hash of secret. Failing to record the last use is
+// logged and does not fail the request
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, secret string) (*entity.APIKey, error) {
+	// Anything without the prefix cannot be a key, so it is rejected without a query
+	if !strings.HasPrefix(secret, entity.APIKeyPrefix) {
+		return nil, ErrAPIKeyRejected
+	}
+
+	key, err := s.keys.GetBySecretHash(ctx, entity.HashAPIKey(secret))
+	if err != nil {
+		s.logger.Errorw("Failed to look up API key", "error", err)
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	if key == nil || !key.Active() {
+		return nil, ErrAPIKeyRejected
+	}
+
+	now := s.now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.keys.TouchLastUsed(ctx, key.ID, now); err != nil {
+			s.logger.Warnw("Failed to record API key use", "error", err, "apiKeyID", key.ID)
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+	return key, nil
+}
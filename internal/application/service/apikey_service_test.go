@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/usecase"
+)
+
+// MockAPIKeyRepository is a mock implementation of APIKeyRepository for testing
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) Create(ctx context.Context, key *entity.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*entity.APIKey, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) GetBySecretHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	args := m.Called(ctx, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) List(ctx context.Context) ([]*entity.APIKey, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func newTestAPIKeyService(repo *MockAPIKeyRepository, now time.Time) *APIKeyService {
+	service := NewAPIKeyService(repo, new(MockLogger), testIDs).(*APIKeyService)
+	service.now = func() time.Time { return now }
+	return service
+}
+
+func TestAPIKeyService_CreateAPIKey(t *testing.T) {
+	repo := new(MockAPIKeyRepository)
+	service := newTestAPIKeyService(repo, time.Now())
+	repo.On("Create", mock.Anything, mock.AnythingOfType("*entity.APIKey")).Return(nil)
+
+	response, err := service.CreateAPIKey(context.Background(), usecase.CreateAPIKeyRequest{Name: "billing", Scopes: []string{"users:read"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, entity.HashAPIKey(response.Secret), response.Key.SecretHash)
+	assert.Equal(t, []string{"users:read"}, response.Key.Scopes)
+	repo.AssertExpectations(t)
+}
+
+func TestAPIKeyService_CreateAPIKey_InvalidScope(t *testing.T) {
+	repo := new(MockAPIKeyRepository)
+	service := newTestAPIKeyService(repo, time.Now())
+
+	_, err := service.CreateAPIKey(context.Background(), usecase.CreateAPIKeyRequest{Name: "billing", Scopes: []string{"Not Valid"}})
+
+	assert.ErrorIs(t, err, entity.ErrInvalidAPIKey)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAPIKeyService_RevokeAPIKey(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	key, _, err := entity.NewAPIKey(uuid.New(), "billing", nil)
+	require.NoError(t, err)
+
+	repo := new(MockAPIKeyRepository)
+	service := newTestAPIKeyService(repo, now)
+	repo.On("GetByID", mock.Anything, key.ID).Return(key, nil)
+	repo.On("Revoke", mock.Anything, key.ID, now).Return(nil).Once()
+
+	revoked, err := service.RevokeAPIKey(context.Background(), key.ID)
+	require.NoError(t, err)
+	assert.Equal(t, now, *revoked.RevokedAt)
+
+	// Revoking again neither fails nor writes
+	_, err = service.RevokeAPIKey(context.Background(), key.ID)
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestAPIKeyService_RevokeAPIKey_NotFound(t *testing.T) {
+	repo := new(MockAPIKeyRepository)
+	service := newTestAPIKeyService(repo, time.Now())
+	id := uuid.New()
+	repo.On("GetByID", mock.Anything, id).Return(nil, nil)
+
+	_, err := service.RevokeAPIKey(context.Background(), id)
+
+	assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+}
+
+func TestAPIKeyService_AuthenticateAPIKey(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	key, secret, err := entity.NewAPIKey(uuid.New(), "billing", []string{"users:read"})
+	require.NoError(t, err)
+
+	repo := new(MockAPIKeyRepository)
+	service := newTestAPIKeyService(repo, now)
+	repo.On("GetBySecretHash", mock.Anything, key.SecretHash).Return(key, nil)
+	repo.On("TouchLastUsed", mock.Anything, key.ID, now).Return(nil).Once()
+
+	authenticated, err := service.AuthenticateAPIKey(context.Background(), secret)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, authenticated.ID)
+	assert.Equal(t, now, *authenticated.LastUsedAt)
+
+	// A second request within apiKeyTouchInterval does not write last_used_at again
+	service.now = func() time.Time { return now.Add(apiKeyTouchInterval / 2) }
+	_, err = service.AuthenticateAPIKey(context.Background(), secret)
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestAPIKeyService_AuthenticateAPIKey_TouchFailureIsNotFatal(t *testing.T) {
+	key, secret, err := entity.NewAPIKey(uuid.New(), "billing", nil)
+	require.NoError(t, err)
+
+	repo := new(MockAPIKeyRepository)
+	service := newTestAPIKeyService(repo, time.Now())
+	repo.On("GetBySecretHash", mock.Anything, key.SecretHash).Return(key, nil)
+	repo.On("TouchLastUsed", mock.Anything, key.ID, mock.Anything).Return(errors.New("read-only replica"))
+
+	authenticated, err := service.AuthenticateAPIKey(context.Background(), secret)
+
+	require.NoError(t, err)
+	assert.Nil(t, authenticated.LastUsedAt)
+}
+
+func TestAPIKeyService_AuthenticateAPIKey_Rejected(t *testing.T) {
+	revoked, revokedSecret, err := entity.NewAPIKey(uuid.New(), "old", nil)
+	require.NoError(t, err)
+	revoked.Revoke(time.Now())
+
+	repo := new(MockAPIKeyRepository)
+	service := newTestAPIKeyService(repo, time.Now())
+	repo.On("GetBySecretHash", mock.Anything, revoked.SecretHash).Return(revoked, nil)
+	repo.On("GetBySecretHash", mock.Anything, entity.HashAPIKey(entity.APIKeyPrefix+"unknown")).Return(nil, nil)
+
+	for _, secret := range []string{revokedSecret, entity.APIKeyPrefix + "unknown", "no-prefix"} {
+		_, err := service.AuthenticateAPIKey(context.Background(), secret)
+		assert.ErrorIs(t, err, ErrAPIKeyRejected, secret)
+	}
+	repo.AssertNumberOfCalls(t, "GetBySecretHash", 2)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"sort"
+	"sync"
+)
+
+// keyedLock serializes work on the same keys within this process, the zero value is ready to use
+type keyedLock struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLockEntry
+}
+
+type keyedLockEntry struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lock acquires every key and returns a function releasing them. Keys are taken in sorted
+// order so two callers sharing more than one key cannot deadlock
+func (l *keyedLock) lock(keys ...string) func() {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+
+	entries := make([]*keyedLockEntry, 0, len(sorted))
+	for i, key := range sorted {
+		if i > 0 && key == sorted[i-1] {
+			continue
+		}
+		entry := l.acquire(key)
+		entry.mu.Lock()
+		entries = append(entries, entry)
+	}
+
+	return func() {
+		for i := len(entries) - 1; i >= 0; i-- {
+			entries[i].mu.Unlock()
+		}
+		l.release(sorted)
+	}
+}
+
+func (l *keyedLock) acquire(key string) *keyedLockEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.locks == nil {
+		l.locks = make(map[string]*keyedLockEntry)
+	}
+	entry, ok := l.locks[key]
+	if !ok {
+		entry = &keyedLockEntry{}
+		l.locks[key] = entry
+	}
+	entry.refs++
+	return entry
+}
+
+// release drops the references taken by lock and forgets keys nobody is waiting on
+func (l *keyedLock) release(sorted []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i, key := range sorted {
+		if i > 0 && key == sorted[i-1] {
+			continue
+		}
+		entry := l.locks[key]
+		entry.refs--
+		if entry.refs == 0 {
+			delete(l.locks, key)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/usecase"
+)
+
+// ImportUsers validates every row, decides what to do with it and, unless req.DryRun is set, writes the
+// decisions: new users are created in transactions of MaxCreateUsersBatch, overwritten users are updated
+// one by one. A row repeating the email or username of an earlier row is a duplicate as well; it is
+// skipped with DuplicateSkip and fails otherwise, since the file cannot say which of the two is meant
+func (s *UserService) ImportUsers(ctx context.Context, req usecase.ImportUsersRequest) (*usecase.ImportUsersResponse, error) {
+	s.logger.Infow("ImportUsers", "count", len(req.Users), "onDuplicate", req.OnDuplicate, "dryRun", req.DryRun)
+
+	if len(req.Users) == 0 || len(req.Users) > usecase.MaxImportUsers {
+		return nil, ErrInvalidImportSize
+	}
+	switch req.OnDuplicate {
+	case usecase.DuplicateSkip, usecase.DuplicateOverwrite, usecase.DuplicateError:
+	default:
+		return nil, ErrInvalidDuplicatePolicy
+	}
+
+	type candidate struct {
+		index    int
+		email    entity.Email
+		username entity.Username
+		name     string
+	}
+
+	results := make([]usecase.ImportUserResult, len(req.Users))
+	candidates := make([]candidate, 0, len(req.Users))
+	keys := make([]string, 0, 2*len(req.Users))
+	for i, row := range req.Users {
+		email, username, err := s.validateNewUser(row)
+		if err != nil {
+			results[i] = usecase.ImportUserResult{Action: usecase.ImportFailed, Err: err}
+			continue
+		}
+		candidates = append(candidates, candidate{index: i, email: email, username: username, name: row.Name})
+		keys = append(keys, creationKeys(email, username)...)
+	}
+
+	// A dry run writes nothing, so it does not need to hold off concurrent signups
+	if !req.DryRun {
+		unlock := s.creating.lock(keys...)
+		defer unlock()
+	}
+
+	seen := make(map[string]bool, len(keys))
+	creates := make([]*entity.User, 0, len(candidates))
+	createIndexes := make([]int, 0, len(candidates))
+	updates := make([]*entity.User, 0)
+	updateIndexes := make([]int, 0)
+	for _, c := range candidates {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		itemKeys := creationKeys(c.email, c.username)
+		if seen[itemKeys[0]] || seen[itemKeys[1]] {
+			s.logger.Warnw("User import - duplicate within import", "email", c.email, "username", c.username)
+			results[c.index] = duplicateRow(req.OnDuplicate, nil)
+			continue
+		}
+		for _, key := range itemKeys {
+			seen[key] = true
+		}
+
+		byEmail, byUsername, err := s.findExisting(ctx, c.email, c.username)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case byEmail == nil && byUsername == nil:
+			user, err := s.newUser(c.email, c.username, c.name)
+			if err != nil {
+				results[c.index] = usecase.ImportUserResult{Action: usecase.ImportFailed, Err: err}
+				continue
+			}
+			results[c.index] = usecase.ImportUserResult{Action: usecase.ImportCreated, User: user}
+			creates = append(creates, user)
+			createIndexes = append(createIndexes, c.index)
+		case req.OnDuplicate != usecase.DuplicateOverwrite:
+			existing := byEmail
+			if existing == nil {
+				existing = byUsername
+			}
+			results[c.index] = duplicateRow(req.OnDuplicate, existing)
+		case byEmail == nil || byUsername == nil || byEmail.ID != byUsername.ID:
+			// Overwriting never changes an email or username, so the row must name one user by both
+			s.logger.Warnw("User import - row matches different users", "email", c.email, "username", c.username)
+			results[c.index] = usecase.ImportUserResult{Action: usecase.ImportFailed, Err: ErrUserAlreadyExists}
+		case byEmail.Name == c.name:
+			results[c.index] = usecase.ImportUserResult{Action: usecase.ImportSkipped, User: byEmail}
+		default:
+			byEmail.UpdateProfile(c.name)
+			if !byEmail.IsValid() {
+				results[c.index] = usecase.ImportUserResult{Action: usecase.ImportFailed, Err: ErrInvalidUserData}
+				continue
+			}
+			results[c.index] = usecase.ImportUserResult{Action: usecase.ImportUpdated, User: byEmail}
+			updates = append(updates, byEmail)
+			updateIndexes = append(updateIndexes, c.index)
+		}
+	}
+
+	if req.DryRun {
+		s.logger.Infow("Users import checked", "rows", len(req.Users), "creates", len(creates), "updates", len(updates))
+		return &usecase.ImportUsersResponse{Results: results, DryRun: true}, nil
+	}
+
+	for start := 0; start < len(creates); start += usecase.MaxCreateUsersBatch {
+		end := min(start+usecase.MaxCreateUsersBatch, len(creates))
+		errs, err := s.createAll(ctx, creates[start:end])
+		for k, rowErr := range errs {
+			if err != nil {
+				// Nothing of this chunk was stored; earlier chunks are, so the import goes on reporting
+				rowErr = err
+			}
+			if rowErr != nil {
+				results[createIndexes[start+k]] = usecase.ImportUserResult{Action: usecase.ImportFailed, Err: rowErr}
+			}
+		}
+	}
+
+	for k, user := range updates {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			s.logger.Warnw("User import - update failed", "userID", user.ID, "error", err)
+			results[updateIndexes[k]] = usecase.ImportUserResult{Action: usecase.ImportFailed, Err: err}
+		}
+	}
+
+	s.logger.Infow("Users imported", "rows", len(req.Users), "creates", len(creates), "updates", len(updates))
+	return &usecase.ImportUsersResponse{Results: results}, nil
+}
+
+// findExisting returns the users already holding the email and the username, nil when it is free
+func (s *UserService) findExisting(ctx context.Context, email entity.Email, username entity.Username) (*entity.User, *entity.User, error) {
+	byEmail, err := s.userRepo.GetByEmail(ctx, email.String())
+	if err != nil {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		byEmail = nil
+	}
+	byUsername, err := s.userRepo.GetByUsername(ctx, username.String())
+	if err != nil {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		byUsername = nil
+	}
+	return byEmail, byUsername, nil
+}
+
+// duplicateRow is the result of a row naming an existing user, or an earlier row when existing is nil,
+// under a policy other than DuplicateOverwrite
+func duplicateRow(policy usecase.DuplicatePolicy, existing *entity.User) usecase.ImportUserResult {
+	if policy == usecase.DuplicateSkip {
+		return usecase.ImportUserResult{Action: usecase.ImportSkipped, User: existing}
+	}
+	return usecase.ImportUserResult{Action: usecase.ImportFailed, Err: ErrUserAlreadyExists}
+}
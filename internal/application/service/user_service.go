@@ -4,31 +4,52 @@ import (
 	"context"
 	"errors"
 	"fmt"
+
 	"github.com/google/uuid"
 	"web-clean/domain"
 	"web-clean/internal/domain/entity"
 	"web-clean/internal/domain/repository"
+	"web-clean/internal/domain/specification"
 	"web-clean/internal/domain/usecase"
 )
 
 var (
-	ErrUserNotFound      = errors.New("user not found")
-	ErrUserAlreadyExists = errors.New("user already exists")
-	ErrInvalidUserData   = errors.New("invalid user data")
+	ErrUserNotFound           = errors.New("user not found")
+	ErrUserAlreadyExists      = errors.New("user already exists")
+	ErrInvalidUserData        = errors.New("invalid user data")
+	ErrUsernameNotAllowed     = errors.New("username not allowed")
+	ErrInvalidBatchSize       = fmt.Errorf("a batch must contain 1 to %d users", usecase.MaxCreateUsersBatch)
+	ErrInvalidImportSize      = fmt.Errorf("an import must contain 1 to %d users", usecase.MaxImportUsers)
+	ErrInvalidDuplicatePolicy = errors.New("on_duplicate must be skip, overwrite or error")
+	ErrWrongPassword          = errors.New("current password is incorrect")
 )
 
 // UserService implements the UserUseCase interface
 // This is the application layer that contains business logic
 type UserService struct {
-	userRepo repository.UserRepository
-	logger   domain.Log
+	userRepo  repository.UserRepository
+	uow       repository.UnitOfWork
+	logger    domain.Log
+	ids       entity.IDGenerator
+	usernames entity.UsernamePolicy
+	pages     usecase.PageLimits
+	passwords entity.PasswordHasher
+
+	// creating serializes concurrent signups for the same email or username
+	creating keyedLock
 }
 
 // NewUserService creates a new UserService instance
-func NewUserService(userRepo repository.UserRepository, logger domain.Log) usecase.UserUseCase {
+// uow is used for writes that must be atomic with their audit record
+func NewUserService(userRepo repository.UserRepository, uow repository.UnitOfWork, logger domain.Log, ids entity.IDGenerator, usernames entity.UsernamePolicy, pages usecase.PageLimits, passwords entity.PasswordHasher) usecase.UserUseCase {
 	return &UserService{
-		userRepo: userRepo,
-		logger:   logger,
+		userRepo:  userRepo,
+		uow:       uow,
+		logger:    logger,
+		ids:       ids,
+		usernames: usernames,
+		pages:     pages,
+		passwords: passwords,
 	}
 }
 
@@ -36,37 +57,260 @@ func NewUserService(userRepo repository.UserRepository, logger domain.Log) useca
 func (s *UserService) CreateUser(ctx context.Context, req usecase.CreateUserRequest) (*entity.User, error) {
 	s.logger.Infow("CreateUser", "email", req.Email, "username", req.Username)
 
+	email, username, err := s.validateNewUser(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Identical signups racing each other are serialized so the uniqueness checks below see
+	// the winner's row: one request creates the user, the others get ErrUserAlreadyExists
+	unlock := s.creating.lock(creationKeys(email, username)...)
+	defer unlock()
+
+	if err := s.checkAvailable(ctx, email, username); err != nil {
+		return nil, err
+	}
+
+	user, err := s.newUser(email, username, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Store the user together with its audit record
+	if err := s.store(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.logger.Infow("User created successfully", "userID", user.ID, "email", user.Email)
+	return user, nil
+}
+
+// CreateUsers validates every request on its own and creates the valid ones in one transaction.
+// Invalid items and items whose email or username is taken (also by an earlier item of the batch)
+// are reported in their result and do not prevent the others from being created
+func (s *UserService) CreateUsers(ctx context.Context, reqs []usecase.CreateUserRequest) (*usecase.CreateUsersResponse, error) {
+	s.logger.Infow("CreateUsers", "count", len(reqs))
+
+	if len(reqs) == 0 || len(reqs) > usecase.MaxCreateUsersBatch {
+		return nil, ErrInvalidBatchSize
+	}
+
+	type candidate struct {
+		index    int
+		email    entity.Email
+		username entity.Username
+		name     string
+	}
+
+	results := make([]usecase.CreateUserResult, len(reqs))
+	candidates := make([]candidate, 0, len(reqs))
+	keys := make([]string, 0, 2*len(reqs))
+	for i, req := range reqs {
+		email, username, err := s.validateNewUser(req)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		candidates = append(candidates, candidate{index: i, email: email, username: username, name: req.Name})
+		keys = append(keys, creationKeys(email, username)...)
+	}
+
+	unlock := s.creating.lock(keys...)
+	defer unlock()
+
+	seen := make(map[string]bool, len(keys))
+	users := make([]*entity.User, 0, len(candidates))
+	indexes := make([]int, 0, len(candidates))
+	for _, c := range candidates {
+		itemKeys := creationKeys(c.email, c.username)
+		if seen[itemKeys[0]] || seen[itemKeys[1]] {
+			s.logger.Warnw("User creation failed - duplicate within batch", "email", c.email, "username", c.username)
+			results[c.index].Err = ErrUserAlreadyExists
+			continue
+		}
+		for _, key := range itemKeys {
+			seen[key] = true
+		}
+
+		if err := s.checkAvailable(ctx, c.email, c.username); err != nil {
+			results[c.index].Err = err
+			continue
+		}
+
+		user, err := s.newUser(c.email, c.username, c.name)
+		if err != nil {
+			results[c.index].Err = err
+			continue
+		}
+		users = append(users, user)
+		indexes = append(indexes, c.index)
+	}
+
+	errs, err := s.createAll(ctx, users)
+	if err != nil {
+		return nil, err
+	}
+	for k, user := range users {
+		if errs[k] != nil {
+			results[indexes[k]].Err = errs[k]
+			continue
+		}
+		results[indexes[k]].User = user
+	}
+
+	s.logger.Infow("Users created", "requested", len(reqs), "created", countCreated(results))
+	return &usecase.CreateUsersResponse{Results: results}, nil
+}
+
+// validateNewUser normalizes and validates the email and username of a signup and applies the username policy
+func (s *UserService) validateNewUser(req usecase.CreateUserRequest) (entity.Email, entity.Username, error) {
+	// Invariants: email and username are normalized and validated by their value objects
+	email, err := entity.NewEmail(req.Email)
+	if err != nil {
+		s.logger.Warnw("User creation failed - invalid email", "email", req.Email, "error", err)
+		return "", "", err
+	}
+	username, err := entity.NewUsername(req.Username)
+	if err != nil {
+		s.logger.Warnw("User creation failed - invalid username", "username", req.Username, "error", err)
+		return "", "", err
+	}
+
+	// Business rule: Reserved or offensive usernames cannot be registered
+	if !s.usernames.Allowed(username.String()) {
+		s.logger.Warnw("User creation failed - username not allowed", "username", username)
+		return "", "", ErrUsernameNotAllowed
+	}
+	return email, username, nil
+}
+
+// creationKeys are the keys of s.creating guarding a signup's email and username
+func creationKeys(email entity.Email, username entity.Username) []string {
+	return []string{"email:" + email.String(), "username:" + username.String()}
+}
+
+// checkAvailable returns ErrUserAlreadyExists when the email or username is taken
+func (s *UserService) checkAvailable(ctx context.Context, email entity.Email, username entity.Username) error {
 	// Business rule: Check if user with email already exists
-	existingUser, err := s.userRepo.GetByEmail(ctx, req.Email)
+	existingUser, err := s.userRepo.GetByEmail(ctx, email.String())
 	if err == nil && existingUser != nil {
-		s.logger.Warnw("User creation failed - email already exists", "email", req.Email)
-		return nil, ErrUserAlreadyExists
+		s.logger.Warnw("User creation failed - email already exists", "email", email)
+		return ErrUserAlreadyExists
 	}
 
 	// Business rule: Check if username already exists
-	existingUser, err = s.userRepo.GetByUsername(ctx, req.Username)
+	existingUser, err = s.userRepo.GetByUsername(ctx, username.String())
 	if err == nil && existingUser != nil {
-		s.logger.Warnw("User creation failed - username already exists", "username", req.Username)
-		return nil, ErrUserAlreadyExists
+		s.logger.Warnw("User creation failed - username already exists", "username", username)
+		return ErrUserAlreadyExists
 	}
+	return nil
+}
 
-	// Create new user entity
-	user := entity.NewUser(req.Email, req.Username, req.Name)
+// newUser creates the user entity with a fresh ID
+func (s *UserService) newUser(email entity.Email, username entity.Username, name string) (*entity.User, error) {
+	id, err := s.ids.NewID()
+	if err != nil {
+		s.logger.Errorw("Failed to generate user ID", "error", err)
+		return nil, fmt.Errorf("failed to generate user id: %w", err)
+	}
+	user := entity.NewUser(id, email, username, name)
 
 	// Business validation
 	if !user.IsValid() {
 		s.logger.Errorw("User creation failed - invalid data", "user", user)
 		return nil, ErrInvalidUserData
 	}
+	return user, nil
+}
+
+// store saves a new user together with its audit record
+func (s *UserService) store(ctx context.Context, user *entity.User) error {
+	return s.storeBatch(ctx, []*entity.User{user})
+}
 
-	// Store the user
-	if err := s.userRepo.Create(ctx, user); err != nil {
-		s.logger.Errorw("Failed to create user", "error", err, "user", user)
-		return nil, fmt.Errorf("failed to create user: %w", err)
+// storeBatch saves new users together with their audit records in one transaction. A single user is
+// stored with Create; a duplicate is only mapped to ErrUserAlreadyExists then, since in a batch it does
+// not tell which user was the duplicate
+func (s *UserService) storeBatch(ctx context.Context, users []*entity.User) error {
+	if len(users) == 0 {
+		return nil
 	}
 
-	s.logger.Infow("User created successfully", "userID", user.ID, "email", user.Email)
-	return user, nil
+	records := make([]*entity.AuditRecord, len(users))
+	for i, user := range users {
+		auditID, err := s.ids.NewID()
+		if err != nil {
+			s.logger.Errorw("Failed to generate audit record ID", "error", err)
+			return fmt.Errorf("failed to generate audit record id: %w", err)
+		}
+		records[i] = entity.NewAuditRecord(auditID, entity.AuditUserCreated, user.ID)
+	}
+
+	err := s.uow.Do(ctx, func(repos repository.Repositories) error {
+		var err error
+		if len(users) == 1 {
+			err = repos.Users().Create(ctx, users[0])
+		} else {
+			err = repos.Users().CreateBatch(ctx, users)
+		}
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := repos.Audit().Record(ctx, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, repository.ErrDuplicate) {
+		if len(users) > 1 {
+			return err
+		}
+		// Another instance won the race, the database's case-insensitive unique index rejected this one
+		s.logger.Warnw("User creation failed - email or username already exists", "email", users[0].Email, "username", users[0].Username)
+		return ErrUserAlreadyExists
+	}
+	if err != nil {
+		s.logger.Errorw("Failed to create users", "error", err, "count", len(users))
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	return nil
+}
+
+// createAll stores new users in one transaction and returns the error of each one. When the batch hits a
+// duplicate, another instance took one of the values after the checks; nothing was stored, so the users
+// are created one by one to find out which one it was
+func (s *UserService) createAll(ctx context.Context, users []*entity.User) ([]error, error) {
+	errs := make([]error, len(users))
+	err := s.storeBatch(ctx, users)
+	if errors.Is(err, repository.ErrDuplicate) {
+		s.logger.Warnw("Batch insert hit a duplicate, creating the users one by one", "count", len(users))
+		for i, user := range users {
+			errs[i] = s.store(ctx, user)
+		}
+		return errs, nil
+	}
+	return errs, err
+}
+
+// lookupFailed wraps a failed user lookup. Repositories report a missing user as nil without an error,
+// so the error is passed on (cancellation, exhausted budget, database failure) rather than reported as
+// ErrUserNotFound
+func lookupFailed(err error) error {
+	return fmt.Errorf("failed to get user: %w", err)
+}
+
+// countCreated counts the results holding a created user
+func countCreated(results []usecase.CreateUserResult) int {
+	created := 0
+	for _, result := range results {
+		if result.User != nil {
+			created++
+		}
+	}
+	return created
 }
 
 // GetUserByID retrieves a user by ID
@@ -76,7 +320,7 @@ func (s *UserService) GetUserByID(ctx context.Context, id uuid.UUID) (*entity.Us
 	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		s.logger.Errorw("Failed to get user by ID", "error", err, "userID", id)
-		return nil, ErrUserNotFound
+		return nil, lookupFailed(err)
 	}
 
 	if user == nil {
@@ -91,12 +335,19 @@ func (s *UserService) GetUserByID(ctx context.Context, id uuid.UUID) (*entity.Us
 func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
 	s.logger.Infow("GetUserByEmail", "email", email)
 
-	user, err := s.userRepo.GetByEmail(ctx, email)
+	// Emails are stored normalized, an address that cannot be normalized matches no user
+	normalized, err := entity.NewEmail(email)
 	if err != nil {
-		s.logger.Errorw("Failed to get user by email", "error", err, "email", email)
+		s.logger.Warnw("User not found - invalid email", "email", email)
 		return nil, ErrUserNotFound
 	}
 
+	user, err := s.userRepo.GetByEmail(ctx, normalized.String())
+	if err != nil {
+		s.logger.Errorw("Failed to get user by email", "error", err, "email", email)
+		return nil, lookupFailed(err)
+	}
+
 	if user == nil {
 		s.logger.Warnw("User not found", "email", email)
 		return nil, ErrUserNotFound
@@ -113,7 +364,7 @@ func (s *UserService) UpdateUserProfile(ctx context.Context, req usecase.UpdateU
 	user, err := s.userRepo.GetByID(ctx, req.ID)
 	if err != nil {
 		s.logger.Errorw("Failed to get user for update", "error", err, "userID", req.ID)
-		return nil, ErrUserNotFound
+		return nil, lookupFailed(err)
 	}
 
 	if user == nil {
@@ -121,6 +372,12 @@ func (s *UserService) UpdateUserProfile(ctx context.Context, req usecase.UpdateU
 		return nil, ErrUserNotFound
 	}
 
+	// The client edited an older version than the stored one
+	if req.Version != 0 && req.Version != user.Version {
+		s.logger.Warnw("User update conflict", "userID", req.ID, "version", req.Version, "currentVersion", user.Version)
+		return nil, fmt.Errorf("user %s is at version %d: %w", req.ID, user.Version, repository.ErrConflict)
+	}
+
 	// Apply business logic for profile update
 	user.UpdateProfile(req.Name)
 
@@ -132,6 +389,10 @@ func (s *UserService) UpdateUserProfile(ctx context.Context, req usecase.UpdateU
 
 	// Update in repository
 	if err := s.userRepo.Update(ctx, user); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			s.logger.Warnw("User update conflict", "userID", req.ID, "version", user.Version)
+			return nil, fmt.Errorf("failed to update user: %w", err)
+		}
 		s.logger.Errorw("Failed to update user", "error", err, "userID", req.ID)
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
@@ -140,19 +401,86 @@ func (s *UserService) UpdateUserProfile(ctx context.Context, req usecase.UpdateU
 	return user, nil
 }
 
+// ChangePassword verifies the current password, if the user has one and this is not a reset, and stores the
+// new password's hash together with its audit record. Passwords are never logged
+func (s *UserService) ChangePassword(ctx context.Context, req usecase.ChangePasswordRequest) error {
+	s.logger.Infow("ChangePassword", "userID", req.ID, "reset", req.Reset)
+
+	user, err := s.userRepo.GetByID(ctx, req.ID)
+	if err != nil {
+		s.logger.Errorw("Failed to get user for password change", "error", err, "userID", req.ID)
+		return lookupFailed(err)
+	}
+	if user == nil {
+		s.logger.Warnw("User not found for password change", "userID", req.ID)
+		return ErrUserNotFound
+	}
+
+	// Business rule: Only the holder of the current password may replace it, unless an operator resets it
+	if !req.Reset && user.HasPassword() && !user.CheckPassword(req.CurrentPassword, s.passwords) {
+		s.logger.Warnw("Password change rejected - wrong current password", "userID", req.ID)
+		return ErrWrongPassword
+	}
+
+	if err := user.SetPassword(req.NewPassword, s.passwords); err != nil {
+		s.logger.Warnw("Password change failed", "userID", req.ID, "error", err)
+		return err
+	}
+
+	auditID, err := s.ids.NewID()
+	if err != nil {
+		s.logger.Errorw("Failed to generate audit record ID", "error", err)
+		return fmt.Errorf("failed to generate audit record id: %w", err)
+	}
+
+	err = s.uow.Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.Users().Update(ctx, user); err != nil {
+			return err
+		}
+		action := entity.AuditPasswordChanged
+		if req.Reset {
+			action = entity.AuditPasswordReset
+		}
+		return repos.Audit().Record(ctx, entity.NewAuditRecord(auditID, action, user.ID))
+	})
+	if err != nil {
+		s.logger.Errorw("Failed to change password", "error", err, "userID", req.ID)
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+
+	s.logger.Infow("Password changed successfully", "userID", user.ID)
+	return nil
+}
+
 // DeleteUser removes a user
 func (s *UserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	s.logger.Infow("DeleteUser", "userID", id)
 
 	// Business rule: Check if user exists before deletion
 	user, err := s.userRepo.GetByID(ctx, id)
-	if err != nil || user == nil {
+	if err != nil {
+		s.logger.Errorw("Failed to get user for deletion", "error", err, "userID", id)
+		return lookupFailed(err)
+	}
+	if user == nil {
 		s.logger.Warnw("User not found for deletion", "userID", id)
 		return ErrUserNotFound
 	}
 
-	// Perform deletion
-	if err := s.userRepo.Delete(ctx, id); err != nil {
+	auditID, err := s.ids.NewID()
+	if err != nil {
+		s.logger.Errorw("Failed to generate audit record ID", "error", err)
+		return fmt.Errorf("failed to generate audit record id: %w", err)
+	}
+
+	// Perform deletion together with its audit record
+	err = s.uow.Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.Users().Delete(ctx, id); err != nil {
+			return err
+		}
+		return repos.Audit().Record(ctx, entity.NewAuditRecord(auditID, entity.AuditUserDeleted, id))
+	})
+	if err != nil {
 		s.logger.Errorw("Failed to delete user", "error", err, "userID", id)
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -163,27 +491,21 @@ func (s *UserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
 
 // ListUsers retrieves paginated list of users
 func (s *UserService) ListUsers(ctx context.Context, req usecase.ListUsersRequest) (*usecase.ListUsersResponse, error) {
-	s.logger.Infow("ListUsers", "offset", req.Offset, "limit", req.Limit)
+	s.logger.Infow("ListUsers", "offset", req.Offset, "limit", req.Limit, "filter", req.Filter, "sort", req.Sort)
 
-	// Business rule: Set default limit if not provided
-	if req.Limit <= 0 {
-		req.Limit = 10
-	}
-	
-	// Business rule: Maximum limit
-	if req.Limit > 100 {
-		req.Limit = 100
-	}
+	req.Limit = s.pages.Normalize(req.Limit)
+
+	spec := specification.New().Where(req.Filter...)
 
 	// Get total count
-	total, err := s.userRepo.Count(ctx)
+	total, err := s.userRepo.Count(ctx, spec)
 	if err != nil {
 		s.logger.Errorw("Failed to get user count", "error", err)
 		return nil, fmt.Errorf("failed to get user count: %w", err)
 	}
 
 	// Get users
-	users, err := s.userRepo.List(ctx, req.Offset, req.Limit)
+	users, err := s.userRepo.List(ctx, userOrdering(spec, req.Sort).Page(req.Offset, req.Limit))
 	if err != nil {
 		s.logger.Errorw("Failed to list users", "error", err, "offset", req.Offset, "limit", req.Limit)
 		return nil, fmt.Errorf("failed to list users: %w", err)
@@ -201,4 +523,79 @@ func (s *UserService) ListUsers(ctx context.Context, req usecase.ListUsersReques
 
 	s.logger.Infow("Users listed successfully", "total", total, "returned", len(users))
 	return response, nil
-}
\ No newline at end of file
+}
+
+// ListUsersAfter retrieves a page of users after the given cursor
+func (s *UserService) ListUsersAfter(ctx context.Context, req usecase.ListUsersAfterRequest) (*usecase.ListUsersAfterResponse, error) {
+	s.logger.Infow("ListUsersAfter", "after", req.After, "limit", req.Limit, "filter", req.Filter)
+
+	req.Limit = s.pages.Normalize(req.Limit)
+
+	// Fetch one extra row to know whether another page exists
+	spec := req.After.Spec(specification.New().Where(req.Filter...)).Take(req.Limit + 1)
+	users, err := s.userRepo.List(ctx, spec)
+	if err != nil {
+		s.logger.Errorw("Failed to list users after cursor", "error", err, "after", req.After, "limit", req.Limit)
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	hasMore := len(users) > req.Limit
+	if hasMore {
+		users = users[:req.Limit]
+	}
+
+	response := &usecase.ListUsersAfterResponse{
+		Users:   users,
+		Limit:   req.Limit,
+		HasMore: hasMore,
+	}
+	if hasMore {
+		response.NextCursor = repository.CursorOf(users[len(users)-1])
+	}
+
+	s.logger.Infow("Users listed successfully", "returned", len(users), "hasMore", hasMore)
+	return response, nil
+}
+
+// ExportUsers walks the matching users with keyset pagination, one chunk per query, and stops at the
+// first error returned by yield or the repository
+func (s *UserService) ExportUsers(ctx context.Context, req usecase.ExportUsersRequest, yield func(user *entity.User) error) error {
+	s.logger.Infow("ExportUsers", "filter", req.Filter)
+
+	var after *repository.UserCursor
+	exported := 0
+	for {
+		spec := after.Spec(specification.New().Where(req.Filter...)).Take(usecase.ExportChunkSize)
+		users, err := s.userRepo.List(ctx, spec)
+		if err != nil {
+			s.logger.Errorw("Failed to export users", "error", err, "exported", exported)
+			return fmt.Errorf("failed to export users: %w", err)
+		}
+
+		for _, user := range users {
+			if err := yield(user); err != nil {
+				return err
+			}
+		}
+		exported += len(users)
+
+		if len(users) < usecase.ExportChunkSize {
+			s.logger.Infow("Users exported successfully", "exported", exported)
+			return nil
+		}
+		after = repository.CursorOf(users[len(users)-1])
+	}
+}
+
+// userOrdering applies the requested sort, defaulting to newest first; a requested sort gets the ID
+// as final tie-breaker since names and emails are not unique enough for stable offset pages
+func userOrdering(spec specification.Specification, sort []specification.Order) specification.Specification {
+	if len(sort) == 0 {
+		return spec.OrderBy("created_at", specification.Descending)
+	}
+
+	for _, order := range sort {
+		spec = spec.OrderBy(order.Field, order.Direction)
+	}
+	return spec.OrderBy("id", specification.Ascending)
+}
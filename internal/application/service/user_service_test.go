@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,9 +12,26 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/repository"
+	"web-clean/internal/domain/specification"
 	"web-clean/internal/domain/usecase"
+	"web-clean/internal/testing/fixtures"
 )
 
+// testIDs generates random IDs for users created in tests
+var testIDs = entity.IDGeneratorFunc(uuid.NewRandom)
+
+// allowAllUsernames accepts every username
+var allowAllUsernames = entity.UsernamePolicyFunc(func(string) bool { return true })
+
+// testPasswords "hashes" by prefixing, fast and easy to assert on
+var testPasswords = prefixHasher("hashed:")
+
+type prefixHasher string
+
+func (p prefixHasher) Hash(password string) (string, error) { return string(p) + password, nil }
+func (p prefixHasher) Verify(hash, password string) bool    { return hash == string(p)+password }
+
 // MockUserRepository is a mock implementation of UserRepository for testing
 type MockUserRepository struct {
 	mock.Mock
@@ -24,6 +42,11 @@ func (m *MockUserRepository) Create(ctx context.Context, user *entity.User) erro
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) CreateBatch(ctx context.Context, users []*entity.User) error {
+	args := m.Called(ctx, users)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -58,19 +81,49 @@ func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) List(ctx context.Context, offset, limit int) ([]*entity.User, error) {
-	args := m.Called(ctx, offset, limit)
+func (m *MockUserRepository) List(ctx context.Context, spec specification.Specification) ([]*entity.User, error) {
+	args := m.Called(ctx, spec)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*entity.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Count(ctx context.Context) (int64, error) {
-	args := m.Called(ctx)
+func (m *MockUserRepository) Count(ctx context.Context, spec specification.Specification) (int64, error) {
+	args := m.Called(ctx, spec)
 	return args.Get(0).(int64), args.Error(1)
 }
 
+// testUnitOfWork runs units of work directly against the given user repository and records audit entries
+type testUnitOfWork struct {
+	users   repository.UserRepository
+	mu      sync.Mutex
+	records []*entity.AuditRecord
+}
+
+func newTestUnitOfWork(users repository.UserRepository) *testUnitOfWork {
+	return &testUnitOfWork{users: users}
+}
+
+func (u *testUnitOfWork) Do(ctx context.Context, fn func(repos repository.Repositories) error) error {
+	return fn(u)
+}
+
+func (u *testUnitOfWork) Users() repository.UserRepository {
+	return u.users
+}
+
+func (u *testUnitOfWork) Audit() repository.AuditRepository {
+	return u
+}
+
+func (u *testUnitOfWork) Record(ctx context.Context, record *entity.AuditRecord) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.records = append(u.records, record)
+	return nil
+}
+
 // MockLogger is a mock implementation of domain.Log for testing
 type MockLogger struct {
 	mock.Mock
@@ -102,7 +155,7 @@ func TestUserService_CreateUser_Success(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
 	mockLogger := new(MockLogger)
-	service := NewUserService(mockRepo, mockLogger)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), mockLogger, testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
 
 	ctx := context.Background()
 	req := usecase.CreateUserRequest{
@@ -122,8 +175,8 @@ func TestUserService_CreateUser_Success(t *testing.T) {
 	// Assert
 	assert.NoError(t, err)
 	assert.NotNil(t, user)
-	assert.Equal(t, req.Email, user.Email)
-	assert.Equal(t, req.Username, user.Username)
+	assert.Equal(t, req.Email, user.Email.String())
+	assert.Equal(t, req.Username, user.Username.String())
 	assert.Equal(t, req.Name, user.Name)
 	assert.NotEqual(t, uuid.Nil, user.ID)
 	mockRepo.AssertExpectations(t)
@@ -133,7 +186,7 @@ func TestUserService_CreateUser_EmailExists(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
 	mockLogger := new(MockLogger)
-	service := NewUserService(mockRepo, mockLogger)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), mockLogger, testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
 
 	ctx := context.Background()
 	req := usecase.CreateUserRequest{
@@ -142,12 +195,7 @@ func TestUserService_CreateUser_EmailExists(t *testing.T) {
 		Name:     "New User",
 	}
 
-	existingUser := &entity.User{
-		ID:       uuid.New(),
-		Email:    req.Email,
-		Username: "olduser",
-		Name:     "Existing User",
-	}
+	existingUser := fixtures.User().WithEmail(req.Email).Build()
 
 	// Mock expectations - user with email exists
 	mockRepo.On("GetByEmail", ctx, req.Email).Return(existingUser, nil)
@@ -162,11 +210,38 @@ func TestUserService_CreateUser_EmailExists(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUserService_CreateUser_DuplicateRejectedByDatabase(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockLogger := new(MockLogger)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), mockLogger, testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+
+	ctx := context.Background()
+	req := usecase.CreateUserRequest{
+		Email:    "race@example.com",
+		Username: "racer",
+		Name:     "Racer",
+	}
+
+	// Mock expectations - another instance inserted the user between the checks and the insert
+	mockRepo.On("GetByEmail", ctx, req.Email).Return(nil, nil)
+	mockRepo.On("GetByUsername", ctx, req.Username).Return(nil, nil)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*entity.User")).Return(repository.ErrDuplicate)
+
+	// Act
+	user, err := service.CreateUser(ctx, req)
+
+	// Assert
+	assert.Equal(t, ErrUserAlreadyExists, err)
+	assert.Nil(t, user)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestUserService_CreateUser_UsernameExists(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
 	mockLogger := new(MockLogger)
-	service := NewUserService(mockRepo, mockLogger)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), mockLogger, testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
 
 	ctx := context.Background()
 	req := usecase.CreateUserRequest{
@@ -175,12 +250,7 @@ func TestUserService_CreateUser_UsernameExists(t *testing.T) {
 		Name:     "New User",
 	}
 
-	existingUser := &entity.User{
-		ID:       uuid.New(),
-		Email:    "existing@example.com",
-		Username: req.Username,
-		Name:     "Existing User",
-	}
+	existingUser := fixtures.User().WithUsername(req.Username).Build()
 
 	// Mock expectations
 	mockRepo.On("GetByEmail", ctx, req.Email).Return(nil, errors.New("not found"))
@@ -196,22 +266,38 @@ func TestUserService_CreateUser_UsernameExists(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUserService_CreateUser_UsernameNotAllowed(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockLogger := new(MockLogger)
+	reserved := entity.UsernamePolicyFunc(func(username string) bool { return username != "admin" })
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), mockLogger, testIDs, reserved, usecase.DefaultPageLimits, testPasswords)
+
+	ctx := context.Background()
+	req := usecase.CreateUserRequest{
+		Email:    "new@example.com",
+		Username: "admin",
+		Name:     "New User",
+	}
+
+	// Act
+	user, err := service.CreateUser(ctx, req)
+
+	// Assert
+	assert.Equal(t, ErrUsernameNotAllowed, err)
+	assert.Nil(t, user)
+	mockRepo.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+}
+
 func TestUserService_GetUserByID_Success(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
 	mockLogger := new(MockLogger)
-	service := NewUserService(mockRepo, mockLogger)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), mockLogger, testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
 
 	ctx := context.Background()
 	userID := uuid.New()
-	expectedUser := &entity.User{
-		ID:        userID,
-		Email:     "test@example.com",
-		Username:  "testuser",
-		Name:      "Test User",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
+	expectedUser := fixtures.User().WithID(userID).Build()
 
 	// Mock expectations
 	mockRepo.On("GetByID", ctx, userID).Return(expectedUser, nil)
@@ -233,13 +319,13 @@ func TestUserService_GetUserByID_NotFound(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
 	mockLogger := new(MockLogger)
-	service := NewUserService(mockRepo, mockLogger)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), mockLogger, testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
 
 	ctx := context.Background()
 	userID := uuid.New()
 
 	// Mock expectations
-	mockRepo.On("GetByID", ctx, userID).Return(nil, errors.New("not found"))
+	mockRepo.On("GetByID", ctx, userID).Return(nil, nil)
 
 	// Act
 	user, err := service.GetUserByID(ctx, userID)
@@ -255,7 +341,7 @@ func TestUserService_UpdateUserProfile_Success(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
 	mockLogger := new(MockLogger)
-	service := NewUserService(mockRepo, mockLogger)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), mockLogger, testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
 
 	ctx := context.Background()
 	userID := uuid.New()
@@ -285,28 +371,63 @@ func TestUserService_UpdateUserProfile_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, user)
 	assert.Equal(t, req.Name, user.Name)
-	assert.Equal(t, "test@example.com", user.Email)
-	assert.Equal(t, "testuser", user.Username)
+	assert.Equal(t, "test@example.com", user.Email.String())
+	assert.Equal(t, "testuser", user.Username.String())
 	// UpdatedAt should be updated (check that it's after the original time)
 	assert.True(t, user.UpdatedAt.After(originalUpdatedAt))
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUserService_UpdateUserProfile_StaleVersion(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	existingUser := &entity.User{ID: userID, Email: "test@example.com", Username: "testuser", Name: "Old Name", Version: 3}
+	mockRepo.On("GetByID", ctx, userID).Return(existingUser, nil)
+
+	// Act
+	user, err := service.UpdateUserProfile(ctx, usecase.UpdateUserProfileRequest{ID: userID, Name: "New Name", Version: 2})
+
+	// Assert
+	assert.ErrorIs(t, err, repository.ErrConflict)
+	assert.Nil(t, user)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUserService_UpdateUserProfile_ConcurrentModification(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	existingUser := &entity.User{ID: userID, Email: "test@example.com", Username: "testuser", Name: "Old Name", Version: 3}
+	mockRepo.On("GetByID", ctx, userID).Return(existingUser, nil)
+	mockRepo.On("Update", ctx, mock.AnythingOfType("*entity.User")).Return(repository.ErrConflict)
+
+	// Act
+	user, err := service.UpdateUserProfile(ctx, usecase.UpdateUserProfileRequest{ID: userID, Name: "New Name", Version: 3})
+
+	// Assert
+	assert.ErrorIs(t, err, repository.ErrConflict)
+	assert.Nil(t, user)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestUserService_DeleteUser_Success(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
 	mockLogger := new(MockLogger)
-	service := NewUserService(mockRepo, mockLogger)
+	uow := newTestUnitOfWork(mockRepo)
+	service := NewUserService(mockRepo, uow, mockLogger, testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
 
 	ctx := context.Background()
 	userID := uuid.New()
 
-	existingUser := &entity.User{
-		ID:       userID,
-		Email:    "test@example.com",
-		Username: "testuser",
-		Name:     "Test User",
-	}
+	existingUser := fixtures.User().WithID(userID).Build()
 
 	// Mock expectations
 	mockRepo.On("GetByID", ctx, userID).Return(existingUser, nil)
@@ -318,19 +439,23 @@ func TestUserService_DeleteUser_Success(t *testing.T) {
 	// Assert
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
+	if assert.Len(t, uow.records, 1) {
+		assert.Equal(t, entity.AuditUserDeleted, uow.records[0].Action)
+		assert.Equal(t, userID, uow.records[0].UserID)
+	}
 }
 
 func TestUserService_DeleteUser_NotFound(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
 	mockLogger := new(MockLogger)
-	service := NewUserService(mockRepo, mockLogger)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), mockLogger, testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
 
 	ctx := context.Background()
 	userID := uuid.New()
 
 	// Mock expectations
-	mockRepo.On("GetByID", ctx, userID).Return(nil, errors.New("not found"))
+	mockRepo.On("GetByID", ctx, userID).Return(nil, nil)
 
 	// Act
 	err := service.DeleteUser(ctx, userID)
@@ -345,7 +470,7 @@ func TestUserService_ListUsers_Success(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
 	mockLogger := new(MockLogger)
-	service := NewUserService(mockRepo, mockLogger)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), mockLogger, testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
 
 	ctx := context.Background()
 	req := usecase.ListUsersRequest{
@@ -369,8 +494,9 @@ func TestUserService_ListUsers_Success(t *testing.T) {
 	}
 
 	// Mock expectations
-	mockRepo.On("Count", ctx).Return(int64(25), nil)
-	mockRepo.On("List", ctx, req.Offset, req.Limit).Return(expectedUsers, nil)
+	spec := specification.New().Where(req.Filter...)
+	mockRepo.On("Count", ctx, spec).Return(int64(25), nil)
+	mockRepo.On("List", ctx, spec.OrderBy("created_at", specification.Descending).Page(req.Offset, req.Limit)).Return(expectedUsers, nil)
 
 	// Act
 	response, err := service.ListUsers(ctx, req)
@@ -390,7 +516,7 @@ func TestUserService_ListUsers_WithDefaultLimit(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
 	mockLogger := new(MockLogger)
-	service := NewUserService(mockRepo, mockLogger)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), mockLogger, testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
 
 	ctx := context.Background()
 	req := usecase.ListUsersRequest{
@@ -399,8 +525,9 @@ func TestUserService_ListUsers_WithDefaultLimit(t *testing.T) {
 	}
 
 	// Mock expectations
-	mockRepo.On("Count", ctx).Return(int64(5), nil)
-	mockRepo.On("List", ctx, 0, 10).Return([]*entity.User{}, nil) // Expects limit to be 10
+	spec := specification.New().Where(req.Filter...)
+	mockRepo.On("Count", ctx, spec).Return(int64(5), nil)
+	mockRepo.On("List", ctx, spec.OrderBy("created_at", specification.Descending).Page(0, 10)).Return([]*entity.User{}, nil) // Expects limit to be 10
 
 	// Act
 	response, err := service.ListUsers(ctx, req)
@@ -415,7 +542,7 @@ func TestUserService_ListUsers_MaxLimitEnforced(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
 	mockLogger := new(MockLogger)
-	service := NewUserService(mockRepo, mockLogger)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), mockLogger, testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
 
 	ctx := context.Background()
 	req := usecase.ListUsersRequest{
@@ -424,8 +551,9 @@ func TestUserService_ListUsers_MaxLimitEnforced(t *testing.T) {
 	}
 
 	// Mock expectations
-	mockRepo.On("Count", ctx).Return(int64(5), nil)
-	mockRepo.On("List", ctx, 0, 100).Return([]*entity.User{}, nil) // Expects limit to be 100
+	spec := specification.New().Where(req.Filter...)
+	mockRepo.On("Count", ctx, spec).Return(int64(5), nil)
+	mockRepo.On("List", ctx, spec.OrderBy("created_at", specification.Descending).Page(0, 100)).Return([]*entity.User{}, nil) // Expects limit to be 100
 
 	// Act
 	response, err := service.ListUsers(ctx, req)
@@ -434,4 +562,562 @@ func TestUserService_ListUsers_MaxLimitEnforced(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 100, response.Limit) // Should be capped
 	mockRepo.AssertExpectations(t)
-}
\ No newline at end of file
+}
+
+func TestUserService_ListUsers_Sort(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+
+	ctx := context.Background()
+	req := usecase.ListUsersRequest{
+		Limit: 10,
+		Sort:  []specification.Order{{Field: "name", Direction: specification.Ascending}},
+	}
+
+	// Mock expectations: the ID breaks ties between equal names
+	spec := specification.New().Where(req.Filter...)
+	mockRepo.On("Count", ctx, spec).Return(int64(0), nil)
+	mockRepo.On("List", ctx, spec.
+		OrderBy("name", specification.Ascending).
+		OrderBy("id", specification.Ascending).
+		Page(0, 10)).Return([]*entity.User{}, nil)
+
+	// Act
+	_, err := service.ListUsers(ctx, req)
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_ListUsersAfter_HasMore(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockLogger := new(MockLogger)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), mockLogger, testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+
+	ctx := context.Background()
+	req := usecase.ListUsersAfterRequest{Limit: 2}
+
+	now := time.Now()
+	expectedUsers := []*entity.User{
+		{ID: uuid.New(), CreatedAt: now},
+		{ID: uuid.New(), CreatedAt: now.Add(-time.Minute)},
+		{ID: uuid.New(), CreatedAt: now.Add(-2 * time.Minute)},
+	}
+
+	// Mock expectations - one extra row is requested to detect the next page
+	spec := specification.New().Where(req.Filter...).
+		OrderBy("created_at", specification.Descending).
+		OrderBy("id", specification.Descending).
+		Take(3)
+	mockRepo.On("List", ctx, spec).Return(expectedUsers, nil)
+
+	// Act
+	response, err := service.ListUsersAfter(ctx, req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, response.Users, 2)
+	assert.True(t, response.HasMore)
+	assert.Equal(t, repository.CursorOf(expectedUsers[1]), response.NextCursor)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_ListUsersAfter_LastPage(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockLogger := new(MockLogger)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), mockLogger, testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+
+	ctx := context.Background()
+	after := &repository.UserCursor{CreatedAt: time.Now(), ID: uuid.New()}
+	req := usecase.ListUsersAfterRequest{After: after, Limit: 0} // Should be defaulted to 10
+
+	// Mock expectations
+	spec := specification.New().Where(req.Filter...).
+		OrderBy("created_at", specification.Descending).
+		OrderBy("id", specification.Descending).
+		After(after.CreatedAt, after.ID).
+		Take(11)
+	mockRepo.On("List", ctx, spec).Return([]*entity.User{{ID: uuid.New()}}, nil)
+
+	// Act
+	response, err := service.ListUsersAfter(ctx, req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, response.Users, 1)
+	assert.False(t, response.HasMore)
+	assert.Nil(t, response.NextCursor)
+	mockRepo.AssertExpectations(t)
+}
+
+// memoryUserRepository is a minimal concurrent-safe repository whose Create is slow enough
+// for unsynchronized uniqueness checks to race
+type memoryUserRepository struct {
+	MockUserRepository
+	mu    sync.Mutex
+	users []*entity.User
+}
+
+func (m *memoryUserRepository) find(match func(*entity.User) bool) (*entity.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, user := range m.users {
+		if match(user) {
+			return user, nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (m *memoryUserRepository) GetByEmail(_ context.Context, email string) (*entity.User, error) {
+	return m.find(func(u *entity.User) bool { return u.Email.String() == email })
+}
+
+func (m *memoryUserRepository) GetByUsername(_ context.Context, username string) (*entity.User, error) {
+	return m.find(func(u *entity.User) bool { return u.Username.String() == username })
+}
+
+func (m *memoryUserRepository) Create(_ context.Context, user *entity.User) error {
+	time.Sleep(20 * time.Millisecond)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users = append(m.users, user)
+	return nil
+}
+
+func (m *memoryUserRepository) CreateBatch(_ context.Context, users []*entity.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.users = append(m.users, users...)
+	return nil
+}
+
+func TestUserService_CreateUser_ConcurrentDuplicates(t *testing.T) {
+	repo := &memoryUserRepository{}
+	service := NewUserService(repo, newTestUnitOfWork(repo), new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+
+	req := usecase.CreateUserRequest{
+		Email:    "race@example.com",
+		Username: "racer",
+		Name:     "Race Condition",
+	}
+
+	const callers = 8
+	errs := make(chan error, callers)
+	var start sync.WaitGroup
+	start.Add(1)
+	for i := 0; i < callers; i++ {
+		go func() {
+			start.Wait()
+			_, err := service.CreateUser(context.Background(), req)
+			errs <- err
+		}()
+	}
+	start.Done()
+
+	created, conflicts := 0, 0
+	for i := 0; i < callers; i++ {
+		switch err := <-errs; {
+		case err == nil:
+			created++
+		case errors.Is(err, ErrUserAlreadyExists):
+			conflicts++
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	assert.Equal(t, 1, created)
+	assert.Equal(t, callers-1, conflicts)
+	assert.Len(t, repo.users, 1)
+}
+
+func TestKeyedLock_ReleasesKeys(t *testing.T) {
+	var l keyedLock
+
+	unlock := l.lock("email:a", "username:a", "email:a")
+	assert.Len(t, l.locks, 2)
+	unlock()
+	assert.Empty(t, l.locks)
+}
+
+func TestUserService_CreateUser_NormalizesAndValidates(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+	ctx := context.Background()
+
+	mockRepo.On("GetByEmail", ctx, "mixed@example.com").Return(nil, errors.New("not found"))
+	mockRepo.On("GetByUsername", ctx, "mixedcase").Return(nil, errors.New("not found"))
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*entity.User")).Return(nil)
+
+	user, err := service.CreateUser(ctx, usecase.CreateUserRequest{
+		Email:    " Mixed@Example.com",
+		Username: "MixedCase ",
+		Name:     "Mixed",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, entity.Email("mixed@example.com"), user.Email)
+	assert.Equal(t, entity.Username("mixedcase"), user.Username)
+
+	_, err = service.CreateUser(ctx, usecase.CreateUserRequest{Email: "not-an-email", Username: "valid", Name: "Invalid"})
+	assert.ErrorIs(t, err, entity.ErrInvalidEmail)
+	mockRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestUserService_CreateUser_RecordsAudit(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uow := newTestUnitOfWork(mockRepo)
+	service := NewUserService(mockRepo, uow, new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+	ctx := context.Background()
+
+	mockRepo.On("GetByEmail", ctx, "audit@example.com").Return(nil, errors.New("not found"))
+	mockRepo.On("GetByUsername", ctx, "audited").Return(nil, errors.New("not found"))
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*entity.User")).Return(nil)
+
+	user, err := service.CreateUser(ctx, usecase.CreateUserRequest{Email: "audit@example.com", Username: "audited", Name: "Audit"})
+	assert.NoError(t, err)
+	if assert.Len(t, uow.records, 1) {
+		assert.Equal(t, entity.AuditUserCreated, uow.records[0].Action)
+		assert.Equal(t, user.ID, uow.records[0].UserID)
+		assert.NotEqual(t, user.ID, uow.records[0].ID)
+	}
+}
+
+func TestUserService_CreateUsers_ReportsEachItem(t *testing.T) {
+	// Arrange
+	repo := &memoryUserRepository{}
+	repo.users = append(repo.users, fixtures.User().WithEmail("taken@example.com").Build())
+	uow := newTestUnitOfWork(repo)
+	service := NewUserService(repo, uow, new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+
+	reqs := []usecase.CreateUserRequest{
+		{Email: "alice@example.com", Username: "alice", Name: "Alice"},
+		{Email: "not-an-email", Username: "bob", Name: "Bob"},
+		{Email: "ALICE@example.com", Username: "alice2", Name: "Alice again"},
+		{Email: "taken@example.com", Username: "carol", Name: "Carol"},
+		{Email: "dave@example.com", Username: "dave", Name: "Dave"},
+	}
+
+	// Act
+	response, err := service.CreateUsers(context.Background(), reqs)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, response.Results, len(reqs))
+	assert.Equal(t, "alice@example.com", response.Results[0].User.Email.String())
+	assert.ErrorIs(t, response.Results[1].Err, entity.ErrInvalidEmail)
+	assert.Equal(t, ErrUserAlreadyExists, response.Results[2].Err, "duplicates within the batch are rejected")
+	assert.Equal(t, ErrUserAlreadyExists, response.Results[3].Err)
+	assert.Equal(t, "dave", response.Results[4].User.Username.String())
+	assert.Len(t, repo.users, 3)
+	assert.Len(t, uow.records, 2, "one audit record per created user")
+}
+
+func TestUserService_CreateUsers_BatchSize(t *testing.T) {
+	service := NewUserService(new(MockUserRepository), nil, new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+
+	_, err := service.CreateUsers(context.Background(), nil)
+	assert.Equal(t, ErrInvalidBatchSize, err)
+
+	_, err = service.CreateUsers(context.Background(), make([]usecase.CreateUserRequest, usecase.MaxCreateUsersBatch+1))
+	assert.Equal(t, ErrInvalidBatchSize, err)
+}
+
+func TestUserService_CreateUsers_FallsBackWhenBatchHitsDuplicate(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+	ctx := context.Background()
+
+	mockRepo.On("GetByEmail", ctx, mock.Anything).Return(nil, nil)
+	mockRepo.On("GetByUsername", ctx, mock.Anything).Return(nil, nil)
+	mockRepo.On("CreateBatch", ctx, mock.Anything).Return(repository.ErrDuplicate)
+	// Another instance registered bob between the checks and the insert
+	mockRepo.On("Create", ctx, mock.MatchedBy(func(u *entity.User) bool { return u.Username == "bob" })).Return(repository.ErrDuplicate)
+	mockRepo.On("Create", ctx, mock.Anything).Return(nil)
+
+	// Act
+	response, err := service.CreateUsers(ctx, []usecase.CreateUserRequest{
+		{Email: "alice@example.com", Username: "alice", Name: "Alice"},
+		{Email: "bob@example.com", Username: "bob", Name: "Bob"},
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotNil(t, response.Results[0].User)
+	assert.Equal(t, ErrUserAlreadyExists, response.Results[1].Err)
+	mockRepo.AssertExpectations(t)
+}
+
+// blockingUserRepository blocks reads until the caller's context is done, like a query the database aborts
+type blockingUserRepository struct {
+	MockUserRepository
+}
+
+func (r *blockingUserRepository) GetByID(ctx context.Context, _ uuid.UUID) (*entity.User, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestUserService_GetUserByID_Cancelled(t *testing.T) {
+	// Arrange
+	repo := &blockingUserRepository{}
+	service := NewUserService(repo, newTestUnitOfWork(repo), new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	// Act
+	user, err := service.GetUserByID(ctx, uuid.New())
+
+	// Assert
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, context.Canceled, "a cancelled lookup is not reported as a missing user")
+	assert.NotErrorIs(t, err, ErrUserNotFound)
+
+	err = service.DeleteUser(ctx, uuid.New())
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestUserService_GetUserByID_BudgetExceeded(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+	ctx := context.Background()
+	exhausted, broken := uuid.New(), uuid.New()
+	mockRepo.On("GetByID", ctx, exhausted).Return(nil, repository.ErrBudgetExceeded)
+	mockRepo.On("GetByID", ctx, broken).Return(nil, errors.New("connection reset"))
+
+	// Act
+	user, err := service.GetUserByID(ctx, exhausted)
+
+	// Assert
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, repository.ErrBudgetExceeded, "an exhausted budget is not reported as a missing user")
+	assert.NotErrorIs(t, err, ErrUserNotFound)
+
+	_, err = service.UpdateUserProfile(ctx, usecase.UpdateUserProfileRequest{ID: exhausted, Name: "Name"})
+	assert.ErrorIs(t, err, repository.ErrBudgetExceeded)
+	assert.ErrorIs(t, service.ChangePassword(ctx, usecase.ChangePasswordRequest{ID: exhausted, NewPassword: "long-enough"}), repository.ErrBudgetExceeded)
+	assert.ErrorIs(t, service.DeleteUser(ctx, exhausted), repository.ErrBudgetExceeded)
+
+	// Database failures are not reported as a missing user either
+	_, err = service.GetUserByID(ctx, broken)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestUserService_ExportUsers_ReadsInChunks(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+	ctx := context.Background()
+
+	first := fixtures.Users(usecase.ExportChunkSize)
+	second := fixtures.Users(2)
+	var noCursor *repository.UserCursor
+	mockRepo.On("List", ctx, noCursor.Spec(specification.New().Where()).Take(usecase.ExportChunkSize)).Return(first, nil)
+	mockRepo.On("List", ctx, repository.CursorOf(first[len(first)-1]).Spec(specification.New().Where()).Take(usecase.ExportChunkSize)).Return(second, nil)
+
+	// Act
+	exported := 0
+	err := service.ExportUsers(ctx, usecase.ExportUsersRequest{}, func(*entity.User) error {
+		exported++
+		return nil
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, usecase.ExportChunkSize+2, exported)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_ImportUsers_DryRunWritesNothing(t *testing.T) {
+	// Arrange
+	repo := &memoryUserRepository{}
+	repo.users = append(repo.users, fixtures.User().WithEmail("taken@example.com").WithUsername("taken").Build())
+	uow := newTestUnitOfWork(repo)
+	service := NewUserService(repo, uow, new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+
+	req := usecase.ImportUsersRequest{
+		Users: []usecase.CreateUserRequest{
+			{Email: "alice@example.com", Username: "alice", Name: "Alice"},
+			{Email: "not-an-email", Username: "bob", Name: "Bob"},
+			{Email: "ALICE@example.com", Username: "alice2", Name: "Alice again"},
+			{Email: "taken@example.com", Username: "carol", Name: "Carol"},
+		},
+		OnDuplicate: usecase.DuplicateSkip,
+		DryRun:      true,
+	}
+
+	// Act
+	response, err := service.ImportUsers(context.Background(), req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, response.DryRun)
+	actions := make([]usecase.ImportAction, len(response.Results))
+	for i, result := range response.Results {
+		actions[i] = result.Action
+	}
+	assert.Equal(t, []usecase.ImportAction{usecase.ImportCreated, usecase.ImportFailed, usecase.ImportSkipped, usecase.ImportSkipped}, actions)
+	assert.ErrorIs(t, response.Results[1].Err, entity.ErrInvalidEmail)
+	assert.Equal(t, "taken", response.Results[3].User.Username.String(), "a skipped row reports the existing user")
+	assert.Len(t, repo.users, 1)
+	assert.Empty(t, uow.records)
+
+	// The same import for real creates what the dry run announced
+	req.DryRun = false
+	response, err = service.ImportUsers(context.Background(), req)
+	assert.NoError(t, err)
+	assert.False(t, response.DryRun)
+	assert.Equal(t, usecase.ImportCreated, response.Results[0].Action)
+	assert.Len(t, repo.users, 2)
+	assert.Len(t, uow.records, 1)
+}
+
+func TestUserService_ImportUsers_Overwrite(t *testing.T) {
+	// Arrange
+	repo := &memoryUserRepository{}
+	existing := fixtures.User().WithEmail("alice@example.com").WithUsername("alice").WithName("Old").Build()
+	other := fixtures.User().WithEmail("bob@example.com").WithUsername("bob").WithName("Bob").Build()
+	repo.users = append(repo.users, existing, other)
+	repo.On("Update", mock.Anything, mock.AnythingOfType("*entity.User")).Return(nil)
+	service := NewUserService(repo, newTestUnitOfWork(repo), new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+
+	// Act
+	response, err := service.ImportUsers(context.Background(), usecase.ImportUsersRequest{
+		Users: []usecase.CreateUserRequest{
+			{Email: "alice@example.com", Username: "alice", Name: "New"},
+			{Email: "bob@example.com", Username: "alice", Name: "Mixed"},
+			{Email: "bob@example.com", Username: "bob", Name: "Bob"},
+			{Email: "carol@example.com", Username: "carol", Name: "Carol"},
+		},
+		OnDuplicate: usecase.DuplicateOverwrite,
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, usecase.ImportUpdated, response.Results[0].Action)
+	assert.Equal(t, "New", response.Results[0].User.Name)
+	assert.Equal(t, usecase.ImportFailed, response.Results[1].Action)
+	assert.Equal(t, ErrUserAlreadyExists, response.Results[1].Err, "the row names two different users")
+	assert.Equal(t, usecase.ImportSkipped, response.Results[2].Action, "an unchanged row is not written")
+	assert.Equal(t, usecase.ImportCreated, response.Results[3].Action)
+	repo.AssertNumberOfCalls(t, "Update", 1)
+	assert.Len(t, repo.users, 3)
+}
+
+func TestUserService_ImportUsers_ErrorOnDuplicate(t *testing.T) {
+	repo := &memoryUserRepository{}
+	repo.users = append(repo.users, fixtures.User().WithEmail("taken@example.com").Build())
+	service := NewUserService(repo, newTestUnitOfWork(repo), new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+
+	response, err := service.ImportUsers(context.Background(), usecase.ImportUsersRequest{
+		Users: []usecase.CreateUserRequest{
+			{Email: "taken@example.com", Username: "someone", Name: "Someone"},
+			{Email: "dave@example.com", Username: "dave", Name: "Dave"},
+		},
+		OnDuplicate: usecase.DuplicateError,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ErrUserAlreadyExists, response.Results[0].Err)
+	assert.Equal(t, usecase.ImportCreated, response.Results[1].Action)
+	assert.Len(t, repo.users, 2)
+}
+
+func TestUserService_ImportUsers_InvalidRequest(t *testing.T) {
+	service := NewUserService(new(MockUserRepository), nil, new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+	ctx := context.Background()
+
+	_, err := service.ImportUsers(ctx, usecase.ImportUsersRequest{OnDuplicate: usecase.DuplicateSkip})
+	assert.Equal(t, ErrInvalidImportSize, err)
+
+	_, err = service.ImportUsers(ctx, usecase.ImportUsersRequest{
+		Users:       make([]usecase.CreateUserRequest, usecase.MaxImportUsers+1),
+		OnDuplicate: usecase.DuplicateSkip,
+	})
+	assert.Equal(t, ErrInvalidImportSize, err)
+
+	_, err = service.ImportUsers(ctx, usecase.ImportUsersRequest{
+		Users:       []usecase.CreateUserRequest{{Email: "a@example.com", Username: "abc", Name: "A"}},
+		OnDuplicate: "replace",
+	})
+	assert.Equal(t, ErrInvalidDuplicatePolicy, err)
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	uow := newTestUnitOfWork(mockRepo)
+	service := NewUserService(mockRepo, uow, new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+	ctx := context.Background()
+
+	user := fixtures.User().Build()
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockRepo.On("Update", ctx, mock.AnythingOfType("*entity.User")).Return(nil)
+
+	// Act: the first password needs no current password
+	err := service.ChangePassword(ctx, usecase.ChangePasswordRequest{ID: user.ID, NewPassword: "first-password"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "hashed:first-password", user.PasswordHash)
+	if assert.Len(t, uow.records, 1) {
+		assert.Equal(t, entity.AuditPasswordChanged, uow.records[0].Action)
+	}
+
+	// Replacing it requires the current one
+	err = service.ChangePassword(ctx, usecase.ChangePasswordRequest{ID: user.ID, CurrentPassword: "guess", NewPassword: "second-password"})
+	assert.Equal(t, ErrWrongPassword, err)
+
+	err = service.ChangePassword(ctx, usecase.ChangePasswordRequest{ID: user.ID, CurrentPassword: "first-password", NewPassword: "second-password"})
+	assert.NoError(t, err)
+	assert.Equal(t, "hashed:second-password", user.PasswordHash)
+	mockRepo.AssertNumberOfCalls(t, "Update", 2)
+}
+
+func TestUserService_ChangePassword_Reset(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	uow := newTestUnitOfWork(mockRepo)
+	service := NewUserService(mockRepo, uow, new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+	ctx := context.Background()
+
+	user := fixtures.User().Build()
+	user.PasswordHash = "hashed:forgotten"
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockRepo.On("Update", ctx, mock.AnythingOfType("*entity.User")).Return(nil)
+
+	// A reset replaces the password without the current one
+	err := service.ChangePassword(ctx, usecase.ChangePasswordRequest{ID: user.ID, NewPassword: "new-password", Reset: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "hashed:new-password", user.PasswordHash)
+	if assert.Len(t, uow.records, 1) {
+		assert.Equal(t, entity.AuditPasswordReset, uow.records[0].Action)
+	}
+}
+
+func TestUserService_ChangePassword_Rejected(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, newTestUnitOfWork(mockRepo), new(MockLogger), testIDs, allowAllUsernames, usecase.DefaultPageLimits, testPasswords)
+	ctx := context.Background()
+
+	user := fixtures.User().Build()
+	missing := uuid.New()
+	mockRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	mockRepo.On("GetByID", ctx, missing).Return(nil, nil)
+
+	err := service.ChangePassword(ctx, usecase.ChangePasswordRequest{ID: user.ID, NewPassword: "short"})
+	assert.ErrorIs(t, err, entity.ErrInvalidPassword)
+
+	err = service.ChangePassword(ctx, usecase.ChangePasswordRequest{ID: missing, NewPassword: "long-enough"})
+	assert.Equal(t, ErrUserNotFound, err)
+
+	assert.False(t, user.HasPassword())
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
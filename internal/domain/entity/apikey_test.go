@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	id := uuid.New()
+
+	key, secret, err := NewAPIKey(id, "  billing sync ", []string{"users:read", "Users:Read", "reports.export"})
+
+	require.NoError(t, err)
+	assert.Equal(t, id, key.ID)
+	assert.Equal(t, "billing sync", key.Name)
+	assert.True(t, strings.HasPrefix(secret, APIKeyPrefix))
+	assert.True(t, strings.HasPrefix(secret, key.DisplayPrefix))
+	assert.Len(t, key.DisplayPrefix, len(APIKeyPrefix)+8)
+	assert.Equal(t, HashAPIKey(secret), key.SecretHash)
+	assert.NotContains(t, key.SecretHash, secret)
+	assert.Equal(t, []string{"reports.export", "users:read"}, key.Scopes)
+	assert.True(t, key.Active())
+
+	_, other, err := NewAPIKey(uuid.New(), "other", nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other, "every key gets its own secret")
+}
+
+func TestNewAPIKey_Invalid(t *testing.T) {
+	tooMany := make([]string, MaxAPIKeyScopes+1)
+	for i := range tooMany {
+		tooMany[i] = "scope" + string(rune('a'+i))
+	}
+
+	tests := map[string]struct {
+		name   string
+		scopes []string
+	}{
+		"empty name":      {name: " "},
+		"long name":       {name: strings.Repeat("a", 101)},
+		"blank scope":     {name: "sync", scopes: []string{""}},
+		"malformed scope": {name: "sync", scopes: []string{"users read"}},
+		"too many scopes": {name: "sync", scopes: tooMany},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := NewAPIKey(uuid.New(), tt.name, tt.scopes)
+			assert.ErrorIs(t, err, ErrInvalidAPIKey)
+		})
+	}
+}
+
+func TestAPIKey_Revoke(t *testing.T) {
+	key, _, err := NewAPIKey(uuid.New(), "sync", nil)
+	require.NoError(t, err)
+
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	key.Revoke(first)
+	key.Revoke(first.Add(time.Hour))
+
+	assert.False(t, key.Active())
+	assert.Equal(t, first, *key.RevokedAt)
+}
@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditAction names a change recorded in the audit trail
+type AuditAction string
+
+const (
+	AuditUserCreated     AuditAction = "user.created"
+	AuditUserDeleted     AuditAction = "user.deleted"
+	AuditPasswordChanged AuditAction = "user.password_changed"
+	AuditPasswordReset   AuditAction = "user.password_reset"
+)
+
+// AuditRecord is an entry of the audit trail, written in the same transaction as the change it describes
+type AuditRecord struct {
+	ID        uuid.UUID   `json:"id"`
+	Action    AuditAction `json:"action"`
+	UserID    uuid.UUID   `json:"user_id"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// NewAuditRecord creates an audit record for an action on the given user
+func NewAuditRecord(id uuid.UUID, action AuditAction, userID uuid.UUID) *AuditRecord {
+	return &AuditRecord{
+		ID:        id,
+		Action:    action,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+	}
+}
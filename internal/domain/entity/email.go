@@ -0,0 +1,57 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// MaxEmailLength matches the width of the users.email column
+const MaxEmailLength = 255
+
+// ErrInvalidEmail is returned when a string is not a usable email address
+var ErrInvalidEmail = errors.New("invalid email")
+
+// Email is a normalized (trimmed, lower-cased) and validated email address.
+// It marshals as a plain JSON string; decoding validates like NewEmail
+type Email string
+
+// NewEmail normalizes raw and validates it as a bare address such as alice@example.com
+func NewEmail(raw string) (Email, error) {
+	normalized := strings.ToLower(strings.TrimSpace(raw))
+	if normalized == "" {
+		return "", fmt.Errorf("%w: email is required", ErrInvalidEmail)
+	}
+	if len(normalized) > MaxEmailLength {
+		return "", fmt.Errorf("%w: email must be at most %d characters", ErrInvalidEmail, MaxEmailLength)
+	}
+
+	// ParseAddress also accepts "Name <addr>", only the bare address form is allowed
+	address, err := mail.ParseAddress(normalized)
+	if err != nil || address.Address != normalized {
+		return "", fmt.Errorf("%w: %q is not a valid email address", ErrInvalidEmail, raw)
+	}
+	return Email(normalized), nil
+}
+
+// String returns the address
+func (e Email) String() string {
+	return string(e)
+}
+
+// Domain returns the part after the @
+func (e Email) Domain() string {
+	_, domain, _ := strings.Cut(string(e), "@")
+	return domain
+}
+
+// UnmarshalText validates and normalizes the decoded value
+func (e *Email) UnmarshalText(text []byte) error {
+	email, err := NewEmail(string(text))
+	if err != nil {
+		return err
+	}
+	*e = email
+	return nil
+}
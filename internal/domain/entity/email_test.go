@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEmail(t *testing.T) {
+	email, err := NewEmail("  Alice@Example.COM ")
+	assert.NoError(t, err)
+	assert.Equal(t, Email("alice@example.com"), email)
+	assert.Equal(t, "example.com", email.Domain())
+
+	for _, raw := range []string{"", "alice", "alice@", "Alice <alice@example.com>", "a b@example.com"} {
+		_, err := NewEmail(raw)
+		assert.ErrorIs(t, err, ErrInvalidEmail, raw)
+	}
+}
+
+func TestEmail_JSON(t *testing.T) {
+	var decoded struct {
+		Email Email `json:"email"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"email":"Bob@Example.com"}`), &decoded))
+	assert.Equal(t, Email("bob@example.com"), decoded.Email)
+
+	data, err := json.Marshal(decoded)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"email":"bob@example.com"}`, string(data))
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"email":"nope"}`), &decoded), ErrInvalidEmail)
+}
@@ -0,0 +1,17 @@
+package entity
+
+import "github.com/google/uuid"
+
+// IDGenerator is the port through which new entities obtain their identifiers.
+// Implementations live in the infrastructure layer and decide the ID strategy
+type IDGenerator interface {
+	NewID() (uuid.UUID, error)
+}
+
+// IDGeneratorFunc adapts a function to the IDGenerator interface
+type IDGeneratorFunc func() (uuid.UUID, error)
+
+// NewID calls f()
+func (f IDGeneratorFunc) NewID() (uuid.UUID, error) {
+	return f()
+}
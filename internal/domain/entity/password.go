@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// MinPasswordLength is the shortest password SetPassword accepts
+	MinPasswordLength = 8
+	// MaxPasswordLength is the longest password in bytes, bcrypt ignores anything beyond it
+	MaxPasswordLength = 72
+)
+
+// ErrInvalidPassword is returned when a password does not meet the length rules
+var ErrInvalidPassword = errors.New("invalid password")
+
+// PasswordHasher is the port through which passwords are hashed and verified.
+// Implementations live in the infrastructure layer and decide the algorithm
+type PasswordHasher interface {
+	// Hash returns a salted hash of password that encodes its own algorithm and parameters
+	Hash(password string) (string, error)
+	// Verify reports whether password matches hash
+	Verify(hash, password string) bool
+}
+
+// SetPassword validates password and replaces the stored hash with a new one
+func (u *User) SetPassword(password string, hasher PasswordHasher) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("%w: password must be at least %d characters", ErrInvalidPassword, MinPasswordLength)
+	}
+	if len(password) > MaxPasswordLength {
+		return fmt.Errorf("%w: password must be at most %d bytes", ErrInvalidPassword, MaxPasswordLength)
+	}
+
+	hash, err := hasher.Hash(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	u.PasswordHash = hash
+	u.UpdatedAt = time.Now()
+	return nil
+}
+
+// CheckPassword reports whether password matches the stored hash, always false for a user without a password
+func (u *User) CheckPassword(password string, hasher PasswordHasher) bool {
+	return u.HasPassword() && hasher.Verify(u.PasswordHash, password)
+}
+
+// HasPassword reports whether a password was ever set
+func (u *User) HasPassword() bool {
+	return u.PasswordHash != ""
+}
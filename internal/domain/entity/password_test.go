@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// reverseHasher stands in for a real hash in domain tests
+type reverseHasher struct{}
+
+func (reverseHasher) Hash(password string) (string, error) {
+	runes := []rune(password)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes), nil
+}
+
+func (h reverseHasher) Verify(hash, password string) bool {
+	expected, _ := h.Hash(password)
+	return hash == expected
+}
+
+func TestUser_SetAndCheckPassword(t *testing.T) {
+	user := NewUser(uuid.New(), "alice@example.com", "alice", "Alice")
+	assert.False(t, user.HasPassword())
+	assert.False(t, user.CheckPassword("", reverseHasher{}), "a user without a password matches nothing")
+
+	assert.NoError(t, user.SetPassword("s3cret-pass", reverseHasher{}))
+	assert.True(t, user.HasPassword())
+	assert.Equal(t, "ssap-terc3s", user.PasswordHash)
+	assert.True(t, user.CheckPassword("s3cret-pass", reverseHasher{}))
+	assert.False(t, user.CheckPassword("other-pass", reverseHasher{}))
+}
+
+func TestUser_SetPasswordLength(t *testing.T) {
+	user := NewUser(uuid.New(), "alice@example.com", "alice", "Alice")
+
+	for _, password := range []string{"", "short", strings.Repeat("a", MaxPasswordLength+1)} {
+		err := user.SetPassword(password, reverseHasher{})
+		assert.ErrorIs(t, err, ErrInvalidPassword)
+	}
+	assert.False(t, user.HasPassword())
+}
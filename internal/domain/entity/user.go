@@ -8,21 +8,27 @@ import (
 // User represents the core business entity for users
 type User struct {
 	ID        uuid.UUID `json:"id"`
-	Email     string    `json:"email"`
-	Username  string    `json:"username"`
+	Email     Email     `json:"email"`
+	Username  Username  `json:"username"`
 	Name      string    `json:"name"`
+	// Version is incremented by every update and guards against lost updates (optimistic locking)
+	Version   int64     `json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// PasswordHash is set through SetPassword and never leaves the service, empty when no password was set
+	PasswordHash string `json:"-"`
 }
 
-// NewUser creates a new user entity with generated ID and timestamps
-func NewUser(email, username, name string) *User {
+// NewUser creates a new user entity with the given ID and fresh timestamps,
+// email and username are already validated value objects
+func NewUser(id uuid.UUID, email Email, username Username, name string) *User {
 	now := time.Now()
 	return &User{
-		ID:        uuid.New(),
+		ID:        id,
 		Email:     email,
 		Username:  username,
 		Name:      name,
+		Version:   1,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MinUsernameLength is the shortest username that can be registered
+	MinUsernameLength = 3
+	// MaxUsernameLength matches the width of the users.username column
+	MaxUsernameLength = 50
+)
+
+// ErrInvalidUsername is returned when a string is not a usable username
+var ErrInvalidUsername = errors.New("invalid username")
+
+// Username is a normalized (trimmed, lower-cased) and validated username.
+// It marshals as a plain JSON string; decoding validates like NewUsername
+type Username string
+
+// NewUsername normalizes raw and validates its length and characters
+func NewUsername(raw string) (Username, error) {
+	normalized := strings.ToLower(strings.TrimSpace(raw))
+
+	length := utf8.RuneCountInString(normalized)
+	if length < MinUsernameLength || length > MaxUsernameLength {
+		return "", fmt.Errorf("%w: username must be %d to %d characters", ErrInvalidUsername, MinUsernameLength, MaxUsernameLength)
+	}
+	for _, r := range normalized {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return "", fmt.Errorf("%w: username must not contain spaces or control characters", ErrInvalidUsername)
+		}
+	}
+	return Username(normalized), nil
+}
+
+// String returns the username
+func (u Username) String() string {
+	return string(u)
+}
+
+// UnmarshalText validates and normalizes the decoded value
+func (u *Username) UnmarshalText(text []byte) error {
+	username, err := NewUsername(string(text))
+	if err != nil {
+		return err
+	}
+	*u = username
+	return nil
+}
+
+// UsernamePolicy is the port deciding whether a username may be registered,
+// e.g. rejecting reserved words such as "admin" or offensive terms
+type UsernamePolicy interface {
+	Allowed(username string) bool
+}
+
+// UsernamePolicyFunc adapts a function to the UsernamePolicy interface
+type UsernamePolicyFunc func(username string) bool
+
+// Allowed calls f(username)
+func (f UsernamePolicyFunc) Allowed(username string) bool {
+	return f(username)
+}
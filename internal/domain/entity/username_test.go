@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUsername(t *testing.T) {
+	username, err := NewUsername(" Alice_01 ")
+	assert.NoError(t, err)
+	assert.Equal(t, Username("alice_01"), username)
+
+	for _, raw := range []string{"ab", strings.Repeat("a", MaxUsernameLength+1), "two words", "tab\there"} {
+		_, err := NewUsername(raw)
+		assert.ErrorIs(t, err, ErrInvalidUsername, raw)
+	}
+}
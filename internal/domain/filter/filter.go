@@ -0,0 +1,225 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	ErrSyntax          = errors.New("invalid filter syntax")
+	ErrUnknownField    = errors.New("unknown filter field")
+	ErrInvalidOperator = errors.New("operator not supported for field")
+	ErrInvalidValue    = errors.New("invalid filter value")
+)
+
+// MaxConditions bounds the number of AND-joined conditions in one expression
+const MaxConditions = 10
+
+// Kind describes the value type of a filterable field
+type Kind int
+
+const (
+	String Kind = iota
+	Time
+)
+
+// Operator is a comparison operator supported by the filter grammar
+type Operator string
+
+const (
+	Equal          Operator = "="
+	NotEqual       Operator = "!="
+	Greater        Operator = ">"
+	GreaterOrEqual Operator = ">="
+	Less           Operator = "<"
+	LessOrEqual    Operator = "<="
+	Contains       Operator = "~"
+	Prefix         Operator = "^="
+)
+
+// operators is ordered so that two-character operators are matched first
+var operators = []Operator{GreaterOrEqual, LessOrEqual, NotEqual, Prefix, Equal, Greater, Less, Contains}
+
+// Condition is a single validated predicate, Value is a string or time.Time depending on the field kind
+type Condition struct {
+	Field    string
+	Operator Operator
+	Value    interface{}
+}
+
+// Expression is a list of conditions joined with AND
+type Expression []Condition
+
+// Fields is the whitelist of filterable fields and their kinds
+type Fields map[string]Kind
+
+// NewCondition validates a single condition given outside the expression grammar, e.g. as its own query parameter
+func NewCondition(field string, op Operator, raw string, fields Fields) (Condition, error) {
+	kind, ok := fields[field]
+	if !ok {
+		return Condition{}, fmt.Errorf("%w: %s", ErrUnknownField, field)
+	}
+
+	value, err := convert(field, kind, op, raw)
+	if err != nil {
+		return Condition{}, err
+	}
+	return Condition{Field: field, Operator: op, Value: value}, nil
+}
+
+// Parse parses an expression such as `created_at>=2024-01-01 AND email~"@corp.com"`
+// and validates every condition against the whitelisted fields
+func Parse(input string, fields Fields) (Expression, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, nil
+	}
+
+	p := &parser{input: input}
+	expr := make(Expression, 0)
+	for {
+		cond, err := p.condition(fields)
+		if err != nil {
+			return nil, err
+		}
+		expr = append(expr, cond)
+		if len(expr) > MaxConditions {
+			return nil, fmt.Errorf("%w: at most %d conditions are allowed", ErrSyntax, MaxConditions)
+		}
+
+		p.skipSpaces()
+		if p.done() {
+			return expr, nil
+		}
+		if !p.keyword("AND") {
+			return nil, fmt.Errorf("%w: expected AND at position %d", ErrSyntax, p.pos)
+		}
+	}
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) skipSpaces() {
+	for !p.done() && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// keyword consumes a case-insensitive keyword followed by at least one space
+func (p *parser) keyword(word string) bool {
+	end := p.pos + len(word)
+	if end >= len(p.input) || !strings.EqualFold(p.input[p.pos:end], word) || p.input[end] != ' ' {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+func (p *parser) condition(fields Fields) (Condition, error) {
+	p.skipSpaces()
+
+	start := p.pos
+	for !p.done() && (p.input[p.pos] == '_' || unicode.IsLetter(rune(p.input[p.pos]))) {
+		p.pos++
+	}
+	name := strings.ToLower(p.input[start:p.pos])
+	if name == "" {
+		return Condition{}, fmt.Errorf("%w: expected field name at position %d", ErrSyntax, start)
+	}
+	kind, ok := fields[name]
+	if !ok {
+		return Condition{}, fmt.Errorf("%w: %s", ErrUnknownField, name)
+	}
+
+	p.skipSpaces()
+	op, ok := p.operator()
+	if !ok {
+		return Condition{}, fmt.Errorf("%w: expected operator at position %d", ErrSyntax, p.pos)
+	}
+
+	p.skipSpaces()
+	raw, err := p.value()
+	if err != nil {
+		return Condition{}, err
+	}
+
+	value, err := convert(name, kind, op, raw)
+	if err != nil {
+		return Condition{}, err
+	}
+
+	return Condition{Field: name, Operator: op, Value: value}, nil
+}
+
+func (p *parser) operator() (Operator, bool) {
+	for _, op := range operators {
+		if strings.HasPrefix(p.input[p.pos:], string(op)) {
+			p.pos += len(op)
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// value reads either a double-quoted string (with \" and \\ escapes) or a bare word
+func (p *parser) value() (string, error) {
+	if p.done() {
+		return "", fmt.Errorf("%w: expected value at position %d", ErrSyntax, p.pos)
+	}
+
+	if p.input[p.pos] != '"' {
+		start := p.pos
+		for !p.done() && p.input[p.pos] != ' ' {
+			p.pos++
+		}
+		return p.input[start:p.pos], nil
+	}
+
+	var sb strings.Builder
+	p.pos++
+	for !p.done() {
+		ch := p.input[p.pos]
+		switch {
+		case ch == '\\' && p.pos+1 < len(p.input):
+			sb.WriteByte(p.input[p.pos+1])
+			p.pos += 2
+		case ch == '"':
+			p.pos++
+			return sb.String(), nil
+		default:
+			sb.WriteByte(ch)
+			p.pos++
+		}
+	}
+	return "", fmt.Errorf("%w: unterminated string", ErrSyntax)
+}
+
+func convert(field string, kind Kind, op Operator, raw string) (interface{}, error) {
+	switch kind {
+	case Time:
+		if op == Contains || op == Prefix {
+			return nil, fmt.Errorf("%w: %s %s", ErrInvalidOperator, field, op)
+		}
+		for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+			if t, err := time.Parse(layout, raw); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %s expects a date (2006-01-02) or RFC3339 timestamp", ErrInvalidValue, field)
+	default:
+		if raw == "" {
+			return nil, fmt.Errorf("%w: %s expects a non-empty value", ErrInvalidValue, field)
+		}
+		return raw, nil
+	}
+}
@@ -0,0 +1,15 @@
+package filter
+
+import "testing"
+
+func BenchmarkParse(b *testing.B) {
+	fields := Fields{"email": String, "name": String, "created_at": Time}
+	input := `created_at>=2024-01-01 AND email~"@corp.com" AND name!="Test User"`
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(input, fields); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
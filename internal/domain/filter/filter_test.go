@@ -0,0 +1,90 @@
+package filter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testFields = Fields{
+	"email":      String,
+	"created_at": Time,
+}
+
+func TestParse_Empty(t *testing.T) {
+	// Act
+	expr, err := Parse("   ", testFields)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Nil(t, expr)
+}
+
+func TestParse_MultipleConditions(t *testing.T) {
+	// Act
+	expr, err := Parse(`created_at>=2024-01-01 and email ~ "@corp.com"`, testFields)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, Expression{
+		{Field: "created_at", Operator: GreaterOrEqual, Value: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Field: "email", Operator: Contains, Value: "@corp.com"},
+	}, expr)
+}
+
+func TestParse_QuotedValueWithEscapes(t *testing.T) {
+	// Act
+	expr, err := Parse(`email="a \"b\" AND c"`, testFields)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, Expression{{Field: "email", Operator: Equal, Value: `a "b" AND c`}}, expr)
+}
+
+func TestParse_Errors(t *testing.T) {
+	cases := map[string]error{
+		`password=secret`:                 ErrUnknownField,
+		`email`:                           ErrSyntax,
+		`email=`:                          ErrSyntax,
+		`email="open`:                     ErrSyntax,
+		`email=a OR email=b`:              ErrSyntax,
+		`created_at~2024`:                 ErrInvalidOperator,
+		`created_at>yesterday`:            ErrInvalidValue,
+		`email=a AND email=b AND email=c`: nil,
+	}
+
+	for input, expected := range cases {
+		_, err := Parse(input, testFields)
+		if expected == nil {
+			assert.NoError(t, err, input)
+			continue
+		}
+		assert.True(t, errors.Is(err, expected), "%s: %v", input, err)
+	}
+}
+
+func TestParse_Prefix(t *testing.T) {
+	// Act
+	expr, err := Parse(`email^="alice"`, testFields)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, Expression{{Field: "email", Operator: Prefix, Value: "alice"}}, expr)
+
+	_, err = Parse(`created_at^=2024`, testFields)
+	assert.ErrorIs(t, err, ErrInvalidOperator)
+}
+
+func TestNewCondition(t *testing.T) {
+	cond, err := NewCondition("created_at", Greater, "2024-01-01", testFields)
+	assert.NoError(t, err)
+	assert.Equal(t, Condition{Field: "created_at", Operator: Greater, Value: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}, cond)
+
+	_, err = NewCondition("password", Equal, "x", testFields)
+	assert.ErrorIs(t, err, ErrUnknownField)
+
+	_, err = NewCondition("created_at", Less, "yesterday", testFields)
+	assert.ErrorIs(t, err, ErrInvalidValue)
+}
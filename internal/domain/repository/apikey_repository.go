@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"web-clean/internal/domain/entity"
+)
+
+// APIKeyRepository defines the contract for API key data access
+type APIKeyRepository interface {
+	// Create stores a new key
+	Create(ctx context.Context, key *entity.APIKey) error
+
+	// GetByID retrieves a key by its ID, nil when there is none
+	GetByID(ctx context.Context, id uuid.UUID) (*entity.APIKey, error)
+
+	// GetBySecretHash retrieves the key whose secret hashes to hash, nil when there is none
+	GetBySecretHash(ctx context.Context, hash string) (*entity.APIKey, error)
+
+	// List retrieves all keys including revoked ones, newest first
+	List(ctx context.Context) ([]*entity.APIKey, error)
+
+	// Revoke stores the revocation time of a key
+	Revoke(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	// TouchLastUsed records when a key last authenticated a request
+	TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+}
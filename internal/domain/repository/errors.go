@@ -0,0 +1,17 @@
+package repository
+
+import "errors"
+
+// ErrReadOnly is returned by write methods when the repository is backed by a read-only replica
+var ErrReadOnly = errors.New("repository is in read-only mode")
+
+// ErrConflict is returned by Update when the record's version no longer matches, i.e. it was modified
+// after it was read
+var ErrConflict = errors.New("record was modified concurrently")
+
+// ErrBudgetExceeded is returned when the current request has used up its database query or row budget
+var ErrBudgetExceeded = errors.New("request database budget exceeded")
+
+// ErrDuplicate is returned by Create and Update when another record already holds a unique value,
+// e.g. the same email in a different case
+var ErrDuplicate = errors.New("record violates a uniqueness constraint")
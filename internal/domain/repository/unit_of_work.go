@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"web-clean/internal/domain/entity"
+)
+
+// AuditRepository defines the contract for writing the audit trail
+type AuditRepository interface {
+	// Record appends an audit record
+	Record(ctx context.Context, record *entity.AuditRecord) error
+}
+
+// Repositories are the repositories taking part in one unit of work
+type Repositories interface {
+	Users() UserRepository
+	Audit() AuditRepository
+}
+
+// UnitOfWork runs several repository calls atomically: either all of their writes are committed or none
+type UnitOfWork interface {
+	// Do calls fn with repositories bound to a single transaction, which is committed when fn
+	// returns nil and rolled back when it returns an error
+	Do(ctx context.Context, fn func(repos Repositories) error) error
+}
+
+// Transactor runs business logic in a transaction carried by a context, so repositories join it
+// without the caller handling a database handle
+type Transactor interface {
+	// WithinTransaction calls fn with a context bound to a new transaction, which is committed when fn
+	// returns nil and rolled back when it returns an error. Repository calls made with that context
+	// take part in the transaction; a nested WithinTransaction or UnitOfWork uses a savepoint in it
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
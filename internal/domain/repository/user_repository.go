@@ -2,8 +2,11 @@ package repository
 
 import (
 	"context"
+	"time"
+
 	"github.com/google/uuid"
 	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/specification"
 )
 
 // UserRepository defines the contract for user data access
@@ -12,6 +15,9 @@ type UserRepository interface {
 	// Create stores a new user
 	Create(ctx context.Context, user *entity.User) error
 	
+	// CreateBatch stores several new users atomically: either all of them are stored or none
+	CreateBatch(ctx context.Context, users []*entity.User) error
+	
 	// GetByID retrieves a user by their ID
 	GetByID(ctx context.Context, id uuid.UUID) (*entity.User, error)
 	
@@ -21,15 +27,37 @@ type UserRepository interface {
 	// GetByUsername retrieves a user by their username
 	GetByUsername(ctx context.Context, username string) (*entity.User, error)
 	
-	// Update updates an existing user
+	// Update updates an existing user if its stored version still equals user.Version, then increments
+	// user.Version; it returns ErrConflict when the user was modified or deleted in the meantime
 	Update(ctx context.Context, user *entity.User) error
 	
 	// Delete removes a user by ID
 	Delete(ctx context.Context, id uuid.UUID) error
 	
-	// List retrieves users with pagination
-	List(ctx context.Context, offset, limit int) ([]*entity.User, error)
+	// List retrieves users matching the specification, honoring its ordering and pagination
+	List(ctx context.Context, spec specification.Specification) ([]*entity.User, error)
 	
-	// Count returns the total number of users
-	Count(ctx context.Context) (int64, error)
-}
\ No newline at end of file
+	// Count returns the total number of users matching the specification's predicates
+	Count(ctx context.Context, spec specification.Specification) (int64, error)
+}
+
+// UserCursor is a keyset position in the (created_at DESC, id DESC) user ordering
+type UserCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Spec orders the specification by (created_at DESC, id DESC) and seeks past the cursor;
+// a nil cursor starts from the newest user
+func (c *UserCursor) Spec(spec specification.Specification) specification.Specification {
+	spec = spec.OrderBy("created_at", specification.Descending).OrderBy("id", specification.Descending)
+	if c == nil {
+		return spec
+	}
+	return spec.After(c.CreatedAt, c.ID)
+}
+
+// CursorOf returns the cursor positioned at the given user
+func CursorOf(user *entity.User) *UserCursor {
+	return &UserCursor{CreatedAt: user.CreatedAt, ID: user.ID}
+}
@@ -0,0 +1,146 @@
+package specification
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"web-clean/internal/domain/filter"
+)
+
+// ErrInvalidSeek is returned when a keyset position does not match the ordering
+var ErrInvalidSeek = errors.New("seek values must match a single-direction ordering")
+
+// Direction is the sort direction of an ordering field
+type Direction int
+
+const (
+	Ascending Direction = iota
+	Descending
+)
+
+// ErrInvalidOrdering is returned when a sort expression is malformed or names a field that cannot be sorted on
+var ErrInvalidOrdering = errors.New("invalid sort")
+
+// MaxOrderFields bounds the number of fields in one sort expression
+const MaxOrderFields = 3
+
+// Order sorts results by a field in domain terms
+type Order struct {
+	Field     string
+	Direction Direction
+}
+
+// Specification describes a query in domain terms: predicates, ordering and pagination.
+// Repositories translate it into their storage query language, so new filter combinations
+// do not require new repository methods.
+//
+// Specifications are values; every builder method returns a copy and never mutates the receiver.
+type Specification struct {
+	// Predicates are joined with AND
+	Predicates filter.Expression
+	Ordering   []Order
+
+	// Offset is ignored when Seek is set
+	Offset int
+	// Limit of 0 means no limit
+	Limit int
+
+	// Seek positions the page after the row with these ordering values (keyset pagination)
+	Seek []interface{}
+}
+
+// New returns an empty specification matching everything
+func New() Specification {
+	return Specification{}
+}
+
+// Where adds predicates to the specification
+func (s Specification) Where(conditions ...filter.Condition) Specification {
+	s.Predicates = append(append(filter.Expression{}, s.Predicates...), conditions...)
+	return s
+}
+
+// And combines the predicates of both specifications; ordering and pagination of s are kept
+func (s Specification) And(other Specification) Specification {
+	return s.Where(other.Predicates...)
+}
+
+// OrderBy appends an ordering field, earlier fields take precedence
+func (s Specification) OrderBy(field string, direction Direction) Specification {
+	s.Ordering = append(append([]Order{}, s.Ordering...), Order{Field: field, Direction: direction})
+	return s
+}
+
+// Page sets offset pagination
+func (s Specification) Page(offset, limit int) Specification {
+	s.Offset = offset
+	s.Limit = limit
+	return s
+}
+
+// Take sets the maximum number of results
+func (s Specification) Take(limit int) Specification {
+	s.Limit = limit
+	return s
+}
+
+// After sets the keyset position, one value per ordering field
+func (s Specification) After(values ...interface{}) Specification {
+	s.Seek = append([]interface{}{}, values...)
+	return s
+}
+
+// SeekDirection validates Seek against Ordering and returns the shared direction of all ordering fields
+func (s Specification) SeekDirection() (Direction, error) {
+	if len(s.Seek) != len(s.Ordering) || len(s.Ordering) == 0 {
+		return Ascending, ErrInvalidSeek
+	}
+
+	direction := s.Ordering[0].Direction
+	for _, order := range s.Ordering[1:] {
+		if order.Direction != direction {
+			return Ascending, ErrInvalidSeek
+		}
+	}
+	return direction, nil
+}
+
+// ParseOrdering parses a sort expression such as `created_at:desc,name` (ascending by default)
+// and validates every field against allowed; an empty expression returns nil
+func ParseOrdering(input string, allowed []string) ([]Order, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(input, ",")
+	if len(parts) > MaxOrderFields {
+		return nil, fmt.Errorf("%w: at most %d fields are allowed", ErrInvalidOrdering, MaxOrderFields)
+	}
+
+	ordering := make([]Order, 0, len(parts))
+	for _, part := range parts {
+		field, dir, _ := strings.Cut(strings.TrimSpace(part), ":")
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !slices.Contains(allowed, field) {
+			return nil, fmt.Errorf("%w: cannot sort by %q, allowed fields are %s", ErrInvalidOrdering, field, strings.Join(allowed, ", "))
+		}
+		if slices.ContainsFunc(ordering, func(order Order) bool { return order.Field == field }) {
+			return nil, fmt.Errorf("%w: %s is listed twice", ErrInvalidOrdering, field)
+		}
+
+		var direction Direction
+		switch strings.ToLower(strings.TrimSpace(dir)) {
+		case "", "asc":
+			direction = Ascending
+		case "desc":
+			direction = Descending
+		default:
+			return nil, fmt.Errorf("%w: direction of %s must be asc or desc", ErrInvalidOrdering, field)
+		}
+		ordering = append(ordering, Order{Field: field, Direction: direction})
+	}
+	return ordering, nil
+}
@@ -0,0 +1,71 @@
+package specification
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"web-clean/internal/domain/filter"
+)
+
+func TestSpecification_BuildersDoNotMutate(t *testing.T) {
+	// Arrange
+	base := New().Where(filter.Condition{Field: "name", Operator: filter.Equal, Value: "a"})
+
+	// Act
+	first := base.Where(filter.Condition{Field: "email", Operator: filter.Contains, Value: "@corp.com"})
+	second := base.OrderBy("created_at", Descending).Page(20, 10)
+
+	// Assert
+	assert.Len(t, base.Predicates, 1)
+	assert.Len(t, first.Predicates, 2)
+	assert.Len(t, second.Predicates, 1)
+	assert.Empty(t, base.Ordering)
+	assert.Equal(t, 20, second.Offset)
+	assert.Equal(t, 10, second.Limit)
+}
+
+func TestSpecification_And(t *testing.T) {
+	// Arrange
+	active := New().Where(filter.Condition{Field: "name", Operator: filter.NotEqual, Value: "deleted"})
+	corp := New().Where(filter.Condition{Field: "email", Operator: filter.Contains, Value: "@corp.com"}).Take(5)
+
+	// Act
+	combined := active.OrderBy("name", Ascending).And(corp)
+
+	// Assert
+	assert.Len(t, combined.Predicates, 2)
+	assert.Equal(t, []Order{{Field: "name", Direction: Ascending}}, combined.Ordering)
+	assert.Equal(t, 0, combined.Limit)
+}
+
+func TestSpecification_SeekDirection(t *testing.T) {
+	desc := New().OrderBy("created_at", Descending).OrderBy("id", Descending)
+
+	direction, err := desc.After("t", "id").SeekDirection()
+	assert.NoError(t, err)
+	assert.Equal(t, Descending, direction)
+
+	_, err = desc.After("t").SeekDirection()
+	assert.ErrorIs(t, err, ErrInvalidSeek)
+
+	_, err = New().OrderBy("created_at", Descending).OrderBy("id", Ascending).After("t", "id").SeekDirection()
+	assert.ErrorIs(t, err, ErrInvalidSeek)
+}
+
+func TestParseOrdering(t *testing.T) {
+	allowed := []string{"created_at", "name"}
+
+	ordering, err := ParseOrdering(" created_at:DESC, name ", allowed)
+	assert.NoError(t, err)
+	assert.Equal(t, []Order{{Field: "created_at", Direction: Descending}, {Field: "name", Direction: Ascending}}, ordering)
+
+	ordering, err = ParseOrdering("", allowed)
+	assert.NoError(t, err)
+	assert.Nil(t, ordering)
+
+	for _, input := range []string{"password", "name:up", "name,name:desc", "name,created_at,name,created_at", ","} {
+		_, err := ParseOrdering(input, allowed)
+		assert.ErrorIs(t, err, ErrInvalidOrdering, input)
+	}
+}
@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"web-clean/internal/domain/entity"
+)
+
+// APIKeyUseCase defines the business operations for API keys used by machine clients
+type APIKeyUseCase interface {
+	// CreateAPIKey generates a key; the secret is only ever returned here
+	CreateAPIKey(ctx context.Context, req CreateAPIKeyRequest) (*CreateAPIKeyResponse, error)
+
+	// ListAPIKeys retrieves all keys including revoked ones, newest first
+	ListAPIKeys(ctx context.Context) ([]*entity.APIKey, error)
+
+	// RevokeAPIKey stops a key from authenticating; it stays listed with its revocation time
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) (*entity.APIKey, error)
+
+	// AuthenticateAPIKey returns the active key matching secret and records that it was used
+	AuthenticateAPIKey(ctx context.Context, secret string) (*entity.APIKey, error)
+}
+
+// CreateAPIKeyRequest represents the request to create an API key
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" validate:"required,min=1,max=100"`
+	Scopes []string `json:"scopes"`
+}
+
+// CreateAPIKeyResponse holds the created key and its secret
+type CreateAPIKeyResponse struct {
+	Key    *entity.APIKey `json:"key"`
+	Secret string         `json:"-"`
+}
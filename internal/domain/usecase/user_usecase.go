@@ -4,6 +4,9 @@ import (
 	"context"
 	"github.com/google/uuid"
 	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/filter"
+	"web-clean/internal/domain/repository"
+	"web-clean/internal/domain/specification"
 )
 
 // UserUseCase defines the business operations for user management
@@ -12,6 +15,9 @@ type UserUseCase interface {
 	// CreateUser creates a new user with validation
 	CreateUser(ctx context.Context, req CreateUserRequest) (*entity.User, error)
 	
+	// CreateUsers creates up to MaxCreateUsersBatch users, reporting the outcome of each one
+	CreateUsers(ctx context.Context, reqs []CreateUserRequest) (*CreateUsersResponse, error)
+	
 	// GetUserByID retrieves a user by ID
 	GetUserByID(ctx context.Context, id uuid.UUID) (*entity.User, error)
 	
@@ -21,11 +27,25 @@ type UserUseCase interface {
 	// UpdateUserProfile updates user profile information
 	UpdateUserProfile(ctx context.Context, req UpdateUserProfileRequest) (*entity.User, error)
 	
+	// ChangePassword sets a new password, verifying the current one when the user already has a password
+	ChangePassword(ctx context.Context, req ChangePasswordRequest) error
+	
 	// DeleteUser removes a user
 	DeleteUser(ctx context.Context, id uuid.UUID) error
 	
 	// ListUsers retrieves paginated list of users
 	ListUsers(ctx context.Context, req ListUsersRequest) (*ListUsersResponse, error)
+	
+	// ListUsersAfter retrieves a page of users using keyset (cursor) pagination
+	ListUsersAfter(ctx context.Context, req ListUsersAfterRequest) (*ListUsersAfterResponse, error)
+	
+	// ExportUsers calls yield for every user matching the filter, newest first, reading them in chunks
+	// of ExportChunkSize so that memory use does not grow with the number of users
+	ExportUsers(ctx context.Context, req ExportUsersRequest, yield func(user *entity.User) error) error
+	
+	// ImportUsers creates up to MaxImportUsers users, handling rows that name an existing user as
+	// req.OnDuplicate says; with req.DryRun nothing is written and the results tell what would happen
+	ImportUsers(ctx context.Context, req ImportUsersRequest) (*ImportUsersResponse, error)
 }
 
 // CreateUserRequest represents the request to create a new user
@@ -35,16 +55,79 @@ type CreateUserRequest struct {
 	Name     string `json:"name" validate:"required,min=1,max=100"`
 }
 
+// MaxCreateUsersBatch is the largest number of users a single CreateUsers call accepts
+const MaxCreateUsersBatch = 100
+
+// CreateUserResult is the outcome of one item of a CreateUsers batch: the created user or why it was not created
+type CreateUserResult struct {
+	User *entity.User `json:"user,omitempty"`
+	Err  error        `json:"-"`
+}
+
+// CreateUsersResponse holds one result per request, in request order
+type CreateUsersResponse struct {
+	Results []CreateUserResult `json:"results"`
+}
+
 // UpdateUserProfileRequest represents the request to update user profile
 type UpdateUserProfileRequest struct {
-	ID   uuid.UUID `json:"id" validate:"required"`
-	Name string    `json:"name" validate:"required,min=1,max=100"`
+	ID      uuid.UUID `json:"id" validate:"required"`
+	Name    string    `json:"name" validate:"required,min=1,max=100"`
+	// Version is the version the client last read (If-Match), 0 updates whatever is stored
+	Version int64 `json:"version"`
+}
+
+// ChangePasswordRequest represents the request to change a user's password
+type ChangePasswordRequest struct {
+	ID uuid.UUID `json:"id" validate:"required"`
+	// CurrentPassword must match the stored password, it is ignored while the user has none
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=72"`
+	// Reset sets the password without checking CurrentPassword, for operators resetting a forgotten password
+	Reset bool `json:"-"`
+}
+
+// UserFilterFields is the whitelist of fields usable in user listing filters
+var UserFilterFields = filter.Fields{
+	"email":      filter.String,
+	"username":   filter.String,
+	"name":       filter.String,
+	"created_at": filter.Time,
+	"updated_at": filter.Time,
+}
+
+// UserSortFields is the whitelist of fields usable in user listing sorts
+var UserSortFields = []string{"created_at", "updated_at", "email", "username", "name"}
+
+// PageLimits are the default and maximum page sizes of list use cases, configured per deployment
+type PageLimits struct {
+	Default int
+	Max     int
+}
+
+// DefaultPageLimits are the page sizes used when none are configured
+var DefaultPageLimits = PageLimits{Default: 10, Max: 100}
+
+// Allows reports whether limit is an acceptable explicit page size
+func (p PageLimits) Allows(limit int) bool {
+	return limit > 0 && limit <= p.Max
+}
+
+// Normalize applies the default to a missing limit and caps it at the maximum
+func (p PageLimits) Normalize(limit int) int {
+	if limit <= 0 {
+		return p.Default
+	}
+	return min(limit, p.Max)
 }
 
 // ListUsersRequest represents the request to list users with pagination
 type ListUsersRequest struct {
-	Offset int `json:"offset" validate:"min=0"`
-	Limit  int `json:"limit" validate:"min=1,max=100"`
+	Offset int               `json:"offset" validate:"min=0"`
+	Limit  int               `json:"limit" validate:"min=1"` // capped at PageLimits.Max
+	Filter filter.Expression `json:"filter"`
+	// Sort orders the page, empty means newest first; ties are broken by ID so offset pages stay stable
+	Sort []specification.Order `json:"sort"`
 }
 
 // ListUsersResponse represents the response for listing users
@@ -54,4 +137,74 @@ type ListUsersResponse struct {
 	Offset     int            `json:"offset"`
 	Limit      int            `json:"limit"`
 	HasMore    bool           `json:"has_more"`
-}
\ No newline at end of file
+}
+
+// ListUsersAfterRequest represents the request to list users with cursor pagination
+type ListUsersAfterRequest struct {
+	After  *repository.UserCursor `json:"after"`
+	Limit  int                    `json:"limit" validate:"min=1"` // capped at PageLimits.Max
+	Filter filter.Expression      `json:"filter"`
+}
+
+// ListUsersAfterResponse represents a page of users with the cursor of the next page
+type ListUsersAfterResponse struct {
+	Users      []*entity.User         `json:"users"`
+	Limit      int                    `json:"limit"`
+	HasMore    bool                   `json:"has_more"`
+	NextCursor *repository.UserCursor `json:"next_cursor"`
+}
+
+// ExportChunkSize is the number of users ExportUsers reads per query
+const ExportChunkSize = 500
+
+// ExportUsersRequest represents the request to export users
+type ExportUsersRequest struct {
+	Filter filter.Expression `json:"filter"`
+}
+
+// MaxImportUsers is the largest number of rows a single ImportUsers call accepts
+const MaxImportUsers = 1000
+
+// DuplicatePolicy decides what an import does with a row whose email or username is already taken
+type DuplicatePolicy string
+
+const (
+	// DuplicateSkip leaves the existing user unchanged and skips the row
+	DuplicateSkip DuplicatePolicy = "skip"
+	// DuplicateOverwrite updates the name of the existing user the row names by both email and username
+	DuplicateOverwrite DuplicatePolicy = "overwrite"
+	// DuplicateError fails the row with ErrUserAlreadyExists, like a single create would
+	DuplicateError DuplicatePolicy = "error"
+)
+
+// ImportUsersRequest represents the request to import users
+type ImportUsersRequest struct {
+	Users       []CreateUserRequest `json:"users"`
+	OnDuplicate DuplicatePolicy     `json:"on_duplicate"`
+	// DryRun validates and decides every row without writing anything
+	DryRun bool `json:"dry_run"`
+}
+
+// ImportAction is what an import did, or would do in a dry run, with one row
+type ImportAction string
+
+const (
+	ImportCreated ImportAction = "created"
+	ImportUpdated ImportAction = "updated"
+	ImportSkipped ImportAction = "skipped"
+	ImportFailed  ImportAction = "failed"
+)
+
+// ImportUserResult is the outcome of one row: the user it created, updated or skipped, or why it failed.
+// In a dry run created users carry an ID that is not stored
+type ImportUserResult struct {
+	Action ImportAction `json:"action"`
+	User   *entity.User `json:"user,omitempty"`
+	Err    error        `json:"-"`
+}
+
+// ImportUsersResponse holds one result per row, in row order
+type ImportUsersResponse struct {
+	Results []ImportUserResult `json:"results"`
+	DryRun  bool               `json:"dry_run"`
+}
@@ -0,0 +1,97 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"web-clean/internal/domain/entity"
+)
+
+const (
+	// StrategyUUIDv7 is time-ordered (RFC 9562) and the default
+	StrategyUUIDv7 = "uuidv7"
+	// StrategyULID is time-ordered with 80 random bits, stored in the uuid column as 16 bytes
+	StrategyULID = "ulid"
+	// StrategyUUIDv4 is fully random; kept for compatibility, it fragments the primary key index
+	StrategyUUIDv4 = "uuidv4"
+	// StrategySnowflake produces 64-bit IDs, which do not fit the uuid primary key
+	StrategySnowflake = "snowflake"
+)
+
+// ErrUnsupportedStrategy is returned for unknown or unusable ID strategies
+var ErrUnsupportedStrategy = errors.New("unsupported id strategy")
+
+// From returns the IDGenerator for the configured strategy, an empty strategy selects UUIDv7
+func From(strategy string) (entity.IDGenerator, error) {
+	switch strings.ToLower(strategy) {
+	case "", StrategyUUIDv7:
+		return entity.IDGeneratorFunc(uuid.NewV7), nil
+	case StrategyULID:
+		return NewULID(), nil
+	case StrategyUUIDv4:
+		return entity.IDGeneratorFunc(uuid.NewRandom), nil
+	case StrategySnowflake:
+		return nil, fmt.Errorf("%w: %s ids are 64-bit and need a bigint primary key, the users table uses uuid", ErrUnsupportedStrategy, strategy)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedStrategy, strategy)
+	}
+}
+
+// ULID generates monotonic ULIDs: a 48-bit millisecond timestamp followed by 80 random bits.
+// Within the same millisecond the random part is incremented, so IDs from one process are strictly increasing
+type ULID struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	lastMs uint64
+	last   [10]byte
+}
+
+// NewULID creates a ULID generator
+func NewULID() *ULID {
+	return &ULID{now: time.Now}
+}
+
+// NewID returns the next ULID as a uuid.UUID
+func (g *ULID) NewID() (uuid.UUID, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(g.now().UnixMilli())
+	if ms <= g.lastMs {
+		// Same millisecond (or clock moved backwards): keep the timestamp and increment the entropy
+		ms = g.lastMs
+		if !increment(g.last[:]) {
+			return uuid.Nil, errors.New("ulid entropy exhausted within one millisecond")
+		}
+	} else {
+		if _, err := rand.Read(g.last[:]); err != nil {
+			return uuid.Nil, err
+		}
+		g.lastMs = ms
+	}
+
+	var id uuid.UUID
+	var timestamp [8]byte
+	binary.BigEndian.PutUint64(timestamp[:], ms)
+	copy(id[:6], timestamp[2:])
+	copy(id[6:], g.last[:])
+	return id, nil
+}
+
+// increment adds one to a big-endian number, returning false on overflow
+func increment(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
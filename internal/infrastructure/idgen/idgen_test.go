@@ -0,0 +1,59 @@
+package idgen
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrom(t *testing.T) {
+	for _, strategy := range []string{"", "uuidv7", "ULID", "uuidv4"} {
+		generator, err := From(strategy)
+		assert.NoError(t, err, strategy)
+
+		id, err := generator.NewID()
+		assert.NoError(t, err)
+		assert.NotEqual(t, [16]byte{}, [16]byte(id))
+	}
+
+	_, err := From("snowflake")
+	assert.ErrorIs(t, err, ErrUnsupportedStrategy)
+	_, err = From("random")
+	assert.ErrorIs(t, err, ErrUnsupportedStrategy)
+}
+
+func TestFrom_UUIDv7IsVersioned(t *testing.T) {
+	generator, _ := From("uuidv7")
+	id, _ := generator.NewID()
+	assert.Equal(t, 7, int(id.Version()))
+}
+
+func TestULID_MonotonicWithinMillisecond(t *testing.T) {
+	fixed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	generator := NewULID()
+	generator.now = func() time.Time { return fixed }
+
+	previous, err := generator.NewID()
+	assert.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		next, err := generator.NewID()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, bytes.Compare(next[:], previous[:]))
+		assert.Equal(t, previous[:6], next[:6])
+		previous = next
+	}
+}
+
+func TestULID_OrderedAcrossMilliseconds(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	generator := NewULID()
+	generator.now = func() time.Time { return now }
+
+	first, _ := generator.NewID()
+	now = now.Add(time.Millisecond)
+	second, _ := generator.NewID()
+
+	assert.Equal(t, 1, bytes.Compare(second[:], first[:]))
+}
@@ -0,0 +1,37 @@
+package passwords
+
+import (
+	"golang.org/x/crypto/bcrypt"
+
+	"web-clean/internal/domain/entity"
+)
+
+// Bcrypt hashes passwords with bcrypt; the cost is stored in each hash, so raising it later
+// still verifies existing hashes
+type Bcrypt struct {
+	cost int
+}
+
+// NewBcrypt creates a bcrypt hasher, a cost outside bcrypt's range selects bcrypt.DefaultCost
+func NewBcrypt(cost int) *Bcrypt {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &Bcrypt{cost: cost}
+}
+
+var _ entity.PasswordHasher = (*Bcrypt)(nil)
+
+// Hash returns the bcrypt hash of password
+func (b *Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify reports whether password matches a hash produced by Hash
+func (b *Bcrypt) Verify(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,30 @@
+package passwords
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBcrypt_HashAndVerify(t *testing.T) {
+	hasher := NewBcrypt(bcrypt.MinCost)
+
+	hash, err := hasher.Hash("correct horse")
+	assert.NoError(t, err)
+	assert.NotContains(t, hash, "correct horse")
+
+	assert.True(t, hasher.Verify(hash, "correct horse"))
+	assert.False(t, hasher.Verify(hash, "wrong horse"))
+	assert.False(t, hasher.Verify("not a hash", "correct horse"))
+
+	again, err := hasher.Hash("correct horse")
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, again, "every hash is salted")
+}
+
+func TestNewBcrypt_CostOutOfRange(t *testing.T) {
+	assert.Equal(t, bcrypt.DefaultCost, NewBcrypt(0).cost)
+	assert.Equal(t, bcrypt.DefaultCost, NewBcrypt(bcrypt.MaxCost+1).cost)
+	assert.Equal(t, 12, NewBcrypt(12).cost)
+}
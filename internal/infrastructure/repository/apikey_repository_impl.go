@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"web-clean/infra/database"
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/repository"
+)
+
+// APIKeyModel represents the database model for API keys; the secret itself is never stored
+type APIKeyModel struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key"`
+	Name          string     `gorm:"type:varchar(100);not null"`
+	DisplayPrefix string     `gorm:"type:varchar(16);not null"`
+	SecretHash    string     `gorm:"type:varchar(64);uniqueIndex;not null"`
+	Scopes        []string   `gorm:"type:jsonb;serializer:json;not null"`
+	CreatedAt     time.Time  `gorm:"not null"`
+	LastUsedAt    *time.Time `gorm:"default:null"`
+	RevokedAt     *time.Time `gorm:"default:null"`
+}
+
+// TableName specifies the table name for GORM
+func (APIKeyModel) TableName() string {
+	return "api_keys"
+}
+
+// ToEntity converts database model to domain entity
+func (m *APIKeyModel) ToEntity() *entity.APIKey {
+	return &entity.APIKey{
+		ID:            m.ID,
+		Name:          m.Name,
+		DisplayPrefix: m.DisplayPrefix,
+		SecretHash:    m.SecretHash,
+		Scopes:        m.Scopes,
+		CreatedAt:     m.CreatedAt,
+		LastUsedAt:    m.LastUsedAt,
+		RevokedAt:     m.RevokedAt,
+	}
+}
+
+// FromEntity converts domain entity to database model
+func (m *APIKeyModel) FromEntity(key *entity.APIKey) {
+	m.ID = key.ID
+	m.Name = key.Name
+	m.DisplayPrefix = key.DisplayPrefix
+	m.SecretHash = key.SecretHash
+	m.Scopes = key.Scopes
+	m.CreatedAt = key.CreatedAt
+	m.LastUsedAt = key.LastUsedAt
+	m.RevokedAt = key.RevokedAt
+}
+
+// APIKeyRepositoryImpl implements the APIKeyRepository interface
+type APIKeyRepositoryImpl struct {
+	db database.Database
+}
+
+// NewAPIKeyRepository creates a new API key repository implementation
+func NewAPIKeyRepository(db database.Database) repository.APIKeyRepository {
+	return &APIKeyRepositoryImpl{db: db}
+}
+
+// Register the schema for auto-migration
+func init() {
+	database.RegisterSchema(APIKeyModel{})
+}
+
+// Create stores a new key
+func (r *APIKeyRepositoryImpl) Create(ctx context.Context, key *entity.APIKey) error {
+	model := &APIKeyModel{}
+	model.FromEntity(key)
+	if model.Scopes == nil {
+		model.Scopes = []string{}
+	}
+	return r.db.WithContext(ctx).Create(model).Error
+}
+
+// GetByID retrieves a key by its ID
+func (r *APIKeyRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*entity.APIKey, error) {
+	return r.first(r.db.WithContext(ctx).Where("id = ?", id))
+}
+
+// GetBySecretHash retrieves a key by the hash of its secret
+func (r *APIKeyRepositoryImpl) GetBySecretHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	return r.first(r.db.WithContext(ctx).Where("secret_hash = ?", hash))
+}
+
+func (r *APIKeyRepositoryImpl) first(query *gorm.DB) (*entity.APIKey, error) {
+	var model APIKeyModel
+	if err := query.First(&model).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return model.ToEntity(), nil
+}
+
+// List retrieves all keys, newest first
+func (r *APIKeyRepositoryImpl) List(ctx context.Context) ([]*entity.APIKey, error) {
+	var models []APIKeyModel
+	if err := r.db.WithContext(ctx).Order("created_at DESC, id DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	keys := make([]*entity.APIKey, len(models))
+	for i := range models {
+		keys[i] = models[i].ToEntity()
+	}
+	return keys, nil
+}
+
+// Revoke stores the revocation time unless the key was already revoked
+func (r *APIKeyRepositoryImpl) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&APIKeyModel{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at).Error
+}
+
+// TouchLastUsed records when a key last authenticated a request
+func (r *APIKeyRepositoryImpl) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&APIKeyModel{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
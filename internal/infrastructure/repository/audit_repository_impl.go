@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"web-clean/infra/database"
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/repository"
+)
+
+// AuditModel represents the database model for audit records
+type AuditModel struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key"`
+	Action    string    `gorm:"type:varchar(50);not null;index"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name for GORM
+func (AuditModel) TableName() string {
+	return "audit_records"
+}
+
+// AuditRepositoryImpl implements the AuditRepository interface
+type AuditRepositoryImpl struct {
+	db database.Database
+}
+
+// NewAuditRepository creates a new audit repository implementation
+func NewAuditRepository(db database.Database) repository.AuditRepository {
+	return &AuditRepositoryImpl{db: db}
+}
+
+// Register the schema for auto-migration
+func init() {
+	database.RegisterSchema(AuditModel{})
+}
+
+// Record stores an audit record
+func (r *AuditRepositoryImpl) Record(ctx context.Context, record *entity.AuditRecord) error {
+	return r.db.WithContext(ctx).Create(&AuditModel{
+		ID:        record.ID,
+		Action:    string(record.Action),
+		UserID:    record.UserID,
+		CreatedAt: record.CreatedAt,
+	}).Error
+}
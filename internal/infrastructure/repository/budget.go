@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"web-clean/infra/budget"
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/repository"
+	"web-clean/internal/domain/specification"
+)
+
+// budgetUserRepository translates the infrastructure budget error into repository.ErrBudgetExceeded
+type budgetUserRepository struct {
+	inner repository.UserRepository
+}
+
+// NewBudgetUserRepository wraps a repository so that statements rejected by the per-request database budget
+// surface as repository.ErrBudgetExceeded, which the HTTP layer maps to 503
+func NewBudgetUserRepository(inner repository.UserRepository) repository.UserRepository {
+	return budgetUserRepository{inner: inner}
+}
+
+func translateBudget(err error) error {
+	if errors.Is(err, budget.ErrExceeded) && !errors.Is(err, repository.ErrBudgetExceeded) {
+		return fmt.Errorf("%w: %w", repository.ErrBudgetExceeded, err)
+	}
+	return err
+}
+
+// Create delegates to the wrapped repository
+func (r budgetUserRepository) Create(ctx context.Context, user *entity.User) error {
+	return translateBudget(r.inner.Create(ctx, user))
+}
+
+// CreateBatch delegates to the wrapped repository
+func (r budgetUserRepository) CreateBatch(ctx context.Context, users []*entity.User) error {
+	return translateBudget(r.inner.CreateBatch(ctx, users))
+}
+
+// GetByID delegates to the wrapped repository
+func (r budgetUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	user, err := r.inner.GetByID(ctx, id)
+	return user, translateBudget(err)
+}
+
+// GetByEmail delegates to the wrapped repository
+func (r budgetUserRepository) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
+	user, err := r.inner.GetByEmail(ctx, email)
+	return user, translateBudget(err)
+}
+
+// GetByUsername delegates to the wrapped repository
+func (r budgetUserRepository) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
+	user, err := r.inner.GetByUsername(ctx, username)
+	return user, translateBudget(err)
+}
+
+// Update delegates to the wrapped repository
+func (r budgetUserRepository) Update(ctx context.Context, user *entity.User) error {
+	return translateBudget(r.inner.Update(ctx, user))
+}
+
+// Delete delegates to the wrapped repository
+func (r budgetUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return translateBudget(r.inner.Delete(ctx, id))
+}
+
+// List delegates to the wrapped repository
+func (r budgetUserRepository) List(ctx context.Context, spec specification.Specification) ([]*entity.User, error) {
+	users, err := r.inner.List(ctx, spec)
+	return users, translateBudget(err)
+}
+
+// Count delegates to the wrapped repository
+func (r budgetUserRepository) Count(ctx context.Context, spec specification.Specification) (int64, error) {
+	count, err := r.inner.Count(ctx, spec)
+	return count, translateBudget(err)
+}
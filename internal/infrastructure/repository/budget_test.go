@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"web-clean/infra/budget"
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/repository"
+	"web-clean/internal/domain/specification"
+)
+
+// failingUserRepository returns err from List
+type failingUserRepository struct {
+	repository.UserRepository
+	err error
+}
+
+func (r failingUserRepository) List(ctx context.Context, spec specification.Specification) ([]*entity.User, error) {
+	return nil, r.err
+}
+
+func TestBudgetUserRepository_TranslatesBudgetError(t *testing.T) {
+	// Arrange
+	repo := NewBudgetUserRepository(failingUserRepository{err: fmt.Errorf("query: %w", budget.ErrExceeded)})
+
+	// Act
+	_, err := repo.List(context.Background(), specification.Specification{})
+
+	// Assert
+	assert.True(t, errors.Is(err, repository.ErrBudgetExceeded))
+	assert.True(t, errors.Is(err, budget.ErrExceeded))
+}
+
+func TestBudgetUserRepository_PassesOtherErrors(t *testing.T) {
+	// Arrange
+	boom := errors.New("boom")
+	repo := NewBudgetUserRepository(failingUserRepository{err: boom})
+
+	// Act
+	_, err := repo.List(context.Background(), specification.Specification{})
+
+	// Assert
+	assert.Equal(t, boom, err)
+}
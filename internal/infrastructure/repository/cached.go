@@ -0,0 +1,307 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"web-clean/infra/cache"
+	"web-clean/infra/database"
+	"web-clean/infra/metrics"
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/repository"
+)
+
+var (
+	// cacheHits counts user reads answered from the cache
+	cacheHits = metrics.NewCounter("repository_cache_hits_total")
+	// cacheMisses counts user reads that went to the wrapped repository
+	cacheMisses = metrics.NewCounter("repository_cache_misses_total")
+	// cacheErrors counts failed cache operations, the read then falls back to the wrapped repository
+	cacheErrors = metrics.NewCounter("repository_cache_errors_total")
+	// cacheStale counts user reads answered with an expired entry while it is refreshed in the background
+	cacheStale = metrics.NewCounter("repository_cache_stale_total")
+	// cacheRevalidateErrors counts background refreshes that failed, the expired entry is kept until it is evicted
+	cacheRevalidateErrors = metrics.NewCounter("repository_cache_revalidate_errors_total")
+)
+
+// cacheRevalidateTimeout bounds a background refresh, which no longer has the request's deadline
+const cacheRevalidateTimeout = 5 * time.Second
+
+// Invalidator is implemented by repositories caching users, so that writes made past them, such as in a
+// unit of work, can drop the cached user once they are committed
+type Invalidator interface {
+	// Invalidate drops the cached user
+	Invalidate(ctx context.Context, id uuid.UUID)
+}
+
+// Drainer is implemented by repositories running background work that should finish before the process exits
+type Drainer interface {
+	// Drain stops new background work and waits for the running work or for ctx to end
+	Drain(ctx context.Context) error
+}
+
+// cachedUserRepository serves GetByID and GetByEmail from a cache and invalidates entries on writes
+type cachedUserRepository struct {
+	repository.UserRepository
+	cache cache.Cache
+	ttl   time.Duration
+	stale time.Duration
+	now   func() time.Time
+
+	// refreshing holds a *refresh per ID with a background reload in flight, so a hot expired user is reloaded once
+	refreshing sync.Map
+	refreshes  sync.WaitGroup
+	mu         sync.Mutex
+	draining   bool
+}
+
+// refresh is one background reload; a write to the user while it runs marks it invalidated
+// so that the reload does not put back a user read before the write
+type refresh struct {
+	invalidated atomic.Bool
+}
+
+// NewCachedUserRepository wraps a repository so that reads by ID and email are cached for ttl.
+// Only the user is cached under its ID; an email lookup caches the email's ID and then reads by ID,
+// so Update and Delete only need to invalidate one key. Cache failures never fail a read.
+//
+// With stale > 0 entries are kept for ttl+stale: a user read after ttl is returned right away and
+// reloaded in the background (stale-while-revalidate), so entries expiring under load do not all
+// wait on the database.
+//
+// Reads inside a transaction bypass the cache, so uncommitted rows are never cached, and writes inside
+// a transaction invalidate once it has committed. Repositories bound to a unit of work should not be
+// wrapped; pass Invalidate to NewUnitOfWork instead. The returned repository is an Invalidator and a
+// Drainer; call Drain at shutdown to let running reloads finish
+func NewCachedUserRepository(inner repository.UserRepository, c cache.Cache, ttl, stale time.Duration) repository.UserRepository {
+	return &cachedUserRepository{UserRepository: inner, cache: c, ttl: ttl, stale: max(stale, 0), now: time.Now}
+}
+
+// cachedUser is the cached form of a user. The entity's JSON leaves out the password hash, so it is
+// carried explicitly; otherwise a cached read would look like a user without a password
+type cachedUser struct {
+	*entity.User
+	PasswordHash string `json:"password_hash,omitempty"`
+	// FreshUntil is when the entry expires in Unix milliseconds, set only when stale reads are enabled;
+	// the cache keeps the entry for the stale window beyond it
+	FreshUntil int64 `json:"fresh_until,omitempty"`
+}
+
+func (r *cachedUserRepository) newCachedUser(user *entity.User) cachedUser {
+	cached := cachedUser{User: user, PasswordHash: user.PasswordHash}
+	if r.stale > 0 {
+		cached.FreshUntil = r.now().Add(r.ttl).UnixMilli()
+	}
+	return cached
+}
+
+// expired reports whether the entry is past its ttl but still within the stale window
+func (r *cachedUserRepository) expired(cached cachedUser) bool {
+	return cached.FreshUntil != 0 && r.now().UnixMilli() >= cached.FreshUntil
+}
+
+func userIDKey(id uuid.UUID) string {
+	return "user:id:" + id.String()
+}
+
+func userEmailKey(email string) string {
+	return "user:email:" + strings.ToLower(email)
+}
+
+// GetByID retrieves a user by ID from the cache, loading and caching it on a miss
+func (r *cachedUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	if _, inTx := database.TxFromContext(ctx); inTx {
+		return r.UserRepository.GetByID(ctx, id)
+	}
+
+	data, ok, err := r.cache.Get(ctx, userIDKey(id))
+	if err != nil {
+		cacheErrors.Inc()
+	}
+	if ok {
+		var cached cachedUser
+		if err := json.Unmarshal(data, &cached); err != nil || cached.User == nil {
+			cacheErrors.Inc()
+		} else {
+			cached.User.PasswordHash = cached.PasswordHash
+			if !r.expired(cached) {
+				cacheHits.Inc()
+				return cached.User, nil
+			}
+			cacheStale.Inc()
+			r.revalidate(id)
+			return cached.User, nil
+		}
+	}
+
+	cacheMisses.Inc()
+	user, err := r.UserRepository.GetByID(ctx, id)
+	if err != nil || user == nil {
+		return user, err
+	}
+	r.store(ctx, userIDKey(id), r.newCachedUser(user))
+	return user, nil
+}
+
+// revalidate reloads an expired user in the background unless a reload is already running.
+// A user that no longer exists is dropped from the cache; a failed reload keeps the expired
+// entry, which is served until the stale window ends. The reload runs on a fresh context: it
+// outlives the request and must not use up the request's database budget
+func (r *cachedUserRepository) revalidate(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.draining {
+		return
+	}
+	entry := &refresh{}
+	if _, running := r.refreshing.LoadOrStore(id, entry); running {
+		return
+	}
+
+	r.refreshes.Add(1)
+	go func() {
+		defer r.refreshes.Done()
+		defer r.refreshing.Delete(id)
+
+		ctx, cancel := context.WithTimeout(context.Background(), cacheRevalidateTimeout)
+		defer cancel()
+
+		user, err := r.UserRepository.GetByID(ctx, id)
+		switch {
+		case err != nil:
+			cacheRevalidateErrors.Inc()
+		case entry.invalidated.Load():
+			// Updated or deleted on this instance during the reload; the write already dropped the entry
+		case user == nil:
+			r.Invalidate(ctx, id)
+		case r.superseded(ctx, id, user):
+		default:
+			r.store(ctx, userIDKey(id), r.newCachedUser(user))
+		}
+	}()
+}
+
+// superseded reports whether a reloaded user must not be stored: the entry was dropped by a write on
+// another instance, or replaced by a newer version, while the reload ran. When that cannot be checked
+// the reload is not stored either, the expired entry then stays until the stale window ends
+func (r *cachedUserRepository) superseded(ctx context.Context, id uuid.UUID, user *entity.User) bool {
+	data, ok, err := r.cache.Get(ctx, userIDKey(id))
+	if err != nil {
+		cacheErrors.Inc()
+		return true
+	}
+	if !ok {
+		return true
+	}
+	var cached cachedUser
+	if err := json.Unmarshal(data, &cached); err != nil || cached.User == nil {
+		return false
+	}
+	return cached.Version > user.Version
+}
+
+// Drain stops starting background reloads and waits for the running ones or for ctx to end
+func (r *cachedUserRepository) Drain(ctx context.Context) error {
+	r.mu.Lock()
+	r.draining = true
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.refreshes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// GetByEmail resolves the email to an ID through the cache and then reads by ID
+func (r *cachedUserRepository) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
+	if _, inTx := database.TxFromContext(ctx); inTx {
+		return r.UserRepository.GetByEmail(ctx, email)
+	}
+
+	data, ok, err := r.cache.Get(ctx, userEmailKey(email))
+	if err != nil {
+		cacheErrors.Inc()
+	}
+	if ok {
+		if id, err := uuid.ParseBytes(data); err == nil {
+			user, err := r.GetByID(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			// The mapping outlives an email change or a delete, treat a mismatch as a miss
+			if user != nil && strings.EqualFold(user.Email.String(), email) {
+				return user, nil
+			}
+		}
+	}
+
+	cacheMisses.Inc()
+	user, err := r.UserRepository.GetByEmail(ctx, email)
+	if err != nil || user == nil {
+		return user, err
+	}
+	r.store(ctx, userEmailKey(email), user.ID.String())
+	r.store(ctx, userIDKey(user.ID), r.newCachedUser(user))
+	return user, nil
+}
+
+// Update updates the user and invalidates its cache entry once the write is committed
+func (r *cachedUserRepository) Update(ctx context.Context, user *entity.User) error {
+	if err := r.UserRepository.Update(ctx, user); err != nil {
+		return err
+	}
+	database.AfterCommit(ctx, func() { r.Invalidate(ctx, user.ID) })
+	return nil
+}
+
+// Delete deletes the user and invalidates its cache entry once the write is committed
+func (r *cachedUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.UserRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	database.AfterCommit(ctx, func() { r.Invalidate(ctx, id) })
+	return nil
+}
+
+func (r *cachedUserRepository) store(ctx context.Context, key string, value interface{}) {
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			cacheErrors.Inc()
+			return
+		}
+		data = encoded
+	}
+
+	if err := r.cache.Set(ctx, key, data, r.ttl+r.stale); err != nil {
+		cacheErrors.Inc()
+	}
+}
+
+// Invalidate drops the cached user and keeps a reload running in the background from storing it again;
+// a failure leaves a stale entry that expires after ttl (plus the stale window)
+func (r *cachedUserRepository) Invalidate(ctx context.Context, id uuid.UUID) {
+	if entry, ok := r.refreshing.Load(id); ok {
+		entry.(*refresh).invalidated.Store(true)
+	}
+	if err := r.cache.Delete(context.WithoutCancel(ctx), userIDKey(id)); err != nil {
+		cacheErrors.Inc()
+	}
+}
@@ -0,0 +1,379 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"web-clean/infra/cache"
+	"web-clean/infra/database"
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/repository"
+)
+
+// countingUserRepository serves users from a map and counts reads
+type countingUserRepository struct {
+	repository.UserRepository
+	users map[uuid.UUID]*entity.User
+	reads int
+}
+
+func (r *countingUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	r.reads++
+	user, ok := r.users[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *countingUserRepository) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
+	r.reads++
+	for _, user := range r.users {
+		if user.Email.String() == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *countingUserRepository) Update(ctx context.Context, user *entity.User) error {
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *countingUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(r.users, id)
+	return nil
+}
+
+// brokenCache fails every operation
+type brokenCache struct{}
+
+func (brokenCache) Get(context.Context, string) ([]byte, bool, error) {
+	return nil, false, errors.New("down")
+}
+func (brokenCache) Set(context.Context, string, []byte, time.Duration) error {
+	return errors.New("down")
+}
+func (brokenCache) Delete(context.Context, ...string) error { return errors.New("down") }
+func (brokenCache) Ping(context.Context) error              { return errors.New("down") }
+
+func newCountingRepository() (*countingUserRepository, *entity.User) {
+	user := &entity.User{ID: uuid.New(), Email: "alice@example.com", Username: "alice", Name: "Alice", Version: 1}
+	return &countingUserRepository{users: map[uuid.UUID]*entity.User{user.ID: user}}, user
+}
+
+func TestCached_GetByIDServesRepeatedReadsFromCache(t *testing.T) {
+	inner, user := newCountingRepository()
+	repo := NewCachedUserRepository(inner, cache.NewMemory(), time.Minute, 0)
+	ctx := context.Background()
+
+	first, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	second, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, inner.reads)
+	assert.Equal(t, first.Email, second.Email)
+	assert.Equal(t, user.Version, second.Version)
+
+	missing, err := repo.GetByID(ctx, uuid.New())
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestCached_KeepsPasswordHash(t *testing.T) {
+	inner, user := newCountingRepository()
+	user.PasswordHash = "$2a$04$hash"
+	repo := NewCachedUserRepository(inner, cache.NewMemory(), time.Minute, 0)
+	ctx := context.Background()
+
+	_, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	cached, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, inner.reads)
+	assert.Equal(t, "$2a$04$hash", cached.PasswordHash)
+}
+
+func TestCached_WritesInvalidate(t *testing.T) {
+	inner, user := newCountingRepository()
+	repo := NewCachedUserRepository(inner, cache.NewMemory(), time.Minute, 0)
+	ctx := context.Background()
+
+	_, _ = repo.GetByID(ctx, user.ID)
+	updated := *user
+	updated.Name = "Alicia"
+	require.NoError(t, repo.Update(ctx, &updated))
+
+	got, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alicia", got.Name)
+
+	require.NoError(t, repo.Delete(ctx, user.ID))
+	got, err = repo.GetByID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestCached_TransactionsInvalidateAfterCommit(t *testing.T) {
+	inner, user := newCountingRepository()
+	repo := NewCachedUserRepository(inner, cache.NewMemory(), time.Minute, 0)
+	ctx := context.Background()
+	_, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+
+	hooked, committed := database.WithCommitHooks(ctx)
+	tx := database.WithTx(hooked, new(gorm.DB))
+	updated := *user
+	updated.Name = "Alicia"
+	require.NoError(t, repo.Update(tx, &updated))
+
+	// Uncommitted reads are neither served from nor stored in the cache, other readers keep the committed user
+	got, err := repo.GetByID(tx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alicia", got.Name)
+	got, err = repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", got.Name, "the entry stays until the commit")
+
+	committed()
+	got, err = repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alicia", got.Name)
+	assert.Equal(t, 3, inner.reads)
+}
+
+func TestUnitOfWork_CommittedWritesInvalidate(t *testing.T) {
+	inner, user := newCountingRepository()
+	repo := NewCachedUserRepository(inner, cache.NewMemory(), time.Minute, 0)
+	ctx := context.Background()
+	_, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+
+	// Repositories in a unit of work write past the cache and report the write after the commit
+	hooked, committed := database.WithCommitHooks(ctx)
+	users := committedWrites{UserRepository: inner, afterCommit: repo.(Invalidator).Invalidate}
+	require.NoError(t, users.Delete(database.WithTx(hooked, new(gorm.DB)), user.ID))
+
+	got, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, got, "the entry stays until the commit")
+
+	committed()
+	got, err = repo.GetByID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestCached_GetByEmailResolvesThroughID(t *testing.T) {
+	inner, user := newCountingRepository()
+	repo := NewCachedUserRepository(inner, cache.NewMemory(), time.Minute, 0)
+	ctx := context.Background()
+
+	_, err := repo.GetByEmail(ctx, "alice@example.com")
+	require.NoError(t, err)
+	got, err := repo.GetByEmail(ctx, "alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+	assert.Equal(t, 1, inner.reads)
+
+	// A stale email mapping is treated as a miss
+	changed := *user
+	changed.Email = "alice@corp.com"
+	require.NoError(t, repo.Update(ctx, &changed))
+	got, err = repo.GetByEmail(ctx, "alice@example.com")
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestCached_FallsBackWhenCacheFails(t *testing.T) {
+	inner, user := newCountingRepository()
+	repo := NewCachedUserRepository(inner, brokenCache{}, time.Minute, 0)
+	ctx := context.Background()
+
+	got, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+
+	got, err = repo.GetByEmail(ctx, "alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+
+	assert.NoError(t, repo.Delete(ctx, user.ID))
+}
+
+// newStaleRepository returns a cached repository with a one-minute ttl and a one-hour stale window whose clock the test moves
+func newStaleRepository(inner repository.UserRepository) (*cachedUserRepository, *time.Time) {
+	now := time.Now()
+	repo := NewCachedUserRepository(inner, cache.NewMemory(), time.Minute, time.Hour).(*cachedUserRepository)
+	repo.now = func() time.Time { return now }
+	return repo, &now
+}
+
+func TestCached_ServesStaleWhileRevalidating(t *testing.T) {
+	inner, user := newCountingRepository()
+	repo, now := newStaleRepository(inner)
+	ctx := context.Background()
+
+	_, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	inner.users[user.ID].Name = "Alicia"
+
+	// Within ttl the cached user is fresh
+	got, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", got.Name)
+	assert.Equal(t, 1, inner.reads)
+
+	// After ttl the expired user is returned right away and reloaded in the background
+	*now = now.Add(2 * time.Minute)
+	got, err = repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", got.Name)
+	repo.refreshes.Wait()
+	assert.Equal(t, 2, inner.reads)
+
+	got, err = repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alicia", got.Name)
+	assert.Equal(t, 2, inner.reads)
+}
+
+func TestCached_RevalidationDropsDeletedUser(t *testing.T) {
+	inner, user := newCountingRepository()
+	repo, now := newStaleRepository(inner)
+	ctx := context.Background()
+
+	_, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	delete(inner.users, user.ID)
+
+	*now = now.Add(2 * time.Minute)
+	got, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, got, "the expired user is served once while it is reloaded")
+	repo.refreshes.Wait()
+
+	got, err = repo.GetByID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestCached_ReloadsExpiredUserInsideTransaction(t *testing.T) {
+	inner, user := newCountingRepository()
+	repo, now := newStaleRepository(inner)
+
+	_, err := repo.GetByID(context.Background(), user.ID)
+	require.NoError(t, err)
+	inner.users[user.ID].Name = "Alicia"
+
+	*now = now.Add(2 * time.Minute)
+	got, err := repo.GetByID(database.WithTx(context.Background(), new(gorm.DB)), user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alicia", got.Name)
+	assert.Equal(t, 2, inner.reads)
+}
+
+// gatedUserRepository reads the user and then holds the result until the gate opens, so a test can
+// write while a background reload holds an old copy
+type gatedUserRepository struct {
+	*countingUserRepository
+	read chan struct{}
+	gate chan struct{}
+}
+
+func (r *gatedUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	user, err := r.countingUserRepository.GetByID(ctx, id)
+	if r.gate != nil {
+		r.read <- struct{}{}
+		<-r.gate
+	}
+	return user, err
+}
+
+func TestCached_WriteDuringRevalidationIsNotUndone(t *testing.T) {
+	counting, user := newCountingRepository()
+	inner := &gatedUserRepository{countingUserRepository: counting}
+	repo, now := newStaleRepository(inner)
+	ctx := context.Background()
+
+	_, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+
+	inner.read, inner.gate = make(chan struct{}), make(chan struct{})
+	*now = now.Add(2 * time.Minute)
+	_, err = repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	<-inner.read
+
+	// The reload holds the old row while the update commits and invalidates
+	updated := *user
+	updated.Name = "Alicia"
+	updated.Version = 2
+	require.NoError(t, repo.Update(ctx, &updated))
+	close(inner.gate)
+	require.NoError(t, repo.Drain(ctx))
+
+	inner.gate = nil
+	got, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Alicia", got.Name)
+}
+
+func TestCached_DeleteOnAnotherInstanceDuringRevalidation(t *testing.T) {
+	counting, user := newCountingRepository()
+	inner := &gatedUserRepository{countingUserRepository: counting}
+	shared := cache.NewMemory()
+	repo := NewCachedUserRepository(inner, shared, time.Minute, time.Hour).(*cachedUserRepository)
+	other := NewCachedUserRepository(counting, shared, time.Minute, time.Hour)
+	now := time.Now()
+	repo.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+
+	inner.read, inner.gate = make(chan struct{}), make(chan struct{})
+	now = now.Add(2 * time.Minute)
+	_, err = repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	<-inner.read
+
+	require.NoError(t, other.Delete(ctx, user.ID))
+	close(inner.gate)
+	require.NoError(t, repo.Drain(ctx))
+
+	inner.gate = nil
+	got, err := repo.GetByID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, got, "the deleted user does not come back")
+}
+
+func TestCached_DrainStopsRevalidation(t *testing.T) {
+	inner, user := newCountingRepository()
+	repo, now := newStaleRepository(inner)
+	ctx := context.Background()
+
+	_, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	require.NoError(t, repo.Drain(ctx))
+
+	*now = now.Add(2 * time.Minute)
+	got, err := repo.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID, "expired users are still served while draining")
+	repo.refreshes.Wait()
+	assert.Equal(t, 1, inner.reads)
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/repository"
+)
+
+// readOnlyUserRepository rejects writes before they reach a read-only replica
+type readOnlyUserRepository struct {
+	repository.UserRepository
+}
+
+// NewReadOnlyUserRepository wraps a repository so that Create, CreateBatch, Update and Delete return repository.ErrReadOnly
+func NewReadOnlyUserRepository(inner repository.UserRepository) repository.UserRepository {
+	return readOnlyUserRepository{UserRepository: inner}
+}
+
+// Create rejects the write
+func (r readOnlyUserRepository) Create(ctx context.Context, user *entity.User) error {
+	return repository.ErrReadOnly
+}
+
+// CreateBatch rejects the write
+func (r readOnlyUserRepository) CreateBatch(ctx context.Context, users []*entity.User) error {
+	return repository.ErrReadOnly
+}
+
+// Update rejects the write
+func (r readOnlyUserRepository) Update(ctx context.Context, user *entity.User) error {
+	return repository.ErrReadOnly
+}
+
+// Delete rejects the write
+func (r readOnlyUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return repository.ErrReadOnly
+}
+
+// readOnlyAPIKeyRepository rejects writes before they reach a read-only replica
+type readOnlyAPIKeyRepository struct {
+	repository.APIKeyRepository
+}
+
+// NewReadOnlyAPIKeyRepository wraps a repository so that Create and Revoke return repository.ErrReadOnly
+// and TouchLastUsed is skipped, so authenticating with a key still works
+func NewReadOnlyAPIKeyRepository(inner repository.APIKeyRepository) repository.APIKeyRepository {
+	return readOnlyAPIKeyRepository{APIKeyRepository: inner}
+}
+
+// Create rejects the write
+func (r readOnlyAPIKeyRepository) Create(ctx context.Context, key *entity.APIKey) error {
+	return repository.ErrReadOnly
+}
+
+// Revoke rejects the write
+func (r readOnlyAPIKeyRepository) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return repository.ErrReadOnly
+}
+
+// TouchLastUsed skips the write; last_used_at is not updated while read-only
+func (r readOnlyAPIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return nil
+}
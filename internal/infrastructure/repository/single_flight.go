@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"web-clean/infra/budget"
+	"web-clean/infra/database"
+	"web-clean/infra/metrics"
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/repository"
+	"web-clean/internal/domain/specification"
+)
+
+var (
+	// singleFlightCalls counts reads that went through the single-flight layer
+	singleFlightCalls = metrics.NewCounter("repository_singleflight_calls_total")
+	// singleFlightShared counts reads answered by another caller's in-flight query
+	singleFlightShared = metrics.NewCounter("repository_singleflight_shared_total")
+)
+
+// singleFlightTimeout bounds a shared query; each caller still stops waiting at its own deadline
+const singleFlightTimeout = 30 * time.Second
+
+// singleFlightUserRepository collapses concurrent identical GetByID and Count calls into one query
+type singleFlightUserRepository struct {
+	repository.UserRepository
+
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is one shared query and the callers waiting for it
+type flight struct {
+	done    chan struct{}
+	value   interface{}
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// NewSingleFlightUserRepository wraps a repository so that concurrent identical hot reads share one database query.
+// Reads inside a transaction are never shared: they must see the transaction's own writes and run on its connection
+func NewSingleFlightUserRepository(inner repository.UserRepository) repository.UserRepository {
+	return &singleFlightUserRepository{UserRepository: inner, flights: make(map[string]*flight)}
+}
+
+// GetByID retrieves a user by ID, sharing the query with concurrent callers for the same ID
+func (r *singleFlightUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	value, err := r.do(ctx, "get:"+id.String(), func(ctx context.Context) (interface{}, error) {
+		return r.UserRepository.GetByID(ctx, id)
+	}, func(value interface{}) int64 {
+		if user, _ := value.(*entity.User); user != nil {
+			return 1
+		}
+		return 0
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user, _ := value.(*entity.User)
+	if user == nil {
+		return nil, nil
+	}
+	// Callers may modify the returned entity, so each one gets its own copy
+	copied := *user
+	return &copied, nil
+}
+
+// Count counts users matching the specification, sharing the query with concurrent callers for the same predicates
+func (r *singleFlightUserRepository) Count(ctx context.Context, spec specification.Specification) (int64, error) {
+	value, err := r.do(ctx, fmt.Sprintf("count:%v", spec.Predicates), func(ctx context.Context) (interface{}, error) {
+		return r.UserRepository.Count(ctx, spec)
+	}, func(interface{}) int64 { return 1 })
+	if err != nil {
+		return 0, err
+	}
+	return value.(int64), nil
+}
+
+// do runs fn once per key among concurrent callers. The shared query runs on its own context, bounded
+// only by singleFlightTimeout: it carries none of the starting caller's values (database budget, chaos
+// faults, principal), so one caller's disconnect, deadline or budget does not fail the others. It is
+// cancelled once every waiting caller is gone. Each caller is charged one query and rowsOf(result) rows
+// against its own budget, and a cancelled caller stops waiting and gets ctx.Err() right away
+func (r *singleFlightUserRepository) do(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error), rowsOf func(value interface{}) int64) (interface{}, error) {
+	if _, inTx := database.TxFromContext(ctx); inTx {
+		return fn(ctx)
+	}
+	usage, metered := budget.UsageFrom(ctx)
+	if metered {
+		if err := usage.BeforeQuery(); err != nil {
+			return nil, err
+		}
+	}
+	singleFlightCalls.Inc()
+
+	r.mu.Lock()
+	f, shared := r.flights[key]
+	if shared {
+		singleFlightShared.Inc()
+	} else {
+		f = &flight{done: make(chan struct{})}
+		r.flights[key] = f
+
+		queryCtx, cancel := context.WithTimeout(context.Background(), singleFlightTimeout)
+		f.cancel = cancel
+		go func() {
+			defer cancel()
+			f.value, f.err = fn(queryCtx)
+
+			r.mu.Lock()
+			if r.flights[key] == f {
+				delete(r.flights, key)
+			}
+			r.mu.Unlock()
+			close(f.done)
+		}()
+	}
+	f.waiters++
+	r.mu.Unlock()
+
+	select {
+	case <-f.done:
+		if f.err != nil {
+			return nil, f.err
+		}
+		if metered {
+			if err := usage.AfterQuery(rowsOf(f.value)); err != nil {
+				return nil, err
+			}
+		}
+		return f.value, nil
+	case <-ctx.Done():
+		r.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			// Nobody is left to use the result; later callers start a new query
+			f.cancel()
+			if r.flights[key] == f {
+				delete(r.flights, key)
+			}
+		}
+		r.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
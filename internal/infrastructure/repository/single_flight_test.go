@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"web-clean/infra/budget"
+	"web-clean/infra/database"
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/repository"
+	"web-clean/internal/domain/specification"
+)
+
+// slowUserRepository counts queries and blocks until released or cancelled
+type slowUserRepository struct {
+	repository.UserRepository
+	queries   atomic.Int32
+	cancelled atomic.Int32
+	metered   atomic.Bool
+	release   chan struct{}
+}
+
+func (r *slowUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	r.queries.Add(1)
+	if _, ok := budget.UsageFrom(ctx); ok {
+		r.metered.Store(true)
+	}
+	select {
+	case <-r.release:
+		return &entity.User{ID: id, Name: "original"}, nil
+	case <-ctx.Done():
+		r.cancelled.Add(1)
+		return nil, ctx.Err()
+	}
+}
+
+func (r *slowUserRepository) Count(ctx context.Context, spec specification.Specification) (int64, error) {
+	r.queries.Add(1)
+	<-r.release
+	return 42, nil
+}
+
+func TestSingleFlight_GetByIDCollapsesConcurrentReads(t *testing.T) {
+	// Arrange
+	inner := &slowUserRepository{release: make(chan struct{})}
+	repo := NewSingleFlightUserRepository(inner)
+	id := uuid.New()
+
+	var wg sync.WaitGroup
+	users := make([]*entity.User, 5)
+	for i := range users {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			users[i], _ = repo.GetByID(context.Background(), id)
+		}(i)
+	}
+
+	// Act
+	time.Sleep(50 * time.Millisecond)
+	close(inner.release)
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, int32(1), inner.queries.Load())
+	users[0].Name = "changed"
+	assert.Equal(t, "original", users[1].Name)
+}
+
+func TestSingleFlight_CountKeyedByPredicates(t *testing.T) {
+	// Arrange
+	inner := &slowUserRepository{release: make(chan struct{})}
+	close(inner.release)
+	repo := NewSingleFlightUserRepository(inner)
+
+	// Act
+	count, err := repo.Count(context.Background(), specification.New())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), count)
+}
+
+func TestSingleFlight_CancelledCallerStopsWaiting(t *testing.T) {
+	// Arrange
+	inner := &slowUserRepository{release: make(chan struct{})}
+	defer close(inner.release)
+	repo := NewSingleFlightUserRepository(inner)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	// Act
+	start := time.Now()
+	user, err := repo.GetByID(ctx, uuid.New())
+
+	// Assert: the caller returns although the shared query is still running
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, user)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestSingleFlight_QueryCancelledWhenAllCallersLeave(t *testing.T) {
+	// Arrange
+	inner := &slowUserRepository{release: make(chan struct{})}
+	defer close(inner.release)
+	repo := NewSingleFlightUserRepository(inner)
+	id := uuid.New()
+	first, cancelFirst := context.WithCancel(context.Background())
+	second, cancelSecond := context.WithCancel(context.Background())
+
+	var wg sync.WaitGroup
+	for _, ctx := range []context.Context{first, second} {
+		wg.Add(1)
+		go func(ctx context.Context) {
+			defer wg.Done()
+			_, _ = repo.GetByID(ctx, id)
+		}(ctx)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// Act: one caller leaving keeps the query running for the other
+	cancelFirst()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(0), inner.cancelled.Load())
+
+	cancelSecond()
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, int32(1), inner.queries.Load())
+	assert.Eventually(t, func() bool { return inner.cancelled.Load() == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestSingleFlight_ReadsInTransactionAreNotShared(t *testing.T) {
+	// Arrange
+	inner := &slowUserRepository{release: make(chan struct{})}
+	repo := NewSingleFlightUserRepository(inner)
+	id := uuid.New()
+	tx := database.WithTx(context.Background(), new(gorm.DB))
+
+	var wg sync.WaitGroup
+	for _, ctx := range []context.Context{tx, context.Background()} {
+		wg.Add(1)
+		go func(ctx context.Context) {
+			defer wg.Done()
+			_, _ = repo.GetByID(ctx, id)
+		}(ctx)
+	}
+
+	// Act
+	time.Sleep(50 * time.Millisecond)
+	close(inner.release)
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, int32(2), inner.queries.Load())
+}
+
+func TestSingleFlight_CallersKeepTheirOwnDeadlineAndBudget(t *testing.T) {
+	// Arrange
+	inner := &slowUserRepository{release: make(chan struct{})}
+	repo := NewSingleFlightUserRepository(inner)
+	id := uuid.New()
+	limits := budget.Limits{MaxQueries: 10}
+	first, firstUsage := budget.WithUsage(context.Background(), limits)
+	first, cancel := context.WithTimeout(first, 20*time.Millisecond)
+	defer cancel()
+	second, secondUsage := budget.WithUsage(context.Background(), limits)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, ctx := range []context.Context{first, second} {
+		wg.Add(1)
+		go func(i int, ctx context.Context) {
+			defer wg.Done()
+			_, errs[i] = repo.GetByID(ctx, id)
+		}(i, ctx)
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Act: the first caller times out, the shared query keeps running for the second
+	time.Sleep(50 * time.Millisecond)
+	close(inner.release)
+	wg.Wait()
+
+	// Assert
+	assert.ErrorIs(t, errs[0], context.DeadlineExceeded)
+	assert.NoError(t, errs[1])
+	assert.Equal(t, int32(1), inner.queries.Load())
+	assert.False(t, inner.metered.Load(), "the shared query runs without the callers' budgets")
+	assert.Equal(t, int64(1), firstUsage.Queries())
+	assert.Equal(t, int64(1), secondUsage.Queries())
+	assert.Equal(t, int64(1), secondUsage.Rows())
+
+	// A caller whose budget is used up does not get the shared result
+	exhausted, usage := budget.WithUsage(context.Background(), budget.Limits{MaxQueries: 1})
+	assert.NoError(t, usage.BeforeQuery())
+	_, err := repo.GetByID(exhausted, id)
+	assert.ErrorIs(t, err, budget.ErrExceeded)
+	assert.Equal(t, int32(1), inner.queries.Load(), "the rejected caller ran no query")
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"web-clean/infra/database"
+	"web-clean/internal/domain/repository"
+)
+
+// gormTransactor begins GORM transactions and hands them down through the context
+type gormTransactor struct {
+	db database.Database
+}
+
+// NewTransactor creates a transactor whose transactions are picked up by every repository built on db
+func NewTransactor(db database.Database) repository.Transactor {
+	return &gormTransactor{db: db}
+}
+
+// WithinTransaction runs fn in a transaction stored in ctx with database.WithTx. When ctx already
+// carries a transaction, GORM nests the new one as a savepoint. Callbacks registered with
+// database.AfterCommit run once the outermost transaction has committed
+func (t *gormTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, committed := database.WithCommitHooks(ctx)
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(database.WithTx(ctx, tx))
+	})
+	if err == nil {
+		committed()
+	}
+	return translateBudget(err)
+}
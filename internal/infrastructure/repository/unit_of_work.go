@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"web-clean/infra/database"
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/repository"
+)
+
+// UserDecorator wraps a user repository, e.g. with NewReadOnlyUserRepository or NewBudgetUserRepository
+type UserDecorator func(repository.UserRepository) repository.UserRepository
+
+// UserWritten is called for each user updated or deleted in a unit of work, once its transaction has committed
+type UserWritten func(ctx context.Context, id uuid.UUID)
+
+// gormUnitOfWork runs a unit of work in one GORM transaction
+type gormUnitOfWork struct {
+	transactor  repository.Transactor
+	decorate    UserDecorator
+	afterCommit UserWritten
+}
+
+// NewUnitOfWork creates a GORM-backed unit of work. The user repository handed to each unit of work is
+// wrapped with decorate, so read-only mode and budgets apply inside transactions too; decorate may be nil.
+// afterCommit, e.g. a cache's Invalidate, learns about the users written once the transaction has committed;
+// it may be nil
+func NewUnitOfWork(db database.Database, decorate UserDecorator, afterCommit UserWritten) repository.UnitOfWork {
+	return &gormUnitOfWork{transactor: NewTransactor(db), decorate: decorate, afterCommit: afterCommit}
+}
+
+// Do runs fn in a transaction bound to ctx, joining the transaction ctx already carries if any
+func (u *gormUnitOfWork) Do(ctx context.Context, fn func(repos repository.Repositories) error) error {
+	return u.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		tx, _ := database.TxFromContext(ctx)
+		session := database.Session(tx)
+
+		var users repository.UserRepository = NewUserRepository(session)
+		if u.decorate != nil {
+			users = u.decorate(users)
+		}
+		if u.afterCommit != nil {
+			users = committedWrites{UserRepository: users, afterCommit: u.afterCommit}
+		}
+
+		return fn(transactionRepositories{users: users, audit: NewAuditRepository(session)})
+	})
+}
+
+// transactionRepositories are the repositories bound to one transaction
+type transactionRepositories struct {
+	users repository.UserRepository
+	audit repository.AuditRepository
+}
+
+func (r transactionRepositories) Users() repository.UserRepository {
+	return r.users
+}
+
+func (r transactionRepositories) Audit() repository.AuditRepository {
+	return r.audit
+}
+
+// committedWrites reports the users written in a transaction to afterCommit once it has committed
+type committedWrites struct {
+	repository.UserRepository
+	afterCommit UserWritten
+}
+
+// Update updates the user and reports it after the commit
+func (r committedWrites) Update(ctx context.Context, user *entity.User) error {
+	if err := r.UserRepository.Update(ctx, user); err != nil {
+		return err
+	}
+	database.AfterCommit(ctx, func() { r.afterCommit(ctx, user.ID) })
+	return nil
+}
+
+// Delete deletes the user and reports it after the commit
+func (r committedWrites) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.UserRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	database.AfterCommit(ctx, func() { r.afterCommit(ctx, id) })
+	return nil
+}
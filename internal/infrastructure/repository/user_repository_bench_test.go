@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"web-clean/internal/domain/filter"
+	"web-clean/internal/domain/specification"
+)
+
+// BenchmarkApplyUserSpecification measures building the list query, without a database round trip
+func BenchmarkApplyUserSpecification(b *testing.B) {
+	db, err := dryRunDBFor(b)
+	if err != nil {
+		b.Fatal(err)
+	}
+	spec := specification.New().
+		Where(
+			filter.Condition{Field: "email", Operator: filter.Contains, Value: "@corp.com"},
+			filter.Condition{Field: "created_at", Operator: filter.GreaterOrEqual, Value: time.Now()},
+		).
+		OrderBy("created_at", specification.Descending).
+		OrderBy("id", specification.Descending).
+		After(time.Now(), uuid.New()).
+		Take(11)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		query, err := applyUserSpecification(db.Model(&UserModel{}), spec)
+		if err != nil {
+			b.Fatal(err)
+		}
+		query.Find(&[]UserModel{})
+	}
+}
+
+func BenchmarkUserModel_ToEntity(b *testing.B) {
+	model := UserModel{ID: uuid.New(), Email: "user@example.com", Username: "user", Name: "User", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = model.ToEntity()
+	}
+}
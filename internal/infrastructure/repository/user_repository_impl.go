@@ -3,25 +3,31 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 	
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	
 	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/filter"
 	"web-clean/internal/domain/repository"
+	"web-clean/internal/domain/specification"
 	"web-clean/infra/database"
 )
 
 // UserModel represents the database model for users
 // This is the infrastructure concern - how we store users in the database
 type UserModel struct {
-	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	Email     string    `gorm:"type:varchar(255);uniqueIndex;not null"`
-	Username  string    `gorm:"type:varchar(50);uniqueIndex;not null"`
-	Name      string    `gorm:"type:varchar(100);not null"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Email        string    `gorm:"type:varchar(255);uniqueIndex;not null"`
+	Username     string    `gorm:"type:varchar(50);uniqueIndex;not null"`
+	Name         string    `gorm:"type:varchar(100);not null"`
+	Version      int64     `gorm:"not null;default:1"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime"`
+	PasswordHash string    `gorm:"type:varchar(255);not null;default:''"`
 }
 
 // TableName specifies the table name for GORM
@@ -32,23 +38,27 @@ func (UserModel) TableName() string {
 // ToEntity converts database model to domain entity
 func (m *UserModel) ToEntity() *entity.User {
 	return &entity.User{
-		ID:        m.ID,
-		Email:     m.Email,
-		Username:  m.Username,
-		Name:      m.Name,
-		CreatedAt: m.CreatedAt,
-		UpdatedAt: m.UpdatedAt,
+		ID:           m.ID,
+		Email:        entity.Email(m.Email),
+		Username:     entity.Username(m.Username),
+		Name:         m.Name,
+		Version:      m.Version,
+		CreatedAt:    m.CreatedAt,
+		UpdatedAt:    m.UpdatedAt,
+		PasswordHash: m.PasswordHash,
 	}
 }
 
 // FromEntity converts domain entity to database model
 func (m *UserModel) FromEntity(user *entity.User) {
 	m.ID = user.ID
-	m.Email = user.Email
-	m.Username = user.Username
+	m.Email = user.Email.String()
+	m.Username = user.Username.String()
 	m.Name = user.Name
+	m.Version = user.Version
 	m.CreatedAt = user.CreatedAt
 	m.UpdatedAt = user.UpdatedAt
+	m.PasswordHash = user.PasswordHash
 }
 
 // UserRepositoryImpl implements the UserRepository interface
@@ -74,8 +84,28 @@ func (r *UserRepositoryImpl) Create(ctx context.Context, user *entity.User) erro
 	model := &UserModel{}
 	model.FromEntity(user)
 	
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		return tx.WithContext(ctx).Create(model).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return translateDuplicate(tx, tx.Create(model).Error)
+	})
+}
+
+// createBatchSize is the number of rows per INSERT statement in CreateBatch
+const createBatchSize = 100
+
+// CreateBatch stores several new users in one transaction
+func (r *UserRepositoryImpl) CreateBatch(ctx context.Context, users []*entity.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+	
+	models := make([]*UserModel, len(users))
+	for i, user := range users {
+		models[i] = &UserModel{}
+		models[i].FromEntity(user)
+	}
+	
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return translateDuplicate(tx, tx.CreateInBatches(models, createBatchSize).Error)
 	})
 }
 
@@ -83,9 +113,7 @@ func (r *UserRepositoryImpl) Create(ctx context.Context, user *entity.User) erro
 func (r *UserRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
 	var model UserModel
 	
-	err := r.db.Transaction(func(tx *gorm.DB) error {
-		return tx.WithContext(ctx).Where("id = ?", id).First(&model).Error
-	})
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&model).Error
 	
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -97,13 +125,11 @@ func (r *UserRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*entity
 	return model.ToEntity(), nil
 }
 
-// GetByEmail retrieves a user by their email
+// GetByEmail retrieves a user by their email, ignoring case
 func (r *UserRepositoryImpl) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
 	var model UserModel
 	
-	err := r.db.Transaction(func(tx *gorm.DB) error {
-		return tx.WithContext(ctx).Where("email = ?", email).First(&model).Error
-	})
+	err := whereFolded(r.db.WithContext(ctx), "email", email).First(&model).Error
 	
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -115,13 +141,11 @@ func (r *UserRepositoryImpl) GetByEmail(ctx context.Context, email string) (*ent
 	return model.ToEntity(), nil
 }
 
-// GetByUsername retrieves a user by their username
+// GetByUsername retrieves a user by their username, ignoring case
 func (r *UserRepositoryImpl) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
 	var model UserModel
 	
-	err := r.db.Transaction(func(tx *gorm.DB) error {
-		return tx.WithContext(ctx).Where("username = ?", username).First(&model).Error
-	})
+	err := whereFolded(r.db.WithContext(ctx), "username", username).First(&model).Error
 	
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -133,38 +157,53 @@ func (r *UserRepositoryImpl) GetByUsername(ctx context.Context, username string)
 	return model.ToEntity(), nil
 }
 
-// Update updates an existing user in the database
+// Update updates an existing user in the database, guarded by its version
 func (r *UserRepositoryImpl) Update(ctx context.Context, user *entity.User) error {
-	model := &UserModel{}
-	model.FromEntity(user)
-	
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		return tx.WithContext(ctx).Model(&UserModel{}).Where("id = ?", user.ID).Updates(model).Error
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&UserModel{}).
+			Where("id = ? AND version = ?", user.ID, user.Version).
+			Updates(map[string]interface{}{
+				"email":         user.Email.String(),
+				"username":      user.Username.String(),
+				"name":          user.Name,
+				"password_hash": user.PasswordHash,
+				"updated_at":    user.UpdatedAt,
+				"version":       gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return translateDuplicate(tx, result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return repository.ErrConflict
+		}
+		return nil
 	})
+	if err != nil {
+		return err
+	}
+	
+	user.Version++
+	return nil
 }
 
 // Delete removes a user from the database
 func (r *UserRepositoryImpl) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		return tx.WithContext(ctx).Delete(&UserModel{}, "id = ?", id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Delete(&UserModel{}, "id = ?", id).Error
 	})
 }
 
-// List retrieves users with pagination
-func (r *UserRepositoryImpl) List(ctx context.Context, offset, limit int) ([]*entity.User, error) {
+// List retrieves users matching the specification
+func (r *UserRepositoryImpl) List(ctx context.Context, spec specification.Specification) ([]*entity.User, error) {
 	var models []UserModel
 	
-	err := r.db.Transaction(func(tx *gorm.DB) error {
-		return tx.WithContext(ctx).
-			Offset(offset).
-			Limit(limit).
-			Order("created_at DESC").
-			Find(&models).Error
-	})
-	
+	query, err := applyUserSpecification(r.db.WithContext(ctx), spec)
 	if err != nil {
 		return nil, err
 	}
+	if err := query.Find(&models).Error; err != nil {
+		return nil, err
+	}
 	
 	users := make([]*entity.User, len(models))
 	for i, model := range models {
@@ -174,13 +213,114 @@ func (r *UserRepositoryImpl) List(ctx context.Context, offset, limit int) ([]*en
 	return users, nil
 }
 
-// Count returns the total number of users
-func (r *UserRepositoryImpl) Count(ctx context.Context) (int64, error) {
+// Count returns the total number of users matching the specification's predicates
+func (r *UserRepositoryImpl) Count(ctx context.Context, spec specification.Specification) (int64, error) {
 	var count int64
 	
-	err := r.db.Transaction(func(tx *gorm.DB) error {
-		return tx.WithContext(ctx).Model(&UserModel{}).Count(&count).Error
-	})
+	query, err := applyUserFilter(r.db.WithContext(ctx).Model(&UserModel{}), spec.Predicates)
+	if err != nil {
+		return 0, err
+	}
+	err = query.Count(&count).Error
 	
 	return count, err
-}
\ No newline at end of file
+}
+
+// userFilterColumns maps filter fields to database columns, anything not listed is rejected
+var userFilterColumns = map[string]string{
+	"email":      "email",
+	"username":   "username",
+	"name":       "name",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// userOrderColumns maps orderable fields to database columns, anything not listed is rejected
+var userOrderColumns = map[string]string{
+	"id":         "id",
+	"email":      "email",
+	"username":   "username",
+	"name":       "name",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// likeEscaper escapes LIKE wildcards so that ~ always means a literal substring match
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// applyUserFilter translates a validated filter expression into GORM where clauses
+func applyUserFilter(query *gorm.DB, expr filter.Expression) (*gorm.DB, error) {
+	for _, cond := range expr {
+		column, ok := userFilterColumns[cond.Field]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", filter.ErrUnknownField, cond.Field)
+		}
+
+		switch cond.Operator {
+		case filter.Equal, filter.NotEqual, filter.Greater, filter.GreaterOrEqual, filter.Less, filter.LessOrEqual:
+			query = query.Where(fmt.Sprintf("%s %s ?", column, cond.Operator), cond.Value)
+		case filter.Contains:
+			value, ok := cond.Value.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s %s", filter.ErrInvalidOperator, cond.Field, cond.Operator)
+			}
+			query = query.Where(fmt.Sprintf("%s ILIKE ?", column), "%"+likeEscaper.Replace(value)+"%")
+		case filter.Prefix:
+			value, ok := cond.Value.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s %s", filter.ErrInvalidOperator, cond.Field, cond.Operator)
+			}
+			query = query.Where(fmt.Sprintf("%s ILIKE ?", column), likeEscaper.Replace(value)+"%")
+		default:
+			return nil, fmt.Errorf("%w: %s %s", filter.ErrInvalidOperator, cond.Field, cond.Operator)
+		}
+	}
+
+	return query, nil
+}
+
+// applyUserSpecification translates a specification into GORM clauses: predicates,
+// keyset seek or offset, ordering and limit
+func applyUserSpecification(query *gorm.DB, spec specification.Specification) (*gorm.DB, error) {
+	query, err := applyUserFilter(query, spec.Predicates)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, len(spec.Ordering))
+	for i, order := range spec.Ordering {
+		column, ok := userOrderColumns[order.Field]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", filter.ErrUnknownField, order.Field)
+		}
+		columns[i] = column
+
+		direction := "ASC"
+		if order.Direction == specification.Descending {
+			direction = "DESC"
+		}
+		query = query.Order(column + " " + direction)
+	}
+
+	if spec.Seek != nil {
+		direction, err := spec.SeekDirection()
+		if err != nil {
+			return nil, err
+		}
+
+		comparison := ">"
+		if direction == specification.Descending {
+			comparison = "<"
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+		query = query.Where(fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), comparison, placeholders), spec.Seek...)
+	} else if spec.Offset > 0 {
+		query = query.Offset(spec.Offset)
+	}
+
+	if spec.Limit > 0 {
+		query = query.Limit(spec.Limit)
+	}
+
+	return query, nil
+}
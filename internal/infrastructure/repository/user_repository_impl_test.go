@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"web-clean/infra/database"
+	"web-clean/internal/domain/filter"
+	"web-clean/internal/domain/specification"
+)
+
+// dryRunDB returns a session that renders SQL without connecting to a database
+func dryRunDB(t *testing.T) *gorm.DB {
+	db, err := dryRunDBFor(t)
+	assert.NoError(t, err)
+	return db
+}
+
+func dryRunDBFor(testing.TB) (*gorm.DB, error) {
+	return gorm.Open(postgres.Open("host=127.0.0.1"), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+}
+
+func TestApplyUserSpecification_Seek(t *testing.T) {
+	// Arrange
+	cursorTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	spec := specification.New().
+		Where(filter.Condition{Field: "email", Operator: filter.Contains, Value: "@corp.com"}).
+		OrderBy("created_at", specification.Descending).
+		OrderBy("id", specification.Descending).
+		After(cursorTime, uuid.Nil).
+		Take(11)
+
+	// Act
+	query, err := applyUserSpecification(dryRunDB(t).Model(&UserModel{}), spec)
+	assert.NoError(t, err)
+	stmt := query.Find(&[]UserModel{}).Statement
+
+	// Assert
+	assert.Equal(t,
+		`SELECT * FROM "users" WHERE email ILIKE $1 AND (created_at, id) < ($2, $3) ORDER BY created_at DESC,id DESC LIMIT $4`,
+		stmt.SQL.String())
+	assert.Equal(t, []interface{}{"%@corp.com%", cursorTime, uuid.Nil, 11}, stmt.Vars)
+}
+
+func TestApplyUserSpecification_Offset(t *testing.T) {
+	// Arrange
+	spec := specification.New().OrderBy("name", specification.Ascending).Page(20, 10)
+
+	// Act
+	query, err := applyUserSpecification(dryRunDB(t).Model(&UserModel{}), spec)
+	assert.NoError(t, err)
+	stmt := query.Find(&[]UserModel{}).Statement
+
+	// Assert
+	assert.Equal(t, `SELECT * FROM "users" ORDER BY name ASC LIMIT $1 OFFSET $2`, stmt.SQL.String())
+}
+
+func TestApplyUserSpecification_Prefix(t *testing.T) {
+	// Arrange
+	spec := specification.New().Where(filter.Condition{Field: "email", Operator: filter.Prefix, Value: "a_b"})
+
+	// Act
+	query, err := applyUserSpecification(dryRunDB(t).Model(&UserModel{}), spec)
+	assert.NoError(t, err)
+	stmt := query.Find(&[]UserModel{}).Statement
+
+	// Assert
+	assert.Equal(t, `SELECT * FROM "users" WHERE email ILIKE $1`, stmt.SQL.String())
+	assert.Equal(t, []interface{}{`a\_b%`}, stmt.Vars)
+}
+
+func TestApplyUserSpecification_Invalid(t *testing.T) {
+	_, err := applyUserSpecification(dryRunDB(t), specification.New().OrderBy("password", specification.Ascending))
+	assert.ErrorIs(t, err, filter.ErrUnknownField)
+
+	_, err = applyUserSpecification(dryRunDB(t), specification.New().OrderBy("id", specification.Ascending).After(1, 2))
+	assert.ErrorIs(t, err, specification.ErrInvalidSeek)
+}
+
+func TestUserRepository_QueriesUseCallerContext(t *testing.T) {
+	// Arrange
+	db := dryRunDB(t)
+	var seen []context.Context
+	assert.NoError(t, db.Callback().Query().Before("gorm:query").Register("test:capture_context", func(tx *gorm.DB) {
+		seen = append(seen, tx.Statement.Context)
+	}))
+	repo := NewUserRepository(database.Session(db))
+
+	type key struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "request"))
+	defer cancel()
+
+	// Act
+	_, _ = repo.GetByID(ctx, uuid.New())
+	_, _ = repo.GetByEmail(ctx, "alice@example.com")
+	_, _ = repo.List(ctx, specification.New().Take(10))
+
+	// Assert: cancelling the request context aborts these statements in the driver
+	assert.Len(t, seen, 3)
+	for _, statementCtx := range seen {
+		assert.Equal(t, "request", statementCtx.Value(key{}))
+	}
+}
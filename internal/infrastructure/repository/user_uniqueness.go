@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"web-clean/infra/database"
+	"web-clean/internal/domain/repository"
+)
+
+// caseFoldedColumns are the user columns that must be unique regardless of case, with the index enforcing it
+var caseFoldedColumns = []struct{ column, index string }{
+	{column: "email", index: "idx_users_email_lower"},
+	{column: "username", index: "idx_users_username_lower"},
+}
+
+// CaseConflict is a group of users whose email or username differ only by case
+type CaseConflict struct {
+	Field   string      `json:"field"`
+	Value   string      `json:"value"`
+	UserIDs []uuid.UUID `json:"user_ids"`
+}
+
+// CaseUniquenessReport is the outcome of MigrateCaseInsensitiveUniqueness
+type CaseUniquenessReport struct {
+	// Normalized is the number of rows whose email or username was lower-cased
+	Normalized int64 `json:"normalized"`
+	// Enforced lists the columns now guarded by a case-insensitive unique index
+	Enforced []string `json:"enforced"`
+	// Conflicts must be resolved by hand before the index on their column can be created
+	Conflicts []CaseConflict `json:"conflicts"`
+}
+
+// whereFolded compares column to value ignoring case, served by the lower(column) indexes
+func whereFolded(tx *gorm.DB, column, value string) *gorm.DB {
+	return tx.Where(fmt.Sprintf("lower(%s) = ?", column), strings.ToLower(value))
+}
+
+// caseConflictsQuery selects the lower-cased values shared by more than one user
+func caseConflictsQuery(tx *gorm.DB, column string) *gorm.DB {
+	return tx.Model(&UserModel{}).
+		Select(fmt.Sprintf("lower(%s) AS value, array_agg(id::text ORDER BY created_at, id) AS ids", column)).
+		Group(fmt.Sprintf("lower(%s)", column)).
+		Having("count(*) > 1").
+		Order("value")
+}
+
+// normalizeCaseQuery lower-cases the column for rows whose folded value is not shared with another user
+func normalizeCaseQuery(tx *gorm.DB, column string) *gorm.DB {
+	return tx.Model(&UserModel{}).
+		Where(fmt.Sprintf("%[1]s <> lower(%[1]s)", column)).
+		Where(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM users other WHERE lower(other.%[1]s) = lower(users.%[1]s) AND other.id <> users.id)", column)).
+		UpdateColumns(map[string]interface{}{
+			column:    gorm.Expr(fmt.Sprintf("lower(%s)", column)),
+			"version": gorm.Expr("version + 1"),
+		})
+}
+
+// FindCaseConflicts lists the users whose email or username collide once case is ignored; the oldest user comes first
+func FindCaseConflicts(ctx context.Context, db database.Database) ([]CaseConflict, error) {
+	conflicts := make([]CaseConflict, 0)
+	for _, folded := range caseFoldedColumns {
+		found, err := findCaseConflicts(db.WithContext(ctx), folded.column)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, found...)
+	}
+	return conflicts, nil
+}
+
+// caseConflictRow is one row of caseConflictsQuery
+type caseConflictRow struct {
+	Value string `gorm:"column:value"`
+	IDs   string `gorm:"column:ids"`
+}
+
+func findCaseConflicts(tx *gorm.DB, column string) ([]CaseConflict, error) {
+	var rows []caseConflictRow
+	if err := caseConflictsQuery(tx, column).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	conflicts := make([]CaseConflict, 0, len(rows))
+	for _, row := range rows {
+		conflict := CaseConflict{Field: column, Value: row.Value}
+		// array_agg of text is returned in Postgres array syntax: {a,b}
+		for _, raw := range strings.Split(strings.Trim(row.IDs, "{}"), ",") {
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				return nil, fmt.Errorf("parsing conflicting user id %q: %w", raw, err)
+			}
+			conflict.UserIDs = append(conflict.UserIDs, id)
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts, nil
+}
+
+// MigrateCaseInsensitiveUniqueness lower-cases legacy mixed-case emails and usernames and creates unique
+// indexes on lower(email) and lower(username). Rows that collide with another user once case is ignored
+// are left untouched and reported instead, and the index on that column is not created until they are
+// resolved, so existing data never blocks startup. Running it again is safe
+func MigrateCaseInsensitiveUniqueness(ctx context.Context, db database.Database) (CaseUniquenessReport, error) {
+	report := CaseUniquenessReport{Enforced: make([]string, 0), Conflicts: make([]CaseConflict, 0)}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		tx = tx.WithContext(ctx)
+		for _, folded := range caseFoldedColumns {
+			result := normalizeCaseQuery(tx, folded.column)
+			if result.Error != nil {
+				return result.Error
+			}
+			report.Normalized += result.RowsAffected
+
+			conflicts, err := findCaseConflicts(tx, folded.column)
+			if err != nil {
+				return err
+			}
+			if len(conflicts) > 0 {
+				report.Conflicts = append(report.Conflicts, conflicts...)
+				continue
+			}
+
+			statement := fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON users (lower(%s))", folded.index, folded.column)
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+			report.Enforced = append(report.Enforced, folded.column)
+		}
+		return nil
+	})
+	return report, err
+}
+
+// translateDuplicate turns a unique constraint violation into repository.ErrDuplicate
+func translateDuplicate(tx *gorm.DB, err error) error {
+	if err == nil {
+		return nil
+	}
+	if translator, ok := tx.Dialector.(gorm.ErrorTranslator); ok {
+		if errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey) {
+			return fmt.Errorf("%w: %w", repository.ErrDuplicate, err)
+		}
+	}
+	return err
+}
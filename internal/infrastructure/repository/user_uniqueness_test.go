@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestWhereFolded(t *testing.T) {
+	db := dryRunDB(t)
+
+	sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return whereFolded(tx, "email", "Alice@Example.com").First(&UserModel{})
+	})
+
+	assert.Equal(t, `SELECT * FROM "users" WHERE lower(email) = 'alice@example.com' ORDER BY "users"."id" LIMIT 1`, sql)
+}
+
+func TestCaseConflictsQuery(t *testing.T) {
+	db := dryRunDB(t)
+
+	sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return caseConflictsQuery(tx, "username").Find(&[]caseConflictRow{})
+	})
+
+	assert.Equal(t,
+		`SELECT lower(username) AS value, array_agg(id::text ORDER BY created_at, id) AS ids FROM "users" GROUP BY lower(username) HAVING count(*) > 1 ORDER BY value`,
+		sql)
+}
+
+func TestNormalizeCaseQuery_SkipsConflictingRows(t *testing.T) {
+	db := dryRunDB(t)
+
+	sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return normalizeCaseQuery(tx, "email")
+	})
+
+	assert.Equal(t,
+		`UPDATE "users" SET "email"=lower(email),"version"=version + 1 WHERE email <> lower(email) AND (NOT EXISTS (SELECT 1 FROM users other WHERE lower(other.email) = lower(users.email) AND other.id <> users.id))`,
+		sql)
+}
@@ -0,0 +1,128 @@
+package usernames
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"web-clean/infra/conf"
+	"web-clean/internal/domain/entity"
+)
+
+// DefaultReserved are usernames that could be mistaken for the service itself
+var DefaultReserved = []string{
+	"admin", "administrator", "api", "help", "me", "null", "root",
+	"security", "settings", "support", "system", "undefined", "www",
+}
+
+// Rules is the reserved-word list and pattern list of a Policy
+type Rules struct {
+	Words    []string `json:"words"`
+	Patterns []string `json:"patterns"`
+}
+
+// Policy rejects reserved words and usernames matching any pattern.
+// Words match case-insensitively and ignoring "-", "_" and "." separators, so "Ad_min" is reserved too;
+// patterns are regular expressions matched against the lower-cased username.
+// The rules can be replaced at runtime and are safe for concurrent use
+type Policy struct {
+	mu       sync.RWMutex
+	words    map[string]bool
+	patterns []*regexp.Regexp
+	rules    Rules
+}
+
+var _ entity.UsernamePolicy = (*Policy)(nil)
+
+// New creates a Policy, returning an error if a pattern does not compile
+func New(rules Rules) (*Policy, error) {
+	p := &Policy{}
+	if err := p.Replace(rules); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// From creates a Policy from config: the default reserved words plus the configured words and patterns
+func From(config *conf.ReservedUsernames) (*Policy, error) {
+	return New(RulesFrom(config))
+}
+
+// RulesFrom returns the default reserved words plus the configured words and patterns
+func RulesFrom(config *conf.ReservedUsernames) Rules {
+	rules := Rules{Words: append([]string{}, DefaultReserved...)}
+	if config != nil {
+		rules.Words = append(rules.Words, config.Words...)
+		rules.Patterns = config.Patterns
+	}
+	return rules
+}
+
+// Allowed reports whether username is neither reserved nor matched by a pattern
+func (p *Policy) Allowed(username string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.words[normalize(username)] {
+		return false
+	}
+	lower := strings.ToLower(username)
+	for _, pattern := range p.patterns {
+		if pattern.MatchString(lower) {
+			return false
+		}
+	}
+	return true
+}
+
+// Rules returns the current rules with words normalized, de-duplicated and sorted
+func (p *Policy) Rules() Rules {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return Rules{
+		Words:    append([]string{}, p.rules.Words...),
+		Patterns: append([]string{}, p.rules.Patterns...),
+	}
+}
+
+// Replace swaps in new rules atomically; on error the current rules are kept
+func (p *Policy) Replace(rules Rules) error {
+	patterns := make([]*regexp.Regexp, 0, len(rules.Patterns))
+	for _, raw := range rules.Patterns {
+		pattern, err := regexp.Compile(raw)
+		if err != nil {
+			return fmt.Errorf("invalid username pattern %q: %w", raw, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	words := make(map[string]bool, len(rules.Words))
+	for _, word := range rules.Words {
+		if word = normalize(word); word != "" {
+			words[word] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(words))
+	for word := range words {
+		sorted = append(sorted, word)
+	}
+	sort.Strings(sorted)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.words = words
+	p.patterns = patterns
+	p.rules = Rules{Words: sorted, Patterns: append([]string{}, rules.Patterns...)}
+	return nil
+}
+
+var separators = strings.NewReplacer("-", "", "_", "", ".", "")
+
+func normalize(username string) string {
+	return separators.Replace(strings.ToLower(strings.TrimSpace(username)))
+}
@@ -0,0 +1,34 @@
+package usernames
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"web-clean/infra/conf"
+)
+
+func TestPolicy_Allowed(t *testing.T) {
+	policy, err := From(&conf.ReservedUsernames{Words: []string{"billing"}, Patterns: []string{`^admin\d*$`, `badword`}})
+	assert.NoError(t, err)
+
+	for _, username := range []string{"admin", "Ad_min", "ROOT", "api", "billing", "admin42", "xxbadwordxx"} {
+		assert.False(t, policy.Allowed(username), username)
+	}
+	for _, username := range []string{"alice", "administrators_fan", "rooted"} {
+		assert.True(t, policy.Allowed(username), username)
+	}
+}
+
+func TestPolicy_Replace(t *testing.T) {
+	policy, err := New(Rules{Words: []string{"admin"}})
+	assert.NoError(t, err)
+
+	assert.Error(t, policy.Replace(Rules{Patterns: []string{"("}}))
+	assert.False(t, policy.Allowed("admin"), "invalid rules must keep the current ones")
+
+	assert.NoError(t, policy.Replace(Rules{Words: []string{"Owner", "owner", " "}}))
+	assert.True(t, policy.Allowed("admin"))
+	assert.False(t, policy.Allowed("owner"))
+	assert.Equal(t, Rules{Words: []string{"owner"}, Patterns: []string{}}, policy.Rules())
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"web-clean/domain"
+	"web-clean/infra/web"
+	"web-clean/internal/domain/usecase"
+)
+
+const (
+	// APIKeyScheme is the Authorization scheme of API keys: "Authorization: ApiKey wc_..."
+	APIKeyScheme = "ApiKey"
+	// APIKeyRole is the role of every caller authenticated by an API key
+	APIKeyRole = "machine"
+	// APIKeySubjectPrefix precedes the key ID in the subject of its principal, so keys never collide with user IDs
+	APIKeySubjectPrefix = "apikey:"
+)
+
+// APIKeyAuth authenticates requests carrying an API key and makes the key's scopes available to
+// route scope checks. Requests without an ApiKey Authorization header pass through unauthenticated,
+// so routes declaring a Scope reject them with 401; an invalid or revoked key is rejected with 401
+func APIKeyAuth(apiKeys usecase.APIKeyUseCase, logger domain.Log) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, secret, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, APIKeyScheme) {
+			c.Next()
+			return
+		}
+
+		key, err := apiKeys.AuthenticateAPIKey(c.Request.Context(), strings.TrimSpace(secret))
+		if err != nil {
+			status, response := errorResponseFor(err)
+			if status == http.StatusInternalServerError {
+				logger.Errorw("Internal server error", "error", err)
+			}
+			if status == http.StatusUnauthorized {
+				c.Header("WWW-Authenticate", APIKeyScheme)
+			}
+			c.AbortWithStatusJSON(status, response)
+			return
+		}
+
+		web.SetPrincipal(c, web.Principal{
+			Subject: APIKeySubjectPrefix + key.ID.String(),
+			Roles:   []string{APIKeyRole},
+			Scopes:  key.Scopes,
+		})
+		c.Next()
+	}
+}
@@ -0,0 +1,158 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"web-clean/domain"
+	"web-clean/infra/web"
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/usecase"
+)
+
+// APIKeyHandler handles HTTP requests for API key management
+type APIKeyHandler struct {
+	apiKeys usecase.APIKeyUseCase
+	logger  domain.Log
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(apiKeys usecase.APIKeyUseCase, logger domain.Log) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeys: apiKeys,
+		logger:  logger,
+	}
+}
+
+// CreateAPIKeyRequest represents the HTTP request for creating an API key
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required,min=1,max=100"`
+	Scopes []string `json:"scopes" binding:"max=20"`
+}
+
+// APIKeyResponse represents an API key in HTTP responses
+type APIKeyResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  string   `json:"created_at"`
+	LastUsedAt *string  `json:"last_used_at,omitempty"`
+	RevokedAt  *string  `json:"revoked_at,omitempty"`
+	// Key is the secret, present only in the response that created the key
+	Key string `json:"key,omitempty"`
+}
+
+// ListAPIKeysResponse represents the HTTP response for listing API keys
+type ListAPIKeysResponse struct {
+	APIKeys []APIKeyResponse `json:"api_keys"`
+}
+
+// CreateAPIKey handles POST /apikeys; the secret is in the response and cannot be retrieved later.
+// An authenticated caller can only grant scopes it holds itself
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := web.BindJSON(c, &req); err != nil {
+		h.logger.Warnw("Invalid request for create API key", "error", err)
+		c.JSON(http.StatusBadRequest, invalidRequest(err))
+		return
+	}
+
+	if principal, ok := web.PrincipalFrom(c.Request.Context()); ok {
+		for _, scope := range req.Scopes {
+			if !principal.HasScope(scope) {
+				c.JSON(http.StatusForbidden, ErrorResponse{
+					Error:   "forbidden",
+					Message: fmt.Sprintf("Cannot grant the %q scope, which the caller does not hold", scope),
+				})
+				return
+			}
+		}
+	}
+
+	created, err := h.apiKeys.CreateAPIKey(c.Request.Context(), usecase.CreateAPIKeyRequest{
+		Name:   req.Name,
+		Scopes: req.Scopes,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response := toAPIKeyResponse(created.Key)
+	response.Key = created.Secret
+
+	// The secret must not end up in a shared or browser cache
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListAPIKeys handles GET /apikeys
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.apiKeys.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response := ListAPIKeysResponse{APIKeys: make([]APIKeyResponse, len(keys))}
+	for i, key := range keys {
+		response.APIKeys[i] = toAPIKeyResponse(key)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeAPIKey handles POST /apikeys/:id/revoke; the key stays listed with its revocation time
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Warnw("Invalid API key ID format", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid API key ID format",
+		})
+		return
+	}
+
+	key, err := h.apiKeys.RevokeAPIKey(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toAPIKeyResponse(key))
+}
+
+func (h *APIKeyHandler) handleError(c *gin.Context, err error) {
+	status, response := errorResponseFor(err)
+	if status == http.StatusInternalServerError {
+		h.logger.Errorw("Internal server error", "error", err)
+	}
+	c.JSON(status, response)
+}
+
+// toAPIKeyResponse converts a domain entity to its HTTP representation, never including the secret
+func toAPIKeyResponse(key *entity.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         key.ID.String(),
+		Name:       key.Name,
+		Prefix:     key.DisplayPrefix,
+		Scopes:     key.Scopes,
+		CreatedAt:  key.CreatedAt.Format(timestampLayout),
+		LastUsedAt: formatOptionalTime(key.LastUsedAt),
+		RevokedAt:  formatOptionalTime(key.RevokedAt),
+	}
+}
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format(timestampLayout)
+	return &formatted
+}
@@ -0,0 +1,234 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"web-clean/infra/web"
+	"web-clean/internal/application/service"
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/usecase"
+)
+
+// apiKeyUseCase keeps keys in memory and accepts the secrets it created
+type apiKeyUseCase struct {
+	keys    []*entity.APIKey
+	secrets map[string]*entity.APIKey
+}
+
+func newAPIKeyUseCase() *apiKeyUseCase {
+	return &apiKeyUseCase{secrets: make(map[string]*entity.APIKey)}
+}
+
+func (u *apiKeyUseCase) CreateAPIKey(_ context.Context, req usecase.CreateAPIKeyRequest) (*usecase.CreateAPIKeyResponse, error) {
+	key, secret, err := entity.NewAPIKey(uuid.New(), req.Name, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	u.keys = append(u.keys, key)
+	u.secrets[secret] = key
+	return &usecase.CreateAPIKeyResponse{Key: key, Secret: secret}, nil
+}
+
+func (u *apiKeyUseCase) ListAPIKeys(context.Context) ([]*entity.APIKey, error) {
+	return u.keys, nil
+}
+
+func (u *apiKeyUseCase) RevokeAPIKey(_ context.Context, id uuid.UUID) (*entity.APIKey, error) {
+	for _, key := range u.keys {
+		if key.ID == id {
+			key.Revoke(time.Now())
+			return key, nil
+		}
+	}
+	return nil, service.ErrAPIKeyNotFound
+}
+
+func (u *apiKeyUseCase) AuthenticateAPIKey(_ context.Context, secret string) (*entity.APIKey, error) {
+	key, ok := u.secrets[secret]
+	if !ok || !key.Active() {
+		return nil, service.ErrAPIKeyRejected
+	}
+	return key, nil
+}
+
+func apiKeyRouter(useCase usecase.APIKeyUseCase) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewAPIKeyHandler(useCase, zap.NewNop().Sugar())
+
+	router := gin.New()
+	router.Use(APIKeyAuth(useCase, zap.NewNop().Sugar()))
+	router.POST("/apikeys", handler.CreateAPIKey)
+	router.GET("/apikeys", handler.ListAPIKeys)
+	router.POST("/apikeys/:id/revoke", handler.RevokeAPIKey)
+	router.GET("/whoami", func(c *gin.Context) {
+		principal, ok := web.PrincipalFrom(c.Request.Context())
+		if !ok {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		c.JSON(http.StatusOK, principal)
+	})
+	return router
+}
+
+func serveAPIKey(router *gin.Engine, method, path, body, authorization string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestAPIKeyHandler_CreateListRevoke(t *testing.T) {
+	useCase := newAPIKeyUseCase()
+	router := apiKeyRouter(useCase)
+
+	created := serveAPIKey(router, http.MethodPost, "/apikeys", `{"name":"billing","scopes":["users:read"]}`, "")
+	require.Equal(t, http.StatusCreated, created.Code)
+	assert.Equal(t, "no-store", created.Header().Get("Cache-Control"))
+
+	var key APIKeyResponse
+	require.NoError(t, json.Unmarshal(created.Body.Bytes(), &key))
+	assert.True(t, strings.HasPrefix(key.Key, entity.APIKeyPrefix))
+	assert.True(t, strings.HasPrefix(key.Key, key.Prefix))
+	assert.Equal(t, []string{"users:read"}, key.Scopes)
+
+	listed := serveAPIKey(router, http.MethodGet, "/apikeys", "", "")
+	require.Equal(t, http.StatusOK, listed.Code)
+	assert.NotContains(t, listed.Body.String(), key.Key, "the secret is only returned on creation")
+	var list ListAPIKeysResponse
+	require.NoError(t, json.Unmarshal(listed.Body.Bytes(), &list))
+	require.Len(t, list.APIKeys, 1)
+	assert.Nil(t, list.APIKeys[0].RevokedAt)
+
+	revoked := serveAPIKey(router, http.MethodPost, "/apikeys/"+key.ID+"/revoke", "", "")
+	require.Equal(t, http.StatusOK, revoked.Code)
+	var revokedKey APIKeyResponse
+	require.NoError(t, json.Unmarshal(revoked.Body.Bytes(), &revokedKey))
+	assert.NotNil(t, revokedKey.RevokedAt)
+	assert.Empty(t, revokedKey.Key)
+}
+
+func TestAPIKeyHandler_Errors(t *testing.T) {
+	router := apiKeyRouter(newAPIKeyUseCase())
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"missing name", http.MethodPost, "/apikeys", `{"scopes":["users:read"]}`, http.StatusBadRequest, "invalid_request"},
+		{"invalid scope", http.MethodPost, "/apikeys", `{"name":"billing","scopes":["users read"]}`, http.StatusBadRequest, "invalid_request"},
+		{"invalid id", http.MethodPost, "/apikeys/nope/revoke", "", http.StatusBadRequest, "invalid_id"},
+		{"unknown key", http.MethodPost, "/apikeys/" + uuid.NewString() + "/revoke", "", http.StatusNotFound, "api_key_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serveAPIKey(router, tt.method, tt.path, tt.body, "")
+
+			assert.Equal(t, tt.status, recorder.Code)
+			var response ErrorResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, tt.code, response.Error)
+		})
+	}
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	useCase := newAPIKeyUseCase()
+	router := apiKeyRouter(useCase)
+	created, err := useCase.CreateAPIKey(context.Background(), usecase.CreateAPIKeyRequest{Name: "billing", Scopes: []string{"users:read"}})
+	require.NoError(t, err)
+
+	t.Run("no api key stays anonymous", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, serveAPIKey(router, http.MethodGet, "/whoami", "", "").Code)
+		assert.Equal(t, http.StatusNoContent, serveAPIKey(router, http.MethodGet, "/whoami", "", "Bearer token").Code)
+	})
+
+	t.Run("valid api key sets the principal", func(t *testing.T) {
+		recorder := serveAPIKey(router, http.MethodGet, "/whoami", "", "ApiKey "+created.Secret)
+
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var principal web.Principal
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &principal))
+		assert.Equal(t, APIKeySubjectPrefix+created.Key.ID.String(), principal.Subject)
+		assert.Equal(t, []string{APIKeyRole}, principal.Roles)
+		assert.Equal(t, []string{"users:read"}, principal.Scopes)
+	})
+
+	t.Run("unknown or revoked api key is rejected", func(t *testing.T) {
+		recorder := serveAPIKey(router, http.MethodGet, "/whoami", "", "apikey wc_unknown")
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+		assert.Equal(t, APIKeyScheme, recorder.Header().Get("WWW-Authenticate"))
+		assert.Contains(t, recorder.Body.String(), "invalid_api_key")
+
+		created.Key.Revoke(time.Now())
+		recorder = serveAPIKey(router, http.MethodGet, "/whoami", "", "ApiKey "+created.Secret)
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	})
+}
+
+func TestAPIKeyHandler_StrictJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewAPIKeyHandler(newAPIKeyUseCase(), zap.NewNop().Sugar())
+	router := gin.New()
+	web.Register(router, []web.RouteMiddleware{web.StrictJSON},
+		web.Route{Method: http.MethodPost, Path: "/apikeys", StrictJSON: true, Handler: handler.CreateAPIKey},
+	)
+
+	recorder := serveAPIKey(router, http.MethodPost, "/apikeys", `{"name":"billing","scope":["users:read"]}`, "")
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	var response ErrorResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, "unknown_fields", response.Error)
+	assert.Equal(t, []string{"scope"}, response.Fields)
+}
+
+func TestAPIKeyRoutes_RequireManageScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	useCase := newAPIKeyUseCase()
+	handler := NewAPIKeyHandler(useCase, zap.NewNop().Sugar())
+	router := gin.New()
+	router.Use(APIKeyAuth(useCase, zap.NewNop().Sugar()))
+	web.Register(router, []web.RouteMiddleware{web.RequireScope},
+		web.Route{Method: http.MethodPost, Path: "/apikeys", Scope: ScopeAPIKeysManage, Handler: handler.CreateAPIKey},
+		web.Route{Method: http.MethodGet, Path: "/apikeys", Scope: ScopeAPIKeysManage, Handler: handler.ListAPIKeys},
+	)
+
+	manager, err := useCase.CreateAPIKey(context.Background(), usecase.CreateAPIKeyRequest{Name: "ops", Scopes: []string{ScopeAPIKeysManage, ScopeUsersRead}})
+	require.NoError(t, err)
+	reader, err := useCase.CreateAPIKey(context.Background(), usecase.CreateAPIKeyRequest{Name: "billing", Scopes: []string{ScopeUsersRead}})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusUnauthorized, serveAPIKey(router, http.MethodGet, "/apikeys", "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serveAPIKey(router, http.MethodPost, "/apikeys", `{"name":"x","scopes":["users:write"]}`, "").Code)
+	assert.Equal(t, http.StatusForbidden, serveAPIKey(router, http.MethodGet, "/apikeys", "", "ApiKey "+reader.Secret).Code)
+	assert.Equal(t, http.StatusOK, serveAPIKey(router, http.MethodGet, "/apikeys", "", "ApiKey "+manager.Secret).Code)
+
+	// A manager can only grant the scopes it holds
+	created := serveAPIKey(router, http.MethodPost, "/apikeys", `{"name":"sync","scopes":["users:read"]}`, "ApiKey "+manager.Secret)
+	assert.Equal(t, http.StatusCreated, created.Code)
+	escalated := serveAPIKey(router, http.MethodPost, "/apikeys", `{"name":"sync","scopes":["users:write"]}`, "ApiKey "+manager.Secret)
+	assert.Equal(t, http.StatusForbidden, escalated.Code)
+	assert.Contains(t, escalated.Body.String(), "users:write")
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"web-clean/internal/domain/entity"
+)
+
+// ErrInvalidIfMatch is returned when If-Match is not an entity tag produced by this API
+var ErrInvalidIfMatch = errors.New(`invalid If-Match header, expected an ETag such as "3" or *`)
+
+// setETag exposes the user's version as a strong entity tag, to be sent back in If-Match on updates
+func setETag(c *gin.Context, user *entity.User) {
+	c.Header("ETag", `"`+strconv.FormatInt(user.Version, 10)+`"`)
+}
+
+// parseIfMatch returns the version required by If-Match, 0 when the header is absent or "*"
+func parseIfMatch(c *gin.Context) (int64, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	// If-Match uses strong comparison, a weak tag can never match
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return 0, ErrInvalidIfMatch
+	}
+	version, err := strconv.ParseInt(value[1:len(value)-1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, ErrInvalidIfMatch
+	}
+	return version, nil
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/usecase"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	cases := []struct {
+		header  string
+		version int64
+		wantErr bool
+	}{
+		{header: "", version: 0},
+		{header: "*", version: 0},
+		{header: `"3"`, version: 3},
+		{header: `W/"3"`, wantErr: true},
+		{header: `3`, wantErr: true},
+		{header: `"abc"`, wantErr: true},
+		{header: `"0"`, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPut, "/", nil)
+		c.Request.Header.Set("If-Match", tc.header)
+
+		version, err := parseIfMatch(c)
+
+		if tc.wantErr {
+			assert.ErrorIs(t, err, ErrInvalidIfMatch, tc.header)
+			continue
+		}
+		assert.NoError(t, err, tc.header)
+		assert.Equal(t, tc.version, version, tc.header)
+	}
+}
+
+// versionedUseCase serves a single user for ETag tests
+type versionedUseCase struct {
+	usecase.UserUseCase
+	user *entity.User
+}
+
+func (v versionedUseCase) GetUserByID(context.Context, uuid.UUID) (*entity.User, error) {
+	return v.user, nil
+}
+
+func TestUserHandlerV2_ETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := &entity.User{ID: uuid.New(), Email: "a@example.com", Username: "alice", Name: "Alice", Version: 4}
+	handler := NewUserHandlerV2(versionedUseCase{user: user}, zap.NewNop().Sugar(), usecase.DefaultPageLimits)
+
+	engine := gin.New()
+	engine.GET("/users/:id", handler.GetUserByID)
+	engine.PATCH("/users/:id", handler.PatchUser)
+
+	get := httptest.NewRecorder()
+	engine.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/users/"+user.ID.String(), nil))
+	assert.Equal(t, http.StatusOK, get.Code)
+	assert.Equal(t, `"4"`, get.Header().Get("ETag"))
+
+	stale := httptest.NewRequest(http.MethodPatch, "/users/"+user.ID.String(), strings.NewReader(`{}`))
+	stale.Header.Set("Content-Type", "application/json")
+	stale.Header.Set("If-Match", `"3"`)
+	conflict := httptest.NewRecorder()
+	engine.ServeHTTP(conflict, stale)
+	assert.Equal(t, http.StatusConflict, conflict.Code)
+	assert.Contains(t, conflict.Body.String(), "version_conflict")
+}
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"web-clean/internal/domain/usecase"
+)
+
+// offsetParams names the query parameters of an offset-paginated list endpoint
+type offsetParams struct {
+	Offset string
+	Limit  string
+	// Origin is the offset value of the first item: 0 for ?offset=, 1 for SCIM's ?startIndex=
+	Origin int
+}
+
+var (
+	// v1PageParams are the pagination parameters of GET /api/v1/users
+	v1PageParams = offsetParams{Offset: "offset", Limit: "limit", Origin: 0}
+	// scimPageParams are the pagination parameters of GET /scim/v2/Users
+	scimPageParams = offsetParams{Offset: "startIndex", Limit: "count", Origin: 1}
+)
+
+// parseLimit reads a page size query parameter, applying the configured default when it is absent
+// and rejecting values outside 1..limits.Max
+func parseLimit(c *gin.Context, name string, limits usecase.PageLimits) (int, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return limits.Default, nil
+	}
+
+	limit, err := strconv.Atoi(raw)
+	if err != nil || !limits.Allows(limit) {
+		return 0, fmt.Errorf("%s must be a positive integer between 1 and %d", strings.ToUpper(name[:1])+name[1:], limits.Max)
+	}
+	return limit, nil
+}
+
+// link is a single entry of an RFC 8288 (formerly RFC 5988) Link header
+type link struct {
+	Rel  string
+	Href string
+}
+
+// writeLinks sets the Link header, links are relative to the request path
+func writeLinks(c *gin.Context, links []link) {
+	if len(links) == 0 {
+		return
+	}
+
+	parts := make([]string, len(links))
+	for i, l := range links {
+		parts[i] = fmt.Sprintf(`<%s>; rel="%s"`, l.Href, l.Rel)
+	}
+	c.Header("Link", strings.Join(parts, ", "))
+}
+
+// pageHref returns the request URL with the given query parameters replaced, keeping filter, fields and tz
+func pageHref(c *gin.Context, params map[string]string) string {
+	query := c.Request.URL.Query()
+	for key, value := range params {
+		if value == "" {
+			query.Del(key)
+		} else {
+			query.Set(key, value)
+		}
+	}
+
+	if encoded := query.Encode(); encoded != "" {
+		return c.Request.URL.Path + "?" + encoded
+	}
+	return c.Request.URL.Path
+}
+
+// writeOffsetLinks emits first, prev, next and last links for a page starting at the 0-based offset
+func (p offsetParams) writeOffsetLinks(c *gin.Context, offset, limit int, total int64) {
+	if limit <= 0 {
+		return
+	}
+
+	href := func(offset int) string {
+		return pageHref(c, map[string]string{
+			p.Offset: strconv.Itoa(offset + p.Origin),
+			p.Limit:  strconv.Itoa(limit),
+		})
+	}
+
+	last := 0
+	if total > 0 {
+		last = int((total - 1) / int64(limit) * int64(limit))
+	}
+
+	links := []link{{Rel: "first", Href: href(0)}}
+	if offset > 0 {
+		links = append(links, link{Rel: "prev", Href: href(max(offset-limit, 0))})
+	}
+	if int64(offset+limit) < total {
+		links = append(links, link{Rel: "next", Href: href(offset + limit)})
+	}
+	links = append(links, link{Rel: "last", Href: href(last)})
+
+	writeLinks(c, links)
+}
+
+// writeCursorLinks emits first and next links for a keyset page. Keyset pagination only moves forward
+// and does not know the total, so prev and last are not available
+func writeCursorLinks(c *gin.Context, limit int, nextCursor string) {
+	links := []link{{Rel: "first", Href: pageHref(c, map[string]string{"cursor": "", "limit": strconv.Itoa(limit)})}}
+	if nextCursor != "" {
+		links = append(links, link{Rel: "next", Href: pageHref(c, map[string]string{"cursor": nextCursor, "limit": strconv.Itoa(limit)})})
+	}
+	writeLinks(c, links)
+}
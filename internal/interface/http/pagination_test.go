@@ -0,0 +1,81 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"web-clean/internal/domain/usecase"
+)
+
+func TestWriteOffsetLinks_MiddlePage(t *testing.T) {
+	// Arrange
+	c := newTestContext("/api/v1/users?offset=20&limit=10&filter=name~ann")
+
+	// Act
+	v1PageParams.writeOffsetLinks(c, 20, 10, 45)
+
+	// Assert
+	assert.Equal(t, `</api/v1/users?filter=name~ann&limit=10&offset=0>; rel="first", `+
+		`</api/v1/users?filter=name~ann&limit=10&offset=10>; rel="prev", `+
+		`</api/v1/users?filter=name~ann&limit=10&offset=30>; rel="next", `+
+		`</api/v1/users?filter=name~ann&limit=10&offset=40>; rel="last"`, c.Writer.Header().Get("Link"))
+}
+
+func TestWriteOffsetLinks_SinglePage(t *testing.T) {
+	// Arrange
+	c := newTestContext("/api/v1/users")
+
+	// Act
+	v1PageParams.writeOffsetLinks(c, 0, 10, 3)
+
+	// Assert
+	assert.Equal(t, `</api/v1/users?limit=10&offset=0>; rel="first", `+
+		`</api/v1/users?limit=10&offset=0>; rel="last"`, c.Writer.Header().Get("Link"))
+}
+
+func TestWriteOffsetLinks_SCIMStartIndex(t *testing.T) {
+	// Arrange
+	c := newTestContext("/scim/v2/Users?startIndex=3&count=2")
+
+	// Act
+	scimPageParams.writeOffsetLinks(c, 2, 2, 5)
+
+	// Assert
+	assert.Equal(t, `</scim/v2/Users?count=2&startIndex=1>; rel="first", `+
+		`</scim/v2/Users?count=2&startIndex=1>; rel="prev", `+
+		`</scim/v2/Users?count=2&startIndex=5>; rel="next", `+
+		`</scim/v2/Users?count=2&startIndex=5>; rel="last"`, c.Writer.Header().Get("Link"))
+}
+
+func TestWriteCursorLinks(t *testing.T) {
+	// Arrange
+	c := newTestContext("/api/v2/users?cursor=abc&limit=5")
+
+	// Act
+	writeCursorLinks(c, 5, "def")
+
+	// Assert
+	assert.Equal(t, `</api/v2/users?limit=5>; rel="first", `+
+		`</api/v2/users?cursor=def&limit=5>; rel="next"`, c.Writer.Header().Get("Link"))
+}
+
+func TestParseLimit(t *testing.T) {
+	limits := usecase.PageLimits{Default: 25, Max: 500}
+
+	// Absent limit uses the configured default
+	limit, err := parseLimit(newTestContext("/api/v1/users"), "limit", limits)
+	assert.NoError(t, err)
+	assert.Equal(t, 25, limit)
+
+	// Limits above the stock 100 are accepted when the deployment allows them
+	limit, err = parseLimit(newTestContext("/api/v1/users?limit=500"), "limit", limits)
+	assert.NoError(t, err)
+	assert.Equal(t, 500, limit)
+
+	_, err = parseLimit(newTestContext("/api/v1/users?limit=501"), "limit", limits)
+	assert.EqualError(t, err, "Limit must be a positive integer between 1 and 500")
+
+	_, err = parseLimit(newTestContext("/api/v1/users?limit=abc"), "limit", limits)
+	assert.Error(t, err)
+}
@@ -0,0 +1,495 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"web-clean/domain"
+	"web-clean/internal/application/service"
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/filter"
+	"web-clean/internal/domain/repository"
+	"web-clean/internal/domain/usecase"
+)
+
+const (
+	scimContentType   = "application/scim+json"
+	scimUserSchema    = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimListSchema    = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimErrorSchema   = "urn:ietf:params:scim:api:messages:2.0:Error"
+	scimPatchOpSchema = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+)
+
+// SCIMHandler exposes the user use cases as a SCIM 2.0 /Users resource (RFC 7643/7644)
+// so identity providers can provision and deprovision users
+type SCIMHandler struct {
+	userUseCase usecase.UserUseCase
+	logger      domain.Log
+	pages       usecase.PageLimits
+}
+
+// NewSCIMHandler creates a new SCIM handler
+func NewSCIMHandler(userUseCase usecase.UserUseCase, logger domain.Log, pages usecase.PageLimits) *SCIMHandler {
+	return &SCIMHandler{
+		userUseCase: userUseCase,
+		logger:      logger,
+		pages:       pages,
+	}
+}
+
+// SCIMName is the SCIM complex name attribute
+type SCIMName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// SCIMEmail is a single entry of the SCIM emails attribute
+type SCIMEmail struct {
+	Value   string `json:"value"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// SCIMMeta is the SCIM resource metadata
+type SCIMMeta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created"`
+	LastModified string `json:"lastModified"`
+	Location     string `json:"location"`
+}
+
+// SCIMUser is the SCIM representation of a user
+type SCIMUser struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	UserName    string      `json:"userName"`
+	Name        *SCIMName   `json:"name,omitempty"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Emails      []SCIMEmail `json:"emails,omitempty"`
+	Active      bool        `json:"active"`
+	Meta        *SCIMMeta   `json:"meta,omitempty"`
+}
+
+// SCIMListResponse is the SCIM ListResponse message
+type SCIMListResponse struct {
+	Schemas      []string   `json:"schemas"`
+	TotalResults int64      `json:"totalResults"`
+	StartIndex   int        `json:"startIndex"`
+	ItemsPerPage int        `json:"itemsPerPage"`
+	Resources    []SCIMUser `json:"Resources"`
+}
+
+// SCIMError is the SCIM error message
+type SCIMError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// SCIMPatchOperation is a single operation of a SCIM PatchOp request
+type SCIMPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// SCIMPatchRequest is the SCIM PatchOp message
+type SCIMPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []SCIMPatchOperation `json:"Operations"`
+}
+
+// CreateUser handles POST /scim/v2/Users
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	var req SCIMUser
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.writeError(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	email := primaryEmail(req.Emails)
+	if req.UserName == "" || email == "" {
+		h.writeError(c, http.StatusBadRequest, "invalidValue", "userName and emails are required")
+		return
+	}
+
+	user, err := h.userUseCase.CreateUser(c.Request.Context(), usecase.CreateUserRequest{
+		Email:    email,
+		Username: req.UserName,
+		Name:     scimDisplayName(req),
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Location", scimLocation(user))
+	h.write(c, http.StatusCreated, toSCIMUser(user))
+}
+
+// GetUser handles GET /scim/v2/Users/:id
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userUseCase.GetUserByID(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, toSCIMUser(user))
+}
+
+// ListUsers handles GET /scim/v2/Users?filter=...&startIndex=1&count=100
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	startIndex, err := strconv.Atoi(c.DefaultQuery("startIndex", "1"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+
+	// SCIM clients treat count as a hint: invalid values fall back to the largest page, oversized ones are capped
+	count, err := strconv.Atoi(c.Query("count"))
+	if err != nil || count <= 0 {
+		count = h.pages.Max
+	}
+	count = h.pages.Normalize(count)
+
+	expr, err := parseSCIMFilter(c.Query("filter"))
+	if err != nil {
+		h.logger.Warnw("Invalid SCIM filter", "filter", c.Query("filter"), "error", err)
+		h.writeError(c, http.StatusBadRequest, "invalidFilter", err.Error())
+		return
+	}
+
+	result, err := h.userUseCase.ListUsers(c.Request.Context(), usecase.ListUsersRequest{
+		Offset: startIndex - 1,
+		Limit:  count,
+		Filter: expr,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	resources := make([]SCIMUser, len(result.Users))
+	for i, user := range result.Users {
+		resources[i] = toSCIMUser(user)
+	}
+
+	scimPageParams.writeOffsetLinks(c, startIndex-1, count, result.Total)
+	h.write(c, http.StatusOK, SCIMListResponse{
+		Schemas:      []string{scimListSchema},
+		TotalResults: result.Total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// PatchUser handles PATCH /scim/v2/Users/:id, only the name attributes are mutable
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req SCIMPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.writeError(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	name, err := scimPatchedName(req.Operations)
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, "mutability", err.Error())
+		return
+	}
+
+	var user *entity.User
+	if name == "" {
+		user, err = h.userUseCase.GetUserByID(c.Request.Context(), id)
+	} else {
+		user, err = h.userUseCase.UpdateUserProfile(c.Request.Context(), usecase.UpdateUserProfileRequest{
+			ID:   id,
+			Name: name,
+		})
+	}
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, toSCIMUser(user))
+}
+
+// DeleteUser handles DELETE /scim/v2/Users/:id, used by identity providers to deprovision users
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userUseCase.DeleteUser(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *SCIMHandler) parseID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		h.writeError(c, http.StatusNotFound, "", "Resource "+c.Param("id")+" not found")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *SCIMHandler) write(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", scimContentType)
+	c.JSON(status, body)
+}
+
+func (h *SCIMHandler) writeError(c *gin.Context, status int, scimType, detail string) {
+	h.write(c, status, SCIMError{
+		Schemas:  []string{scimErrorSchema},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+// handleError converts use case errors to SCIM error responses
+func (h *SCIMHandler) handleError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrReadOnly) {
+		h.writeError(c, http.StatusServiceUnavailable, "", "The service is in read-only mode")
+		return
+	}
+	if errors.Is(err, repository.ErrBudgetExceeded) {
+		h.writeError(c, http.StatusServiceUnavailable, "", "The request needed too many database resources")
+		return
+	}
+	if errors.Is(err, repository.ErrConflict) {
+		h.writeError(c, http.StatusConflict, "", "The user was modified concurrently, retry the request")
+		return
+	}
+	if errors.Is(err, entity.ErrInvalidEmail) || errors.Is(err, entity.ErrInvalidUsername) {
+		h.writeError(c, http.StatusBadRequest, "invalidValue", err.Error())
+		return
+	}
+
+	switch err {
+	case service.ErrUserNotFound:
+		h.writeError(c, http.StatusNotFound, "", "User not found")
+	case service.ErrUserAlreadyExists:
+		h.writeError(c, http.StatusConflict, "uniqueness", "User with email or username already exists")
+	case service.ErrInvalidUserData:
+		h.writeError(c, http.StatusBadRequest, "invalidValue", "Invalid user data provided")
+	case service.ErrUsernameNotAllowed:
+		h.writeError(c, http.StatusBadRequest, "invalidValue", "This userName is reserved or not allowed")
+	default:
+		h.logger.Errorw("Internal server error", "error", err)
+		h.writeError(c, http.StatusInternalServerError, "", "An internal error occurred")
+	}
+}
+
+func toSCIMUser(user *entity.User) SCIMUser {
+	return SCIMUser{
+		Schemas:     []string{scimUserSchema},
+		ID:          user.ID.String(),
+		UserName:    user.Username.String(),
+		Name:        &SCIMName{Formatted: user.Name},
+		DisplayName: user.Name,
+		Emails:      []SCIMEmail{{Value: user.Email.String(), Primary: true}},
+		Active:      true,
+		Meta: &SCIMMeta{
+			ResourceType: "User",
+			Created:      user.CreatedAt.Format(timestampLayout),
+			LastModified: user.UpdatedAt.Format(timestampLayout),
+			Location:     scimLocation(user),
+		},
+	}
+}
+
+func scimLocation(user *entity.User) string {
+	return "/scim/v2/Users/" + user.ID.String()
+}
+
+func primaryEmail(emails []SCIMEmail) string {
+	for _, email := range emails {
+		if email.Primary {
+			return email.Value
+		}
+	}
+	if len(emails) > 0 {
+		return emails[0].Value
+	}
+	return ""
+}
+
+// scimDisplayName picks the best available name, falling back to userName
+func scimDisplayName(user SCIMUser) string {
+	if user.Name != nil {
+		if user.Name.Formatted != "" {
+			return user.Name.Formatted
+		}
+		if full := strings.TrimSpace(user.Name.GivenName + " " + user.Name.FamilyName); full != "" {
+			return full
+		}
+	}
+	if user.DisplayName != "" {
+		return user.DisplayName
+	}
+	return user.UserName
+}
+
+// scimPatchedName extracts the new name from PatchOp operations, returning "" when the name is unchanged
+func scimPatchedName(operations []SCIMPatchOperation) (string, error) {
+	name := ""
+	for _, op := range operations {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+		default:
+			return "", fmt.Errorf("operation %q is not supported", op.Op)
+		}
+
+		values := map[string]interface{}{}
+		if op.Path == "" {
+			object, ok := op.Value.(map[string]interface{})
+			if !ok {
+				return "", errors.New("operation without path requires an object value")
+			}
+			values = object
+		} else {
+			values[op.Path] = op.Value
+		}
+
+		for path, value := range values {
+			switch strings.ToLower(path) {
+			case "displayname", "name.formatted":
+				s, ok := value.(string)
+				if !ok {
+					return "", fmt.Errorf("%s must be a string", path)
+				}
+				name = s
+			case "name":
+				object, ok := value.(map[string]interface{})
+				if !ok {
+					return "", errors.New("name must be an object")
+				}
+				if formatted, ok := object["formatted"].(string); ok {
+					name = formatted
+				}
+			case "active":
+				if active, ok := value.(bool); !ok || !active {
+					return "", errors.New("deactivation is not supported, use DELETE to deprovision the user")
+				}
+			default:
+				return "", fmt.Errorf("attribute %q is read-only", path)
+			}
+		}
+	}
+	return name, nil
+}
+
+// scimAttributes maps SCIM attribute paths (case-insensitive) to user filter fields
+var scimAttributes = map[string]string{
+	"username":          "username",
+	"emails":            "email",
+	"emails.value":      "email",
+	"displayname":       "name",
+	"name.formatted":    "name",
+	"meta.created":      "created_at",
+	"meta.lastmodified": "updated_at",
+}
+
+// scimOperators maps SCIM comparison operators to the filter grammar
+var scimOperators = map[string]filter.Operator{
+	"eq": filter.Equal,
+	"ne": filter.NotEqual,
+	"co": filter.Contains,
+	"gt": filter.Greater,
+	"ge": filter.GreaterOrEqual,
+	"lt": filter.Less,
+	"le": filter.LessOrEqual,
+}
+
+var scimValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// parseSCIMFilter translates the supported subset of SCIM filters (`attr op value` joined with `and`)
+// into the user filter grammar, so validation and whitelisting stay in one place
+func parseSCIMFilter(raw string) (filter.Expression, error) {
+	tokens, err := scimTokens(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	conditions := make([]string, 0)
+	for i := 0; i < len(tokens); i += 4 {
+		if i+3 > len(tokens) {
+			return nil, errors.New("expected `attribute operator value`")
+		}
+
+		field, ok := scimAttributes[strings.ToLower(tokens[i])]
+		if !ok {
+			return nil, fmt.Errorf("attribute %q is not filterable", tokens[i])
+		}
+		op, ok := scimOperators[strings.ToLower(tokens[i+1])]
+		if !ok {
+			return nil, fmt.Errorf("operator %q is not supported", tokens[i+1])
+		}
+		conditions = append(conditions, fmt.Sprintf(`%s%s"%s"`, field, op, scimValueEscaper.Replace(tokens[i+2])))
+
+		if i+3 < len(tokens) && !strings.EqualFold(tokens[i+3], "and") {
+			return nil, fmt.Errorf("logical operator %q is not supported", tokens[i+3])
+		}
+	}
+
+	return filter.Parse(strings.Join(conditions, " AND "), usecase.UserFilterFields)
+}
+
+// scimTokens splits a SCIM filter on spaces, keeping JSON quoted strings intact and unquoted
+func scimTokens(raw string) ([]string, error) {
+	tokens := make([]string, 0)
+	var current strings.Builder
+	inQuotes := false
+
+	for i := 0; i < len(raw); i++ {
+		ch := raw[i]
+		switch {
+		case inQuotes && ch == '\\' && i+1 < len(raw):
+			i++
+			current.WriteByte(raw[i])
+		case ch == '"':
+			inQuotes = !inQuotes
+		case ch == ' ' && !inQuotes:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteByte(ch)
+		}
+	}
+	if inQuotes {
+		return nil, errors.New("unterminated string")
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"web-clean/infra/web"
+	"web-clean/internal/domain/filter"
+	"web-clean/internal/domain/usecase"
+)
+
+func TestParseSCIMFilter(t *testing.T) {
+	// Act
+	expr, err := parseSCIMFilter(`userName eq "bjensen" and emails.value co "@corp.com"`)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, filter.Expression{
+		{Field: "username", Operator: filter.Equal, Value: "bjensen"},
+		{Field: "email", Operator: filter.Contains, Value: "@corp.com"},
+	}, expr)
+}
+
+func TestParseSCIMFilter_Unsupported(t *testing.T) {
+	for _, raw := range []string{
+		`userName sw "b"`,
+		`userName eq "a" or userName eq "b"`,
+		`password eq "x"`,
+		`userName eq`,
+		`userName eq "open`,
+	} {
+		_, err := parseSCIMFilter(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestSCIMPatchedName(t *testing.T) {
+	name, err := scimPatchedName([]SCIMPatchOperation{
+		{Op: "Replace", Path: "name.formatted", Value: "Barbara Jensen"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Barbara Jensen", name)
+
+	name, err = scimPatchedName([]SCIMPatchOperation{
+		{Op: "replace", Value: map[string]interface{}{"displayName": "Babs", "active": true}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Babs", name)
+
+	_, err = scimPatchedName([]SCIMPatchOperation{{Op: "replace", Path: "active", Value: false}})
+	assert.Error(t, err)
+
+	_, err = scimPatchedName([]SCIMPatchOperation{{Op: "replace", Path: "userName", Value: "x"}})
+	assert.Error(t, err)
+}
+
+// scimDeleteUseCase records the users deleted through SCIM
+type scimDeleteUseCase struct {
+	usecase.UserUseCase
+	deleted []uuid.UUID
+}
+
+func (u *scimDeleteUseCase) DeleteUser(_ context.Context, id uuid.UUID) error {
+	u.deleted = append(u.deleted, id)
+	return nil
+}
+
+func TestSCIMRoutes_RequireUsersAdminScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	useCase := &scimDeleteUseCase{}
+	handler := NewSCIMHandler(useCase, zap.NewNop().Sugar(), usecase.DefaultPageLimits)
+
+	serve := func(method, path string, principal *web.Principal) int {
+		router := gin.New()
+		if principal != nil {
+			router.Use(func(c *gin.Context) { web.SetPrincipal(c, *principal) })
+		}
+		web.Register(router, []web.RouteMiddleware{web.RequireScope},
+			web.Route{Method: http.MethodPost, Path: "/Users", Handler: handler.CreateUser, Scope: ScopeUsersAdmin},
+			web.Route{Method: http.MethodDelete, Path: "/Users/:id", Handler: handler.DeleteUser, Scope: ScopeUsersAdmin},
+		)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(`{"userName":"bjensen"}`)))
+		return recorder.Code
+	}
+	path := "/Users/" + uuid.NewString()
+
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodPost, "/Users", nil))
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodDelete, path, nil))
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodDelete, path, &web.Principal{Subject: "apikey:1", Scopes: []string{ScopeUsersWrite}}))
+	assert.Empty(t, useCase.deleted)
+
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, path, &web.Principal{Subject: "apikey:1", Scopes: []string{ScopeUsersAdmin}}))
+	assert.Len(t, useCase.deleted, 1)
+}
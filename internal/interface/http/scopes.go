@@ -0,0 +1,17 @@
+package http
+
+// Scopes declared on routes. Callers get them from their API key; a route declaring a scope
+// answers 401 to unauthenticated callers and 403 to callers without the scope
+const (
+	// ScopeUsersRead allows listing, exporting and reading users
+	ScopeUsersRead = "users:read"
+	// ScopeUsersWrite allows updating and deleting users
+	ScopeUsersWrite = "users:write"
+	// ScopeUsersAdmin allows operator actions on users: importing (and overwriting) users, resetting
+	// passwords and SCIM provisioning
+	ScopeUsersAdmin = "users:admin"
+	// ScopeAPIKeysManage allows creating, listing and revoking API keys
+	ScopeAPIKeysManage = "apikeys:manage"
+	// ScopeAdmin allows every operator endpoint under /admin
+	ScopeAdmin = "admin"
+)
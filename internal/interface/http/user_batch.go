@@ -0,0 +1,103 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"web-clean/infra/web"
+	"web-clean/internal/application/service"
+	"web-clean/internal/domain/usecase"
+)
+
+// CreateUsersRequest is the body of POST /users/batch
+type CreateUsersRequest struct {
+	Users []CreateUserRequest `json:"users" binding:"required"`
+}
+
+// CreateUserItemResult is the outcome of one item of a batch, Status is the status a single create would have returned
+type CreateUserItemResult struct {
+	Index  int            `json:"index"`
+	Status int            `json:"status"`
+	User   *UserResponse  `json:"user,omitempty"`
+	Error  *ErrorResponse `json:"error,omitempty"`
+}
+
+// CreateUsersResponse reports every item of a batch in request order
+type CreateUsersResponse struct {
+	Created int                    `json:"created"`
+	Failed  int                    `json:"failed"`
+	Results []CreateUserItemResult `json:"results"`
+}
+
+// CreateUsers handles POST /users/batch. Items are validated and created independently, the response
+// is 200 with a per-item status even when some or all items failed
+func (h *UserHandler) CreateUsers(c *gin.Context) {
+	format, ok := h.bindTimeFormat(c)
+	if !ok {
+		return
+	}
+
+	var req CreateUsersRequest
+	if err := web.BindJSON(c, &req); err != nil {
+		h.logger.Warnw("Invalid request for create users", "error", err)
+		c.JSON(http.StatusBadRequest, invalidRequest(err))
+		return
+	}
+	if len(req.Users) == 0 || len(req.Users) > usecase.MaxCreateUsersBatch {
+		h.handleError(c, service.ErrInvalidBatchSize)
+		return
+	}
+
+	results := make([]CreateUserItemResult, len(req.Users))
+	useCaseReqs := make([]usecase.CreateUserRequest, 0, len(req.Users))
+	indexes := make([]int, 0, len(req.Users))
+	for i, item := range req.Users {
+		results[i].Index = i
+		// Items are bound one by one so a malformed item fails alone
+		if err := binding.Validator.ValidateStruct(&item); err != nil {
+			results[i].Status = http.StatusBadRequest
+			results[i].Error = &ErrorResponse{Error: "invalid_request", Message: err.Error()}
+			continue
+		}
+		useCaseReqs = append(useCaseReqs, usecase.CreateUserRequest{
+			Email:    item.Email,
+			Username: item.Username,
+			Name:     item.Name,
+		})
+		indexes = append(indexes, i)
+	}
+
+	if len(useCaseReqs) > 0 {
+		response, err := h.userUseCase.CreateUsers(c.Request.Context(), useCaseReqs)
+		if err != nil {
+			h.handleError(c, err)
+			return
+		}
+
+		for k, result := range response.Results {
+			item := &results[indexes[k]]
+			if result.Err != nil {
+				status, body := errorResponseFor(result.Err)
+				if status == http.StatusInternalServerError {
+					h.logger.Errorw("Internal server error in batch item", "index", item.Index, "error", result.Err)
+				}
+				item.Status, item.Error = status, &body
+				continue
+			}
+			user := toUserResponse(result.User, format)
+			item.Status, item.User = http.StatusCreated, &user
+		}
+	}
+
+	body := CreateUsersResponse{Results: results}
+	for _, result := range results {
+		if result.User != nil {
+			body.Created++
+		} else {
+			body.Failed++
+		}
+	}
+	c.JSON(http.StatusOK, body)
+}
@@ -0,0 +1,91 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"web-clean/internal/application/service"
+	"web-clean/internal/domain/usecase"
+	"web-clean/internal/testing/fixtures"
+)
+
+// batchUseCase creates every user except those named "taken", recording the requests it was given
+type batchUseCase struct {
+	usecase.UserUseCase
+	got []usecase.CreateUserRequest
+}
+
+func (u *batchUseCase) CreateUsers(_ context.Context, reqs []usecase.CreateUserRequest) (*usecase.CreateUsersResponse, error) {
+	u.got = reqs
+	results := make([]usecase.CreateUserResult, len(reqs))
+	for i, req := range reqs {
+		if req.Username == "taken" {
+			results[i].Err = service.ErrUserAlreadyExists
+			continue
+		}
+		results[i].User = fixtures.User().WithEmail(req.Email).Build()
+	}
+	return &usecase.CreateUsersResponse{Results: results}, nil
+}
+
+func postBatch(handler *UserHandler, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/users/batch", handler.CreateUsers)
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/users/batch", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestCreateUsers_ReportsEachItem(t *testing.T) {
+	// Arrange
+	useCase := &batchUseCase{}
+	handler := NewUserHandler(useCase, zap.NewNop().Sugar(), usecase.DefaultPageLimits)
+
+	// Act
+	recorder := postBatch(handler, `{"users":[
+		{"email":"alice@example.com","username":"alice","name":"Alice"},
+		{"email":"bob@example.com","username":"bob"},
+		{"email":"carol@example.com","username":"taken","name":"Carol"}
+	]}`)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var body CreateUsersResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Equal(t, 1, body.Created)
+	assert.Equal(t, 2, body.Failed)
+
+	assert.Equal(t, http.StatusCreated, body.Results[0].Status)
+	assert.Equal(t, "alice@example.com", body.Results[0].User.Email)
+	assert.Equal(t, http.StatusBadRequest, body.Results[1].Status, "an item failing binding is reported without reaching the use case")
+	assert.Equal(t, "invalid_request", body.Results[1].Error.Error)
+	assert.Equal(t, 2, body.Results[2].Index)
+	assert.Equal(t, http.StatusConflict, body.Results[2].Status)
+	assert.Equal(t, "user_already_exists", body.Results[2].Error.Error)
+
+	assert.Len(t, useCase.got, 2)
+}
+
+func TestCreateUsers_BatchSize(t *testing.T) {
+	handler := NewUserHandler(&batchUseCase{}, zap.NewNop().Sugar(), usecase.DefaultPageLimits)
+
+	recorder := postBatch(handler, `{"users":[]}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "invalid_batch_size")
+
+	items := strings.Repeat(`{"email":"a@example.com","username":"abc","name":"A"},`, usecase.MaxCreateUsersBatch+1)
+	recorder = postBatch(handler, `{"users":[`+strings.TrimSuffix(items, ",")+`]}`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
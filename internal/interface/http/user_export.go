@@ -0,0 +1,154 @@
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/usecase"
+)
+
+// ErrInvalidExportFormat is returned for a ?format= other than csv or jsonl
+var ErrInvalidExportFormat = errors.New("format must be csv or jsonl")
+
+// exportFlushEvery is the number of exported users after which buffered output is sent to the client
+const exportFlushEvery = 500
+
+// exportColumns are the CSV header, in the order of UserResponse's fields
+var exportColumns = []string{"id", "email", "username", "name", "created_at", "updated_at"}
+
+// ExportUsers handles GET /users/export?format=csv|jsonl&filter=. Users are streamed as they are read;
+// an error after the first row can only truncate the response, so it is logged and recorded on the request
+func (h *UserHandler) ExportUsers(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", "csv"))
+	if format != "csv" && format != "jsonl" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_format",
+			Message: ErrInvalidExportFormat.Error(),
+		})
+		return
+	}
+
+	expr, err := parseUserFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_filter",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	timeFormat, ok := h.bindTimeFormat(c)
+	if !ok {
+		return
+	}
+
+	writer := newExportWriter(c, format)
+	err = h.userUseCase.ExportUsers(c.Request.Context(), usecase.ExportUsersRequest{Filter: expr}, func(user *entity.User) error {
+		return writer.write(toUserResponse(user, timeFormat))
+	})
+	if err == nil {
+		err = writer.close()
+	}
+
+	if err != nil && !writer.started {
+		h.handleError(c, err)
+		return
+	}
+	if err != nil {
+		h.logger.Errorw("User export aborted", "error", err, "exported", writer.rows)
+		_ = c.Error(err)
+	}
+}
+
+// exportWriter writes users as CSV rows or JSON lines, sending the headers with the first row so that
+// an error before any output still gets a regular error response
+type exportWriter struct {
+	c       *gin.Context
+	format  string
+	csv     *csv.Writer
+	started bool
+	rows    int
+}
+
+func newExportWriter(c *gin.Context, format string) *exportWriter {
+	return &exportWriter{c: c, format: format}
+}
+
+func (w *exportWriter) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+
+	contentType := "text/csv; charset=utf-8"
+	if w.format == "jsonl" {
+		contentType = "application/x-ndjson"
+	}
+	filename := fmt.Sprintf("users-%s.%s", time.Now().UTC().Format("20060102T150405Z"), w.format)
+	w.c.Header("Content-Type", contentType)
+	w.c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.c.Status(http.StatusOK)
+
+	if w.format == "csv" {
+		w.csv = csv.NewWriter(w.c.Writer)
+		return w.csv.Write(exportColumns)
+	}
+	return nil
+}
+
+func (w *exportWriter) write(user UserResponse) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	if w.format == "csv" {
+		err := w.csv.Write([]string{
+			user.ID, user.Email, user.Username, user.Name,
+			fmt.Sprint(user.CreatedAt), fmt.Sprint(user.UpdatedAt),
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		line, err := json.Marshal(user)
+		if err != nil {
+			return err
+		}
+		if _, err := w.c.Writer.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+
+	w.rows++
+	if w.rows%exportFlushEvery == 0 {
+		return w.flush()
+	}
+	return nil
+}
+
+// close writes the CSV header of an empty export and flushes what is buffered
+func (w *exportWriter) close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	return w.flush()
+}
+
+func (w *exportWriter) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+	w.c.Writer.Flush()
+	return nil
+}
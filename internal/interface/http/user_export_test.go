@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/usecase"
+	"web-clean/internal/testing/fixtures"
+)
+
+// exportUseCase yields fixed users, then returns err
+type exportUseCase struct {
+	usecase.UserUseCase
+	users []*entity.User
+	err   error
+	got   usecase.ExportUsersRequest
+}
+
+func (u *exportUseCase) ExportUsers(_ context.Context, req usecase.ExportUsersRequest, yield func(*entity.User) error) error {
+	u.got = req
+	for _, user := range u.users {
+		if err := yield(user); err != nil {
+			return err
+		}
+	}
+	return u.err
+}
+
+func getExport(useCase usecase.UserUseCase, query string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	handler := NewUserHandler(useCase, zap.NewNop().Sugar(), usecase.DefaultPageLimits)
+	engine := gin.New()
+	engine.GET("/users/export", handler.ExportUsers)
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users/export"+query, nil))
+	return recorder
+}
+
+func TestExportUsers_CSV(t *testing.T) {
+	// Arrange
+	users := fixtures.Users(2)
+	useCase := &exportUseCase{users: users}
+
+	// Act
+	recorder := getExport(useCase, "?name_contains=user")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Regexp(t, `^attachment; filename="users-\d{8}T\d{6}Z\.csv"$`, recorder.Header().Get("Content-Disposition"))
+
+	rows, err := csv.NewReader(recorder.Body).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, exportColumns, rows[0])
+	assert.Len(t, rows, 3)
+	assert.Equal(t, users[1].Email.String(), rows[2][1])
+	assert.Len(t, useCase.got.Filter, 1)
+}
+
+func TestExportUsers_JSONLines(t *testing.T) {
+	// Arrange
+	users := fixtures.Users(3)
+
+	// Act
+	recorder := getExport(&exportUseCase{users: users}, "?format=jsonl&time_format=epoch_ms")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/x-ndjson", recorder.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+	assert.Len(t, lines, 3)
+
+	var first map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, users[0].ID.String(), first["id"])
+	assert.Equal(t, float64(users[0].CreatedAt.UnixMilli()), first["created_at"])
+}
+
+func TestExportUsers_EmptyCSVHasHeader(t *testing.T) {
+	recorder := getExport(&exportUseCase{}, "")
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, strings.Join(exportColumns, ",")+"\n", recorder.Body.String())
+}
+
+func TestExportUsers_Errors(t *testing.T) {
+	recorder := getExport(&exportUseCase{}, "?format=xml")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "invalid_format")
+
+	// Nothing was sent yet, so the failure is a regular error response
+	recorder = getExport(&exportUseCase{err: errors.New("database down")}, "?format=jsonl")
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "internal_server_error")
+
+	// After the first row the export can only be cut short
+	recorder = getExport(&exportUseCase{users: fixtures.Users(1), err: errors.New("database down")}, "?format=jsonl")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, 1, strings.Count(recorder.Body.String(), "\n"))
+}
@@ -1,13 +1,20 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	
+	"web-clean/infra/web"
 	"web-clean/internal/application/service"
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/filter"
+	"web-clean/internal/domain/repository"
+	"web-clean/internal/domain/specification"
 	"web-clean/internal/domain/usecase"
 	"web-clean/domain"
 )
@@ -17,20 +24,22 @@ import (
 type UserHandler struct {
 	userUseCase usecase.UserUseCase
 	logger      domain.Log
+	pages       usecase.PageLimits
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler(userUseCase usecase.UserUseCase, logger domain.Log) *UserHandler {
+func NewUserHandler(userUseCase usecase.UserUseCase, logger domain.Log, pages usecase.PageLimits) *UserHandler {
 	return &UserHandler{
 		userUseCase: userUseCase,
 		logger:      logger,
+		pages:       pages,
 	}
 }
 
 // CreateUserRequest represents the HTTP request for creating a user
 type CreateUserRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Username string `json:"username" binding:"required,min=3,max=50"`
+	Email    string `json:"email" binding:"required"`    // validated by entity.NewEmail
+	Username string `json:"username" binding:"required"` // validated by entity.NewUsername
 	Name     string `json:"name" binding:"required,min=1,max=100"`
 }
 
@@ -40,34 +49,48 @@ type UserResponse struct {
 	Email     string `json:"email"`
 	Username  string `json:"username"`
 	Name      string `json:"name"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	// CreatedAt and UpdatedAt are RFC 3339 strings, or integer milliseconds with ?time_format=epoch_ms
+	CreatedAt interface{} `json:"created_at"`
+	UpdatedAt interface{} `json:"updated_at"`
 }
 
 // ListUsersResponse represents the HTTP response for listing users
+// Users holds UserResponse values, or partial maps when ?fields= is given
 type ListUsersResponse struct {
-	Users   []UserResponse `json:"users"`
-	Total   int64          `json:"total"`
-	Offset  int            `json:"offset"`
-	Limit   int            `json:"limit"`
-	HasMore bool           `json:"has_more"`
+	Users   []interface{} `json:"users"`
+	Total   int64         `json:"total"`
+	Offset  int           `json:"offset"`
+	Limit   int           `json:"limit"`
+	HasMore bool          `json:"has_more"`
+}
+
+// ListUsersCursorResponse is the v1 list response in cursor mode (?cursor=), which has no offset or total
+type ListUsersCursorResponse struct {
+	Users      []interface{} `json:"users"`
+	Limit      int           `json:"limit"`
+	HasMore    bool          `json:"has_more"`
+	NextCursor string        `json:"next_cursor,omitempty"`
 }
 
 // ErrorResponse represents error response
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message,omitempty"`
+	// Fields lists the unknown request body fields of an unknown_fields error
+	Fields []string `json:"fields,omitempty"`
 }
 
 // CreateUser handles POST /users
 func (h *UserHandler) CreateUser(c *gin.Context) {
+	format, ok := h.bindTimeFormat(c)
+	if !ok {
+		return
+	}
+
 	var req CreateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := web.BindJSON(c, &req); err != nil {
 		h.logger.Warnw("Invalid request for create user", "error", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_request",
-			Message: err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, invalidRequest(err))
 		return
 	}
 
@@ -86,15 +109,9 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 	}
 
 	// Convert domain entity to HTTP response
-	response := UserResponse{
-		ID:        user.ID.String(),
-		Email:     user.Email,
-		Username:  user.Username,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
+	response := toUserResponse(user, format)
 
+	setETag(c, user)
 	c.JSON(http.StatusCreated, response)
 }
 
@@ -111,6 +128,16 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 		return
 	}
 
+	fields, ok := h.bindFieldSelection(c)
+	if !ok {
+		return
+	}
+
+	format, ok := h.bindTimeFormat(c)
+	if !ok {
+		return
+	}
+
 	// Call use case
 	user, err := h.userUseCase.GetUserByID(c.Request.Context(), id)
 	if err != nil {
@@ -119,16 +146,8 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 	}
 
 	// Convert domain entity to HTTP response
-	response := UserResponse{
-		ID:        user.ID.String(),
-		Email:     user.Email,
-		Username:  user.Username,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
-
-	c.JSON(http.StatusOK, response)
+	setETag(c, user)
+	c.JSON(http.StatusOK, toUserPayload(user, fields, format))
 }
 
 // UpdateUserProfile handles PUT /users/:id
@@ -144,23 +163,35 @@ func (h *UserHandler) UpdateUserProfile(c *gin.Context) {
 		return
 	}
 
+	format, ok := h.bindTimeFormat(c)
+	if !ok {
+		return
+	}
+
+	version, err := parseIfMatch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_if_match",
+			Message: err.Error(),
+		})
+		return
+	}
+
 	var req struct {
 		Name string `json:"name" binding:"required,min=1,max=100"`
 	}
 	
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := web.BindJSON(c, &req); err != nil {
 		h.logger.Warnw("Invalid request for update user", "error", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_request",
-			Message: err.Error(),
-		})
+		c.JSON(http.StatusBadRequest, invalidRequest(err))
 		return
 	}
 
 	// Convert HTTP request to use case request
 	useCaseReq := usecase.UpdateUserProfileRequest{
-		ID:   id,
-		Name: req.Name,
+		ID:      id,
+		Name:    req.Name,
+		Version: version,
 	}
 
 	// Call use case
@@ -171,15 +202,9 @@ func (h *UserHandler) UpdateUserProfile(c *gin.Context) {
 	}
 
 	// Convert domain entity to HTTP response
-	response := UserResponse{
-		ID:        user.ID.String(),
-		Email:     user.Email,
-		Username:  user.Username,
-		Name:      user.Name,
-		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
+	response := toUserResponse(user, format)
 
+	setETag(c, user)
 	c.JSON(http.StatusOK, response)
 }
 
@@ -210,7 +235,6 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 func (h *UserHandler) ListUsers(c *gin.Context) {
 	// Parse query parameters
 	offsetStr := c.DefaultQuery("offset", "0")
-	limitStr := c.DefaultQuery("limit", "10")
 
 	offset, err := strconv.Atoi(offsetStr)
 	if err != nil || offset < 0 {
@@ -222,20 +246,65 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 		return
 	}
 
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 || limit > 100 {
-		h.logger.Warnw("Invalid limit parameter", "limit", limitStr)
+	limit, err := parseLimit(c, "limit", h.pages)
+	if err != nil {
+		h.logger.Warnw("Invalid limit parameter", "limit", c.Query("limit"))
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error:   "invalid_limit",
-			Message: "Limit must be a positive integer between 1 and 100",
+			Message: err.Error(),
 		})
 		return
 	}
 
+	expr, err := parseUserFilter(c)
+	if err != nil {
+		h.logger.Warnw("Invalid filter parameter", "filter", c.Query("filter"), "error", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_filter",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	sort, err := specification.ParseOrdering(c.Query("sort"), usecase.UserSortFields)
+	if err != nil {
+		h.logger.Warnw("Invalid sort parameter", "sort", c.Query("sort"), "error", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_sort",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	fields, ok := h.bindFieldSelection(c)
+	if !ok {
+		return
+	}
+
+	format, ok := h.bindTimeFormat(c)
+	if !ok {
+		return
+	}
+
+	// Cursor mode: keyset pagination over (created_at, id), stable and fast on large tables
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		if _, hasOffset := c.GetQuery("offset"); hasOffset || sort != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_cursor",
+				Message: "cursor cannot be combined with offset or sort",
+			})
+			return
+		}
+		h.listUsersAfter(c, cursor, limit, expr, fields, format)
+		return
+	}
+
 	// Convert HTTP request to use case request
 	useCaseReq := usecase.ListUsersRequest{
 		Offset: offset,
 		Limit:  limit,
+		Filter: expr,
+		Sort:   sort,
 	}
 
 	// Call use case
@@ -246,16 +315,9 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 	}
 
 	// Convert domain response to HTTP response
-	users := make([]UserResponse, len(result.Users))
+	users := make([]interface{}, len(result.Users))
 	for i, user := range result.Users {
-		users[i] = UserResponse{
-			ID:        user.ID.String(),
-			Email:     user.Email,
-			Username:  user.Username,
-			Name:      user.Name,
-			CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		}
+		users[i] = toUserPayload(user, fields, format)
 	}
 
 	response := ListUsersResponse{
@@ -266,32 +328,211 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 		HasMore: result.HasMore,
 	}
 
+	v1PageParams.writeOffsetLinks(c, result.Offset, result.Limit, result.Total)
 	c.JSON(http.StatusOK, response)
 }
 
+// listUsersAfter serves GET /users?cursor=, an empty cursor starts at the newest user
+func (h *UserHandler) listUsersAfter(c *gin.Context, token string, limit int, expr filter.Expression, fields FieldSelection, format TimeFormat) {
+	after, err := decodeCursor(token)
+	if err != nil {
+		h.logger.Warnw("Invalid cursor parameter", "cursor", token)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_cursor",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	result, err := h.userUseCase.ListUsersAfter(c.Request.Context(), usecase.ListUsersAfterRequest{
+		After:  after,
+		Limit:  limit,
+		Filter: expr,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	users := make([]interface{}, len(result.Users))
+	for i, user := range result.Users {
+		users[i] = toUserPayload(user, fields, format)
+	}
+
+	nextCursor := encodeCursor(result.NextCursor)
+	writeCursorLinks(c, result.Limit, nextCursor)
+	c.JSON(http.StatusOK, ListUsersCursorResponse{
+		Users:      users,
+		Limit:      result.Limit,
+		HasMore:    result.HasMore,
+		NextCursor: nextCursor,
+	})
+}
+
+// bindFieldSelection parses ?fields= and writes a 400 response when it is invalid
+func (h *UserHandler) bindFieldSelection(c *gin.Context) (FieldSelection, bool) {
+	fields, err := parseFieldSelection(c)
+	if err != nil {
+		h.logger.Warnw("Invalid fields parameter", "fields", c.Query("fields"), "error", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_fields",
+			Message: err.Error(),
+		})
+		return nil, false
+	}
+	return fields, true
+}
+
+// bindTimeFormat parses ?tz= and ?time_format=, writing a 400 response when they are invalid
+func (h *UserHandler) bindTimeFormat(c *gin.Context) (TimeFormat, bool) {
+	format, err := parseTimeFormat(c)
+	if err != nil {
+		h.logger.Warnw("Invalid time format parameters", "tz", c.Query("tz"), "time_format", c.Query("time_format"), "error", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_time_format",
+			Message: err.Error(),
+		})
+		return TimeFormat{}, false
+	}
+	return format, true
+}
+
 // handleError converts use case errors to appropriate HTTP responses
 func (h *UserHandler) handleError(c *gin.Context, err error) {
+	status, response := errorResponseFor(err)
+	if status == http.StatusInternalServerError {
+		h.logger.Errorw("Internal server error", "error", err)
+	}
+	c.JSON(status, response)
+}
+
+// statusClientClosedRequest is nginx's status for a client that disconnected before the response was written
+const statusClientClosedRequest = 499
+
+// invalidRequest builds the 400 body for a request body that could not be bound; on strict routes
+// unknown fields are reported as unknown_fields with their paths
+func invalidRequest(err error) ErrorResponse {
+	var unknown *web.UnknownFieldsError
+	if errors.As(err, &unknown) {
+		return ErrorResponse{
+			Error:   "unknown_fields",
+			Message: err.Error(),
+			Fields:  unknown.Fields,
+		}
+	}
+	return ErrorResponse{
+		Error:   "invalid_request",
+		Message: err.Error(),
+	}
+}
+
+// errorResponseFor maps use case errors to an HTTP status and error body shared by all API versions
+func errorResponseFor(err error) (int, ErrorResponse) {
+	// The client is gone and nobody reads this response, but the status keeps cancellations out of the 5xx
+	if errors.Is(err, context.Canceled) {
+		return statusClientClosedRequest, ErrorResponse{
+			Error:   "client_closed_request",
+			Message: "The request was cancelled by the client",
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout, ErrorResponse{
+			Error:   "timeout",
+			Message: "The request took too long to complete",
+		}
+	}
+
+	if errors.Is(err, repository.ErrReadOnly) {
+		return http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "read_only_mode",
+			Message: "The service is in read-only mode, writes are temporarily unavailable",
+		}
+	}
+
+	if errors.Is(err, repository.ErrBudgetExceeded) {
+		return http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "budget_exceeded",
+			Message: "The request needed too many database resources, narrow it down (e.g. a smaller limit)",
+		}
+	}
+
+	if errors.Is(err, repository.ErrConflict) {
+		return http.StatusConflict, ErrorResponse{
+			Error:   "version_conflict",
+			Message: "The user was modified since it was read, fetch it again and retry with its current ETag",
+		}
+	}
+
+	if errors.Is(err, entity.ErrInvalidEmail) || errors.Is(err, entity.ErrInvalidUsername) || errors.Is(err, entity.ErrInvalidAPIKey) {
+		return http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		}
+	}
+
+	if errors.Is(err, entity.ErrInvalidPassword) {
+		return http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_password",
+			Message: err.Error(),
+		}
+	}
+
 	switch err {
 	case service.ErrUserNotFound:
-		c.JSON(http.StatusNotFound, ErrorResponse{
+		return http.StatusNotFound, ErrorResponse{
 			Error:   "user_not_found",
 			Message: "User not found",
-		})
+		}
 	case service.ErrUserAlreadyExists:
-		c.JSON(http.StatusConflict, ErrorResponse{
+		return http.StatusConflict, ErrorResponse{
 			Error:   "user_already_exists",
 			Message: "User with email or username already exists",
-		})
+		}
 	case service.ErrInvalidUserData:
-		c.JSON(http.StatusBadRequest, ErrorResponse{
+		return http.StatusBadRequest, ErrorResponse{
 			Error:   "invalid_user_data",
 			Message: "Invalid user data provided",
-		})
+		}
+	case service.ErrWrongPassword:
+		return http.StatusForbidden, ErrorResponse{
+			Error:   "wrong_password",
+			Message: "The current password is incorrect",
+		}
+	case service.ErrInvalidBatchSize:
+		return http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_batch_size",
+			Message: err.Error(),
+		}
+	case service.ErrInvalidImportSize:
+		return http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_import_size",
+			Message: err.Error(),
+		}
+	case service.ErrInvalidDuplicatePolicy:
+		return http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_duplicate_policy",
+			Message: err.Error(),
+		}
+	case service.ErrUsernameNotAllowed:
+		return http.StatusBadRequest, ErrorResponse{
+			Error:   "username_not_allowed",
+			Message: "This username is reserved or not allowed",
+		}
+	case service.ErrAPIKeyNotFound:
+		return http.StatusNotFound, ErrorResponse{
+			Error:   "api_key_not_found",
+			Message: "API key not found",
+		}
+	case service.ErrAPIKeyRejected:
+		return http.StatusUnauthorized, ErrorResponse{
+			Error:   "invalid_api_key",
+			Message: "The API key is invalid or revoked",
+		}
 	default:
-		h.logger.Errorw("Internal server error", "error", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
+		return http.StatusInternalServerError, ErrorResponse{
 			Error:   "internal_server_error",
 			Message: "An internal error occurred",
-		})
+		}
 	}
-}
\ No newline at end of file
+}
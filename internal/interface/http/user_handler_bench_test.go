@@ -0,0 +1,65 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/usecase"
+	"web-clean/internal/testing/fixtures"
+)
+
+// benchUseCase answers reads from memory so benchmarks measure only the handler path
+type benchUseCase struct {
+	usecase.UserUseCase
+	user  *entity.User
+	users []*entity.User
+}
+
+func (b benchUseCase) GetUserByID(context.Context, uuid.UUID) (*entity.User, error) {
+	return b.user, nil
+}
+
+func (b benchUseCase) ListUsers(_ context.Context, req usecase.ListUsersRequest) (*usecase.ListUsersResponse, error) {
+	return &usecase.ListUsersResponse{Users: b.users, Total: int64(len(b.users)), Offset: req.Offset, Limit: req.Limit}, nil
+}
+
+func newBenchEngine() (*gin.Engine, uuid.UUID) {
+	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = io.Discard
+
+	users := fixtures.Users(10)
+
+	handler := NewUserHandler(benchUseCase{user: users[0], users: users}, zap.NewNop().Sugar(), usecase.DefaultPageLimits)
+	engine := gin.New()
+	engine.GET("/api/v1/users", handler.ListUsers)
+	engine.GET("/api/v1/users/:id", handler.GetUserByID)
+	return engine, users[0].ID
+}
+
+func BenchmarkUserHandler_GetUserByID(b *testing.B) {
+	engine, id := newBenchEngine()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/"+id.String(), nil)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
+
+func BenchmarkUserHandler_ListUsers(b *testing.B) {
+	engine, _ := newBenchEngine()
+	req := httptest.NewRequest(http.MethodGet, `/api/v1/users?limit=10&filter=email~"@example.com"&fields=id,email`, nil)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
@@ -0,0 +1,126 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"web-clean/internal/application/service"
+	"web-clean/internal/domain/entity"
+	"web-clean/internal/domain/repository"
+	"web-clean/internal/domain/usecase"
+	"web-clean/internal/testing/fixtures"
+)
+
+func TestErrorResponseFor_ReadOnly(t *testing.T) {
+	// Act
+	status, response := errorResponseFor(fmt.Errorf("failed to create user: %w", repository.ErrReadOnly))
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "read_only_mode", response.Error)
+}
+
+func TestErrorResponseFor_BudgetExceeded(t *testing.T) {
+	// Act
+	status, response := errorResponseFor(fmt.Errorf("failed to list users: %w", repository.ErrBudgetExceeded))
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "budget_exceeded", response.Error)
+}
+
+func TestErrorResponseFor_Conflict(t *testing.T) {
+	// Act
+	status, response := errorResponseFor(fmt.Errorf("failed to update user: %w", repository.ErrConflict))
+
+	// Assert
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, "version_conflict", response.Error)
+}
+
+func TestErrorResponseFor_UsernameNotAllowed(t *testing.T) {
+	// Act
+	status, response := errorResponseFor(service.ErrUsernameNotAllowed)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "username_not_allowed", response.Error)
+}
+
+func TestErrorResponseFor_Cancelled(t *testing.T) {
+	// Act
+	status, response := errorResponseFor(fmt.Errorf("failed to get user: %w", context.Canceled))
+
+	// Assert
+	assert.Equal(t, statusClientClosedRequest, status)
+	assert.Equal(t, "client_closed_request", response.Error)
+
+	status, _ = errorResponseFor(fmt.Errorf("failed to list users: %w", context.DeadlineExceeded))
+	assert.Equal(t, http.StatusGatewayTimeout, status)
+}
+
+// cursorUseCase returns a fixed cursor page and records the request it was given
+type cursorUseCase struct {
+	usecase.UserUseCase
+	users []*entity.User
+	got   *usecase.ListUsersAfterRequest
+}
+
+func (u *cursorUseCase) ListUsersAfter(_ context.Context, req usecase.ListUsersAfterRequest) (*usecase.ListUsersAfterResponse, error) {
+	u.got = &req
+	return &usecase.ListUsersAfterResponse{
+		Users:      u.users,
+		Limit:      req.Limit,
+		HasMore:    true,
+		NextCursor: repository.CursorOf(u.users[len(u.users)-1]),
+	}, nil
+}
+
+func TestUserHandler_ListUsers_CursorMode(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	users := fixtures.Users(2)
+	useCase := &cursorUseCase{users: users}
+	handler := NewUserHandler(useCase, zap.NewNop().Sugar(), usecase.DefaultPageLimits)
+	engine := gin.New()
+	engine.GET("/users", handler.ListUsers)
+
+	// Act
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users?cursor=&limit=2", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var body ListUsersCursorResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Len(t, body.Users, 2)
+	assert.True(t, body.HasMore)
+	assert.Equal(t, encodeCursor(repository.CursorOf(users[1])), body.NextCursor)
+	assert.Nil(t, useCase.got.After)
+	assert.Equal(t, 2, useCase.got.Limit)
+	assert.NotContains(t, recorder.Body.String(), `"total"`)
+}
+
+func TestUserHandler_ListUsers_CursorWithOffset(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	handler := NewUserHandler(&cursorUseCase{}, zap.NewNop().Sugar(), usecase.DefaultPageLimits)
+	engine := gin.New()
+	engine.GET("/users", handler.ListUsers)
+
+	// Act
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/users?cursor=&offset=10", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "invalid_cursor")
+}
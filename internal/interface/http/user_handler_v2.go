@@ -0,0 +1,334 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"web-clean/domain"
+	"web-clean/infra/web"
+	"web-clean/internal/domain/filter"
+	"web-clean/internal/domain/repository"
+	"web-clean/internal/domain/usecase"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// UserHandlerV2 handles the v2 user API: cursor pagination, enveloped bodies and PATCH updates
+type UserHandlerV2 struct {
+	userUseCase usecase.UserUseCase
+	logger      domain.Log
+	pages       usecase.PageLimits
+}
+
+// NewUserHandlerV2 creates a new v2 user handler
+func NewUserHandlerV2(userUseCase usecase.UserUseCase, logger domain.Log, pages usecase.PageLimits) *UserHandlerV2 {
+	return &UserHandlerV2{
+		userUseCase: userUseCase,
+		logger:      logger,
+		pages:       pages,
+	}
+}
+
+// Envelope wraps every successful v2 response
+type Envelope struct {
+	Data interface{} `json:"data"`
+	Meta interface{} `json:"meta,omitempty"`
+}
+
+// ErrorEnvelope wraps every v2 error response
+type ErrorEnvelope struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody is the error detail of a v2 error response
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+	// Fields lists the unknown request body fields of an unknown_fields error
+	Fields []string `json:"fields,omitempty"`
+}
+
+// PageMeta describes a cursor page
+type PageMeta struct {
+	Limit      int    `json:"limit"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// PatchUserRequest represents a v2 partial update, absent fields are left unchanged
+type PatchUserRequest struct {
+	Name *string `json:"name" binding:"omitempty,min=1,max=100"`
+}
+
+// CreateUser handles POST /api/v2/users
+func (h *UserHandlerV2) CreateUser(c *gin.Context) {
+	format, ok := h.bindTimeFormat(c)
+	if !ok {
+		return
+	}
+
+	var req CreateUserRequest
+	if err := web.BindJSON(c, &req); err != nil {
+		h.logger.Warnw("Invalid request for create user", "error", err)
+		h.writeInvalidRequest(c, err)
+		return
+	}
+
+	user, err := h.userUseCase.CreateUser(c.Request.Context(), usecase.CreateUserRequest{
+		Email:    req.Email,
+		Username: req.Username,
+		Name:     req.Name,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	setETag(c, user)
+	c.JSON(http.StatusCreated, Envelope{Data: toUserResponse(user, format)})
+}
+
+// GetUserByID handles GET /api/v2/users/:id
+func (h *UserHandlerV2) GetUserByID(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	fields, err := parseFieldSelection(c)
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, "invalid_fields", err.Error())
+		return
+	}
+
+	format, ok := h.bindTimeFormat(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userUseCase.GetUserByID(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	setETag(c, user)
+	c.JSON(http.StatusOK, Envelope{Data: toUserPayload(user, fields, format)})
+}
+
+// PatchUser handles PATCH /api/v2/users/:id
+func (h *UserHandlerV2) PatchUser(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	format, ok := h.bindTimeFormat(c)
+	if !ok {
+		return
+	}
+
+	version, err := parseIfMatch(c)
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, "invalid_if_match", err.Error())
+		return
+	}
+
+	var req PatchUserRequest
+	if err := web.BindJSON(c, &req); err != nil {
+		h.logger.Warnw("Invalid request for patch user", "error", err)
+		h.writeInvalidRequest(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	user, err := h.userUseCase.GetUserByID(ctx, id)
+	switch {
+	case err != nil:
+	case req.Name != nil:
+		user, err = h.userUseCase.UpdateUserProfile(ctx, usecase.UpdateUserProfileRequest{
+			ID:      id,
+			Name:    *req.Name,
+			Version: version,
+		})
+	case version != 0 && version != user.Version:
+		// An empty patch still honors If-Match
+		err = repository.ErrConflict
+	}
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	setETag(c, user)
+	c.JSON(http.StatusOK, Envelope{Data: toUserResponse(user, format)})
+}
+
+// DeleteUser handles DELETE /api/v2/users/:id
+func (h *UserHandlerV2) DeleteUser(c *gin.Context) {
+	id, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userUseCase.DeleteUser(c.Request.Context(), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListUsers handles GET /api/v2/users?cursor=&limit=&filter=&fields=
+func (h *UserHandlerV2) ListUsers(c *gin.Context) {
+	limit, err := parseLimit(c, "limit", h.pages)
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, "invalid_limit", err.Error())
+		return
+	}
+
+	after, err := decodeCursor(c.Query("cursor"))
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, "invalid_cursor", err.Error())
+		return
+	}
+
+	expr, err := filter.Parse(c.Query("filter"), usecase.UserFilterFields)
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, "invalid_filter", err.Error())
+		return
+	}
+
+	fields, err := parseFieldSelection(c)
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, "invalid_fields", err.Error())
+		return
+	}
+
+	format, ok := h.bindTimeFormat(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.userUseCase.ListUsersAfter(c.Request.Context(), usecase.ListUsersAfterRequest{
+		After:  after,
+		Limit:  limit,
+		Filter: expr,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	users := make([]interface{}, len(result.Users))
+	for i, user := range result.Users {
+		users[i] = toUserPayload(user, fields, format)
+	}
+
+	nextCursor := encodeCursor(result.NextCursor)
+	writeCursorLinks(c, result.Limit, nextCursor)
+
+	c.JSON(http.StatusOK, Envelope{
+		Data: users,
+		Meta: PageMeta{
+			Limit:      result.Limit,
+			HasMore:    result.HasMore,
+			NextCursor: encodeCursor(result.NextCursor),
+		},
+	})
+}
+
+func (h *UserHandlerV2) parseID(c *gin.Context) (uuid.UUID, bool) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Warnw("Invalid user ID format", "id", idStr, "error", err)
+		h.writeError(c, http.StatusBadRequest, "invalid_id", "Invalid user ID format")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// bindTimeFormat parses ?tz= and ?time_format=, writing a 400 response when they are invalid
+func (h *UserHandlerV2) bindTimeFormat(c *gin.Context) (TimeFormat, bool) {
+	format, err := parseTimeFormat(c)
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, "invalid_time_format", err.Error())
+		return TimeFormat{}, false
+	}
+	return format, true
+}
+
+func (h *UserHandlerV2) writeError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, ErrorEnvelope{Error: ErrorBody{Code: code, Message: message}})
+}
+
+// writeInvalidRequest answers a request body that could not be bound with 400
+func (h *UserHandlerV2) writeInvalidRequest(c *gin.Context, err error) {
+	response := invalidRequest(err)
+	c.JSON(http.StatusBadRequest, ErrorEnvelope{Error: ErrorBody{Code: response.Error, Message: response.Message, Fields: response.Fields}})
+}
+
+// handleError converts use case errors to enveloped HTTP responses
+func (h *UserHandlerV2) handleError(c *gin.Context, err error) {
+	status, response := errorResponseFor(err)
+	if status == http.StatusInternalServerError {
+		h.logger.Errorw("Internal server error", "error", err)
+	}
+	h.writeError(c, status, response.Error, response.Message)
+}
+
+// cursorPayload is the JSON form of a cursor before base64 encoding
+type cursorPayload struct {
+	CreatedAt string `json:"t"`
+	ID        string `json:"id"`
+}
+
+// encodeCursor turns a keyset position into an opaque URL-safe token, nil encodes to ""
+func encodeCursor(cursor *repository.UserCursor) string {
+	if cursor == nil {
+		return ""
+	}
+
+	data, _ := json.Marshal(cursorPayload{
+		CreatedAt: cursor.CreatedAt.UTC().Format(time.RFC3339Nano),
+		ID:        cursor.ID.String(),
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a token produced by encodeCursor, "" decodes to nil (first page)
+func decodeCursor(token string) (*repository.UserCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, payload.CreatedAt)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := uuid.Parse(payload.ID)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &repository.UserCursor{CreatedAt: createdAt, ID: id}, nil
+}
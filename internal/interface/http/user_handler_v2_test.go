@@ -0,0 +1,47 @@
+package http
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"web-clean/internal/domain/repository"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	// Arrange
+	cursor := &repository.UserCursor{
+		CreatedAt: time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC),
+		ID:        uuid.New(),
+	}
+
+	// Act
+	decoded, err := decodeCursor(encodeCursor(cursor))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, cursor.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, cursor.ID, decoded.ID)
+}
+
+func TestCursor_Empty(t *testing.T) {
+	// Act
+	decoded, err := decodeCursor("")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Nil(t, decoded)
+	assert.Equal(t, "", encodeCursor(nil))
+}
+
+func TestCursor_Invalid(t *testing.T) {
+	for _, token := range []string{"not base64!", "bm90IGpzb24", "eyJ0IjoieCIsImlkIjoieSJ9"} {
+		// Act
+		_, err := decodeCursor(token)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidCursor, token)
+	}
+}
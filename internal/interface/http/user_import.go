@@ -0,0 +1,310 @@
+package http
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"web-clean/internal/application/service"
+	"web-clean/internal/domain/usecase"
+)
+
+// ErrInvalidImport is returned for an upload that cannot be read as CSV or JSON users
+var ErrInvalidImport = errors.New("invalid import file")
+
+// maxImportBytes bounds the size of an import upload
+const maxImportBytes = 10 << 20
+
+// importColumns are the CSV columns an import reads; other columns, such as those of an export, are ignored
+var importColumns = []string{"email", "username", "name"}
+
+// ImportUserItemResult is the outcome of one row of an import, Index counts data rows from 0
+type ImportUserItemResult struct {
+	Index  int            `json:"index"`
+	Action string         `json:"action"`
+	User   *UserResponse  `json:"user,omitempty"`
+	Error  *ErrorResponse `json:"error,omitempty"`
+}
+
+// ImportUsersResponse reports every row of an import in file order
+type ImportUsersResponse struct {
+	DryRun  bool                   `json:"dry_run"`
+	Created int                    `json:"created"`
+	Updated int                    `json:"updated"`
+	Skipped int                    `json:"skipped"`
+	Failed  int                    `json:"failed"`
+	Results []ImportUserItemResult `json:"results"`
+}
+
+// ImportUsers handles POST /users/import?on_duplicate=skip|overwrite|error&dry_run=true. The body is a CSV
+// file with email, username and name columns, or a JSON array or JSON lines of users, sent as is or as the
+// "file" field of a multipart form. Like a batch, the response is 200 with a per-row action
+func (h *UserHandler) ImportUsers(c *gin.Context) {
+	format, ok := h.bindTimeFormat(c)
+	if !ok {
+		return
+	}
+
+	policy := usecase.DuplicatePolicy(strings.ToLower(c.DefaultQuery("on_duplicate", string(usecase.DuplicateError))))
+	switch policy {
+	case usecase.DuplicateSkip, usecase.DuplicateOverwrite, usecase.DuplicateError:
+	default:
+		h.handleError(c, service.ErrInvalidDuplicatePolicy)
+		return
+	}
+
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "dry_run must be true or false",
+		})
+		return
+	}
+
+	rows, err := readImport(c)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+			Error:   "import_too_large",
+			Message: fmt.Sprintf("An import file may be at most %d bytes", maxImportBytes),
+		})
+		return
+	}
+	if err != nil {
+		h.logger.Warnw("Invalid import file", "error", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_import",
+			Message: err.Error(),
+		})
+		return
+	}
+	if len(rows) == 0 || len(rows) > usecase.MaxImportUsers {
+		h.handleError(c, service.ErrInvalidImportSize)
+		return
+	}
+
+	results := make([]ImportUserItemResult, len(rows))
+	useCaseRows := make([]usecase.CreateUserRequest, 0, len(rows))
+	indexes := make([]int, 0, len(rows))
+	for i, row := range rows {
+		results[i].Index = i
+		if err := binding.Validator.ValidateStruct(&row); err != nil {
+			results[i].Action = string(usecase.ImportFailed)
+			results[i].Error = &ErrorResponse{Error: "invalid_request", Message: err.Error()}
+			continue
+		}
+		useCaseRows = append(useCaseRows, usecase.CreateUserRequest{
+			Email:    row.Email,
+			Username: row.Username,
+			Name:     row.Name,
+		})
+		indexes = append(indexes, i)
+	}
+
+	if len(useCaseRows) > 0 {
+		response, err := h.userUseCase.ImportUsers(c.Request.Context(), usecase.ImportUsersRequest{
+			Users:       useCaseRows,
+			OnDuplicate: policy,
+			DryRun:      dryRun,
+		})
+		if err != nil {
+			h.handleError(c, err)
+			return
+		}
+
+		for k, result := range response.Results {
+			item := &results[indexes[k]]
+			item.Action = string(result.Action)
+			if result.Err != nil {
+				status, body := errorResponseFor(result.Err)
+				if status == http.StatusInternalServerError {
+					h.logger.Errorw("Internal server error in import row", "index", item.Index, "error", result.Err)
+				}
+				item.Error = &body
+				continue
+			}
+			if result.User != nil {
+				user := toUserResponse(result.User, format)
+				item.User = &user
+			}
+		}
+	}
+
+	body := ImportUsersResponse{DryRun: dryRun, Results: results}
+	for _, result := range results {
+		switch usecase.ImportAction(result.Action) {
+		case usecase.ImportCreated:
+			body.Created++
+		case usecase.ImportUpdated:
+			body.Updated++
+		case usecase.ImportSkipped:
+			body.Skipped++
+		default:
+			body.Failed++
+		}
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// readImport reads at most MaxImportUsers+1 rows of the upload, so that an oversized import is detected
+// without reading all of it
+func readImport(c *gin.Context) ([]CreateUserRequest, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+	var body io.Reader = c.Request.Body
+	contentType, filename := c.GetHeader("Content-Type"), ""
+	if c.ContentType() == binding.MIMEMultipartPOSTForm {
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: multipart upload needs a file field: %v", ErrInvalidImport, err)
+		}
+		defer file.Close()
+		body, contentType, filename = file, header.Header.Get("Content-Type"), header.Filename
+	}
+
+	limit := usecase.MaxImportUsers + 1
+	switch importFormat(contentType, filename) {
+	case "csv":
+		return readCSVImport(body, limit)
+	case "json":
+		return readJSONImport(body, limit)
+	default:
+		return nil, fmt.Errorf("%w: upload text/csv or application/json (a .csv, .json or .jsonl file)", ErrInvalidImport)
+	}
+}
+
+// importFormat picks csv or json from the media type, falling back to the file extension
+func importFormat(contentType, filename string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return "csv"
+	case "application/json", "application/x-ndjson", "application/jsonl":
+		return "json"
+	}
+
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return "csv"
+	case ".json", ".jsonl", ".ndjson":
+		return "json"
+	}
+	return ""
+}
+
+// readCSVImport reads users from a CSV file whose header names at least the importColumns
+func readCSVImport(r io.Reader, limit int) ([]CreateUserRequest, error) {
+	reader := csv.NewReader(r)
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	positions := make(map[string]int, len(header))
+	for i, column := range header {
+		if i == 0 {
+			column = strings.TrimPrefix(column, "\ufeff") // a byte order mark written by spreadsheets
+		}
+		positions[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, column := range importColumns {
+		if _, ok := positions[column]; !ok {
+			return nil, fmt.Errorf("%w: missing column %q", ErrInvalidImport, column)
+		}
+	}
+
+	rows := make([]CreateUserRequest, 0)
+	for len(rows) < limit {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+		rows = append(rows, CreateUserRequest{
+			Email:    record[positions["email"]],
+			Username: record[positions["username"]],
+			Name:     record[positions["name"]],
+		})
+	}
+	return rows, nil
+}
+
+// readJSONImport reads users from a JSON array or from JSON lines, one user object per line
+func readJSONImport(r io.Reader, limit int) ([]CreateUserRequest, error) {
+	buffered := bufio.NewReader(r)
+	first, err := peekNonSpace(buffered)
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(buffered)
+	array := first == '['
+	if array {
+		if _, err := decoder.Token(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+	}
+
+	rows := make([]CreateUserRequest, 0)
+	for len(rows) < limit && (!array || decoder.More()) {
+		var row CreateUserRequest
+		err := decoder.Decode(&row)
+		if err == io.EOF && !array {
+			break
+		}
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: user %d: %v", ErrInvalidImport, len(rows), err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// peekNonSpace skips leading whitespace and returns the first byte without consuming it
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			_, _ = r.ReadByte()
+		default:
+			return b[0], nil
+		}
+	}
+}
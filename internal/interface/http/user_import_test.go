@@ -0,0 +1,160 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"web-clean/internal/application/service"
+	"web-clean/internal/domain/usecase"
+	"web-clean/internal/testing/fixtures"
+)
+
+// importUseCase creates every row except those named "taken", which are skipped, recording the request
+type importUseCase struct {
+	usecase.UserUseCase
+	got *usecase.ImportUsersRequest
+}
+
+func (u *importUseCase) ImportUsers(_ context.Context, req usecase.ImportUsersRequest) (*usecase.ImportUsersResponse, error) {
+	u.got = &req
+	results := make([]usecase.ImportUserResult, len(req.Users))
+	for i, row := range req.Users {
+		if row.Username == "taken" {
+			results[i] = usecase.ImportUserResult{Action: usecase.ImportFailed, Err: service.ErrUserAlreadyExists}
+			continue
+		}
+		results[i] = usecase.ImportUserResult{Action: usecase.ImportCreated, User: fixtures.User().WithEmail(row.Email).Build()}
+	}
+	return &usecase.ImportUsersResponse{Results: results, DryRun: req.DryRun}, nil
+}
+
+func postImport(handler *UserHandler, query, contentType string, body io.Reader) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/users/import", handler.ImportUsers)
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/users/import"+query, body)
+	request.Header.Set("Content-Type", contentType)
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestImportUsers_CSV(t *testing.T) {
+	// Arrange
+	useCase := &importUseCase{}
+	handler := NewUserHandler(useCase, zap.NewNop().Sugar(), usecase.DefaultPageLimits)
+
+	// Columns of an export are accepted, extra ones are ignored
+	csvBody := "\ufeffid,email,username,name,created_at\n" +
+		"1,alice@example.com,alice,Alice,2024-01-01\n" +
+		"2,bob@example.com,bob,,2024-01-01\n" +
+		"3,carol@example.com,taken,Carol,2024-01-01\n"
+
+	// Act
+	recorder := postImport(handler, "?on_duplicate=skip&dry_run=true", "text/csv", strings.NewReader(csvBody))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	var body ImportUsersResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.True(t, body.DryRun)
+	assert.Equal(t, 1, body.Created)
+	assert.Equal(t, 2, body.Failed)
+	assert.Equal(t, "alice@example.com", body.Results[0].User.Email)
+	assert.Equal(t, "invalid_request", body.Results[1].Error.Error, "a row failing binding does not reach the use case")
+	assert.Equal(t, 2, body.Results[2].Index)
+	assert.Equal(t, "user_already_exists", body.Results[2].Error.Error)
+
+	assert.Len(t, useCase.got.Users, 2)
+	assert.Equal(t, usecase.DuplicateSkip, useCase.got.OnDuplicate)
+	assert.True(t, useCase.got.DryRun)
+}
+
+func TestImportUsers_JSONArrayAndLines(t *testing.T) {
+	for name, payload := range map[string]string{
+		"array": `[{"email":"alice@example.com","username":"alice","name":"Alice"},{"email":"bob@example.com","username":"bob","name":"Bob"}]`,
+		"lines": "{\"email\":\"alice@example.com\",\"username\":\"alice\",\"name\":\"Alice\"}\n{\"email\":\"bob@example.com\",\"username\":\"bob\",\"name\":\"Bob\"}\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			useCase := &importUseCase{}
+			handler := NewUserHandler(useCase, zap.NewNop().Sugar(), usecase.DefaultPageLimits)
+
+			recorder := postImport(handler, "", "application/json", strings.NewReader(payload))
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Len(t, useCase.got.Users, 2)
+			assert.Equal(t, usecase.DuplicateError, useCase.got.OnDuplicate, "duplicates fail by default")
+			assert.False(t, useCase.got.DryRun)
+		})
+	}
+}
+
+func TestImportUsers_MultipartUpload(t *testing.T) {
+	useCase := &importUseCase{}
+	handler := NewUserHandler(useCase, zap.NewNop().Sugar(), usecase.DefaultPageLimits)
+
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	file, err := writer.CreateFormFile("file", "users.csv")
+	assert.NoError(t, err)
+	_, _ = file.Write([]byte("email,username,name\nalice@example.com,alice,Alice\n"))
+	assert.NoError(t, writer.Close())
+
+	recorder := postImport(handler, "?on_duplicate=overwrite", writer.FormDataContentType(), &form)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Len(t, useCase.got.Users, 1)
+	assert.Equal(t, usecase.DuplicateOverwrite, useCase.got.OnDuplicate)
+}
+
+func TestImportUsers_RejectsInvalidUploads(t *testing.T) {
+	handler := NewUserHandler(&importUseCase{}, zap.NewNop().Sugar(), usecase.DefaultPageLimits)
+
+	tests := []struct {
+		name        string
+		query       string
+		contentType string
+		body        string
+		code        string
+	}{
+		{"unknown policy", "?on_duplicate=replace", "text/csv", "email,username,name\n", "invalid_duplicate_policy"},
+		{"bad dry_run", "?dry_run=maybe", "text/csv", "email,username,name\n", "invalid_request"},
+		{"unknown format", "", "text/plain", "alice", "invalid_import"},
+		{"missing column", "", "text/csv", "email,username\na@example.com,alice\n", "invalid_import"},
+		{"malformed json", "", "application/json", `[{"email":`, "invalid_import"},
+		{"empty", "", "text/csv", "email,username,name\n", "invalid_import_size"},
+		{"too many rows", "", "text/csv", "email,username,name\n" + strings.Repeat("a@example.com,alice,Alice\n", usecase.MaxImportUsers+1), "invalid_import_size"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := postImport(handler, tt.query, tt.contentType, strings.NewReader(tt.body))
+
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			var body ErrorResponse
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+			assert.Equal(t, tt.code, body.Error)
+		})
+	}
+}
+
+func TestImportUsers_TooLarge(t *testing.T) {
+	handler := NewUserHandler(&importUseCase{}, zap.NewNop().Sugar(), usecase.DefaultPageLimits)
+	body := "email,username,name\n" + strings.Repeat("x", maxImportBytes)
+
+	recorder := postImport(handler, "", "text/csv", strings.NewReader(body))
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+}
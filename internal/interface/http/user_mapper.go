@@ -0,0 +1,132 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"web-clean/internal/domain/entity"
+)
+
+// timestampLayout is the format used for all timestamps in user responses
+const timestampLayout = "2006-01-02T15:04:05Z07:00"
+
+// ErrUnknownField is returned when a client requests a field outside the whitelist
+var ErrUnknownField = errors.New("unknown field")
+
+// ErrInvalidTimeFormat is returned for an unknown ?tz= zone or ?time_format= value
+var ErrInvalidTimeFormat = errors.New("invalid time format")
+
+// EpochMillis is the ?time_format= value that renders timestamps as integer milliseconds since the Unix epoch
+const EpochMillis = "epoch_ms"
+
+// userFieldGetters is the whitelist of fields selectable via ?fields=
+var userFieldGetters = map[string]func(UserResponse) interface{}{
+	"id":         func(r UserResponse) interface{} { return r.ID },
+	"email":      func(r UserResponse) interface{} { return r.Email },
+	"username":   func(r UserResponse) interface{} { return r.Username },
+	"name":       func(r UserResponse) interface{} { return r.Name },
+	"created_at": func(r UserResponse) interface{} { return r.CreatedAt },
+	"updated_at": func(r UserResponse) interface{} { return r.UpdatedAt },
+}
+
+// FieldSelection holds the fields requested by the client, nil means all fields
+type FieldSelection []string
+
+// parseFieldSelection reads the ?fields= query parameter and validates it against the whitelist
+func parseFieldSelection(c *gin.Context) (FieldSelection, error) {
+	raw := strings.TrimSpace(c.Query("fields"))
+	if raw == "" {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool)
+	fields := make(FieldSelection, 0)
+	for _, part := range strings.Split(raw, ",") {
+		field := strings.ToLower(strings.TrimSpace(part))
+		if field == "" || seen[field] {
+			continue
+		}
+		if _, ok := userFieldGetters[field]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownField, field)
+		}
+		seen[field] = true
+		fields = append(fields, field)
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+// TimeFormat controls how timestamps are rendered, the zero value keeps the stored offset
+type TimeFormat struct {
+	// Location converts timestamps to the requester's zone before formatting, nil leaves them unchanged
+	Location *time.Location
+	// EpochMillis renders timestamps as integer milliseconds instead of strings
+	EpochMillis bool
+}
+
+// parseTimeFormat reads ?tz= (an IANA zone such as Europe/Berlin) and ?time_format= (rfc3339 or epoch_ms)
+func parseTimeFormat(c *gin.Context) (TimeFormat, error) {
+	var format TimeFormat
+
+	if tz := strings.TrimSpace(c.Query("tz")); tz != "" {
+		location, err := time.LoadLocation(tz)
+		if err != nil {
+			return TimeFormat{}, fmt.Errorf("%w: unknown time zone %q", ErrInvalidTimeFormat, tz)
+		}
+		format.Location = location
+	}
+
+	switch value := strings.ToLower(strings.TrimSpace(c.Query("time_format"))); value {
+	case "", "rfc3339":
+	case EpochMillis:
+		format.EpochMillis = true
+	default:
+		return TimeFormat{}, fmt.Errorf("%w: time_format must be rfc3339 or %s", ErrInvalidTimeFormat, EpochMillis)
+	}
+
+	return format, nil
+}
+
+// format renders t as an RFC 3339 string or, for EpochMillis, as an int64
+func (f TimeFormat) format(t time.Time) interface{} {
+	if f.EpochMillis {
+		return t.UnixMilli()
+	}
+	if f.Location != nil {
+		t = t.In(f.Location)
+	}
+	return t.Format(timestampLayout)
+}
+
+// toUserResponse converts a domain entity to its HTTP representation
+func toUserResponse(user *entity.User, format TimeFormat) UserResponse {
+	return UserResponse{
+		ID:        user.ID.String(),
+		Email:     user.Email.String(),
+		Username:  user.Username.String(),
+		Name:      user.Name,
+		CreatedAt: format.format(user.CreatedAt),
+		UpdatedAt: format.format(user.UpdatedAt),
+	}
+}
+
+// toUserPayload converts a domain entity to the response body honoring the field selection
+func toUserPayload(user *entity.User, fields FieldSelection, format TimeFormat) interface{} {
+	response := toUserResponse(user, format)
+	if fields == nil {
+		return response
+	}
+
+	partial := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		partial[field] = userFieldGetters[field](response)
+	}
+	return partial
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"web-clean/internal/testing/fixtures"
+)
+
+func newTestContext(target string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", target, nil)
+	return c
+}
+
+func TestParseFieldSelection_Empty(t *testing.T) {
+	// Act
+	fields, err := parseFieldSelection(newTestContext("/users"))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Nil(t, fields)
+}
+
+func TestParseFieldSelection_Whitelisted(t *testing.T) {
+	// Act
+	fields, err := parseFieldSelection(newTestContext("/users?fields=id,%20Username,id,,name"))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, FieldSelection{"id", "username", "name"}, fields)
+}
+
+func TestParseFieldSelection_UnknownField(t *testing.T) {
+	// Act
+	fields, err := parseFieldSelection(newTestContext("/users?fields=id,password"))
+
+	// Assert
+	assert.True(t, errors.Is(err, ErrUnknownField))
+	assert.Nil(t, fields)
+}
+
+func TestToUserPayload_PartialFields(t *testing.T) {
+	// Arrange
+	user := fixtures.User().WithUsername("testuser").Build()
+
+	// Act
+	full := toUserPayload(user, nil, TimeFormat{})
+	partial := toUserPayload(user, FieldSelection{"id", "username"}, TimeFormat{})
+
+	// Assert
+	assert.Equal(t, toUserResponse(user, TimeFormat{}), full)
+	assert.Equal(t, map[string]interface{}{
+		"id":       user.ID.String(),
+		"username": "testuser",
+	}, partial)
+}
+
+func TestParseTimeFormat(t *testing.T) {
+	// Act
+	format, err := parseTimeFormat(newTestContext("/users?tz=Asia/Tokyo&time_format=RFC3339"))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Tokyo", format.Location.String())
+	assert.False(t, format.EpochMillis)
+}
+
+func TestParseTimeFormat_Invalid(t *testing.T) {
+	_, err := parseTimeFormat(newTestContext("/users?tz=Mars/Olympus"))
+	assert.True(t, errors.Is(err, ErrInvalidTimeFormat))
+
+	_, err = parseTimeFormat(newTestContext("/users?time_format=unix"))
+	assert.True(t, errors.Is(err, ErrInvalidTimeFormat))
+}
+
+func TestToUserResponse_TimeFormat(t *testing.T) {
+	// Arrange
+	created := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	user := fixtures.User().CreatedAt(created).Build()
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+
+	// Act
+	zoned := toUserResponse(user, TimeFormat{Location: tokyo})
+	epoch := toUserResponse(user, TimeFormat{Location: tokyo, EpochMillis: true})
+
+	// Assert
+	assert.Equal(t, "2024-03-01T21:30:00+09:00", zoned.CreatedAt)
+	assert.Equal(t, created.UnixMilli(), epoch.CreatedAt)
+	assert.Equal(t, created.UnixMilli(), epoch.UpdatedAt)
+}
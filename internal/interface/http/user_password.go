@@ -0,0 +1,50 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"web-clean/infra/web"
+	"web-clean/internal/domain/usecase"
+)
+
+// ChangePasswordRequest is the body of POST /users/:id/password
+type ChangePasswordRequest struct {
+	NewPassword string `json:"new_password" binding:"required,min=8,max=72"`
+}
+
+// ChangePassword handles POST /users/:id/password, responding 204 without a body. Users cannot authenticate
+// yet, so this is an operator reset: the route requires ScopeUsersAdmin and the current password is not checked
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Warnw("Invalid user ID format", "id", idStr, "error", err)
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "Invalid user ID format",
+		})
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := web.BindJSON(c, &req); err != nil {
+		h.logger.Warnw("Invalid request for change password", "error", err)
+		c.JSON(http.StatusBadRequest, invalidRequest(err))
+		return
+	}
+
+	err = h.userUseCase.ChangePassword(c.Request.Context(), usecase.ChangePasswordRequest{
+		ID:          id,
+		NewPassword: req.NewPassword,
+		Reset:       true,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"web-clean/infra/web"
+	"web-clean/internal/application/service"
+	"web-clean/internal/domain/usecase"
+	"web-clean/internal/testing/fixtures"
+)
+
+// passwordUseCase records the request
+type passwordUseCase struct {
+	usecase.UserUseCase
+	got *usecase.ChangePasswordRequest
+	err error
+}
+
+func (u *passwordUseCase) ChangePassword(_ context.Context, req usecase.ChangePasswordRequest) error {
+	u.got = &req
+	return u.err
+}
+
+// postPassword posts as an operator holding ScopeUsersAdmin
+func postPassword(handler *UserHandler, id, body string) *httptest.ResponseRecorder {
+	return postPasswordAs(handler, id, body, &web.Principal{Subject: "apikey:1", Scopes: []string{ScopeUsersAdmin}})
+}
+
+// postPasswordAs posts as principal, or anonymously when principal is nil, through the route's scope check
+func postPasswordAs(handler *UserHandler, id, body string, principal *web.Principal) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if principal != nil {
+		router.Use(func(c *gin.Context) {
+			web.SetPrincipal(c, *principal)
+		})
+	}
+	web.Register(router, []web.RouteMiddleware{web.RequireScope}, web.Route{
+		Method:  http.MethodPost,
+		Path:    "/users/:id/password",
+		Handler: handler.ChangePassword,
+		Scope:   ScopeUsersAdmin,
+	})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/users/"+id+"/password", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestChangePassword(t *testing.T) {
+	useCase := &passwordUseCase{}
+	handler := NewUserHandler(useCase, zap.NewNop().Sugar(), usecase.DefaultPageLimits)
+	id := uuid.New()
+
+	recorder := postPassword(handler, id.String(), `{"new_password":"new-password"}`)
+
+	assert.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Empty(t, recorder.Body.String())
+	assert.Equal(t, id, useCase.got.ID)
+	assert.Equal(t, "new-password", useCase.got.NewPassword)
+	assert.True(t, useCase.got.Reset, "operators reset the password without the current one")
+}
+
+func TestChangePassword_Errors(t *testing.T) {
+	id := uuid.NewString()
+
+	tests := []struct {
+		name   string
+		id     string
+		body   string
+		err    error
+		status int
+		code   string
+	}{
+		{"invalid id", "nope", `{"new_password":"new-password"}`, nil, http.StatusBadRequest, "invalid_id"},
+		{"short password", id, `{"new_password":"short"}`, nil, http.StatusBadRequest, "invalid_request"},
+		{"unknown user", id, `{"new_password":"new-password"}`, service.ErrUserNotFound, http.StatusNotFound, "user_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewUserHandler(&passwordUseCase{err: tt.err}, zap.NewNop().Sugar(), usecase.DefaultPageLimits)
+
+			recorder := postPassword(handler, tt.id, tt.body)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			var body ErrorResponse
+			assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+			assert.Equal(t, tt.code, body.Error)
+		})
+	}
+}
+
+func TestChangePassword_RequiresUsersAdmin(t *testing.T) {
+	id := uuid.NewString()
+	body := `{"new_password":"new-password"}`
+
+	tests := []struct {
+		name      string
+		principal *web.Principal
+		status    int
+		code      string
+	}{
+		{"anonymous", nil, http.StatusUnauthorized, "unauthenticated"},
+		{"the user itself", &web.Principal{Subject: id}, http.StatusForbidden, "forbidden"},
+		{"api key without scope", &web.Principal{Subject: "apikey:1", Scopes: []string{ScopeUsersWrite}}, http.StatusForbidden, "forbidden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCase := &passwordUseCase{}
+			handler := NewUserHandler(useCase, zap.NewNop().Sugar(), usecase.DefaultPageLimits)
+
+			recorder := postPasswordAs(handler, id, body, tt.principal)
+
+			assert.Equal(t, tt.status, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), tt.code)
+			assert.Nil(t, useCase.got, "the use case is not reached")
+		})
+	}
+}
+
+func TestUserResponse_NeverContainsPasswordHash(t *testing.T) {
+	user := fixtures.User().Build()
+	user.PasswordHash = "$2a$10$secrethash"
+
+	data, err := json.Marshal(toUserResponse(user, TimeFormat{}))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "secrethash")
+
+	data, err = json.Marshal(toSCIMUser(user))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "secrethash")
+
+	data, err = json.Marshal(user)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "secrethash", "the entity's own JSON leaves the hash out as well")
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"web-clean/internal/domain/filter"
+	"web-clean/internal/domain/usecase"
+)
+
+// userShorthandFilters maps simple query parameters to filter conditions, for clients that
+// do not want to build a ?filter= expression
+var userShorthandFilters = []struct {
+	param    string
+	field    string
+	operator filter.Operator
+}{
+	{param: "email_like", field: "email", operator: filter.Prefix},
+	{param: "name_contains", field: "name", operator: filter.Contains},
+	{param: "created_after", field: "created_at", operator: filter.Greater},
+	{param: "created_before", field: "created_at", operator: filter.Less},
+}
+
+// parseUserFilter reads ?filter= and the shorthand parameters, all joined with AND
+func parseUserFilter(c *gin.Context) (filter.Expression, error) {
+	expr, err := filter.Parse(c.Query("filter"), usecase.UserFilterFields)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, shorthand := range userShorthandFilters {
+		raw := strings.TrimSpace(c.Query(shorthand.param))
+		if raw == "" {
+			continue
+		}
+		cond, err := filter.NewCondition(shorthand.field, shorthand.operator, raw, usecase.UserFilterFields)
+		if err != nil {
+			return nil, err
+		}
+		expr = append(expr, cond)
+	}
+
+	if len(expr) > filter.MaxConditions {
+		return nil, fmt.Errorf("%w: at most %d conditions are allowed", filter.ErrSyntax, filter.MaxConditions)
+	}
+	return expr, nil
+}
@@ -0,0 +1,38 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"web-clean/internal/domain/filter"
+)
+
+func TestParseUserFilter_Shorthands(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, `/?filter=username="bob"&email_like=bo&name_contains=Smith&created_after=2024-01-01&created_before=2024-02-01`, nil)
+
+	expr, err := parseUserFilter(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, filter.Expression{
+		{Field: "username", Operator: filter.Equal, Value: "bob"},
+		{Field: "email", Operator: filter.Prefix, Value: "bo"},
+		{Field: "name", Operator: filter.Contains, Value: "Smith"},
+		{Field: "created_at", Operator: filter.Greater, Value: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Field: "created_at", Operator: filter.Less, Value: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}, expr)
+}
+
+func TestParseUserFilter_InvalidShorthand(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/?created_after=yesterday", nil)
+
+	_, err := parseUserFilter(c)
+
+	assert.ErrorIs(t, err, filter.ErrInvalidValue)
+}
@@ -0,0 +1,57 @@
+package fixtures
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUser_DefaultsAreValidAndUnique(t *testing.T) {
+	// Act
+	a := User().Build()
+	b := User().Build()
+
+	// Assert
+	assert.True(t, a.IsValid())
+	assert.NotEqual(t, a.ID, b.ID)
+	assert.NotEqual(t, a.Email, b.Email)
+	assert.NotEqual(t, a.Username, b.Username)
+}
+
+func TestUserBuilder_Overrides(t *testing.T) {
+	// Arrange
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Act
+	user := User().WithEmail("alice@example.com").WithName("Alice").CreatedAt(created).Build()
+
+	// Assert
+	assert.Equal(t, "alice@example.com", user.Email.String())
+	assert.Equal(t, "Alice", user.Name)
+	assert.Equal(t, created, user.CreatedAt)
+	assert.Equal(t, created, user.UpdatedAt)
+}
+
+func TestUserBuilder_BuildReturnsCopies(t *testing.T) {
+	// Arrange
+	builder := User()
+
+	// Act
+	first := builder.Build()
+	first.Name = "changed"
+	second := builder.Build()
+
+	// Assert
+	assert.NotEqual(t, "changed", second.Name)
+}
+
+func TestUsers_OrderedByCreatedAtDescending(t *testing.T) {
+	// Act
+	users := Users(3)
+
+	// Assert
+	assert.Len(t, users, 3)
+	assert.True(t, users[0].CreatedAt.After(users[1].CreatedAt))
+	assert.True(t, users[1].CreatedAt.After(users[2].CreatedAt))
+}
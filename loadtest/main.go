@@ -0,0 +1,195 @@
+// Command loadtest drives a constant request rate against a running server and records latency
+// percentiles in a baseline file. Comparing a run with a previous baseline fails when p95 latency or
+// the error rate regress beyond the tolerance, so performance-sensitive changes can be validated.
+//
+//	go run ./loadtest -target http://localhost:9000 -rate 200 -duration 30s -out after.json -baseline before.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Scenario is one endpoint under load
+type Scenario struct {
+	Name string
+	Path string
+}
+
+// scenarios are the read hot paths of the API
+var scenarios = []Scenario{
+	{Name: "health", Path: "/health"},
+	{Name: "list_users_v1", Path: "/api/v1/users?limit=10"},
+	{Name: "list_users_v2", Path: "/api/v2/users?limit=10"},
+}
+
+// Result is the baseline record of one scenario
+type Result struct {
+	Scenario  string        `json:"scenario"`
+	Requests  int           `json:"requests"`
+	Errors    int           `json:"errors"`
+	ErrorRate float64       `json:"error_rate"`
+	P50       time.Duration `json:"p50_ns"`
+	P95       time.Duration `json:"p95_ns"`
+	P99       time.Duration `json:"p99_ns"`
+	Max       time.Duration `json:"max_ns"`
+}
+
+// Baseline is the file format written with -out and read with -baseline
+type Baseline struct {
+	Target   string        `json:"target"`
+	Rate     int           `json:"rate"`
+	Duration time.Duration `json:"duration_ns"`
+	Results  []Result      `json:"results"`
+}
+
+func main() {
+	target := flag.String("target", "http://localhost:9000", "base URL of the server under test")
+	rate := flag.Int("rate", 100, "requests per second per scenario")
+	duration := flag.Duration("duration", 10*time.Second, "how long each scenario runs")
+	out := flag.String("out", "", "write the results to this baseline file")
+	baseline := flag.String("baseline", "", "compare the results with this baseline file")
+	tolerance := flag.Float64("tolerance", 0.2, "allowed relative p95 regression against the baseline")
+	flag.Parse()
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	run := Baseline{Target: *target, Rate: *rate, Duration: *duration}
+	for _, scenario := range scenarios {
+		result := attack(client, *target+scenario.Path, *rate, *duration)
+		result.Scenario = scenario.Name
+		run.Results = append(run.Results, result)
+		fmt.Printf("%-16s requests=%d errors=%d p50=%s p95=%s p99=%s max=%s\n",
+			result.Scenario, result.Requests, result.Errors, result.P50, result.P95, result.P99, result.Max)
+	}
+
+	if *out != "" {
+		if err := writeBaseline(*out, run); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", *out, err)
+			os.Exit(1)
+		}
+	}
+
+	if *baseline != "" {
+		previous, err := readBaseline(*baseline)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read %s: %v\n", *baseline, err)
+			os.Exit(1)
+		}
+		if regressions := compare(previous, run, *tolerance); len(regressions) > 0 {
+			for _, regression := range regressions {
+				fmt.Fprintln(os.Stderr, "REGRESSION", regression)
+			}
+			os.Exit(1)
+		}
+		fmt.Println("no regressions against", *baseline)
+	}
+}
+
+// attack sends requests at a constant rate and collects latencies; non-2xx responses count as errors
+func attack(client *http.Client, url string, rate int, duration time.Duration) Result {
+	ticker := time.NewTicker(time.Second / time.Duration(rate))
+	defer ticker.Stop()
+	deadline := time.After(duration)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	latencies := make([]time.Duration, 0, rate*int(duration/time.Second))
+	errors := 0
+
+loop:
+	for {
+		select {
+		case <-deadline:
+			break loop
+		case <-ticker.C:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				start := time.Now()
+				resp, err := client.Get(url)
+				elapsed := time.Since(start)
+				failed := err != nil
+				if err == nil {
+					failed = resp.StatusCode < 200 || resp.StatusCode >= 300
+					resp.Body.Close()
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				latencies = append(latencies, elapsed)
+				if failed {
+					errors++
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	return summarize(latencies, errors)
+}
+
+func summarize(latencies []time.Duration, errors int) Result {
+	result := Result{Requests: len(latencies), Errors: errors}
+	if len(latencies) == 0 {
+		return result
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	percentile := func(p float64) time.Duration {
+		return latencies[int(p*float64(len(latencies)-1))]
+	}
+
+	result.ErrorRate = float64(errors) / float64(len(latencies))
+	result.P50 = percentile(0.50)
+	result.P95 = percentile(0.95)
+	result.P99 = percentile(0.99)
+	result.Max = latencies[len(latencies)-1]
+	return result
+}
+
+// compare reports scenarios whose p95 grew beyond tolerance or whose error rate increased
+func compare(previous, current Baseline, tolerance float64) []string {
+	before := make(map[string]Result, len(previous.Results))
+	for _, result := range previous.Results {
+		before[result.Scenario] = result
+	}
+
+	regressions := make([]string, 0)
+	for _, result := range current.Results {
+		old, ok := before[result.Scenario]
+		if !ok {
+			continue
+		}
+		if limit := time.Duration(float64(old.P95) * (1 + tolerance)); old.P95 > 0 && result.P95 > limit {
+			regressions = append(regressions, fmt.Sprintf("%s: p95 %s > %s (baseline %s)", result.Scenario, result.P95, limit, old.P95))
+		}
+		if result.ErrorRate > old.ErrorRate {
+			regressions = append(regressions, fmt.Sprintf("%s: error rate %.4f > baseline %.4f", result.Scenario, result.ErrorRate, old.ErrorRate))
+		}
+	}
+	return regressions
+}
+
+func writeBaseline(path string, baseline Baseline) error {
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func readBaseline(path string) (Baseline, error) {
+	var baseline Baseline
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return baseline, err
+	}
+	return baseline, json.Unmarshal(data, &baseline)
+}
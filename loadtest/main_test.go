@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarize(t *testing.T) {
+	latencies := make([]time.Duration, 0, 100)
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	result := summarize(latencies, 5)
+
+	assert.Equal(t, 100, result.Requests)
+	assert.Equal(t, 0.05, result.ErrorRate)
+	assert.Equal(t, 50*time.Millisecond, result.P50)
+	assert.Equal(t, 95*time.Millisecond, result.P95)
+	assert.Equal(t, 100*time.Millisecond, result.Max)
+}
+
+func TestCompare(t *testing.T) {
+	previous := Baseline{Results: []Result{{Scenario: "health", P95: 10 * time.Millisecond}}}
+
+	within := Baseline{Results: []Result{{Scenario: "health", P95: 11 * time.Millisecond}}}
+	assert.Empty(t, compare(previous, within, 0.2))
+
+	slower := Baseline{Results: []Result{{Scenario: "health", P95: 13 * time.Millisecond, ErrorRate: 0.01}}}
+	assert.Len(t, compare(previous, slower, 0.2), 2)
+}
@@ -0,0 +1,261 @@
+// Package app runs web-clean as a library: another project embeds the framework (configuration,
+// database, the middleware stack, health checks and background tasks) and contributes its own
+// routes as modules instead of forking cmd/main.go.
+//
+//	err := app.New(app.Config{Service: "billing"}).
+//		WithModule(app.NewModule("invoices", invoices.Register)).
+//		Run(ctx)
+//
+// Database models are registered with database.RegisterSchema from an init function, as the
+// repositories in this repository do, so they are migrated before any module is registered.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"web-clean/infra"
+	"web-clean/infra/database"
+	"web-clean/infra/health"
+	"web-clean/infra/loader"
+	byenv "web-clean/infra/loader/env"
+	byjson "web-clean/infra/loader/json"
+	"web-clean/infra/scheduler"
+	"web-clean/infra/web"
+)
+
+var (
+	ErrNoModules       = errors.New("no modules registered")
+	ErrInvalidModule   = errors.New("invalid module")
+	ErrDuplicateModule = errors.New("duplicate module")
+)
+
+// DefaultService is the service name reported by GET /health when Config.Service is empty
+const DefaultService = "web-clean"
+
+// DefaultLoader reads the JSON config file and its WEBCLEAN_PROFILE overlay, then lets
+// WEBCLEAN_* environment variables override it
+var DefaultLoader = loader.Chain(loader.Profiles(byjson.JSONLoader), byenv.EnvLoader)
+
+// Config configures an embedded application
+type Config struct {
+	// Loader reads the configuration, DefaultLoader when nil
+	Loader loader.Loader
+	// Service is reported by GET /health, DefaultService when empty
+	Service string
+	// ErrorsFallbackPath is where error stacks are written while the database is unavailable,
+	// DefaultErrorsFallbackPath when empty
+	ErrorsFallbackPath string
+}
+
+// Module contributes routes, background tasks and health checks to an application
+type Module interface {
+	// Name identifies the module in logs and must be unique within an application
+	Name() string
+	// Register is called once, after migrations and before the server starts listening
+	Register(host *Host) error
+}
+
+type module struct {
+	name     string
+	register func(host *Host) error
+}
+
+func (m module) Name() string {
+	return m.name
+}
+
+func (m module) Register(host *Host) error {
+	return m.register(host)
+}
+
+// NewModule creates a module from its registration function
+func NewModule(name string, register func(host *Host) error) Module {
+	return module{name: name, register: register}
+}
+
+// Host is what a module registers against
+type Host struct {
+	*infra.Context
+	Database database.Database
+	// Engine serves every route behind the global middleware stack
+	Engine *gin.Engine
+	// Tasks run in the background once all modules are registered
+	Tasks *scheduler.Scheduler
+	// Health checks are reported by GET /ready
+	Health *health.Registry
+
+	routeMiddlewares []web.RouteMiddleware
+}
+
+// Routes registers declarative routes on router, applying their rate limit, scope, cache and strict JSON metadata
+func (h *Host) Routes(router gin.IRoutes, routes ...web.Route) {
+	web.Register(router, h.routeMiddlewares, routes...)
+}
+
+// App is an embeddable web-clean application
+type App struct {
+	config  Config
+	modules []Module
+}
+
+// New creates an application; modules are added with WithModule
+func New(config Config) *App {
+	if config.Loader == nil {
+		config.Loader = DefaultLoader
+	}
+	if config.Service == "" {
+		config.Service = DefaultService
+	}
+	if config.ErrorsFallbackPath == "" {
+		config.ErrorsFallbackPath = DefaultErrorsFallbackPath
+	}
+	return &App{config: config}
+}
+
+// WithModule adds modules, registered in the order they are added
+func (a *App) WithModule(modules ...Module) *App {
+	a.modules = append(a.modules, modules...)
+	return a
+}
+
+// Run loads the configuration, connects and migrates the database, registers the modules and
+// serves until ctx is cancelled or the process receives SIGINT or SIGTERM
+func (a *App) Run(ctx context.Context) error {
+	if err := a.validate(); err != nil {
+		return err
+	}
+
+	server, err := a.build(ctx)
+	if err != nil {
+		return err
+	}
+
+	server.Serve()
+	return nil
+}
+
+// validate rejects an application whose modules cannot all be registered
+func (a *App) validate() error {
+	if len(a.modules) == 0 {
+		return ErrNoModules
+	}
+
+	seen := make(map[string]bool, len(a.modules))
+	for i, m := range a.modules {
+		if m == nil || m.Name() == "" {
+			return fmt.Errorf("%w: module %d has no name", ErrInvalidModule, i)
+		}
+		if seen[m.Name()] {
+			return fmt.Errorf("%w: %s", ErrDuplicateModule, m.Name())
+		}
+		seen[m.Name()] = true
+	}
+	return nil
+}
+
+// build wires the framework and the modules together and returns the server without listening
+func (a *App) build(ctx context.Context) (web.Web, error) {
+	infraContext, err := infra.Prepare(infra.PrepareConfig{Loader: a.config.Loader})
+	if err != nil {
+		return nil, err
+	}
+	// Cancelling ctx shuts the server down and stops the background tasks
+	infraContext.Ctx = ctx
+
+	db, err := database.From(infraContext)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := migrate(infraContext, db); err != nil {
+		return nil, err
+	}
+
+	persistence, err := NewPersistence(infraContext, db, a.config.ErrorsFallbackPath)
+	if err != nil {
+		return nil, err
+	}
+
+	readOnly := infraContext.Conf.Database.ReadOnly
+	host := &Host{
+		Context:  infraContext,
+		Database: db,
+		Tasks:    scheduler.New(infraContext.Log),
+		Health:   health.NewRegistry(),
+		routeMiddlewares: []web.RouteMiddleware{
+			web.NewRateLimiter(infraContext.Conf.Web.RateLimits).Route,
+			web.RequireScope,
+			web.CacheControl,
+			web.StrictJSON,
+		},
+	}
+
+	host.Health.Register(health.Check{
+		Name:     "database",
+		Severity: health.Critical,
+		Probe:    db.Ping,
+	})
+	for _, check := range persistence.Checks() {
+		host.Health.Register(check)
+	}
+	if !readOnly {
+		if err := host.Tasks.Register(persistence.ReplayTask()); err != nil {
+			return nil, err
+		}
+	}
+
+	var registerErr error
+	server := web.Gin(infraContext, func(engine *gin.Engine) {
+		host.Engine = engine
+
+		Stack{
+			Context:  infraContext,
+			Database: db,
+			Logs:     persistence.Logs,
+			Errors:   persistence.Errors,
+			Redactor: web.RedactorFrom(infraContext.Conf.Logger),
+		}.Apply(engine)
+
+		Probes(engine, a.config.Service, host.Health)
+
+		for _, m := range a.modules {
+			if err := m.Register(host); err != nil {
+				registerErr = fmt.Errorf("module %s: %w", m.Name(), err)
+				return
+			}
+			infraContext.Log.Infow("Module registered", "module", m.Name())
+		}
+	})
+	if registerErr != nil {
+		return nil, registerErr
+	}
+
+	host.Tasks.Start(ctx)
+	return server, nil
+}
+
+// migrate auto-migrates the registered models, or fails on drift it would have fixed when
+// auto-migration is disabled or the database is read-only
+func migrate(ctx *infra.Context, db database.Database) error {
+	drifts, err := database.Diff(db)
+	if err != nil {
+		return err
+	}
+	for _, drift := range drifts {
+		ctx.Log.Warnw("Schema drift detected", "kind", drift.Kind, "drift", drift.String())
+	}
+
+	if ctx.Conf.Database.AutoMigrateEnabled() && !ctx.Conf.Database.ReadOnly {
+		return database.AutoMigrateRegisteredSchema(db)
+	}
+	for _, drift := range drifts {
+		if drift.Fixable() {
+			return fmt.Errorf("the schema is not migrated and auto-migration is disabled or read-only: %s", drift)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"web-clean/infra"
+	"web-clean/infra/conf"
+	"web-clean/infra/health"
+	"web-clean/infra/loader"
+	"web-clean/infra/web"
+)
+
+func noop(*Host) error { return nil }
+
+type failingLoader struct{ err error }
+
+func (l failingLoader) Load(*loader.Context) (*conf.Conf, error) {
+	return nil, l.err
+}
+
+type nopLogs struct{}
+
+func (nopLogs) Persist(string, []web.Log) error { return nil }
+
+type recordedErrors struct{ errors []web.Errors }
+
+func (r *recordedErrors) Persist(errors web.Errors) {
+	r.errors = append(r.errors, errors)
+}
+
+type savedRecordings struct{ saved []web.Recording }
+
+func (s *savedRecordings) Save(_ context.Context, recording web.Recording) error {
+	s.saved = append(s.saved, recording)
+	return nil
+}
+
+func (s *savedRecordings) List(context.Context, web.RecordingQuery) ([]web.Recording, error) {
+	return s.saved, nil
+}
+
+func (s *savedRecordings) DeleteBefore(context.Context, time.Time) (int64, error) { return 0, nil }
+
+func TestNew_Defaults(t *testing.T) {
+	a := New(Config{})
+
+	assert.Equal(t, DefaultLoader, a.config.Loader)
+	assert.Equal(t, DefaultService, a.config.Service)
+	assert.Equal(t, DefaultErrorsFallbackPath, a.config.ErrorsFallbackPath)
+}
+
+func TestWithModule_KeepsOrder(t *testing.T) {
+	a := New(Config{}).
+		WithModule(NewModule("billing", noop)).
+		WithModule(NewModule("invoices", noop), NewModule("reports", noop))
+
+	names := make([]string, 0, len(a.modules))
+	for _, m := range a.modules {
+		names = append(names, m.Name())
+	}
+	assert.Equal(t, []string{"billing", "invoices", "reports"}, names)
+}
+
+func TestRun_RejectsInvalidModules(t *testing.T) {
+	tests := []struct {
+		name    string
+		modules []Module
+		want    error
+	}{
+		{name: "none", want: ErrNoModules},
+		{name: "nil", modules: []Module{nil}, want: ErrInvalidModule},
+		{name: "unnamed", modules: []Module{NewModule("", noop)}, want: ErrInvalidModule},
+		{name: "duplicate", modules: []Module{NewModule("billing", noop), NewModule("billing", noop)}, want: ErrDuplicateModule},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(Config{}).WithModule(tt.modules...).Run(context.Background())
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestRun_ReturnsConfigError(t *testing.T) {
+	loadErr := errors.New("config unavailable")
+
+	err := New(Config{Loader: failingLoader{err: loadErr}}).WithModule(NewModule("billing", noop)).Run(context.Background())
+	assert.ErrorIs(t, err, loadErr)
+}
+
+func TestHost_Routes_AppliesRouteMetadata(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	host := &Host{Engine: engine, routeMiddlewares: []web.RouteMiddleware{web.CacheControl}}
+
+	host.Routes(engine.Group("/billing"), web.Route{
+		Method:   http.MethodGet,
+		Path:     "/plans",
+		CacheTTL: time.Minute,
+		Handler:  func(c *gin.Context) { c.Status(http.StatusOK) },
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/billing/plans", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "private, max-age=60", w.Header().Get("Cache-Control"))
+}
+
+func testStack(readOnly bool, errs *recordedErrors) Stack {
+	return Stack{
+		Context: &infra.Context{
+			Log: zap.NewNop().Sugar(),
+			Conf: &conf.Conf{
+				Web:      &conf.Web{},
+				Database: &conf.DatabaseConf{ReadOnly: readOnly},
+			},
+		},
+		Logs:   nopLogs{},
+		Errors: errs,
+	}
+}
+
+func TestStack_Apply(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("provides the request context", func(t *testing.T) {
+		engine := gin.New()
+		testStack(false, &recordedErrors{}).Apply(engine)
+		engine.GET("/ping", func(c *gin.Context) {
+			requestID, ok := web.From(c, web.RequestIDKey)
+			require.True(t, ok)
+			c.String(http.StatusOK, requestID)
+		})
+
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ping", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEmpty(t, w.Body.String())
+	})
+
+	t.Run("recovers panics and persists the stack", func(t *testing.T) {
+		errs := &recordedErrors{}
+		engine := gin.New()
+		testStack(false, errs).Apply(engine)
+		engine.GET("/boom", func(*gin.Context) { panic("boom") })
+
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/boom", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), "internal_server_error")
+		assert.Len(t, errs.errors, 1)
+	})
+
+	t.Run("rejects writes in read-only mode", func(t *testing.T) {
+		engine := gin.New()
+		testStack(true, &recordedErrors{}).Apply(engine)
+		engine.POST("/items", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("does not record requests in read-only mode", func(t *testing.T) {
+		for _, readOnly := range []bool{false, true} {
+			store := &savedRecordings{}
+			stack := testStack(readOnly, &recordedErrors{})
+			stack.Recorder = web.NewRecorder(store, nil, zap.NewNop().Sugar())
+			_, err := stack.Recorder.Start("", ".*", time.Minute)
+			require.NoError(t, err)
+
+			engine := gin.New()
+			stack.Apply(engine)
+			engine.GET("/items", func(c *gin.Context) { c.Status(http.StatusOK) })
+			engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items", nil))
+
+			assert.Equal(t, !readOnly, len(store.saved) == 1, "read-only: %v", readOnly)
+		}
+	})
+}
+
+func TestProbes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	checks := health.NewRegistry()
+	checks.Register(health.Check{
+		Name:     "database",
+		Severity: health.Critical,
+		Probe:    func(context.Context) error { return errors.New("connection refused") },
+	})
+	Probes(engine, "billing", checks)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"service":"billing"`)
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "connection refused")
+}
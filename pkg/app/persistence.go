@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"web-clean/infra"
+	"web-clean/infra/database"
+	"web-clean/infra/health"
+	"web-clean/infra/httpclient"
+	"web-clean/infra/metrics"
+	"web-clean/infra/scheduler"
+	"web-clean/infra/sink"
+	"web-clean/infra/web"
+	"web-clean/repository"
+)
+
+// DefaultErrorsFallbackPath is where error stacks are written when the database is unavailable
+const DefaultErrorsFallbackPath = "./errors"
+
+// errorsReplayInterval is how often fallback error files are replayed into the database
+const errorsReplayInterval = time.Minute
+
+// Persistence stores request logs and error stacks, in the database or the configured external sink.
+// Against a read-only replica, request logs are only written by the logger and error stacks go to files
+type Persistence struct {
+	Logs   web.LogPersister
+	Errors web.ErrorStackPersister
+	// Fallback is the database persister; its error stacks go to files while the database is unavailable
+	Fallback repository.Errors
+}
+
+// NewPersistence validates the persistence config and builds the persisters, counted in /admin/metrics
+func NewPersistence(ctx *infra.Context, db database.Database, fallbackPath string) (*Persistence, error) {
+	// Validate how log/error payloads are stored before the first request hits the persisters
+	if _, err := repository.NewPayloadCodec(ctx.Conf.Persistence); err != nil {
+		return nil, err
+	}
+
+	fallbackMaxBytes, fallbackMaxAge := ctx.Conf.Persistence.FallbackQuota()
+	fallback := repository.Errors{
+		Context:          ctx,
+		FallbackFilePath: fallbackPath,
+		Quota:            repository.FallbackQuota{MaxBytes: fallbackMaxBytes, MaxAge: fallbackMaxAge},
+		Database:         db,
+		ReadOnly:         ctx.Conf.Database.ReadOnly,
+	}
+
+	// Optional external sink for logs and errors; errors fall back to the database when it is unreachable
+	externalSink, err := sink.From(ctx.Conf.Sink, httpclient.New("sink", ctx.Log, httpclient.Default()))
+	if err != nil {
+		return nil, err
+	}
+
+	var logs web.LogPersister = &repository.Logs{Context: ctx, Database: db, ReadOnly: ctx.Conf.Database.ReadOnly}
+	var errors web.ErrorStackPersister = fallback
+	if externalSink != nil {
+		logs = sink.LogPersister(externalSink)
+		errors = sink.ErrorPersister(externalSink, fallback, ctx.Log)
+	}
+
+	return &Persistence{
+		Logs:     metrics.LogPersister(logs),
+		Errors:   metrics.ErrorPersister(errors),
+		Fallback: fallback,
+	}, nil
+}
+
+// ReplayTask re-ingests error stacks that fell back to files while the database was unavailable
+func (p *Persistence) ReplayTask() scheduler.Task {
+	return scheduler.Task{
+		Name:     "errors_replay",
+		Interval: errorsReplayInterval,
+		Run:      p.Fallback.ReplayAndRotate,
+	}
+}
+
+// Checks reports a fallback directory that cannot be written and error stacks going to files
+func (p *Persistence) Checks() []health.Check {
+	return []health.Check{
+		{
+			Name:     "error_fallback_dir",
+			Severity: health.Degraded,
+			Probe: func(context.Context) error {
+				return p.Fallback.CheckWritable()
+			},
+		},
+		{
+			Name:     "error_fallback_mode",
+			Severity: health.Degraded,
+			Probe: func(context.Context) error {
+				return p.Fallback.CheckFallback()
+			},
+		},
+	}
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"web-clean/domain"
+	"web-clean/infra"
+	"web-clean/infra/budget"
+	"web-clean/infra/chaos"
+	"web-clean/infra/database"
+	"web-clean/infra/health"
+	"web-clean/infra/propagation"
+	"web-clean/infra/web"
+)
+
+// Stack is the global middleware every request passes through, shared by the web-clean
+// server and embedding applications
+type Stack struct {
+	Context  *infra.Context
+	Database database.Database
+	Logs     web.LogPersister
+	Errors   web.ErrorStackPersister
+	// Redactor masks passwords, tokens and auth headers in request logs, nil logs them unchanged
+	Redactor *web.Redactor
+	// Recorder records full request/response pairs matching admin-created rules, nil disables recording;
+	// recording is also disabled against a read-only replica
+	Recorder *web.Recorder
+	// Providers register request-scoped dependencies on every web.Context; the request ID is always provided
+	Providers []web.Provider
+}
+
+// Apply installs the stack on engine; routes registered afterwards run behind it
+func (s Stack) Apply(engine *gin.Engine) {
+	conf := s.Context.Conf
+
+	engine.Use(web.RequestIDMiddleware(func() string {
+		return uuid.NewString()
+	}))
+
+	// One line per request; requests whose client disconnected are logged with status 499
+	engine.Use(web.AccessLogMiddleware(s.Context.Log))
+
+	// Trace context, baggage, request and tenant IDs flow to every outbound HTTP call
+	engine.Use(web.PropagationMiddleware(propagation.NewAllowlist(conf.Web.PropagateHeaders)))
+
+	engine.Use(web.ErrorPersisterMiddleware(s.Errors, s.Context.Log, web.RequestIdGetter, s.Redactor))
+
+	// Outside recovery so that recorded responses include the 500 written for a panic
+	if s.Recorder != nil && !conf.Database.ReadOnly {
+		engine.Use(s.Recorder.Middleware())
+	}
+
+	engine.Use(web.RecoverWithError(func(context *gin.Context, err *web.PanicError) {
+		// Handle panics gracefully; the stack is persisted by ErrorPersister, never returned
+		context.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error":      "internal_server_error",
+			"message":    "An internal error occurred",
+			"kind":       err.Kind,
+			"request_id": web.RequestIdGetter(context),
+		})
+	}))
+
+	if conf.Database.ReadOnly {
+		engine.Use(web.ReadOnlyMiddleware())
+	}
+
+	// Fault injection for resilience testing; never enabled in production mode
+	if chaos.Enabled(conf) {
+		s.Context.Log.Warnw("Chaos middleware enabled", "routes", conf.Chaos.Routes, "headers", conf.Chaos.Headers)
+		engine.Use(web.ChaosMiddleware(conf.Chaos))
+	}
+
+	// Per-request database budget; statements beyond it fail and the request gets a 503
+	if limits := budget.LimitsFrom(conf.Database); limits.Enabled() {
+		engine.Use(web.BudgetMiddleware(limits, s.Context.Log))
+	}
+
+	providers := append([]web.Provider{web.RequestIDProvider}, s.Providers...)
+	engine.Use(web.ContextMiddleware(func(log domain.Log) *web.Context {
+		return &web.Context{
+			Database: s.Database,
+			Log:      log,
+		}
+	}, s.Context.Log, s.Logs, s.Redactor, providers...))
+}
+
+// Probes registers GET /health, reporting service as alive, and GET /ready, which answers 503
+// only when a critical dependency in checks is down and reports degraded ones
+func Probes(router gin.IRoutes, service string, checks *health.Registry) {
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "healthy",
+			"service": service,
+		})
+	})
+
+	router.GET("/ready", func(c *gin.Context) {
+		report := checks.Run(c.Request.Context())
+		status := http.StatusOK
+		if !report.Ready() {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	})
+}
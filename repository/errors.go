@@ -1,22 +1,31 @@
 package repository
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 
 	"web-clean/infra"
 	"web-clean/infra/database"
+	"web-clean/infra/metrics"
 	"web-clean/infra/web"
 )
 
 type ErrorModel struct {
 	gorm.Model
-	Error web.Errors `gorm:"type:jsonb"`
+	RequestID string     `gorm:"index"`
+	Error     web.Errors `gorm:"type:jsonb"`
+
+	// StackGzip 在 gzip 模式下保存压缩后的 Error.Stack，此时 Error.Stack 为空，其余字段仍可查询
+	StackGzip []byte `gorm:"type:bytea"`
 }
 
 func init() {
@@ -27,22 +36,72 @@ type Errors struct {
 	*infra.Context
 
 	FallbackFilePath string
+	// Quota 限制回退目录的大小与保留时间，零值表示不限制
+	Quota FallbackQuota
 
 	Database database.Database
+	// ReadOnly 表示数据库是只读副本，此时错误堆栈直接写入回退文件
+	ReadOnly bool
 }
 
 func (e Errors) Persist(errors web.Errors) {
+	if e.ReadOnly {
+		e.fallback(errors)
+		return
+	}
+
 	err := e.Database.Transaction(func(tx *gorm.DB) error {
-		return tx.Create(&ErrorModel{
-			Error: errors,
-		}).Error
+		model, err := e.model(errors)
+		if err != nil {
+			return err
+		}
+		return tx.Create(model).Error
 	})
 	if err != nil {
-		err := e.saveToFile(errors)
+		metrics.Errors.Failures.Inc()
+		e.fallback(errors)
+	}
+}
+
+// fallback 将错误堆栈写入回退文件，并按 Quota 清理回退目录
+func (e Errors) fallback(errors web.Errors) {
+	metrics.Errors.Fallbacks.Inc()
+
+	err := e.saveToFile(errors)
+
+	if err != nil {
+		e.Log.Errorw("无法向错误回退文件写入错误堆栈", "err", err, "errors", errors)
+	}
+
+	if _, err := e.Rotate(time.Now()); err != nil {
+		e.Log.Warnw("错误回退目录清理失败", "err", err)
+	}
+}
+
+// model 按配置的 PayloadCodec 构造待写入的记录
+func (e Errors) model(rec web.Errors) (*ErrorModel, error) {
+	codec, err := payloadCodecOf(e.Context)
+	if err != nil {
+		return nil, err
+	}
 
+	switch codec.Mode {
+	case PayloadGzip:
+		data, err := codec.compress(rec.Stack)
+		if err != nil {
+			return nil, err
+		}
+		rec.Stack = nil
+		return &ErrorModel{RequestID: rec.RequestID, Error: rec, StackGzip: data}, nil
+	case PayloadTrim:
+		stack, err := codec.trimAny(rec.Stack)
 		if err != nil {
-			e.Log.Errorw("无法向错误数据库写入错误堆栈，也无法向错误文件写入", "err", err, "errors", errors)
+			return nil, err
 		}
+		rec.Stack = stack
+		return &ErrorModel{RequestID: rec.RequestID, Error: rec}, nil
+	default:
+		return &ErrorModel{RequestID: rec.RequestID, Error: rec}, nil
 	}
 }
 
@@ -69,3 +128,142 @@ func (e Errors) saveToFile(rec web.Errors) error {
 	_, err = f.Write(data)
 	return err
 }
+
+// CheckWritable 检查错误回退目录是否可写，用于启动前自检
+func (e Errors) CheckWritable() error {
+	if err := os.MkdirAll(e.FallbackFilePath, 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(e.FallbackFilePath, ".writable_check_*")
+	if err != nil {
+		return err
+	}
+
+	name := f.Name()
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(name)
+}
+
+// archivedDirName 是回放成功的错误文件被移动到的子目录名称
+const archivedDirName = "archived"
+
+// ReplayResult 描述一次回放的结果
+type ReplayResult struct {
+	// Files 成功回放并归档的文件数
+	Files int
+	// Records 写入数据库的错误记录数
+	Records int
+	// Skipped 无法解析而被跳过的文件
+	Skipped []string
+}
+
+// Replay 将数据库不可用时写入回退目录的错误文件重新写入 ErrorModel，成功的文件会被移动到 archived 子目录。
+//
+// 单个文件的记录在同一个事务中写入；一旦写库失败就停止回放，剩余文件留待下次处理
+func (e Errors) Replay() (ReplayResult, error) {
+	result := ReplayResult{Skipped: make([]string, 0)}
+
+	entries, err := os.ReadDir(e.FallbackFilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+
+	archivedDir := filepath.Join(e.FallbackFilePath, archivedDirName)
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, "error_") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+
+		path := filepath.Join(e.FallbackFilePath, name)
+
+		records, err := readFallbackFile(path)
+		if err != nil {
+			e.Log.Warnw("无法解析错误回退文件，跳过", "file", path, "err", err)
+			result.Skipped = append(result.Skipped, path)
+			continue
+		}
+
+		err = e.Database.Transaction(func(tx *gorm.DB) error {
+			for _, record := range records {
+				model, err := e.model(record)
+				if err != nil {
+					return err
+				}
+				if err := tx.Create(model).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return result, err
+		}
+
+		// 归档失败时必须停止，否则下次回放会重复写入
+		if err := os.MkdirAll(archivedDir, 0o755); err != nil {
+			return result, err
+		}
+		if err := os.Rename(path, filepath.Join(archivedDir, name)); err != nil {
+			return result, err
+		}
+
+		result.Files++
+		result.Records += len(records)
+	}
+
+	return result, nil
+}
+
+// ReplayAndRotate 执行一次 Replay 并按配额清理回退目录，作为调度器的周期任务注册
+func (e Errors) ReplayAndRotate(context.Context) error {
+	result, err := e.Replay()
+	if err != nil {
+		return fmt.Errorf("错误回退文件回放中断（已回放 %d 个文件）：%w", result.Files, err)
+	}
+	if result.Files > 0 {
+		e.Log.Infow("错误回退文件回放完成", "files", result.Files, "records", result.Records, "skipped", result.Skipped)
+	}
+
+	// 已归档的文件同样受配额限制，数据库恢复后也需要清理
+	if _, err := e.Rotate(time.Now()); err != nil {
+		return fmt.Errorf("错误回退目录清理失败：%w", err)
+	}
+	return nil
+}
+
+// readFallbackFile 读取一个回退文件，同名文件被追加写入时会包含多个连续的 JSON 对象
+func readFallbackFile(path string) ([]web.Errors, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records := make([]web.Errors, 0, 1)
+	decoder := json.NewDecoder(f)
+	for {
+		var record web.Errors
+		err := decoder.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	if len(records) == 0 {
+		return nil, errors.New("文件为空")
+	}
+	return records, nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"web-clean/infra"
+	"web-clean/infra/web"
+)
+
+func TestReadFallbackFile_AppendedRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "error_req_20240101T000000.000.json")
+
+	first, _ := json.MarshalIndent(web.Errors{RequestID: "req", Path: "/a"}, "", "  ")
+	second, _ := json.MarshalIndent(web.Errors{RequestID: "req", Path: "/b"}, "", "  ")
+	assert.NoError(t, os.WriteFile(path, append(first, second...), 0o644))
+
+	records, err := readFallbackFile(path)
+
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "/a", records[0].Path)
+	assert.Equal(t, "/b", records[1].Path)
+}
+
+func TestReadFallbackFile_Invalid(t *testing.T) {
+	dir := t.TempDir()
+
+	empty := filepath.Join(dir, "error_empty.json")
+	assert.NoError(t, os.WriteFile(empty, nil, 0o644))
+	_, err := readFallbackFile(empty)
+	assert.Error(t, err)
+
+	broken := filepath.Join(dir, "error_broken.json")
+	assert.NoError(t, os.WriteFile(broken, []byte(`{"Path":`), 0o644))
+	_, err = readFallbackFile(broken)
+	assert.Error(t, err)
+}
+
+func TestReadOnly_SkipsDatabaseWrites(t *testing.T) {
+	ctx := &infra.Context{Log: zap.NewNop().Sugar()}
+	dir := t.TempDir()
+
+	// Database 为 nil，任何数据库写入都会 panic
+	Errors{Context: ctx, FallbackFilePath: dir, ReadOnly: true}.Persist(web.Errors{RequestID: "req", Path: "/a"})
+
+	files, err := filepath.Glob(filepath.Join(dir, "error_req_*.json"))
+	assert.NoError(t, err)
+	if assert.Len(t, files, 1) {
+		records, err := readFallbackFile(files[0])
+		assert.NoError(t, err)
+		assert.Equal(t, "/a", records[0].Path)
+	}
+
+	logs := &Logs{Context: ctx, ReadOnly: true}
+	assert.NoError(t, logs.Persist("req", []web.Log{{Level: "INFO", Msg: "hello"}}))
+}
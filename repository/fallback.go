@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"web-clean/infra/metrics"
+)
+
+// FallbackQuota 限制错误回退目录的总大小与文件保留时间，字段为零表示该项不限制
+type FallbackQuota struct {
+	MaxBytes int64
+	MaxAge   time.Duration
+}
+
+// fallbackFile 是回退目录（含 archived 子目录）中的一个错误文件
+type fallbackFile struct {
+	path     string
+	size     int64
+	modTime  time.Time
+	archived bool
+}
+
+// fallbackFiles 列出回退目录与 archived 子目录中的错误文件，按修改时间从旧到新排序
+func (e Errors) fallbackFiles() ([]fallbackFile, error) {
+	files := make([]fallbackFile, 0)
+
+	for _, dir := range []string{e.FallbackFilePath, filepath.Join(e.FallbackFilePath, archivedDirName)} {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasPrefix(name, "error_") || !strings.HasSuffix(name, ".json") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				// 文件可能刚被回放归档或被其他实例删除
+				continue
+			}
+			files = append(files, fallbackFile{
+				path:     filepath.Join(dir, name),
+				size:     info.Size(),
+				modTime:  info.ModTime(),
+				archived: dir != e.FallbackFilePath,
+			})
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	return files, nil
+}
+
+// Rotate 按 Quota 清理回退目录：先删除超过 MaxAge 的文件，再从最旧的文件开始删除直到总大小不超过 MaxBytes，
+// 返回删除的文件数。被删除的未回放文件中的错误堆栈会丢失，因此每次删除都会记录警告日志
+func (e Errors) Rotate(now time.Time) (int, error) {
+	if e.Quota.MaxBytes <= 0 && e.Quota.MaxAge <= 0 {
+		return 0, nil
+	}
+
+	files, err := e.fallbackFiles()
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, file := range files {
+		total += file.size
+	}
+
+	evicted := 0
+	for _, file := range files {
+		expired := e.Quota.MaxAge > 0 && now.Sub(file.modTime) > e.Quota.MaxAge
+		overQuota := e.Quota.MaxBytes > 0 && total > e.Quota.MaxBytes
+		if !expired && !overQuota {
+			// 文件按时间排序，之后的文件既未过期，删除它们也不再需要
+			break
+		}
+
+		if err := os.Remove(file.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return evicted, err
+		}
+		total -= file.size
+		evicted++
+		metrics.Errors.Evictions.Inc()
+
+		if !file.archived {
+			e.Log.Warnw("错误回退目录超出配额，删除未回放的错误文件", "file", file.path, "expired", expired)
+		}
+	}
+
+	return evicted, nil
+}
+
+// CheckFallback 在回退目录中存在等待回放的错误文件时返回错误，说明错误堆栈正处于回退模式、数据库写入失败
+func (e Errors) CheckFallback() error {
+	files, err := e.fallbackFiles()
+	if err != nil {
+		return err
+	}
+
+	pending := 0
+	var oldest time.Time
+	for _, file := range files {
+		if file.archived {
+			continue
+		}
+		if pending == 0 {
+			oldest = file.modTime
+		}
+		pending++
+	}
+
+	if pending > 0 {
+		return fmt.Errorf("错误堆栈处于回退模式：%d 个文件等待回放，最早写入于 %s", pending, oldest.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"web-clean/infra"
+)
+
+// writeFallbackFile writes size bytes to name under dir and backdates it to modTime
+func writeFallbackFile(t *testing.T, dir, name string, size int, modTime time.Time) string {
+	assert.NoError(t, os.MkdirAll(dir, 0o755))
+	path := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(path, make([]byte, size), 0o644))
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	return path
+}
+
+func fallbackErrors(dir string, quota FallbackQuota) Errors {
+	return Errors{
+		Context:          &infra.Context{Log: zap.NewNop().Sugar()},
+		FallbackFilePath: dir,
+		Quota:            quota,
+	}
+}
+
+func TestErrors_Rotate_EvictsOldestOverSize(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	oldest := writeFallbackFile(t, filepath.Join(dir, archivedDirName), "error_a_1.json", 100, now.Add(-3*time.Minute))
+	older := writeFallbackFile(t, dir, "error_b_2.json", 100, now.Add(-2*time.Minute))
+	newest := writeFallbackFile(t, dir, "error_c_3.json", 100, now.Add(-time.Minute))
+
+	evicted, err := fallbackErrors(dir, FallbackQuota{MaxBytes: 150}).Rotate(now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, evicted)
+	assert.NoFileExists(t, oldest)
+	assert.NoFileExists(t, older)
+	assert.FileExists(t, newest)
+}
+
+func TestErrors_Rotate_EvictsExpired(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	expired := writeFallbackFile(t, dir, "error_a_1.json", 10, now.Add(-48*time.Hour))
+	fresh := writeFallbackFile(t, dir, "error_b_2.json", 10, now.Add(-time.Hour))
+	other := writeFallbackFile(t, dir, "notes.txt", 10, now.Add(-48*time.Hour))
+
+	evicted, err := fallbackErrors(dir, FallbackQuota{MaxAge: 24 * time.Hour}).Rotate(now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, evicted)
+	assert.NoFileExists(t, expired)
+	assert.FileExists(t, fresh)
+	assert.FileExists(t, other)
+}
+
+func TestErrors_Rotate_NoQuota(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFallbackFile(t, dir, "error_a_1.json", 10, time.Now().Add(-365*24*time.Hour))
+
+	evicted, err := fallbackErrors(dir, FallbackQuota{}).Rotate(time.Now())
+
+	assert.NoError(t, err)
+	assert.Zero(t, evicted)
+	assert.FileExists(t, path)
+}
+
+func TestErrors_CheckFallback(t *testing.T) {
+	dir := t.TempDir()
+	errs := fallbackErrors(dir, FallbackQuota{})
+
+	assert.NoError(t, errs.CheckFallback(), "missing directory is not fallback mode")
+
+	writeFallbackFile(t, filepath.Join(dir, archivedDirName), "error_a_1.json", 10, time.Now())
+	assert.NoError(t, errs.CheckFallback(), "archived files are already in the database")
+
+	writeFallbackFile(t, dir, "error_b_2.json", 10, time.Now())
+	err := errs.CheckFallback()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "1 个文件等待回放")
+}
@@ -10,7 +10,11 @@ import (
 
 type LogsModel struct {
 	gorm.Model
-	Logs []web.Log `gorm:"type:jsonb"`
+	RequestID string    `gorm:"index"`
+	Logs      []web.Log `gorm:"type:jsonb"`
+
+	// LogsGzip 在 gzip 模式下保存压缩后的 Logs，此时 Logs 为空
+	LogsGzip []byte `gorm:"type:bytea"`
 }
 
 func init() {
@@ -20,12 +24,48 @@ func init() {
 type Logs struct {
 	*infra.Context
 	database.Database
+
+	// ReadOnly 表示数据库是只读副本，此时不写入数据库，请求日志仍由 Log 输出
+	ReadOnly bool
 }
 
-func (l *Logs) Persist(logs []web.Log) error {
+func (l *Logs) Persist(requestID string, logs []web.Log) error {
+	if l.ReadOnly {
+		return nil
+	}
+
+	model, err := l.model(logs)
+	if err != nil {
+		return err
+	}
+	model.RequestID = requestID
+
 	return l.Database.Transaction(func(tx *gorm.DB) error {
-		return tx.Create(&LogsModel{
-			Logs: logs,
-		}).Error
+		return tx.Create(model).Error
 	})
 }
+
+// model 按配置的 PayloadCodec 构造待写入的记录
+func (l *Logs) model(logs []web.Log) (*LogsModel, error) {
+	codec, err := payloadCodecOf(l.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	switch codec.Mode {
+	case PayloadGzip:
+		data, err := codec.compress(logs)
+		if err != nil {
+			return nil, err
+		}
+		return &LogsModel{LogsGzip: data}, nil
+	case PayloadTrim:
+		trimmed := make([]web.Log, len(logs))
+		for i, log := range logs {
+			trimmed[i] = web.Log{Level: log.Level, Msg: codec.trimString(log.Msg)}
+		}
+		return &LogsModel{Logs: trimmed}, nil
+	default:
+		return &LogsModel{Logs: logs}, nil
+	}
+}
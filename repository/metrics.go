@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"web-clean/infra/database"
+)
+
+// ErrInvalidSpan 表示 window 或 bucket 参数不合法
+var ErrInvalidSpan = errors.New("invalid window or bucket")
+
+const (
+	// MaxSummaryWindow 汇总的最大时间跨度
+	MaxSummaryWindow = 90 * 24 * time.Hour
+	// MinSummaryBucket 最小的分桶粒度
+	MinSummaryBucket = time.Minute
+	// MaxSummaryBuckets 单次汇总最多返回的桶数
+	MaxSummaryBuckets = 2000
+)
+
+// MetricsBucket 是一个时间桶内的计数，Start 为桶的起始时间（含）
+type MetricsBucket struct {
+	Start    time.Time `json:"start"`
+	Requests int64     `json:"requests"`
+	Errors   int64     `json:"errors"`
+	Signups  int64     `json:"signups"`
+}
+
+// MetricsTotals 是整个时间窗口内的计数
+type MetricsTotals struct {
+	Requests int64 `json:"requests"`
+	Errors   int64 `json:"errors"`
+	Signups  int64 `json:"signups"`
+}
+
+// MetricsSummary 是按时间分桶的请求、错误与注册数量
+type MetricsSummary struct {
+	From    time.Time       `json:"from"`
+	To      time.Time       `json:"to"`
+	Bucket  string          `json:"bucket"`
+	Buckets []MetricsBucket `json:"buckets"`
+	Totals  MetricsTotals   `json:"totals"`
+}
+
+// Metrics 从已持久化的数据中统计汇总指标：
+//   - 请求数取自 LogsModel，每个请求写入一行，因此日志写入外部 sink 时为 0
+//   - 错误数取自 ErrorModel
+//   - 注册数取自 SignupsTable 的 created_at，已删除的用户不再计入
+type Metrics struct {
+	Database     database.Database
+	SignupsTable string
+}
+
+// bucketCount 是按桶分组的计数查询结果，Bucket 为桶起始时间的 Unix 秒数
+type bucketCount struct {
+	Bucket int64
+	Count  int64
+}
+
+// Summary 统计截至 now 所在桶结束、长度为 window 的时间窗口，按 bucket 分桶，没有数据的桶计数为 0
+func (m Metrics) Summary(ctx context.Context, now time.Time, window, bucket time.Duration) (*MetricsSummary, error) {
+	if err := validateSpan(window, bucket); err != nil {
+		return nil, err
+	}
+
+	to := now.UTC().Truncate(bucket).Add(bucket)
+	from := to.Add(-window)
+	seconds := int64(bucket / time.Second)
+
+	var requests, errorRows, signups []bucketCount
+	err := m.Database.Transaction(func(tx *gorm.DB) error {
+		tx = tx.WithContext(ctx)
+		if err := countByBucket(tx.Model(&LogsModel{}), seconds, from, to, &requests); err != nil {
+			return err
+		}
+		if err := countByBucket(tx.Model(&ErrorModel{}), seconds, from, to, &errorRows); err != nil {
+			return err
+		}
+		return countByBucket(tx.Table(m.SignupsTable), seconds, from, to, &signups)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return assembleSummary(from, to, bucket, requests, errorRows, signups), nil
+}
+
+// countByBucket 按 created_at 所在桶分组计数，桶边界对齐到 Unix 纪元
+func countByBucket(query *gorm.DB, seconds int64, from, to time.Time, out *[]bucketCount) error {
+	return query.
+		Select("CAST(FLOOR(EXTRACT(EPOCH FROM created_at) / ?) AS BIGINT) * ? AS bucket, COUNT(*) AS count", seconds, seconds).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("1").
+		Scan(out).Error
+}
+
+func assembleSummary(from, to time.Time, bucket time.Duration, requests, errorRows, signups []bucketCount) *MetricsSummary {
+	summary := &MetricsSummary{
+		From:    from,
+		To:      to,
+		Bucket:  FormatSpan(bucket),
+		Buckets: make([]MetricsBucket, 0, int(to.Sub(from)/bucket)),
+	}
+
+	index := make(map[int64]int)
+	for start := from; start.Before(to); start = start.Add(bucket) {
+		index[start.Unix()] = len(summary.Buckets)
+		summary.Buckets = append(summary.Buckets, MetricsBucket{Start: start})
+	}
+
+	for _, row := range requests {
+		if i, ok := index[row.Bucket]; ok {
+			summary.Buckets[i].Requests = row.Count
+			summary.Totals.Requests += row.Count
+		}
+	}
+	for _, row := range errorRows {
+		if i, ok := index[row.Bucket]; ok {
+			summary.Buckets[i].Errors = row.Count
+			summary.Totals.Errors += row.Count
+		}
+	}
+	for _, row := range signups {
+		if i, ok := index[row.Bucket]; ok {
+			summary.Buckets[i].Signups = row.Count
+			summary.Totals.Signups += row.Count
+		}
+	}
+
+	return summary
+}
+
+func validateSpan(window, bucket time.Duration) error {
+	switch {
+	case bucket < MinSummaryBucket || bucket%time.Second != 0:
+		return fmt.Errorf("%w: bucket 不能小于 %s 且必须是整秒", ErrInvalidSpan, FormatSpan(MinSummaryBucket))
+	case window <= 0 || window > MaxSummaryWindow:
+		return fmt.Errorf("%w: window 必须在 0 到 %s 之间", ErrInvalidSpan, FormatSpan(MaxSummaryWindow))
+	case window%bucket != 0:
+		return fmt.Errorf("%w: window 必须是 bucket 的整数倍", ErrInvalidSpan)
+	case window/bucket > MaxSummaryBuckets:
+		return fmt.Errorf("%w: 最多 %d 个桶", ErrInvalidSpan, MaxSummaryBuckets)
+	}
+	return nil
+}
+
+// ParseSpan 解析 7d、12h、30m 形式的时长，在 time.ParseDuration 的基础上支持以天为单位的 d
+func ParseSpan(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidSpan, s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSpan, s)
+	}
+	return d, nil
+}
+
+// FormatSpan 是 ParseSpan 的逆操作，整天的时长格式化为 Nd
+func FormatSpan(d time.Duration) string {
+	day := 24 * time.Hour
+	if d >= day && d%day == 0 {
+		return strconv.Itoa(int(d/day)) + "d"
+	}
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSpan(t *testing.T) {
+	for input, want := range map[string]time.Duration{
+		"7d":  7 * 24 * time.Hour,
+		"1h":  time.Hour,
+		"15m": 15 * time.Minute,
+	} {
+		got, err := ParseSpan(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+		assert.Equal(t, input, FormatSpan(got))
+	}
+
+	for _, input := range []string{"", "d", "-1d", "soon"} {
+		_, err := ParseSpan(input)
+		assert.True(t, errors.Is(err, ErrInvalidSpan), input)
+	}
+}
+
+func TestValidateSpan(t *testing.T) {
+	assert.NoError(t, validateSpan(7*24*time.Hour, time.Hour))
+	assert.ErrorIs(t, validateSpan(time.Hour, time.Second), ErrInvalidSpan)
+	assert.ErrorIs(t, validateSpan(90*time.Minute, time.Hour), ErrInvalidSpan)
+	assert.ErrorIs(t, validateSpan(91*24*time.Hour, 24*time.Hour), ErrInvalidSpan)
+	assert.ErrorIs(t, validateSpan(30*24*time.Hour, time.Minute), ErrInvalidSpan)
+}
+
+func TestAssembleSummary(t *testing.T) {
+	from := time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC)
+	to := from.Add(3 * time.Hour)
+
+	summary := assembleSummary(from, to, time.Hour,
+		[]bucketCount{{Bucket: from.Unix(), Count: 10}, {Bucket: from.Add(2 * time.Hour).Unix(), Count: 4}},
+		[]bucketCount{{Bucket: from.Add(time.Hour).Unix(), Count: 2}},
+		[]bucketCount{{Bucket: from.Unix(), Count: 1}, {Bucket: from.Add(-time.Hour).Unix(), Count: 7}},
+	)
+
+	assert.Equal(t, "1h", summary.Bucket)
+	assert.Equal(t, []MetricsBucket{
+		{Start: from, Requests: 10, Signups: 1},
+		{Start: from.Add(time.Hour), Errors: 2},
+		{Start: from.Add(2 * time.Hour), Requests: 4},
+	}, summary.Buckets)
+	assert.Equal(t, MetricsTotals{Requests: 14, Errors: 2, Signups: 1}, summary.Totals)
+}
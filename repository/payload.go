@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"web-clean/infra"
+	"web-clean/infra/conf"
+)
+
+// PayloadMode 决定日志与错误载荷以何种形式写入数据库
+type PayloadMode string
+
+const (
+	// PayloadRaw 原样写入 jsonb
+	PayloadRaw PayloadMode = "raw"
+	// PayloadGzip 将载荷 gzip 压缩后写入 bytea 列，jsonb 列留空
+	PayloadGzip PayloadMode = "gzip"
+	// PayloadTrim 仍写入 jsonb，但超过上限的字符串会被截断并附加截断标记
+	PayloadTrim PayloadMode = "trim"
+)
+
+// defaultMaxStringBytes 是 trim 模式下未配置上限时使用的默认值
+const defaultMaxStringBytes = 2048
+
+// truncatedMarker 追加在被截断的字符串末尾，%d 为被丢弃的字节数
+const truncatedMarker = "…[truncated %d bytes]"
+
+// PayloadCodec 负责在持久化前压缩或截断载荷
+type PayloadCodec struct {
+	Mode           PayloadMode
+	MaxStringBytes int
+}
+
+// payloadCodecOf 从应用配置中读取载荷处理方式，未配置时使用 PayloadRaw
+func payloadCodecOf(ctx *infra.Context) (PayloadCodec, error) {
+	if ctx == nil || ctx.Conf == nil {
+		return PayloadCodec{Mode: PayloadRaw}, nil
+	}
+	return NewPayloadCodec(ctx.Conf.Persistence)
+}
+
+// NewPayloadCodec 根据配置创建 PayloadCodec，config 为 nil 时原样写入
+func NewPayloadCodec(config *conf.Persistence) (PayloadCodec, error) {
+	if config == nil || config.Payload == "" {
+		return PayloadCodec{Mode: PayloadRaw}, nil
+	}
+
+	codec := PayloadCodec{Mode: PayloadMode(config.Payload), MaxStringBytes: config.MaxStringBytes}
+	switch codec.Mode {
+	case PayloadRaw, PayloadGzip:
+	case PayloadTrim:
+		if codec.MaxStringBytes <= 0 {
+			codec.MaxStringBytes = defaultMaxStringBytes
+		}
+	default:
+		return PayloadCodec{}, fmt.Errorf("未知的载荷处理方式: %s", config.Payload)
+	}
+	return codec, nil
+}
+
+// compress 将 v 序列化为 JSON 后进行 gzip 压缩
+func (p PayloadCodec) compress(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(writer).Encode(v); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress 将 compress 的结果还原到 v 中，供查询压缩载荷时使用
+func (p PayloadCodec) Decompress(data []byte, v any) error {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// trimString 截断超过上限的字符串，截断位置不会落在 UTF-8 字符中间
+func (p PayloadCodec) trimString(s string) string {
+	if len(s) <= p.MaxStringBytes {
+		return s
+	}
+
+	cut := p.MaxStringBytes
+	for cut > 0 && !isRuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + fmt.Sprintf(truncatedMarker, len(s)-cut)
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// trimAny 截断任意载荷中的过长字符串。载荷先经过一次 JSON 往返，
+// 因此返回值与原始值序列化后的结构一致，但类型变为 map/slice/基础类型
+func (p PayloadCodec) trimAny(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return p.trimValue(generic), nil
+}
+
+func (p PayloadCodec) trimValue(v any) any {
+	switch value := v.(type) {
+	case string:
+		return p.trimString(value)
+	case []any:
+		for i := range value {
+			value[i] = p.trimValue(value[i])
+		}
+		return value
+	case map[string]any:
+		for key := range value {
+			value[key] = p.trimValue(value[key])
+		}
+		return value
+	default:
+		return value
+	}
+}
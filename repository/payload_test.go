@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"web-clean/infra/conf"
+	"web-clean/infra/web"
+)
+
+func TestNewPayloadCodec(t *testing.T) {
+	codec, err := NewPayloadCodec(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, PayloadRaw, codec.Mode)
+
+	codec, err = NewPayloadCodec(&conf.Persistence{Payload: "trim"})
+	assert.NoError(t, err)
+	assert.Equal(t, defaultMaxStringBytes, codec.MaxStringBytes)
+
+	_, err = NewPayloadCodec(&conf.Persistence{Payload: "zstd"})
+	assert.Error(t, err)
+}
+
+func TestPayloadCodec_GzipRoundTrip(t *testing.T) {
+	codec := PayloadCodec{Mode: PayloadGzip}
+	logs := []web.Log{{Level: "DEBUG", Msg: strings.Repeat("verbose ", 1000)}}
+
+	data, err := codec.compress(logs)
+	assert.NoError(t, err)
+	assert.Less(t, len(data), len(logs[0].Msg))
+
+	var decoded []web.Log
+	assert.NoError(t, codec.Decompress(data, &decoded))
+	assert.Equal(t, logs, decoded)
+}
+
+func TestPayloadCodec_TrimString(t *testing.T) {
+	codec := PayloadCodec{Mode: PayloadTrim, MaxStringBytes: 4}
+
+	assert.Equal(t, "abcd", codec.trimString("abcd"))
+	assert.Equal(t, "abcd…[truncated 2 bytes]", codec.trimString("abcdef"))
+	// 不在多字节字符中间截断："错" 占 3 字节
+	assert.Equal(t, "ab…[truncated 6 bytes]", codec.trimString("ab错误"))
+}
+
+func TestPayloadCodec_TrimAny(t *testing.T) {
+	codec := PayloadCodec{Mode: PayloadTrim, MaxStringBytes: 3}
+	stack := []web.ErrorEntry{{Error: "boom!", Panic: &web.PanicEntry{Kind: "runtime", Stack: "goroutine 1"}}}
+
+	trimmed, err := codec.trimAny(stack)
+
+	assert.NoError(t, err)
+	entry := trimmed.([]any)[0].(map[string]any)
+	assert.Equal(t, "boo…[truncated 2 bytes]", entry["error"])
+	assert.Equal(t, "gor…[truncated 8 bytes]", entry["panic"].(map[string]any)["stack"])
+}
+
+func TestLogs_ModelByMode(t *testing.T) {
+	logs := []web.Log{{Level: "INFO", Msg: "hello world"}}
+
+	model, err := (&Logs{}).model(logs)
+	assert.NoError(t, err)
+	assert.Equal(t, logs, model.Logs)
+	assert.Nil(t, model.LogsGzip)
+}
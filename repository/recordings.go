@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"web-clean/infra/database"
+	"web-clean/infra/web"
+)
+
+const (
+	// DefaultRecordingsLimit 是查询录制时未指定条数的默认值
+	DefaultRecordingsLimit = 50
+	// MaxRecordingsLimit 是单次查询录制的最大条数
+	MaxRecordingsLimit = 500
+)
+
+// RecordingModel 保存调试模式下录制的一次请求与响应
+type RecordingModel struct {
+	ID              uint   `gorm:"primarykey"`
+	RuleID          string `gorm:"index"`
+	RequestID       string `gorm:"index"`
+	UserID          string `gorm:"index"`
+	Method          string
+	URL             string
+	Status          int
+	Duration        time.Duration
+	RequestHeaders  map[string][]string `gorm:"type:jsonb;serializer:json"`
+	RequestBody     string              `gorm:"type:text"`
+	ResponseHeaders map[string][]string `gorm:"type:jsonb;serializer:json"`
+	ResponseBody    string              `gorm:"type:text"`
+	Truncated       bool
+	RecordedAt      time.Time `gorm:"index"`
+}
+
+func (RecordingModel) TableName() string {
+	return "debug_recordings"
+}
+
+func init() {
+	database.RegisterSchema(RecordingModel{})
+}
+
+// Recordings 是 web.RecordingStore 基于数据库的实现
+type Recordings struct {
+	Database database.Database
+}
+
+var _ web.RecordingStore = Recordings{}
+
+func (r Recordings) Save(ctx context.Context, recording web.Recording) error {
+	model := RecordingModel{
+		RuleID:          recording.RuleID,
+		RequestID:       recording.RequestID,
+		UserID:          recording.UserID,
+		Method:          recording.Method,
+		URL:             recording.URL,
+		Status:          recording.Status,
+		Duration:        recording.Duration,
+		RequestHeaders:  recording.RequestHeaders,
+		RequestBody:     recording.RequestBody,
+		ResponseHeaders: recording.ResponseHeaders,
+		ResponseBody:    recording.ResponseBody,
+		Truncated:       recording.Truncated,
+		RecordedAt:      recording.RecordedAt,
+	}
+	return r.Database.WithContext(ctx).Create(&model).Error
+}
+
+// List 按录制时间倒序返回录制，Limit 不在 1 到 MaxRecordingsLimit 之间时使用默认值或上限
+func (r Recordings) List(ctx context.Context, query web.RecordingQuery) ([]web.Recording, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultRecordingsLimit
+	}
+	limit = min(limit, MaxRecordingsLimit)
+
+	tx := r.Database.WithContext(ctx).Order("recorded_at DESC, id DESC").Limit(limit)
+	if query.RuleID != "" {
+		tx = tx.Where("rule_id = ?", query.RuleID)
+	}
+
+	var rows []RecordingModel
+	if err := tx.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	recordings := make([]web.Recording, len(rows))
+	for i, row := range rows {
+		recordings[i] = row.recording()
+	}
+	return recordings, nil
+}
+
+// DeleteBefore 删除 before 之前录制的记录
+func (r Recordings) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.Database.WithContext(ctx).Where("recorded_at < ?", before).Delete(&RecordingModel{})
+	return result.RowsAffected, result.Error
+}
+
+func (m RecordingModel) recording() web.Recording {
+	return web.Recording{
+		RuleID:          m.RuleID,
+		RequestID:       m.RequestID,
+		UserID:          m.UserID,
+		Method:          m.Method,
+		URL:             m.URL,
+		Status:          m.Status,
+		Duration:        m.Duration,
+		RequestHeaders:  m.RequestHeaders,
+		RequestBody:     m.RequestBody,
+		ResponseHeaders: m.ResponseHeaders,
+		ResponseBody:    m.ResponseBody,
+		Truncated:       m.Truncated,
+		RecordedAt:      m.RecordedAt,
+	}
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"web-clean/infra/database"
+	"web-clean/infra/web"
+)
+
+// ErrRequestNotFound 表示数据库中没有该 RequestID 的任何日志或错误记录
+var ErrRequestNotFound = errors.New("request not found")
+
+// RequestAccess 是一次请求的访问信息，取自该请求的错误记录
+type RequestAccess struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Path   string `json:"path"`
+	IP     string `json:"ip"`
+}
+
+// RequestTrace 汇总同一个 RequestID 下持久化的日志与错误
+type RequestTrace struct {
+	RequestID string         `json:"request_id"`
+	Access    *RequestAccess `json:"access,omitempty"`
+	Logs      []web.Log      `json:"logs"`
+	Errors    []web.Errors   `json:"errors"`
+}
+
+// Requests 按 RequestID 查询 LogsModel 与 ErrorModel
+type Requests struct {
+	Database database.Database
+}
+
+// Trace 返回 requestID 对应的日志与错误，gzip 模式下写入的载荷会被解压。
+// 访问信息仅在请求产生过错误时可用，因为只有错误记录保存了请求方法、URL 与来源 IP
+func (r Requests) Trace(requestID string) (*RequestTrace, error) {
+	var logRows []LogsModel
+	var errorRows []ErrorModel
+
+	err := r.Database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("request_id = ?", requestID).Order("id").Find(&logRows).Error; err != nil {
+			return err
+		}
+		return tx.Where("request_id = ?", requestID).Order("id").Find(&errorRows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(logRows) == 0 && len(errorRows) == 0 {
+		return nil, ErrRequestNotFound
+	}
+
+	return assembleTrace(requestID, logRows, errorRows)
+}
+
+func assembleTrace(requestID string, logRows []LogsModel, errorRows []ErrorModel) (*RequestTrace, error) {
+	trace := &RequestTrace{
+		RequestID: requestID,
+		Logs:      make([]web.Log, 0),
+		Errors:    make([]web.Errors, 0, len(errorRows)),
+	}
+
+	var codec PayloadCodec
+	for _, row := range logRows {
+		logs := row.Logs
+		if row.LogsGzip != nil {
+			if err := codec.Decompress(row.LogsGzip, &logs); err != nil {
+				return nil, err
+			}
+		}
+		trace.Logs = append(trace.Logs, logs...)
+	}
+
+	for _, row := range errorRows {
+		rec := row.Error
+		if row.StackGzip != nil {
+			if err := codec.Decompress(row.StackGzip, &rec.Stack); err != nil {
+				return nil, err
+			}
+		}
+		trace.Errors = append(trace.Errors, rec)
+
+		if trace.Access == nil {
+			trace.Access = &RequestAccess{Method: rec.Method, URL: rec.URL, Path: rec.Path, IP: rec.IP}
+		}
+	}
+
+	return trace, nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"web-clean/infra/web"
+)
+
+func TestAssembleTrace(t *testing.T) {
+	codec := PayloadCodec{Mode: PayloadGzip}
+	compressedLogs, err := codec.compress([]web.Log{{Level: "INFO", Msg: "second"}})
+	assert.NoError(t, err)
+	compressedStack, err := codec.compress([]web.ErrorEntry{{Error: "boom"}})
+	assert.NoError(t, err)
+
+	logRows := []LogsModel{
+		{RequestID: "req", Logs: []web.Log{{Level: "INFO", Msg: "first"}}},
+		{RequestID: "req", LogsGzip: compressedLogs},
+	}
+	errorRows := []ErrorModel{
+		{RequestID: "req", Error: web.Errors{RequestID: "req", Method: "GET", Path: "/a", IP: "127.0.0.1"}, StackGzip: compressedStack},
+	}
+
+	trace, err := assembleTrace("req", logRows, errorRows)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []web.Log{{Level: "INFO", Msg: "first"}, {Level: "INFO", Msg: "second"}}, trace.Logs)
+	assert.Len(t, trace.Errors, 1)
+	assert.Equal(t, []any{map[string]any{"error": "boom"}}, trace.Errors[0].Stack)
+	assert.Equal(t, &RequestAccess{Method: "GET", Path: "/a", IP: "127.0.0.1"}, trace.Access)
+}